@@ -22,6 +22,9 @@ func main() {
 	// Start daily routines
 	go routines.StartDailyRoutines()
 
+	// Start sending push messages from the push outbox
+	go routines.StartPushDeliveryWorkers()
+
 	// Define a port using argument flag
 	// Set default port to :8080
 	port := flag.String("port", "8080", "Port on which the server will run")
@@ -11,6 +11,10 @@ import (
 type ChatControllerInterface interface {
 	CreateChat(c *gin.Context)
 	GetChats(c *gin.Context)
+	AddChatMembers(c *gin.Context)
+	RemoveChatMember(c *gin.Context)
+	UpdateChatMemberRole(c *gin.Context)
+	LeaveChat(c *gin.Context)
 }
 
 type ChatController struct {
@@ -75,3 +79,103 @@ func (controller *ChatController) GetChats(c *gin.Context) {
 
 	c.JSON(httpStatus, chats)
 }
+
+// AddChatMembers adds users to a group chat and returns the updated chat
+func (controller *ChatController) AddChatMembers(c *gin.Context) {
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	var req models.ChatMembersAddRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	chat, customErr, httpStatus := controller.chatService.AddChatMembers(c.Param("chatId"), &req, currentUsername.(string))
+	if customErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": customErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, chat)
+}
+
+// RemoveChatMember removes a user from a group chat and returns the updated chat
+func (controller *ChatController) RemoveChatMember(c *gin.Context) {
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	chat, customErr, httpStatus := controller.chatService.RemoveChatMember(c.Param("chatId"), c.Param("username"), currentUsername.(string))
+	if customErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": customErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, chat)
+}
+
+// UpdateChatMemberRole changes the role of a user in a group chat and returns the updated chat
+func (controller *ChatController) UpdateChatMemberRole(c *gin.Context) {
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	var req models.ChatMemberRoleUpdateRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	chat, customErr, httpStatus := controller.chatService.UpdateChatMemberRole(c.Param("chatId"), c.Param("username"), &req, currentUsername.(string))
+	if customErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": customErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, chat)
+}
+
+// LeaveChat removes the current user from a group chat
+func (controller *ChatController) LeaveChat(c *gin.Context) {
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	customErr, httpStatus := controller.chatService.LeaveChat(c.Param("chatId"), currentUsername.(string))
+	if customErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": customErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, gin.H{})
+}
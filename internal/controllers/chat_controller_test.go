@@ -30,9 +30,10 @@ func TestCreateChatSuccess(t *testing.T) {
 	mockChatRepo := new(repositories.MockChatRepository)
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
-	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService)
-	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService, nil, services.NewMemoryChatBroker(), nil)
 	chatController := controllers.NewChatController(chatService)
 
 	currentUser := &models.User{
@@ -64,6 +65,8 @@ func TestCreateChatSuccess(t *testing.T) {
 			capturedChat = args.Get(0).(models.Chat)
 			capturedMessage = args.Get(1).(models.Message)
 		}).Return(nil)
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
 	mockNotificationRepo.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
 		Run(func(args mock.Arguments) {
 			capturedNotification = args.Get(0).(*models.Notification)
@@ -141,9 +144,10 @@ func TestCreateChatBadRequest(t *testing.T) {
 		mockChatRepo := new(repositories.MockChatRepository)
 		mockNotificationRepo := new(repositories.MockNotificationRepository)
 		mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
-		pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
-		notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService)
-		chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService)
+		pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+		mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+		notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+		chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService, nil, services.NewMemoryChatBroker(), nil)
 		chatController := controllers.NewChatController(chatService)
 
 		currentUser := &models.User{
@@ -191,9 +195,10 @@ func TestCreateChatUnauthorized(t *testing.T) {
 	mockChatRepo := new(repositories.MockChatRepository)
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
-	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService)
-	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService, nil, services.NewMemoryChatBroker(), nil)
 	chatController := controllers.NewChatController(chatService)
 
 	// Setup HTTP request
@@ -230,9 +235,10 @@ func TestCreateChatUserNotFound(t *testing.T) {
 	mockChatRepo := new(repositories.MockChatRepository)
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
-	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService)
-	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService, nil, services.NewMemoryChatBroker(), nil)
 	chatController := controllers.NewChatController(chatService)
 
 	currentUser := &models.User{
@@ -291,9 +297,10 @@ func TestCreateChatChatAlreadyExists(t *testing.T) {
 	mockChatRepo := new(repositories.MockChatRepository)
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
-	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService)
-	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService, nil, services.NewMemoryChatBroker(), nil)
 	chatController := controllers.NewChatController(chatService)
 
 	currentUser := &models.User{
@@ -356,9 +363,12 @@ func TestGetChatsSuccess(t *testing.T) {
 	mockChatRepo := new(repositories.MockChatRepository)
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
-	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService)
-	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	chatBroker := services.NewMemoryChatBroker()
+	presenceService := services.NewPresenceService(mockUserRepo, nil, chatBroker)
+	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService, nil, chatBroker, presenceService)
 	chatController := controllers.NewChatController(chatService)
 
 	currentUsername := "myUser"
@@ -408,6 +418,7 @@ func TestGetChatsSuccess(t *testing.T) {
 
 	// Mock expectations
 	mockChatRepo.On("GetChatsByUsername", currentUsername).Return(chats, nil)
+	mockChatRepo.On("GetUnreadMessageCounts", currentUsername).Return(map[string]int64{chats[0].Id.String(): 3}, nil)
 
 	// Setup HTTP request
 	url := "/chats"
@@ -432,17 +443,30 @@ func TestGetChatsSuccess(t *testing.T) {
 	assert.Len(t, response.Records, 2)
 	for i, chat := range chats {
 		assert.Equal(t, chat.Id.String(), response.Records[i].ChatId)
-		assert.Equal(t, chat.Users[0].Username, response.Records[i].User.Username)
-		assert.Equal(t, chat.Users[0].Nickname, response.Records[i].User.Nickname)
+		assert.False(t, response.Records[i].IsGroup)
+		if i == 0 {
+			assert.Equal(t, int64(3), response.Records[i].UnreadCount)
+		} else {
+			assert.Equal(t, int64(0), response.Records[i].UnreadCount) // no unread messages in second chat
+		}
+		assert.Nil(t, response.Records[i].Picture)
+		assert.Len(t, response.Records[i].Participants, 1)
+
+		participant := response.Records[i].Participants[0]
+		assert.Equal(t, chat.Users[0].Username, participant.Username)
+		assert.Equal(t, chat.Users[0].Nickname, participant.Nickname)
+		assert.Equal(t, services.ChatRoleMember, participant.Role)
+		assert.False(t, participant.Online)
+		assert.Nil(t, participant.LastSeen)
 
 		if chat.Users[0].ImageId != nil {
-			assert.NotNil(t, response.Records[i].User.Picture)
-			assert.Equal(t, expectedImageUrl, response.Records[i].User.Picture.Url)
-			assert.Equal(t, chat.Users[0].Image.Width, response.Records[i].User.Picture.Width)
-			assert.Equal(t, chat.Users[0].Image.Height, response.Records[i].User.Picture.Height)
-			assert.True(t, chat.Users[0].Image.Tag.Equal(response.Records[i].User.Picture.Tag))
+			assert.NotNil(t, participant.Picture)
+			assert.Equal(t, expectedImageUrl, participant.Picture.Url)
+			assert.Equal(t, chat.Users[0].Image.Width, participant.Picture.Width)
+			assert.Equal(t, chat.Users[0].Image.Height, participant.Picture.Height)
+			assert.True(t, chat.Users[0].Image.Tag.Equal(participant.Picture.Tag))
 		} else {
-			assert.Nil(t, response.Records[i].User.Picture)
+			assert.Nil(t, participant.Picture)
 		}
 	}
 
@@ -452,6 +476,78 @@ func TestGetChatsSuccess(t *testing.T) {
 	mockNotificationRepo.AssertExpectations(t)
 }
 
+// TestGetChatsPresence tests if GetChats returns the presence of the participants and hides it from users that are no contacts
+func TestGetChatsPresence(t *testing.T) {
+	// Arrange
+	mockChatRepo := new(repositories.MockChatRepository)
+	mockSubscriptionRepo := new(repositories.MockSubscriptionRepository)
+	chatBroker := services.NewMemoryChatBroker()
+	presenceService := services.NewPresenceService(nil, mockSubscriptionRepo, chatBroker)
+	chatService := services.NewChatService(mockChatRepo, nil, nil, nil, chatBroker, presenceService)
+	chatController := controllers.NewChatController(chatService)
+
+	currentUsername := "myUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lastSeen := time.Now().UTC().Add(-time.Hour)
+	chat := models.Chat{
+		Id:      uuid.New(),
+		IsGroup: true,
+		Name:    "Presence Group",
+		Users: []models.User{
+			{Username: currentUsername, LastSeenAt: &lastSeen},
+			{Username: "onlineUser", LastSeenAt: &lastSeen, PresenceVisibility: services.PresenceVisibilityEveryone},
+			{Username: "contactUser", LastSeenAt: &lastSeen, PresenceVisibility: services.PresenceVisibilityContacts},
+			{Username: "privateUser", LastSeenAt: &lastSeen, PresenceVisibility: services.PresenceVisibilityContacts},
+		},
+	}
+	_ = chatBroker.Join(uuid.New().String(), "onlineUser") // connected to another chat
+	_ = chatBroker.Join(uuid.New().String(), "privateUser")
+
+	// Mock expectations
+	mockChatRepo.On("GetChatsByUsername", currentUsername).Return([]models.Chat{chat}, nil)
+	mockChatRepo.On("GetUnreadMessageCounts", currentUsername).Return(map[string]int64{}, nil)
+	mockSubscriptionRepo.On("GetFollowerUsernames", currentUsername, []string{"contactUser", "privateUser"}).
+		Return([]string{"contactUser"}, nil).Once() // only contactUser follows the current user, both are loaded in one query
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/chats", nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/chats", middleware.AuthorizeUser, chatController.GetChats)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+	var response models.ChatsResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Records, 1)
+
+	participants := response.Records[0].Participants
+	if assert.Len(t, participants, 4) {
+		expectedOnline := []bool{false, true, false, false} // privateUser is online but hides it
+		for i, participant := range participants {
+			assert.Equal(t, expectedOnline[i], participant.Online, participant.Username)
+			if participant.Username == "privateUser" {
+				assert.Nil(t, participant.LastSeen)
+			} else if assert.NotNil(t, participant.LastSeen, participant.Username) {
+				assert.True(t, lastSeen.Equal(*participant.LastSeen))
+			}
+		}
+	}
+
+	mockChatRepo.AssertExpectations(t)
+	mockSubscriptionRepo.AssertExpectations(t)
+}
+
 // TestGetChatsUnauthorized tests the GetChats function if it returns 401 Unauthorized when the user is not authenticated
 func TestGetChatsUnauthorized(t *testing.T) {
 	// Arrange
@@ -459,9 +555,10 @@ func TestGetChatsUnauthorized(t *testing.T) {
 	mockChatRepo := new(repositories.MockChatRepository)
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
-	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService)
-	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService, nil, services.NewMemoryChatBroker(), nil)
 	chatController := controllers.NewChatController(chatService)
 
 	// Setup HTTP request
@@ -491,3 +588,555 @@ func TestGetChatsUnauthorized(t *testing.T) {
 	mockPushSubscriptionRepo.AssertExpectations(t)
 	mockNotificationRepo.AssertExpectations(t)
 }
+
+// TestCreateGroupChatSuccess tests the CreateChat function if it returns 201 Created after creating a group chat with the current user as admin
+func TestCreateGroupChatSuccess(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(repositories.MockUserRepository)
+	mockChatRepo := new(repositories.MockChatRepository)
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	chatService := services.NewChatService(mockChatRepo, mockUserRepo, notificationService, utils.NewValidator(), services.NewMemoryChatBroker(), nil)
+	chatController := controllers.NewChatController(chatService)
+
+	currentUser := &models.User{Username: "testUser"}
+	otherUsers := []*models.User{{Username: "testUser2"}, {Username: "testUser3"}}
+
+	chatCreateRequest := models.ChatCreateRequestDTO{
+		Content:   "Hello everyone",
+		Name:      "Test Group",
+		Usernames: []string{"testUser2", "testUser3", "testUser2", "testUser"}, // duplicates and the current user are ignored
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken(currentUser.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	var capturedChat models.Chat
+	var notifiedUsers []string
+	mockUserRepo.On("FindUserByUsername", currentUser.Username).Return(currentUser, nil)
+	for _, user := range otherUsers {
+		mockUserRepo.On("FindUserByUsername", user.Username).Return(user, nil)
+		mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", user.Username).Return([]models.PushSubscription{}, nil)
+	}
+	mockChatRepo.On("CreateChatWithFirstMessage", mock.AnythingOfType("models.Chat"), mock.AnythingOfType("models.Message")).
+		Run(func(args mock.Arguments) {
+			capturedChat = args.Get(0).(models.Chat)
+		}).Return(nil)
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound)
+	mockNotificationRepo.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
+		Run(func(args mock.Arguments) {
+			notifiedUsers = append(notifiedUsers, args.Get(0).(*models.Notification).ForUsername)
+		}).Return(nil)
+	mockNotificationRepo.On("GetNotificationById", mock.AnythingOfType("string")).Return(models.Notification{}, nil)
+
+	// Setup HTTP request
+	requestBody, err := json.Marshal(chatCreateRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", "/chats", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/chats", middleware.AuthorizeUser, chatController.CreateChat)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code) // Expect 201 Created
+	var response models.ChatCreateResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, capturedChat.Id.String(), response.ChatId)
+
+	assert.True(t, capturedChat.IsGroup)
+	assert.Equal(t, chatCreateRequest.Name, capturedChat.Name)
+	assert.Nil(t, capturedChat.ImageId)
+	assert.Len(t, capturedChat.Users, 3)
+	assert.Equal(t, []models.ChatUser{
+		{ChatId: capturedChat.Id, UserUsername: "testUser", Role: services.ChatRoleAdmin, JoinedAt: capturedChat.CreatedAt},
+		{ChatId: capturedChat.Id, UserUsername: "testUser2", Role: services.ChatRoleMember, JoinedAt: capturedChat.CreatedAt},
+		{ChatId: capturedChat.Id, UserUsername: "testUser3", Role: services.ChatRoleMember, JoinedAt: capturedChat.CreatedAt},
+	}, capturedChat.Members)
+
+	assert.ElementsMatch(t, []string{"testUser2", "testUser3"}, notifiedUsers) // Expect all other members to be notified
+
+	mockChatRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+// TestCreateGroupChatBadRequest tests the CreateChat function if it returns 400 Bad Request for invalid group chats
+func TestCreateGroupChatBadRequest(t *testing.T) {
+	invalidBodies := []string{
+		`{"content": "Hello", "usernames": ["testUser2"]}`,                                                // missing name
+		`{"content": "Hello", "name": "Test Group"}`,                                                      // missing other users
+		`{"content": "Hello", "name": "Test Group", "usernames": ["testUser"]}`,                           // only the current user
+		`{"content": "Hello", "name": "` + strings.Repeat("A", 51) + `", "usernames": ["testUser2"]}`,     // name too long
+		`{"content": "Hello", "name": "Test Group", "username": "testUser2", "usernames": ["testUser3"]}`, // direct and group chat mixed
+		`{"content": "Hello", "name": "Test Group", "usernames": ["testUser2"], "picture": "no base64!"}`, // invalid picture
+		`{"content": "Hello", "username": "testUser2", "picture": "aGVsbG8="}`,                            // picture for direct chat
+	}
+
+	for _, body := range invalidBodies {
+		// Arrange
+		mockUserRepo := new(repositories.MockUserRepository)
+		mockChatRepo := new(repositories.MockChatRepository)
+		chatService := services.NewChatService(mockChatRepo, mockUserRepo, nil, utils.NewValidator(), services.NewMemoryChatBroker(), nil)
+		chatController := controllers.NewChatController(chatService)
+
+		authenticationToken, err := utils.GenerateAccessToken("testUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Setup HTTP request
+		req, _ := http.NewRequest("POST", "/chats", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.POST("/chats", middleware.AuthorizeUser, chatController.CreateChat)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code, body) // Expect 400 Bad Request
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, customerrors.BadRequest.Code, errorResponse.Error.Code)
+
+		mockChatRepo.AssertNotCalled(t, "CreateChatWithFirstMessage", mock.Anything, mock.Anything)
+	}
+}
+
+// newTestGroupChat creates a group chat with an admin and a member for the membership tests
+func newTestGroupChat() models.Chat {
+	chatId := uuid.New()
+	return models.Chat{
+		Id:      chatId,
+		IsGroup: true,
+		Name:    "Test Group",
+		Users:   []models.User{{Username: "adminUser"}, {Username: "memberUser"}},
+		Members: []models.ChatUser{
+			{ChatId: chatId, UserUsername: "adminUser", Role: services.ChatRoleAdmin},
+			{ChatId: chatId, UserUsername: "memberUser", Role: services.ChatRoleMember},
+		},
+	}
+}
+
+// TestAddChatMembersSuccess tests the AddChatMembers function if it returns 200 OK and only adds users that are not members yet
+func TestAddChatMembersSuccess(t *testing.T) {
+	// Arrange
+	mockUserRepo := new(repositories.MockUserRepository)
+	mockChatRepo := new(repositories.MockChatRepository)
+	chatBroker := services.NewMemoryChatBroker()
+	presenceService := services.NewPresenceService(nil, nil, chatBroker)
+	chatService := services.NewChatService(mockChatRepo, mockUserRepo, nil, nil, chatBroker, presenceService)
+	chatController := controllers.NewChatController(chatService)
+
+	lastSeen := time.Now().UTC().Add(-time.Hour)
+	chat := newTestGroupChat()
+	updatedChat := newTestGroupChat()
+	updatedChat.Id = chat.Id
+	updatedChat.Users[1].LastSeenAt = &lastSeen
+	updatedChat.Users = append(updatedChat.Users, models.User{Username: "newUser"})
+	_ = chatBroker.Join(uuid.New().String(), "memberUser") // connected to another chat
+	updatedChat.Members = append(updatedChat.Members, models.ChatUser{ChatId: chat.Id, UserUsername: "newUser", Role: services.ChatRoleMember})
+
+	authenticationToken, err := utils.GenerateAccessToken("adminUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	var capturedMembers []models.ChatUser
+	mockChatRepo.On("GetChatById", chat.Id.String()).Return(chat, nil).Once()
+	mockChatRepo.On("GetChatById", chat.Id.String()).Return(updatedChat, nil).Once()
+	mockUserRepo.On("FindUserByUsername", "newUser").Return(&models.User{Username: "newUser"}, nil)
+	mockChatRepo.On("AddChatMembers", mock.AnythingOfType("[]models.ChatUser")).
+		Run(func(args mock.Arguments) {
+			capturedMembers = args.Get(0).([]models.ChatUser)
+		}).Return(nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("POST", "/chats/"+chat.Id.String()+"/members", strings.NewReader(`{"usernames": ["memberUser", "newUser", "newUser"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/chats/:chatId/members", middleware.AuthorizeUser, chatController.AddChatMembers)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+	assert.Len(t, capturedMembers, 1)
+	assert.Equal(t, "newUser", capturedMembers[0].UserUsername)
+	assert.Equal(t, services.ChatRoleMember, capturedMembers[0].Role)
+
+	var response models.ChatRecordDTO
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, chat.Id.String(), response.ChatId)
+	assert.True(t, response.IsGroup)
+	assert.Equal(t, "Test Group", response.Name)
+	assert.Len(t, response.Participants, 3)
+	assert.Equal(t, services.ChatRoleAdmin, response.Participants[0].Role)
+	assert.Equal(t, "newUser", response.Participants[2].Username)
+	assert.True(t, response.Participants[1].Online) // presence of the participants is included like in the chat list
+	if assert.NotNil(t, response.Participants[1].LastSeen) {
+		assert.True(t, lastSeen.Equal(*response.Participants[1].LastSeen))
+	}
+	assert.False(t, response.Participants[2].Online)
+
+	mockChatRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+}
+
+// TestManageChatMembersForbidden tests if the membership endpoints return 403 Forbidden for members that are no admins
+func TestManageChatMembersForbidden(t *testing.T) {
+	chat := newTestGroupChat()
+	requests := []struct {
+		method string
+		url    string
+		body   string
+	}{
+		{"POST", "/chats/" + chat.Id.String() + "/members", `{"usernames": ["newUser"]}`},
+		{"DELETE", "/chats/" + chat.Id.String() + "/members/adminUser", ""},
+		{"PATCH", "/chats/" + chat.Id.String() + "/members/memberUser", `{"role": "admin"}`},
+	}
+
+	for _, request := range requests {
+		// Arrange
+		mockUserRepo := new(repositories.MockUserRepository)
+		mockChatRepo := new(repositories.MockChatRepository)
+		chatService := services.NewChatService(mockChatRepo, mockUserRepo, nil, nil, services.NewMemoryChatBroker(), nil)
+		chatController := controllers.NewChatController(chatService)
+
+		authenticationToken, err := utils.GenerateAccessToken("memberUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Mock expectations
+		mockChatRepo.On("GetChatById", chat.Id.String()).Return(chat, nil)
+
+		// Setup HTTP request
+		req, _ := http.NewRequest(request.method, request.url, strings.NewReader(request.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.POST("/chats/:chatId/members", middleware.AuthorizeUser, chatController.AddChatMembers)
+		router.PATCH("/chats/:chatId/members/:username", middleware.AuthorizeUser, chatController.UpdateChatMemberRole)
+		router.DELETE("/chats/:chatId/members/:username", middleware.AuthorizeUser, chatController.RemoveChatMember)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, w.Code) // Expect 403 Forbidden
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, customerrors.ChatAdminRequired.Code, errorResponse.Error.Code)
+
+		mockChatRepo.AssertNotCalled(t, "AddChatMembers", mock.Anything)
+		mockChatRepo.AssertNotCalled(t, "RemoveChatMember", mock.Anything, mock.Anything)
+		mockChatRepo.AssertNotCalled(t, "UpdateChatMemberRole", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+// TestRemoveChatMemberSuccess tests the RemoveChatMember function if it returns 200 OK after removing a member
+func TestRemoveChatMemberSuccess(t *testing.T) {
+	// Arrange
+	mockChatRepo := new(repositories.MockChatRepository)
+	chatBroker := services.NewMemoryChatBroker()
+	presenceService := services.NewPresenceService(nil, nil, chatBroker)
+	chatService := services.NewChatService(mockChatRepo, nil, nil, nil, chatBroker, presenceService)
+	chatController := controllers.NewChatController(chatService)
+
+	chat := newTestGroupChat()
+	updatedChat := chat
+	updatedChat.Users = chat.Users[:1]
+	updatedChat.Members = chat.Members[:1]
+
+	authenticationToken, err := utils.GenerateAccessToken("adminUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockChatRepo.On("GetChatById", chat.Id.String()).Return(chat, nil).Once()
+	mockChatRepo.On("GetChatById", chat.Id.String()).Return(updatedChat, nil).Once()
+	mockChatRepo.On("RemoveChatMember", chat.Id.String(), "memberUser").Return(nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("DELETE", "/chats/"+chat.Id.String()+"/members/memberUser", nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/chats/:chatId/members/:username", middleware.AuthorizeUser, chatController.RemoveChatMember)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+	var response models.ChatRecordDTO
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Participants, 1)
+	assert.Equal(t, "adminUser", response.Participants[0].Username)
+
+	mockChatRepo.AssertExpectations(t)
+}
+
+// TestRemoveChatMemberErrors tests the RemoveChatMember function for direct chats, non-members and the admin itself
+func TestRemoveChatMemberErrors(t *testing.T) {
+	directChat := models.Chat{
+		Id:    uuid.New(),
+		Users: []models.User{{Username: "adminUser"}, {Username: "memberUser"}},
+	}
+	groupChat := newTestGroupChat()
+
+	tests := []struct {
+		chat          models.Chat
+		username      string
+		expectedError *customerrors.CustomError
+	}{
+		{directChat, "memberUser", customerrors.BadRequest}, // members of direct chats cannot be removed
+		{groupChat, "adminUser", customerrors.BadRequest},   // admins leave the chat instead of removing themselves
+		{groupChat, "unknownUser", customerrors.UserNotFound},
+	}
+
+	for _, test := range tests {
+		// Arrange
+		mockChatRepo := new(repositories.MockChatRepository)
+		chatService := services.NewChatService(mockChatRepo, nil, nil, nil, services.NewMemoryChatBroker(), nil)
+		chatController := controllers.NewChatController(chatService)
+
+		authenticationToken, err := utils.GenerateAccessToken("adminUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Mock expectations
+		mockChatRepo.On("GetChatById", test.chat.Id.String()).Return(test.chat, nil)
+
+		// Setup HTTP request
+		req, _ := http.NewRequest("DELETE", "/chats/"+test.chat.Id.String()+"/members/"+test.username, nil)
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.DELETE("/chats/:chatId/members/:username", middleware.AuthorizeUser, chatController.RemoveChatMember)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, test.expectedError.HttpStatus, w.Code)
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedError.Code, errorResponse.Error.Code)
+
+		mockChatRepo.AssertNotCalled(t, "RemoveChatMember", mock.Anything, mock.Anything)
+	}
+}
+
+// TestUpdateChatMemberRoleSuccess tests the UpdateChatMemberRole function if it returns 200 OK after promoting a member to admin
+func TestUpdateChatMemberRoleSuccess(t *testing.T) {
+	// Arrange
+	mockChatRepo := new(repositories.MockChatRepository)
+	chatBroker := services.NewMemoryChatBroker()
+	presenceService := services.NewPresenceService(nil, nil, chatBroker)
+	chatService := services.NewChatService(mockChatRepo, nil, nil, nil, chatBroker, presenceService)
+	chatController := controllers.NewChatController(chatService)
+
+	chat := newTestGroupChat()
+	updatedChat := newTestGroupChat()
+	updatedChat.Id = chat.Id
+	updatedChat.Members[1].Role = services.ChatRoleAdmin
+
+	authenticationToken, err := utils.GenerateAccessToken("adminUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockChatRepo.On("GetChatById", chat.Id.String()).Return(chat, nil).Once()
+	mockChatRepo.On("GetChatById", chat.Id.String()).Return(updatedChat, nil).Once()
+	mockChatRepo.On("UpdateChatMemberRole", chat.Id.String(), "memberUser", services.ChatRoleAdmin).Return(nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("PATCH", "/chats/"+chat.Id.String()+"/members/memberUser", strings.NewReader(`{"role": "admin"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PATCH("/chats/:chatId/members/:username", middleware.AuthorizeUser, chatController.UpdateChatMemberRole)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+	var response models.ChatRecordDTO
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ChatRoleAdmin, response.Participants[1].Role)
+
+	mockChatRepo.AssertExpectations(t)
+}
+
+// TestUpdateChatMemberRoleBadRequest tests the UpdateChatMemberRole function if it returns 400 Bad Request for invalid roles and the last admin
+func TestUpdateChatMemberRoleBadRequest(t *testing.T) {
+	chat := newTestGroupChat()
+	requests := []struct {
+		username string
+		body     string
+	}{
+		{"memberUser", `{"role": "owner"}`}, // invalid role
+		{"memberUser", `{}`},                // missing role
+		{"adminUser", `{"role": "member"}`}, // last admin cannot be demoted
+	}
+
+	for _, request := range requests {
+		// Arrange
+		mockChatRepo := new(repositories.MockChatRepository)
+		chatService := services.NewChatService(mockChatRepo, nil, nil, nil, services.NewMemoryChatBroker(), nil)
+		chatController := controllers.NewChatController(chatService)
+
+		authenticationToken, err := utils.GenerateAccessToken("adminUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Mock expectations
+		mockChatRepo.On("GetChatById", chat.Id.String()).Return(chat, nil)
+
+		// Setup HTTP request
+		req, _ := http.NewRequest("PATCH", "/chats/"+chat.Id.String()+"/members/"+request.username, strings.NewReader(request.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.PATCH("/chats/:chatId/members/:username", middleware.AuthorizeUser, chatController.UpdateChatMemberRole)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code) // Expect 400 Bad Request
+		mockChatRepo.AssertNotCalled(t, "UpdateChatMemberRole", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+// TestLeaveChatSuccess tests the LeaveChat function if it returns 204 No Content after the current user left a group chat
+func TestLeaveChatSuccess(t *testing.T) {
+	// Arrange
+	mockChatRepo := new(repositories.MockChatRepository)
+	chatService := services.NewChatService(mockChatRepo, nil, nil, nil, services.NewMemoryChatBroker(), nil)
+	chatController := controllers.NewChatController(chatService)
+
+	chat := newTestGroupChat()
+
+	authenticationToken, err := utils.GenerateAccessToken("adminUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockChatRepo.On("GetChatById", chat.Id.String()).Return(chat, nil)
+	mockChatRepo.On("RemoveChatMember", chat.Id.String(), "adminUser").Return(nil) // repository promotes another admin
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("POST", "/chats/"+chat.Id.String()+"/leave", nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/chats/:chatId/leave", middleware.AuthorizeUser, chatController.LeaveChat)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect 204 No Content
+	mockChatRepo.AssertExpectations(t)
+}
+
+// TestLeaveChatErrors tests the LeaveChat function if it returns 400 for direct chats and 404 for chats of other users
+func TestLeaveChatErrors(t *testing.T) {
+	directChat := models.Chat{
+		Id:    uuid.New(),
+		Users: []models.User{{Username: "adminUser"}, {Username: "memberUser"}},
+	}
+	groupChat := newTestGroupChat()
+
+	tests := []struct {
+		chat          models.Chat
+		username      string
+		expectedError *customerrors.CustomError
+	}{
+		{directChat, "adminUser", customerrors.BadRequest},
+		{groupChat, "otherUser", customerrors.ChatNotFound},
+	}
+
+	for _, test := range tests {
+		// Arrange
+		mockChatRepo := new(repositories.MockChatRepository)
+		chatService := services.NewChatService(mockChatRepo, nil, nil, nil, services.NewMemoryChatBroker(), nil)
+		chatController := controllers.NewChatController(chatService)
+
+		authenticationToken, err := utils.GenerateAccessToken(test.username)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Mock expectations
+		mockChatRepo.On("GetChatById", test.chat.Id.String()).Return(test.chat, nil)
+
+		// Setup HTTP request
+		req, _ := http.NewRequest("POST", "/chats/"+test.chat.Id.String()+"/leave", nil)
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.POST("/chats/:chatId/leave", middleware.AuthorizeUser, chatController.LeaveChat)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, test.expectedError.HttpStatus, w.Code)
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedError.Code, errorResponse.Error.Code)
+
+		mockChatRepo.AssertNotCalled(t, "RemoveChatMember", mock.Anything, mock.Anything)
+	}
+}
@@ -12,6 +12,9 @@ import (
 type CommentControllerInterface interface {
 	CreateComment(c *gin.Context)
 	GetCommentsByPostId(c *gin.Context)
+	UpdateComment(c *gin.Context)
+	DeleteComment(c *gin.Context)
+	GetRepliesByCommentId(c *gin.Context)
 }
 
 type CommentController struct {
@@ -73,11 +76,14 @@ func (controller *CommentController) GetCommentsByPostId(c *gin.Context) {
 		limit = 10
 	}
 
+	// Comments are sorted by creation date unless sorting by likes is requested
+	sortByLikes := c.DefaultQuery("sort", "date") == "likes"
+
 	// Get post id from URL
 	postId := c.Param("postId")
 
 	// Check if user is logged in
-	_, exists := c.Get("username")
+	username, exists := c.Get("username")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": customerrors.Unauthorized,
@@ -86,7 +92,7 @@ func (controller *CommentController) GetCommentsByPostId(c *gin.Context) {
 	}
 
 	// Get comments by post id
-	commentFeedDto, serviceErr, httpStatus := controller.commentService.GetCommentsByPostId(postId, offset, limit)
+	commentFeedDto, serviceErr, httpStatus := controller.commentService.GetCommentsByPostId(postId, offset, limit, sortByLikes, username.(string))
 	if serviceErr != nil {
 		c.JSON(httpStatus, gin.H{
 			"error": serviceErr,
@@ -96,3 +102,102 @@ func (controller *CommentController) GetCommentsByPostId(c *gin.Context) {
 
 	c.JSON(httpStatus, commentFeedDto)
 }
+
+// UpdateComment is a controller function that changes the content of a comment and can be called from router.go
+func (controller *CommentController) UpdateComment(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Read body
+	var commentUpdateRequestDTO models.CommentUpdateRequestDTO
+	if c.ShouldBindJSON(&commentUpdateRequestDTO) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	postId := c.Param("postId")
+	commentId := c.Param("commentId")
+
+	commentDto, serviceErr, httpStatus := controller.commentService.UpdateComment(&commentUpdateRequestDTO, postId, commentId, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, commentDto)
+}
+
+// DeleteComment is a controller function that deletes a comment and can be called from router.go
+func (controller *CommentController) DeleteComment(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	postId := c.Param("postId")
+	commentId := c.Param("commentId")
+
+	serviceErr, httpStatus := controller.commentService.DeleteComment(postId, commentId, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.Status(httpStatus)
+}
+
+// GetRepliesByCommentId is a controller function that retrieves the replies to a comment and can be called from router.go
+func (controller *CommentController) GetRepliesByCommentId(c *gin.Context) {
+	// Get pagination information
+	offsetQuery := c.DefaultQuery("offset", "0")
+	limitQuery := c.DefaultQuery("limit", "10")
+
+	offset, err := strconv.Atoi(offsetQuery)
+	if err != nil {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(limitQuery)
+	if err != nil {
+		limit = 10
+	}
+
+	// Get post and comment id from URL
+	postId := c.Param("postId")
+	commentId := c.Param("commentId")
+
+	// Check if user is logged in
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Get replies by comment id
+	replyFeedDto, serviceErr, httpStatus := controller.commentService.GetRepliesByCommentId(postId, commentId, offset, limit, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, replyFeedDto)
+}
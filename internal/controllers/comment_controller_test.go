@@ -30,8 +30,13 @@ func TestCreateCommentSuccess(t *testing.T) {
 	mockPostRepository := new(repositories.MockPostRepository)
 	mockCommentRepository := new(repositories.MockCommentRepository)
 	mockUserRepository := new(repositories.MockUserRepository)
+	mockNotificationRepository := new(repositories.MockNotificationRepository)
+	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
 
-	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository, nil, notificationService)
 	commentController := controllers.NewCommentController(commentService)
 
 	testUsername := "testUser"
@@ -62,7 +67,8 @@ func TestCreateCommentSuccess(t *testing.T) {
 	}
 
 	post := models.Post{
-		Id: uuid.New(),
+		Id:       uuid.New(),
+		Username: "postAuthor",
 	}
 	commentCreateRequest := models.CommentCreateRequestDTO{
 		Content: "Test comment",
@@ -70,12 +76,21 @@ func TestCreateCommentSuccess(t *testing.T) {
 
 	// Mock expectations
 	var capturedComment *models.Comment
+	var capturedNotification *models.Notification
 	mockPostRepository.On("GetPostById", post.Id.String()).Return(post, nil)
 	mockCommentRepository.On("CreateComment", mock.AnythingOfType("*models.Comment")).
 		Run(func(args mock.Arguments) {
 			capturedComment = args.Get(0).(*models.Comment)
 		}).Return(nil)
 	mockUserRepository.On("FindUserByUsername", testUsername).Return(&user, nil)
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
+	mockNotificationRepository.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
+		Run(func(args mock.Arguments) {
+			capturedNotification = args.Get(0).(*models.Notification)
+		}).Return(nil)
+	mockNotificationRepository.On("GetNotificationById", mock.AnythingOfType("string")).Return(models.Notification{}, nil)
+	mockPushSubscriptionRepository.On("GetPushSubscriptionsByUsername", post.Username).Return([]models.PushSubscription{}, nil)
 
 	// Setup HTTP request
 	requestBody, err := json.Marshal(commentCreateRequest)
@@ -119,9 +134,18 @@ func TestCreateCommentSuccess(t *testing.T) {
 	assert.Equal(t, user.Image.Height, responseComment.Author.Picture.Height)
 	assert.Equal(t, user.Image.Tag, responseComment.Author.Picture.Tag)
 
+	// Author of the post is notified about the comment
+	assert.NotNil(t, capturedNotification)
+	assert.Equal(t, "comment", capturedNotification.NotificationType)
+	assert.Equal(t, post.Username, capturedNotification.ForUsername)
+	assert.Equal(t, testUsername, capturedNotification.FromUsername)
+	assert.Equal(t, post.Id, *capturedNotification.PostId)
+	assert.Equal(t, capturedComment.Id, *capturedNotification.CommentId)
+
 	mockCommentRepository.AssertExpectations(t)
 	mockPostRepository.AssertExpectations(t)
 	mockUserRepository.AssertExpectations(t)
+	mockNotificationRepository.AssertExpectations(t)
 }
 
 // TestCreateCommentBadRequest tests the CreateComment function if it returns 400 Bad Request when the request body is invalid
@@ -139,7 +163,7 @@ func TestCreateCommentBadRequest(t *testing.T) {
 		mockCommentRepository := new(repositories.MockCommentRepository)
 		mockUserRepository := new(repositories.MockUserRepository)
 
-		commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository)
+		commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository, nil, nil)
 		commentController := controllers.NewCommentController(commentService)
 
 		testUsername := "testUser"
@@ -188,7 +212,7 @@ func TestCreateCommentUnauthorized(t *testing.T) {
 	mockCommentRepository := new(repositories.MockCommentRepository)
 	mockUserRepository := new(repositories.MockUserRepository)
 
-	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository)
+	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository, nil, nil)
 	commentController := controllers.NewCommentController(commentService)
 
 	post := models.Post{
@@ -229,7 +253,7 @@ func TestCreateCommentPostNotFound(t *testing.T) {
 	mockCommentRepository := new(repositories.MockCommentRepository)
 	mockUserRepository := new(repositories.MockUserRepository)
 
-	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository)
+	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository, nil, nil)
 	commentController := controllers.NewCommentController(commentService)
 
 	testUsername := "testUser"
@@ -287,7 +311,9 @@ func TestGetCommentsByPostIdSuccess(t *testing.T) {
 	mockCommentRepository := new(repositories.MockCommentRepository)
 	mockUserRepository := new(repositories.MockUserRepository)
 
-	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository)
+	mockLikeRepository := new(repositories.MockLikeRepository)
+
+	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository, mockLikeRepository, nil)
 	commentController := controllers.NewCommentController(commentService)
 
 	authenticationToken, err := utils.GenerateAccessToken("myUser")
@@ -345,7 +371,13 @@ func TestGetCommentsByPostIdSuccess(t *testing.T) {
 
 	// Mock expectations
 	mockPostRepository.On("GetPostById", post.Id.String()).Return(models.Post{}, nil)
-	mockCommentRepository.On("GetCommentsByPostId", post.Id.String(), offset, limit).Return(comments, int64(totalNumberOfComments), nil)
+	mockCommentRepository.On("GetCommentsByPostId", post.Id.String(), offset, limit, false).Return(comments, int64(totalNumberOfComments), nil)
+	mockCommentRepository.On("CountRepliesByCommentIds", []string{comments[0].Id.String(), comments[1].Id.String()}).
+		Return(map[string]int64{comments[0].Id.String(): 3}, nil) // comments without replies are missing in the result
+	mockLikeRepository.On("FindCommentLikesByCommentIds", []string{comments[0].Id.String(), comments[1].Id.String()}, "myUser").
+		Return([]models.CommentLike{{Id: uuid.New(), CommentId: comments[0].Id, Username: "myUser"}}, nil)
+	mockLikeRepository.On("CountCommentLikesByCommentIds", []string{comments[0].Id.String(), comments[1].Id.String()}).
+		Return(map[string]int64{comments[0].Id.String(): 5}, nil)
 
 	// Setup HTTP request
 	url := "/posts/" + post.Id.String() + "/comments?offset=" + fmt.Sprint(offset) + "&limit=" + fmt.Sprint(limit)
@@ -370,6 +402,12 @@ func TestGetCommentsByPostIdSuccess(t *testing.T) {
 	assert.Equal(t, int64(totalNumberOfComments), responseList.Pagination.Records)
 	assert.Equal(t, offset, responseList.Pagination.Offset)
 	assert.Equal(t, limit, responseList.Pagination.Limit)
+	assert.Equal(t, int64(3), responseList.Records[0].Replies)
+	assert.Equal(t, int64(0), responseList.Records[1].Replies)
+	assert.Equal(t, int64(5), responseList.Records[0].Likes)
+	assert.True(t, responseList.Records[0].Liked)
+	assert.Equal(t, int64(0), responseList.Records[1].Likes)
+	assert.False(t, responseList.Records[1].Liked)
 
 	for i, comment := range comments {
 		fmt.Printf("Expected Comment User: %+v\n", comment.User)
@@ -380,6 +418,7 @@ func TestGetCommentsByPostIdSuccess(t *testing.T) {
 		assert.True(t, comment.CreatedAt.Equal(responseList.Records[i].CreationDate))
 		assert.Equal(t, comment.User.Username, responseList.Records[i].Author.Username)
 		assert.Equal(t, comment.User.Nickname, responseList.Records[i].Author.Nickname)
+		assert.Nil(t, responseList.Records[i].ParentCommentId)
 
 		if comment.User.ImageId != nil {
 			assert.NotNil(t, responseList.Records[i].Author.Picture)
@@ -404,7 +443,7 @@ func TestGetCommentsByPostIdUnauthorized(t *testing.T) {
 	mockCommentRepository := new(repositories.MockCommentRepository)
 	mockUserRepository := new(repositories.MockUserRepository)
 
-	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository)
+	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository, nil, nil)
 	commentController := controllers.NewCommentController(commentService)
 
 	post := models.Post{
@@ -445,7 +484,7 @@ func TestGetCommentsByPostIdPostNotFound(t *testing.T) {
 	mockCommentRepository := new(repositories.MockCommentRepository)
 	mockUserRepository := new(repositories.MockUserRepository)
 
-	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository)
+	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository, nil, nil)
 	commentController := controllers.NewCommentController(commentService)
 
 	authenticationToken, err := utils.GenerateAccessToken("myUser")
@@ -487,3 +526,688 @@ func TestGetCommentsByPostIdPostNotFound(t *testing.T) {
 	mockPostRepository.AssertExpectations(t)
 	mockUserRepository.AssertExpectations(t)
 }
+
+// TestUpdateCommentSuccess tests the UpdateComment function if it returns 200 OK and the updated comment if the author edits it
+func TestUpdateCommentSuccess(t *testing.T) {
+	// Arrange
+	mockCommentRepository := new(repositories.MockCommentRepository)
+
+	mockLikeRepository := new(repositories.MockLikeRepository)
+
+	commentService := services.NewCommentService(mockCommentRepository, nil, nil, mockLikeRepository, nil)
+	commentController := controllers.NewCommentController(commentService)
+
+	testUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(testUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := models.Comment{
+		Id:        uuid.New(),
+		PostID:    uuid.New(),
+		Username:  testUsername,
+		User:      models.User{Username: testUsername, Nickname: "test user"},
+		Content:   "Tset comment",
+		CreatedAt: time.Now().UTC().Add(-time.Hour),
+	}
+	commentUpdateRequest := models.CommentUpdateRequestDTO{
+		Content: " Test comment ",
+	}
+
+	// Mock expectations
+	var capturedComment *models.Comment
+	mockCommentRepository.On("GetCommentById", comment.Id.String()).Return(comment, nil)
+	mockCommentRepository.On("UpdateComment", mock.AnythingOfType("*models.Comment")).
+		Run(func(args mock.Arguments) {
+			capturedComment = args.Get(0).(*models.Comment)
+		}).Return(nil)
+	mockCommentRepository.On("CountRepliesByCommentIds", []string{comment.Id.String()}).Return(map[string]int64{comment.Id.String(): 1}, nil)
+	mockLikeRepository.On("FindCommentLikesByCommentIds", []string{comment.Id.String()}, testUsername).Return([]models.CommentLike{}, nil)
+	mockLikeRepository.On("CountCommentLikesByCommentIds", []string{comment.Id.String()}).Return(map[string]int64{}, nil)
+
+	// Setup HTTP request
+	requestBody, err := json.Marshal(commentUpdateRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "/posts/" + comment.PostID.String() + "/comments/" + comment.Id.String()
+	req, _ := http.NewRequest("PATCH", url, bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PATCH("/posts/:postId/comments/:commentId", middleware.AuthorizeUser, commentController.UpdateComment)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+
+	var responseComment models.CommentResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseComment)
+	assert.NoError(t, err)
+
+	assert.Equal(t, comment.Id, capturedComment.Id)
+	assert.Equal(t, "Test comment", capturedComment.Content)
+	assert.NotNil(t, capturedComment.EditedAt)
+
+	assert.Equal(t, comment.Id, responseComment.CommentId)
+	assert.Equal(t, "Test comment", responseComment.Content)
+	assert.True(t, comment.CreatedAt.Equal(responseComment.CreationDate))
+	assert.NotNil(t, responseComment.EditedAt)
+	assert.Equal(t, testUsername, responseComment.Author.Username)
+	assert.Equal(t, int64(1), responseComment.Replies)
+
+	mockCommentRepository.AssertExpectations(t)
+}
+
+// TestUpdateCommentBadRequest tests the UpdateComment function if it returns 400 Bad Request when the content is invalid
+func TestUpdateCommentBadRequest(t *testing.T) {
+	invalidBodies := []string{
+		`{"content": ""}`,
+		`{"content": "   "}`,
+		`{"content": "` + strings.Repeat("a", 129) + `"}`,
+		`{"invalid": "body"}`,
+	}
+
+	for _, body := range invalidBodies {
+		// Arrange
+		mockCommentRepository := new(repositories.MockCommentRepository)
+
+		commentService := services.NewCommentService(mockCommentRepository, nil, nil, nil, nil)
+		commentController := controllers.NewCommentController(commentService)
+
+		authenticationToken, err := utils.GenerateAccessToken("testUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Setup HTTP request
+		url := "/posts/" + uuid.New().String() + "/comments/" + uuid.New().String()
+		req, _ := http.NewRequest("PATCH", url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.PATCH("/posts/:postId/comments/:commentId", middleware.AuthorizeUser, commentController.UpdateComment)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code) // Expect 400 Bad Request
+
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+
+		expectedCustomError := customerrors.BadRequest
+		assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+		assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+		mockCommentRepository.AssertExpectations(t)
+	}
+}
+
+// TestUpdateCommentForbidden tests the UpdateComment function if it returns 403 Forbidden when the user is not the author, even if the user owns the post
+func TestUpdateCommentForbidden(t *testing.T) {
+	// Arrange
+	mockCommentRepository := new(repositories.MockCommentRepository)
+
+	commentService := services.NewCommentService(mockCommentRepository, nil, nil, nil, nil)
+	commentController := controllers.NewCommentController(commentService)
+
+	testUsername := "postAuthor"
+	authenticationToken, err := utils.GenerateAccessToken(testUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := models.Comment{
+		Id:       uuid.New(),
+		PostID:   uuid.New(),
+		Post:     models.Post{Username: testUsername},
+		Username: "commentAuthor",
+		Content:  "Test comment",
+	}
+
+	// Mock expectations
+	mockCommentRepository.On("GetCommentById", comment.Id.String()).Return(comment, nil)
+
+	// Setup HTTP request
+	url := "/posts/" + comment.PostID.String() + "/comments/" + comment.Id.String()
+	req, _ := http.NewRequest("PATCH", url, strings.NewReader(`{"content": "Changed comment"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PATCH("/posts/:postId/comments/:commentId", middleware.AuthorizeUser, commentController.UpdateComment)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code) // Expect 403 Forbidden
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.UpdateCommentForbidden
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockCommentRepository.AssertExpectations(t)
+}
+
+// TestDeleteCommentSuccess tests the DeleteComment function if it returns 204 No Content for the comment author and the post author
+func TestDeleteCommentSuccess(t *testing.T) {
+	for _, currentUsername := range []string{"commentAuthor", "postAuthor"} {
+		// Arrange
+		mockCommentRepository := new(repositories.MockCommentRepository)
+
+		commentService := services.NewCommentService(mockCommentRepository, nil, nil, nil, nil)
+		commentController := controllers.NewCommentController(commentService)
+
+		authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		comment := models.Comment{
+			Id:       uuid.New(),
+			PostID:   uuid.New(),
+			Post:     models.Post{Username: "postAuthor"},
+			Username: "commentAuthor",
+			Content:  "Test comment",
+		}
+
+		// Mock expectations
+		mockCommentRepository.On("GetCommentById", comment.Id.String()).Return(comment, nil)
+		mockCommentRepository.On("DeleteCommentById", comment.Id.String()).Return(nil)
+
+		// Setup HTTP request
+		url := "/posts/" + comment.PostID.String() + "/comments/" + comment.Id.String()
+		req, _ := http.NewRequest("DELETE", url, nil)
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.DELETE("/posts/:postId/comments/:commentId", middleware.AuthorizeUser, commentController.DeleteComment)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNoContent, w.Code) // Expect 204 No Content
+
+		mockCommentRepository.AssertExpectations(t)
+	}
+}
+
+// TestDeleteCommentUnauthorized tests the DeleteComment function if it returns 401 Unauthorized when the user is not authenticated
+func TestDeleteCommentUnauthorized(t *testing.T) {
+	// Arrange
+	mockCommentRepository := new(repositories.MockCommentRepository)
+
+	commentService := services.NewCommentService(mockCommentRepository, nil, nil, nil, nil)
+	commentController := controllers.NewCommentController(commentService)
+
+	// Setup HTTP request without Authorization Header
+	url := "/posts/" + uuid.New().String() + "/comments/" + uuid.New().String()
+	req, _ := http.NewRequest("DELETE", url, nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/posts/:postId/comments/:commentId", middleware.AuthorizeUser, commentController.DeleteComment)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code) // Expect 401 Unauthorized
+
+	var errorResponse customerrors.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.Unauthorized
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockCommentRepository.AssertExpectations(t)
+}
+
+// TestDeleteCommentForbidden tests the DeleteComment function if it returns 403 Forbidden when the user is neither comment nor post author
+func TestDeleteCommentForbidden(t *testing.T) {
+	// Arrange
+	mockCommentRepository := new(repositories.MockCommentRepository)
+
+	commentService := services.NewCommentService(mockCommentRepository, nil, nil, nil, nil)
+	commentController := controllers.NewCommentController(commentService)
+
+	authenticationToken, err := utils.GenerateAccessToken("someoneElse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := models.Comment{
+		Id:       uuid.New(),
+		PostID:   uuid.New(),
+		Post:     models.Post{Username: "postAuthor"},
+		Username: "commentAuthor",
+		Content:  "Test comment",
+	}
+
+	// Mock expectations
+	mockCommentRepository.On("GetCommentById", comment.Id.String()).Return(comment, nil)
+
+	// Setup HTTP request
+	url := "/posts/" + comment.PostID.String() + "/comments/" + comment.Id.String()
+	req, _ := http.NewRequest("DELETE", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/posts/:postId/comments/:commentId", middleware.AuthorizeUser, commentController.DeleteComment)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code) // Expect 403 Forbidden
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.DeleteCommentForbidden
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockCommentRepository.AssertExpectations(t)
+}
+
+// TestDeleteCommentNotFound tests the DeleteComment function if it returns 404 Not Found when the comment does not exist or belongs to another post
+func TestDeleteCommentNotFound(t *testing.T) {
+	existingComment := models.Comment{
+		Id:       uuid.New(),
+		PostID:   uuid.New(),
+		Username: "testUser",
+	}
+
+	testCases := []struct {
+		name    string
+		comment models.Comment
+		err     error
+	}{
+		{"Comment not found", models.Comment{}, gorm.ErrRecordNotFound},
+		{"Comment of other post", existingComment, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			mockCommentRepository := new(repositories.MockCommentRepository)
+
+			commentService := services.NewCommentService(mockCommentRepository, nil, nil, nil, nil)
+			commentController := controllers.NewCommentController(commentService)
+
+			authenticationToken, err := utils.GenerateAccessToken("testUser")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Mock expectations
+			mockCommentRepository.On("GetCommentById", existingComment.Id.String()).Return(tc.comment, tc.err)
+
+			// Setup HTTP request
+			url := "/posts/" + uuid.New().String() + "/comments/" + existingComment.Id.String()
+			req, _ := http.NewRequest("DELETE", url, nil)
+			req.Header.Set("Authorization", "Bearer "+authenticationToken)
+			w := httptest.NewRecorder()
+
+			// Act
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.DELETE("/posts/:postId/comments/:commentId", middleware.AuthorizeUser, commentController.DeleteComment)
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusNotFound, w.Code) // Expect 404 Not Found
+
+			var errorResponse customerrors.ErrorResponse
+			err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+			assert.NoError(t, err)
+
+			expectedCustomError := customerrors.CommentNotFound
+			assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+			assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+			mockCommentRepository.AssertExpectations(t)
+		})
+	}
+}
+
+// TestCreateCommentReplySuccess tests the CreateComment function if it creates a reply to another comment with increased depth
+func TestCreateCommentReplySuccess(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+	mockCommentRepository := new(repositories.MockCommentRepository)
+	mockUserRepository := new(repositories.MockUserRepository)
+
+	mockNotificationRepository := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepository, nil, mockNotificationSettingRepo, nil)
+	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository, nil, notificationService)
+	commentController := controllers.NewCommentController(commentService)
+
+	user := models.User{
+		Username: "testUser",
+		Nickname: "test user",
+	}
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := models.Post{
+		Id:       uuid.New(),
+		Username: user.Username, // reply under own post, so no notification is created
+	}
+	parentComment := models.Comment{
+		Id:       uuid.New(),
+		PostID:   post.Id,
+		Username: "anotherUser",
+		Content:  "Parent comment",
+		Depth:    1,
+	}
+	commentCreateRequest := models.CommentCreateRequestDTO{
+		Content:         "Test reply",
+		ParentCommentId: parentComment.Id.String(),
+	}
+
+	// Mock expectations
+	var capturedComment *models.Comment
+	mockPostRepository.On("GetPostById", post.Id.String()).Return(post, nil)
+	mockCommentRepository.On("GetCommentById", parentComment.Id.String()).Return(parentComment, nil)
+	mockCommentRepository.On("CreateComment", mock.AnythingOfType("*models.Comment")).
+		Run(func(args mock.Arguments) {
+			capturedComment = args.Get(0).(*models.Comment)
+		}).Return(nil)
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+
+	// Setup HTTP request
+	requestBody, err := json.Marshal(commentCreateRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "/posts/" + post.Id.String() + "/comments"
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/posts/:postId/comments", middleware.AuthorizeUser, commentController.CreateComment)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code) // Expect 201 Created
+
+	var responseComment models.CommentResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseComment)
+	assert.NoError(t, err)
+
+	assert.Equal(t, parentComment.Id, *capturedComment.ParentCommentId)
+	assert.Equal(t, 2, capturedComment.Depth)
+	assert.Equal(t, post.Id, capturedComment.PostID)
+
+	assert.Equal(t, capturedComment.Id, responseComment.CommentId)
+	assert.Equal(t, parentComment.Id, *responseComment.ParentCommentId)
+	assert.Equal(t, int64(0), responseComment.Replies)
+
+	mockCommentRepository.AssertExpectations(t)
+	mockNotificationRepository.AssertNotCalled(t, "CreateNotification", mock.Anything)
+	mockPostRepository.AssertExpectations(t)
+	mockUserRepository.AssertExpectations(t)
+}
+
+// TestCreateCommentReplyDepthExceeded tests the CreateComment function if it returns 400 Bad Request when the maximum reply depth is reached
+func TestCreateCommentReplyDepthExceeded(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+	mockCommentRepository := new(repositories.MockCommentRepository)
+	mockUserRepository := new(repositories.MockUserRepository)
+
+	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, mockUserRepository, nil, nil)
+	commentController := controllers.NewCommentController(commentService)
+
+	authenticationToken, err := utils.GenerateAccessToken("testUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := models.Post{
+		Id: uuid.New(),
+	}
+	parentComment := models.Comment{
+		Id:     uuid.New(),
+		PostID: post.Id,
+		Depth:  3,
+	}
+	commentCreateRequest := models.CommentCreateRequestDTO{
+		Content:         "Test reply",
+		ParentCommentId: parentComment.Id.String(),
+	}
+
+	// Mock expectations
+	mockPostRepository.On("GetPostById", post.Id.String()).Return(post, nil)
+	mockCommentRepository.On("GetCommentById", parentComment.Id.String()).Return(parentComment, nil)
+
+	// Setup HTTP request
+	requestBody, err := json.Marshal(commentCreateRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "/posts/" + post.Id.String() + "/comments"
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/posts/:postId/comments", middleware.AuthorizeUser, commentController.CreateComment)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code) // Expect 400 Bad Request
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.CommentDepthExceeded
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockCommentRepository.AssertExpectations(t)
+	mockPostRepository.AssertExpectations(t)
+	mockUserRepository.AssertExpectations(t)
+}
+
+// TestGetRepliesByCommentIdSuccess tests the GetRepliesByCommentId function if it returns 200 OK and a paginated list of replies
+func TestGetRepliesByCommentIdSuccess(t *testing.T) {
+	// Arrange
+	mockCommentRepository := new(repositories.MockCommentRepository)
+
+	mockLikeRepository := new(repositories.MockLikeRepository)
+
+	commentService := services.NewCommentService(mockCommentRepository, nil, nil, mockLikeRepository, nil)
+	commentController := controllers.NewCommentController(commentService)
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parentComment := models.Comment{
+		Id:     uuid.New(),
+		PostID: uuid.New(),
+	}
+	replies := []models.Comment{
+		{
+			Id:              uuid.New(),
+			PostID:          parentComment.PostID,
+			Username:        "testUser",
+			User:            models.User{Username: "testUser", Nickname: "test user"},
+			Content:         "Test reply",
+			CreatedAt:       time.Now().UTC(),
+			ParentCommentId: &parentComment.Id,
+			Depth:           1,
+		},
+	}
+
+	offset := 2
+	limit := 1
+
+	// Mock expectations
+	mockCommentRepository.On("GetCommentById", parentComment.Id.String()).Return(parentComment, nil)
+	mockCommentRepository.On("GetRepliesByCommentId", parentComment.Id.String(), offset, limit).Return(replies, int64(3), nil)
+	mockCommentRepository.On("CountRepliesByCommentIds", []string{replies[0].Id.String()}).Return(map[string]int64{replies[0].Id.String(): 2}, nil)
+	mockLikeRepository.On("FindCommentLikesByCommentIds", []string{replies[0].Id.String()}, "myUser").
+		Return([]models.CommentLike{{Id: uuid.New(), CommentId: replies[0].Id, Username: "myUser"}}, nil)
+	mockLikeRepository.On("CountCommentLikesByCommentIds", []string{replies[0].Id.String()}).Return(map[string]int64{replies[0].Id.String(): 1}, nil)
+
+	// Setup HTTP request
+	url := "/posts/" + parentComment.PostID.String() + "/comments/" + parentComment.Id.String() + "/replies?offset=" + fmt.Sprint(offset) + "&limit=" + fmt.Sprint(limit)
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/posts/:postId/comments/:commentId/replies", middleware.AuthorizeUser, commentController.GetRepliesByCommentId)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+
+	var responseList models.CommentFeedResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseList)
+	assert.NoError(t, err)
+
+	assert.Len(t, responseList.Records, 1)
+	assert.Equal(t, int64(3), responseList.Pagination.Records)
+	assert.Equal(t, offset, responseList.Pagination.Offset)
+	assert.Equal(t, limit, responseList.Pagination.Limit)
+
+	assert.Equal(t, replies[0].Id, responseList.Records[0].CommentId)
+	assert.Equal(t, replies[0].Content, responseList.Records[0].Content)
+	assert.Equal(t, parentComment.Id, *responseList.Records[0].ParentCommentId)
+	assert.Equal(t, int64(2), responseList.Records[0].Replies)
+	assert.Equal(t, int64(1), responseList.Records[0].Likes)
+	assert.True(t, responseList.Records[0].Liked)
+	assert.Equal(t, replies[0].User.Username, responseList.Records[0].Author.Username)
+
+	mockCommentRepository.AssertExpectations(t)
+}
+
+// TestGetRepliesByCommentIdCommentNotFound tests the GetRepliesByCommentId function if it returns 404 Not Found when the comment does not exist
+func TestGetRepliesByCommentIdCommentNotFound(t *testing.T) {
+	// Arrange
+	mockCommentRepository := new(repositories.MockCommentRepository)
+
+	commentService := services.NewCommentService(mockCommentRepository, nil, nil, nil, nil)
+	commentController := controllers.NewCommentController(commentService)
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commentId := uuid.New().String()
+
+	// Mock expectations
+	mockCommentRepository.On("GetCommentById", commentId).Return(models.Comment{}, gorm.ErrRecordNotFound)
+
+	// Setup HTTP request
+	url := "/posts/" + uuid.New().String() + "/comments/" + commentId + "/replies"
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/posts/:postId/comments/:commentId/replies", middleware.AuthorizeUser, commentController.GetRepliesByCommentId)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code) // Expect 404 Not Found
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.CommentNotFound
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockCommentRepository.AssertExpectations(t)
+}
+
+// TestGetCommentsByPostIdSortByLikes tests the GetCommentsByPostId function if it requests the comments sorted by likes when sort=likes is given
+func TestGetCommentsByPostIdSortByLikes(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+	mockCommentRepository := new(repositories.MockCommentRepository)
+
+	commentService := services.NewCommentService(mockCommentRepository, mockPostRepository, nil, nil, nil)
+	commentController := controllers.NewCommentController(commentService)
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postId := uuid.New().String()
+
+	// Mock expectations
+	mockPostRepository.On("GetPostById", postId).Return(models.Post{}, nil)
+	mockCommentRepository.On("GetCommentsByPostId", postId, 0, 10, true).Return([]models.Comment{}, int64(0), nil)
+
+	// Setup HTTP request
+	url := "/posts/" + postId + "/comments?sort=likes"
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/posts/:postId/comments", middleware.AuthorizeUser, commentController.GetCommentsByPostId)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+
+	var responseList models.CommentFeedResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseList)
+	assert.NoError(t, err)
+	assert.Empty(t, responseList.Records)
+
+	mockCommentRepository.AssertExpectations(t)
+	mockPostRepository.AssertExpectations(t)
+}
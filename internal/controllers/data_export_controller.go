@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
+	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"net/http"
+)
+
+type DataExportControllerInterface interface {
+	CreateDataExport(c *gin.Context)
+	GetDataExportArchive(c *gin.Context)
+}
+
+type DataExportController struct {
+	dataExportService services.DataExportServiceInterface
+}
+
+// NewDataExportController can be used as a constructor to create a DataExportController "object"
+func NewDataExportController(dataExportService services.DataExportServiceInterface) *DataExportController {
+	return &DataExportController{dataExportService: dataExportService}
+}
+
+// CreateDataExport starts an export of all data of the logged-in user, the download link is sent via mail
+func (controller *DataExportController) CreateDataExport(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	responseDto, serviceErr, httpStatus := controller.dataExportService.CreateDataExport(username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, responseDto)
+}
+
+// GetDataExportArchive returns the zip archive of a data export, the token from the mail is used for authentication
+func (controller *DataExportController) GetDataExportArchive(c *gin.Context) {
+	// Read export id and token from url
+	exportId := c.Param("exportId")
+	token := c.Query("token")
+
+	archive, serviceErr, httpStatus := controller.dataExportService.GetDataExportArchive(exportId, token)
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=server-beta-export-"+exportId+".zip")
+	c.Data(httpStatus, "application/zip", archive)
+}
@@ -0,0 +1,475 @@
+package controllers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wwi21seb-projekt/server-beta/internal/controllers"
+	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
+	"github.com/wwi21seb-projekt/server-beta/internal/middleware"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestCreateDataExportSuccess tests if CreateDataExport returns 202-Accepted and builds the archive and sends the mail in the background
+func TestCreateDataExportSuccess(t *testing.T) {
+	// Arrange
+	mockDataExportRepository := new(repositories.MockDataExportRepository)
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockMailService := new(services.MockMailService)
+	dataExportService := services.NewDataExportService(mockDataExportRepository, mockUserRepository, mockMailService)
+	dataExportController := controllers.NewDataExportController(dataExportService)
+
+	user := models.User{
+		Username:           "testUser",
+		Nickname:           "Test User",
+		Email:              "test@domain.com",
+		Locale:             "de",
+		PresenceVisibility: services.PresenceVisibilityContacts,
+	}
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imageId := uuid.New()
+	postId := uuid.New()
+	likedCommentId := uuid.New()
+	exportData := models.UserExportData{
+		User:          user,
+		DigestSetting: &models.DigestSetting{Id: uuid.New(), Username: user.Username, Frequency: "daily"},
+		Posts: []models.Post{
+			{
+				Id:       postId,
+				Username: user.Username,
+				Content:  "Test post #test",
+				Hashtags: []models.Hashtag{{Id: uuid.New(), Name: "test"}},
+				ImageId:  &imageId,
+				Image:    models.Image{Id: imageId, Format: "png", ImageData: []byte("image data")},
+			},
+		},
+		PostRevisions:        []models.PostRevision{{Id: uuid.New(), PostId: postId, Content: "Old test post"}},
+		Comments:             []models.Comment{{Id: uuid.New(), PostID: uuid.New(), Username: user.Username, Content: "Test comment"}},
+		Likes:                []models.Like{{Id: uuid.New(), PostId: uuid.New(), Username: user.Username}},
+		CommentLikes:         []models.CommentLike{{Id: uuid.New(), CommentId: likedCommentId, Username: user.Username}},
+		NotificationSettings: []models.NotificationSetting{{Id: uuid.New(), Username: user.Username, NotificationType: "like", InApp: true, Push: false}},
+		NotificationMutes:    []models.NotificationMute{{Id: uuid.New(), Username: user.Username, MutedUsername: "mutedUser"}},
+		PushSubscriptions: []models.PushSubscription{
+			{Id: uuid.New(), Username: user.Username, Type: "expo", ExpoToken: "ExponentPushToken[test]"},
+		},
+	}
+
+	// Mock expectations
+	var capturedExport *models.DataExport
+	var capturedArchive []byte
+	mailSent := make(chan bool, 1)
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+	mockDataExportRepository.On("GetPendingDataExportByUsername", user.Username).Return(&models.DataExport{}, gorm.ErrRecordNotFound)
+	mockDataExportRepository.On("CreateDataExport", mock.AnythingOfType("*models.DataExport")).
+		Run(func(args mock.Arguments) {
+			capturedExport = args.Get(0).(*models.DataExport)
+		}).Return(nil)
+	mockDataExportRepository.On("GetUserExportData", user.Username).Return(&exportData, nil)
+	mockDataExportRepository.On("UpdateDataExport", mock.AnythingOfType("*models.DataExport")).
+		Run(func(args mock.Arguments) {
+			updatedExport := args.Get(0).(*models.DataExport)
+			assert.Equal(t, "ready", updatedExport.Status)
+			capturedArchive = updatedExport.Data
+		}).Return(nil)
+	mockMailService.On("SendMail", user.Email, "Dein Datenexport ist bereit", mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			mailSent <- true
+		}).Return(nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/users/me/export", middleware.AuthorizeUser, dataExportController.CreateDataExport)
+	router.ServeHTTP(w, req)
+
+	// Wait for background job
+	select {
+	case <-mailSent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("data export mail was not sent")
+	}
+
+	// Assert
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var responseDto models.DataExportResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseDto)
+	assert.NoError(t, err)
+	assert.Equal(t, capturedExport.Id.String(), responseDto.ExportId)
+	assert.Equal(t, "pending", responseDto.Status)
+
+	assert.Equal(t, user.Username, capturedExport.Username)
+	assert.Len(t, capturedExport.Token, 64)
+	assert.True(t, capturedExport.ExpirationTime.After(time.Now()))
+
+	// Check contents of archive
+	zipReader, err := zip.NewReader(bytes.NewReader(capturedArchive), int64(len(capturedArchive)))
+	assert.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, file := range zipReader.File {
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		files[file.Name] = content
+	}
+	for _, fileName := range []string{"profile.json", "posts.json", "comments.json", "likes.json", "comment_likes.json", "subscriptions.json", "notifications.json", "notification_settings.json", "push_subscriptions.json", "messages.json"} {
+		assert.Contains(t, files, fileName)
+	}
+	assert.Equal(t, []byte("image data"), files["images/"+imageId.String()+".png"])
+
+	var exportedProfile models.ExportProfileDTO
+	err = json.Unmarshal(files["profile.json"], &exportedProfile)
+	assert.NoError(t, err)
+	assert.Equal(t, user.Username, exportedProfile.Username)
+	assert.Equal(t, "daily", exportedProfile.DigestFrequency)
+	assert.Equal(t, "de", exportedProfile.Locale)
+	assert.Equal(t, services.PresenceVisibilityContacts, exportedProfile.PresenceVisibility)
+
+	var exportedPosts []models.ExportPostDTO
+	err = json.Unmarshal(files["posts.json"], &exportedPosts)
+	assert.NoError(t, err)
+	assert.Len(t, exportedPosts, 1)
+	assert.Equal(t, postId.String(), exportedPosts[0].PostId)
+	assert.Equal(t, []string{"test"}, exportedPosts[0].Hashtags)
+	assert.Equal(t, "images/"+imageId.String()+".png", exportedPosts[0].Picture)
+	assert.Len(t, exportedPosts[0].Revisions, 1)
+	assert.Equal(t, "Old test post", exportedPosts[0].Revisions[0].Content)
+
+	var exportedCommentLikes []models.ExportCommentLikeDTO
+	err = json.Unmarshal(files["comment_likes.json"], &exportedCommentLikes)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ExportCommentLikeDTO{{CommentId: likedCommentId.String()}}, exportedCommentLikes)
+
+	var exportedNotificationSettings models.ExportNotificationSettingsDTO
+	err = json.Unmarshal(files["notification_settings.json"], &exportedNotificationSettings)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.NotificationTypeSettingDTO{{NotificationType: "like", InApp: true, Push: false}}, exportedNotificationSettings.Settings)
+	assert.Len(t, exportedNotificationSettings.MutedUsers, 1)
+	assert.Equal(t, "mutedUser", exportedNotificationSettings.MutedUsers[0].Username)
+
+	var exportedPushSubscriptions []models.ExportPushSubscriptionDTO
+	err = json.Unmarshal(files["push_subscriptions.json"], &exportedPushSubscriptions)
+	assert.NoError(t, err)
+	assert.Len(t, exportedPushSubscriptions, 1)
+	assert.Equal(t, "expo", exportedPushSubscriptions[0].Type)
+	assert.Equal(t, "ExponentPushToken[test]", exportedPushSubscriptions[0].Token)
+
+	mockUserRepository.AssertExpectations(t)
+	mockDataExportRepository.AssertExpectations(t)
+	mockMailService.AssertExpectations(t)
+}
+
+// TestCreateDataExportPending tests if CreateDataExport returns the export that is still being built instead of starting another one
+func TestCreateDataExportPending(t *testing.T) {
+	// Arrange
+	mockDataExportRepository := new(repositories.MockDataExportRepository)
+	mockUserRepository := new(repositories.MockUserRepository)
+	dataExportService := services.NewDataExportService(mockDataExportRepository, mockUserRepository, nil)
+	dataExportController := controllers.NewDataExportController(dataExportService)
+
+	user := models.User{
+		Username: "testUser",
+		Email:    "test@domain.com",
+	}
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pendingExport := models.DataExport{
+		Id:             uuid.New(),
+		Username:       user.Username,
+		Status:         "pending",
+		CreatedAt:      time.Now().Add(-time.Minute),
+		ExpirationTime: time.Now().Add(7 * 24 * time.Hour),
+	}
+
+	// Mock expectations
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+	mockDataExportRepository.On("GetPendingDataExportByUsername", user.Username).Return(&pendingExport, nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/users/me/export", middleware.AuthorizeUser, dataExportController.CreateDataExport)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var responseDto models.DataExportResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseDto)
+	assert.NoError(t, err)
+	assert.Equal(t, pendingExport.Id.String(), responseDto.ExportId)
+	assert.Equal(t, "pending", responseDto.Status)
+
+	mockUserRepository.AssertExpectations(t)
+	mockDataExportRepository.AssertExpectations(t)
+	mockDataExportRepository.AssertNotCalled(t, "CreateDataExport", mock.Anything)
+}
+
+// TestCreateDataExportStalePending tests if CreateDataExport marks a pending export that is not built anymore as failed and starts a new one
+func TestCreateDataExportStalePending(t *testing.T) {
+	// Arrange
+	mockDataExportRepository := new(repositories.MockDataExportRepository)
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockMailService := new(services.MockMailService)
+	dataExportService := services.NewDataExportService(mockDataExportRepository, mockUserRepository, mockMailService)
+	dataExportController := controllers.NewDataExportController(dataExportService)
+
+	user := models.User{
+		Username: "testUser",
+		Email:    "test@domain.com",
+	}
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stalePendingExport := models.DataExport{
+		Id:             uuid.New(),
+		Username:       user.Username,
+		Status:         "pending",
+		CreatedAt:      time.Now().Add(-2 * time.Hour), // server restarted while building the archive
+		ExpirationTime: time.Now().Add(7*24*time.Hour - 2*time.Hour),
+	}
+
+	// Mock expectations
+	var capturedExport *models.DataExport
+	mailSent := make(chan bool, 1)
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+	mockDataExportRepository.On("GetPendingDataExportByUsername", user.Username).Return(&stalePendingExport, nil)
+	mockDataExportRepository.On("UpdateDataExport", &stalePendingExport).Return(nil).Once()
+	mockDataExportRepository.On("CreateDataExport", mock.AnythingOfType("*models.DataExport")).
+		Run(func(args mock.Arguments) {
+			capturedExport = args.Get(0).(*models.DataExport)
+		}).Return(nil)
+	mockDataExportRepository.On("GetUserExportData", user.Username).Return(&models.UserExportData{User: user}, nil)
+	mockDataExportRepository.On("UpdateDataExport", mock.AnythingOfType("*models.DataExport")).Return(nil).Once()
+	mockMailService.On("SendMail", user.Email, "Your data export is ready", mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			mailSent <- true
+		}).Return(nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/users/me/export", middleware.AuthorizeUser, dataExportController.CreateDataExport)
+	router.ServeHTTP(w, req)
+
+	// Wait for background job
+	select {
+	case <-mailSent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("data export mail was not sent")
+	}
+
+	// Assert
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var responseDto models.DataExportResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseDto)
+	assert.NoError(t, err)
+	assert.Equal(t, capturedExport.Id.String(), responseDto.ExportId)
+	assert.NotEqual(t, stalePendingExport.Id.String(), responseDto.ExportId)
+	assert.Equal(t, "failed", stalePendingExport.Status)
+
+	mockUserRepository.AssertExpectations(t)
+	mockDataExportRepository.AssertExpectations(t)
+	mockMailService.AssertExpectations(t)
+}
+
+// TestCreateDataExportUnauthorized tests if CreateDataExport returns 401-Unauthorized when the user is not logged in
+func TestCreateDataExportUnauthorized(t *testing.T) {
+	// Arrange
+	mockDataExportRepository := new(repositories.MockDataExportRepository)
+	dataExportService := services.NewDataExportService(mockDataExportRepository, nil, nil)
+	dataExportController := controllers.NewDataExportController(dataExportService)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer invalidToken")
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/users/me/export", middleware.AuthorizeUser, dataExportController.CreateDataExport)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var errorResponse customerrors.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.Unauthorized
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockDataExportRepository.AssertExpectations(t)
+}
+
+// TestGetDataExportArchiveSuccess tests if GetDataExportArchive returns the zip archive when the token is valid
+func TestGetDataExportArchiveSuccess(t *testing.T) {
+	// Arrange
+	mockDataExportRepository := new(repositories.MockDataExportRepository)
+	dataExportService := services.NewDataExportService(mockDataExportRepository, nil, nil)
+	dataExportController := controllers.NewDataExportController(dataExportService)
+
+	dataExport := models.DataExport{
+		Id:             uuid.New(),
+		Username:       "testUser",
+		Status:         "ready",
+		Token:          "validToken",
+		Data:           []byte("zip data"),
+		CreatedAt:      time.Now(),
+		ExpirationTime: time.Now().Add(time.Hour),
+	}
+
+	// Mock expectations
+	mockDataExportRepository.On("GetDataExportById", dataExport.Id.String()).Return(&dataExport, nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/exports/"+dataExport.Id.String()+"?token="+dataExport.Token, nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/exports/:exportId", dataExportController.GetDataExportArchive)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, dataExport.Data, w.Body.Bytes())
+
+	mockDataExportRepository.AssertExpectations(t)
+}
+
+// TestGetDataExportArchiveNotFound tests if GetDataExportArchive returns 404-Not Found for unknown exports, invalid tokens and expired exports
+func TestGetDataExportArchiveNotFound(t *testing.T) {
+	exportId := uuid.New()
+	testCases := []struct {
+		name       string
+		dataExport *models.DataExport
+		err        error
+		token      string
+	}{
+		{"Export not found", &models.DataExport{}, gorm.ErrRecordNotFound, "validToken"},
+		{"Invalid token", &models.DataExport{Id: exportId, Status: "ready", Token: "validToken", ExpirationTime: time.Now().Add(time.Hour)}, nil, "invalidToken"},
+		{"Missing token", &models.DataExport{Id: exportId, Status: "ready", Token: "validToken", ExpirationTime: time.Now().Add(time.Hour)}, nil, ""},
+		{"Expired export", &models.DataExport{Id: exportId, Status: "ready", Token: "validToken", ExpirationTime: time.Now().Add(-time.Hour)}, nil, "validToken"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			mockDataExportRepository := new(repositories.MockDataExportRepository)
+			dataExportService := services.NewDataExportService(mockDataExportRepository, nil, nil)
+			dataExportController := controllers.NewDataExportController(dataExportService)
+
+			// Mock expectations
+			mockDataExportRepository.On("GetDataExportById", exportId.String()).Return(tc.dataExport, tc.err)
+
+			// Setup HTTP request
+			req, _ := http.NewRequest("GET", "/exports/"+exportId.String()+"?token="+tc.token, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.GET("/exports/:exportId", dataExportController.GetDataExportArchive)
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusNotFound, w.Code)
+
+			var errorResponse customerrors.ErrorResponse
+			err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+			assert.NoError(t, err)
+
+			expectedCustomError := customerrors.DataExportNotFound
+			assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+			assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+			mockDataExportRepository.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetDataExportArchiveNotReady tests if GetDataExportArchive returns 409-Conflict when the archive is still being built
+func TestGetDataExportArchiveNotReady(t *testing.T) {
+	// Arrange
+	mockDataExportRepository := new(repositories.MockDataExportRepository)
+	dataExportService := services.NewDataExportService(mockDataExportRepository, nil, nil)
+	dataExportController := controllers.NewDataExportController(dataExportService)
+
+	dataExport := models.DataExport{
+		Id:             uuid.New(),
+		Status:         "pending",
+		Token:          "validToken",
+		ExpirationTime: time.Now().Add(time.Hour),
+	}
+
+	// Mock expectations
+	mockDataExportRepository.On("GetDataExportById", dataExport.Id.String()).Return(&dataExport, nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/exports/"+dataExport.Id.String()+"?token="+dataExport.Token, nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/exports/:exportId", dataExportController.GetDataExportArchive)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var errorResponse customerrors.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.DataExportNotReady
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockDataExportRepository.AssertExpectations(t)
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
+	"net/http"
+)
+
+type DigestControllerInterface interface {
+	GetDigestSetting(c *gin.Context)
+	UpdateDigestSetting(c *gin.Context)
+	GetUnsubscribePage(c *gin.Context)
+	Unsubscribe(c *gin.Context)
+}
+
+type DigestController struct {
+	digestService services.DigestServiceInterface
+}
+
+// NewDigestController can be used as a constructor to create a DigestController "object"
+func NewDigestController(digestService services.DigestServiceInterface) *DigestController {
+	return &DigestController{digestService: digestService}
+}
+
+// GetDigestSetting returns the email digest frequency of the logged-in user
+func (controller *DigestController) GetDigestSetting(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	responseDto, serviceErr, httpStatus := controller.digestService.GetDigestSetting(username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, responseDto)
+}
+
+// UpdateDigestSetting changes the email digest frequency of the logged-in user
+func (controller *DigestController) UpdateDigestSetting(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Read body
+	var dto models.DigestSettingUpdateRequestDTO
+	if c.ShouldBindJSON(&dto) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	responseDto, serviceErr, httpStatus := controller.digestService.UpdateDigestSetting(&dto, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, responseDto)
+}
+
+// GetUnsubscribePage returns the page that the unsubscribe link in the mail opens,
+// the digest is only turned off when the user confirms it on the page, because opening a link must not change anything
+func (controller *DigestController) GetUnsubscribePage(c *gin.Context) {
+	locale := utils.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(utils.GetDigestUnsubscribePage(locale)))
+}
+
+// Unsubscribe turns off the email digest, the token from the unsubscribe link in the mail is used for authentication
+// Forms of the unsubscribe page are answered with a page instead of json
+func (controller *DigestController) Unsubscribe(c *gin.Context) {
+	// Read token from url
+	token := c.Query("token")
+
+	responseDto, serviceErr, httpStatus := controller.digestService.Unsubscribe(token)
+	if c.ContentType() == "application/x-www-form-urlencoded" {
+		locale := utils.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+		c.Data(httpStatus, "text/html; charset=utf-8", []byte(utils.GetDigestUnsubscribedPage(locale, serviceErr == nil)))
+		return
+	}
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, responseDto)
+}
@@ -0,0 +1,283 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wwi21seb-projekt/server-beta/internal/controllers"
+	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
+	"github.com/wwi21seb-projekt/server-beta/internal/middleware"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestGetDigestSettingDefault tests if GetDigestSetting returns the default frequency if the user has not chosen one yet
+func TestGetDigestSettingDefault(t *testing.T) {
+	// Arrange
+	mockDigestRepository := new(repositories.MockDigestRepository)
+	digestService := services.NewDigestService(mockDigestRepository)
+	digestController := controllers.NewDigestController(digestService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockDigestRepository.On("GetDigestSettingByUsername", currentUsername).Return(&models.DigestSetting{}, gorm.ErrRecordNotFound)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodGet, "/users/me/digest-settings", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/users/me/digest-settings", middleware.AuthorizeUser, digestController.GetDigestSetting)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+	mockDigestRepository.AssertExpectations(t)
+
+	var responseDto models.DigestSettingResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseDto)
+	assert.NoError(t, err)
+	assert.Equal(t, services.DefaultDigestFrequency, responseDto.Frequency)
+}
+
+// TestUpdateDigestSettingSuccess tests if UpdateDigestSetting creates a digest setting with unsubscribe token for a user without one
+func TestUpdateDigestSettingSuccess(t *testing.T) {
+	// Arrange
+	mockDigestRepository := new(repositories.MockDigestRepository)
+	digestService := services.NewDigestService(mockDigestRepository)
+	digestController := controllers.NewDigestController(digestService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	var capturedSetting *models.DigestSetting
+	mockDigestRepository.On("GetDigestSettingByUsername", currentUsername).Return(&models.DigestSetting{}, gorm.ErrRecordNotFound)
+	mockDigestRepository.On("CreateDigestSetting", mock.AnythingOfType("*models.DigestSetting")).
+		Run(func(args mock.Arguments) {
+			capturedSetting = args.Get(0).(*models.DigestSetting)
+		}).Return(nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPut, "/users/me/digest-settings", strings.NewReader(`{"frequency": "daily"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PUT("/users/me/digest-settings", middleware.AuthorizeUser, digestController.UpdateDigestSetting)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+	mockDigestRepository.AssertExpectations(t)
+
+	assert.Equal(t, currentUsername, capturedSetting.Username)
+	assert.Equal(t, "daily", capturedSetting.Frequency)
+	assert.NotEmpty(t, capturedSetting.UnsubscribeToken)
+
+	var responseDto models.DigestSettingResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseDto)
+	assert.NoError(t, err)
+	assert.Equal(t, "daily", responseDto.Frequency)
+}
+
+// TestUpdateDigestSettingBadRequest tests if UpdateDigestSetting returns 400-Bad Request for an unknown frequency
+func TestUpdateDigestSettingBadRequest(t *testing.T) {
+	// Arrange
+	mockDigestRepository := new(repositories.MockDigestRepository)
+	digestService := services.NewDigestService(mockDigestRepository)
+	digestController := controllers.NewDigestController(digestService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPut, "/users/me/digest-settings", strings.NewReader(`{"frequency": "hourly"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PUT("/users/me/digest-settings", middleware.AuthorizeUser, digestController.UpdateDigestSetting)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code) // Expect HTTP 400 Bad Request
+	mockDigestRepository.AssertNotCalled(t, "CreateDigestSetting", mock.Anything)
+	mockDigestRepository.AssertNotCalled(t, "UpdateDigestSetting", mock.Anything)
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.BadRequest
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+}
+
+// TestUnsubscribeDigestSuccess tests if Unsubscribe turns off the digest of the user the token belongs to
+func TestUnsubscribeDigestSuccess(t *testing.T) {
+	// Arrange
+	mockDigestRepository := new(repositories.MockDigestRepository)
+	digestService := services.NewDigestService(mockDigestRepository)
+	digestController := controllers.NewDigestController(digestService)
+
+	setting := models.DigestSetting{
+		Id:               uuid.New(),
+		Username:         "testUser",
+		Frequency:        "weekly",
+		UnsubscribeToken: "token",
+	}
+
+	// Mock expectations
+	var capturedSetting *models.DigestSetting
+	mockDigestRepository.On("GetDigestSettingByToken", setting.UnsubscribeToken).Return(&setting, nil)
+	mockDigestRepository.On("UpdateDigestSetting", mock.AnythingOfType("*models.DigestSetting")).
+		Run(func(args mock.Arguments) {
+			capturedSetting = args.Get(0).(*models.DigestSetting)
+		}).Return(nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPost, "/digests/unsubscribe?token="+setting.UnsubscribeToken, nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/digests/unsubscribe", digestController.Unsubscribe)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+	mockDigestRepository.AssertExpectations(t)
+	assert.Equal(t, setting.Id, capturedSetting.Id)
+	assert.Equal(t, "off", capturedSetting.Frequency)
+}
+
+// TestUnsubscribeDigestInvalidToken tests if Unsubscribe returns 404-Not Found for an invalid or missing token
+func TestUnsubscribeDigestInvalidToken(t *testing.T) {
+	for _, token := range []string{"", "invalid"} {
+		// Arrange
+		mockDigestRepository := new(repositories.MockDigestRepository)
+		digestService := services.NewDigestService(mockDigestRepository)
+		digestController := controllers.NewDigestController(digestService)
+
+		// Mock expectations
+		mockDigestRepository.On("GetDigestSettingByToken", "invalid").Return(&models.DigestSetting{}, gorm.ErrRecordNotFound)
+
+		// Setup HTTP request and recorder
+		req, _ := http.NewRequest(http.MethodPost, "/digests/unsubscribe?token="+token, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.POST("/digests/unsubscribe", digestController.Unsubscribe)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code) // Expect HTTP 404 Not Found
+		mockDigestRepository.AssertNotCalled(t, "UpdateDigestSetting", mock.Anything)
+
+		var errorResponse customerrors.ErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+
+		expectedCustomError := customerrors.DigestSettingNotFound
+		assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+		assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+	}
+}
+
+// TestGetUnsubscribePage tests if GetUnsubscribePage returns a page with a form to confirm unsubscribing without turning off the digest
+func TestGetUnsubscribePage(t *testing.T) {
+	// Arrange
+	mockDigestRepository := new(repositories.MockDigestRepository)
+	digestService := services.NewDigestService(mockDigestRepository)
+	digestController := controllers.NewDigestController(digestService)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodGet, "/digests/unsubscribe?token=token", nil)
+	req.Header.Set("Accept-Language", "de")
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/digests/unsubscribe", digestController.GetUnsubscribePage)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `<form method="post">`)
+	assert.Contains(t, w.Body.String(), "Abbestellen")
+	mockDigestRepository.AssertNotCalled(t, "GetDigestSettingByToken", mock.Anything)
+	mockDigestRepository.AssertNotCalled(t, "UpdateDigestSetting", mock.Anything)
+}
+
+// TestUnsubscribeDigestForm tests if Unsubscribe answers the form of the unsubscribe page with a page
+func TestUnsubscribeDigestForm(t *testing.T) {
+	// Arrange
+	mockDigestRepository := new(repositories.MockDigestRepository)
+	digestService := services.NewDigestService(mockDigestRepository)
+	digestController := controllers.NewDigestController(digestService)
+
+	setting := models.DigestSetting{
+		Id:               uuid.New(),
+		Username:         "testUser",
+		Frequency:        "daily",
+		UnsubscribeToken: "token",
+	}
+
+	// Mock expectations
+	mockDigestRepository.On("GetDigestSettingByToken", setting.UnsubscribeToken).Return(&setting, nil)
+	mockDigestRepository.On("UpdateDigestSetting", &setting).Return(nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPost, "/digests/unsubscribe?token="+setting.UnsubscribeToken, nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/digests/unsubscribe", digestController.Unsubscribe)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "You will not receive email digests anymore.")
+	assert.Equal(t, "off", setting.Frequency)
+	mockDigestRepository.AssertExpectations(t)
+}
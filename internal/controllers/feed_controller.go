@@ -13,6 +13,8 @@ type FeedControllerInterface interface {
 	GetPostsByUserUsername(c *gin.Context)
 	GetPostFeed(c *gin.Context)
 	GetPostsByHashtag(c *gin.Context)
+	GetPostsByMention(c *gin.Context)
+	GetPostById(c *gin.Context)
 }
 
 type FeedController struct {
@@ -146,3 +148,59 @@ func (controller *FeedController) GetPostsByHashtag(c *gin.Context) {
 
 	c.JSON(httpStatus, feedDto)
 }
+
+// GetPostsByMention is a controller function that gets posts mentioning a user and can be called from router
+func (controller *FeedController) GetPostsByMention(c *gin.Context) {
+	// Check if user is logged in
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Read parameters from url
+	username := c.Param("username")
+	lastPostId := c.DefaultQuery("postId", "")
+	limitQuery := c.DefaultQuery("limit", "10")
+
+	limit, err := strconv.Atoi(limitQuery)
+	if err != nil {
+		limit = 10
+	}
+
+	feedDto, serviceErr, httpStatus := controller.feedService.GetPostsByMention(username, lastPostId, limit, currentUsername.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, feedDto)
+}
+
+// GetPostById is a controller function that gets a single post with its first page of comments and can be called from router.go
+func (controller *FeedController) GetPostById(c *gin.Context) {
+	postId := c.Param("postId")
+
+	// Check if user is logged in
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	postDto, serviceErr, httpStatus := controller.feedService.GetPostById(postId, currentUsername.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, postDto)
+}
@@ -1020,3 +1020,329 @@ func TestGetPostsByHashtagUnauthorized(t *testing.T) {
 		assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
 	}
 }
+
+// TestGetPostsByMentionSuccess tests if the GetPostsByMention function returns the posts mentioning a user with their mentions and 200 ok
+func TestGetPostsByMentionSuccess(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockPostRepository := new(repositories.MockPostRepository)
+	mockLikeRepository := new(repositories.MockLikeRepository)
+	mockCommentRepository := new(repositories.MockCommentRepository)
+
+	feedService := services.NewFeedService(
+		mockPostRepository,
+		mockUserRepository,
+		mockLikeRepository,
+		mockCommentRepository,
+	)
+	feedController := controllers.NewFeedController(feedService)
+
+	mentionedUser := models.User{
+		Username: "mentionedUser",
+		Nickname: "mentionedNickname",
+	}
+	posts := []models.Post{
+		{
+			Id:       uuid.New(),
+			Username: "testUser",
+			User: models.User{
+				Username: "testUser",
+				Nickname: "testNickname",
+			},
+			Content:   "Hello @mentionedUser",
+			CreatedAt: time.Now().UTC(),
+			Mentions:  []models.User{mentionedUser},
+		},
+	}
+
+	currentUsername := "someOtherUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totalCount := int64(3)
+	limit := 1
+	lastPost := models.Post{
+		Id:        uuid.New(),
+		Username:  "testUser",
+		Content:   "Older post for @mentionedUser",
+		CreatedAt: time.Now().UTC().Add(-1 * time.Hour),
+	}
+
+	// Mock expectations
+	mockUserRepository.On("FindUserByUsername", mentionedUser.Username).Return(&mentionedUser, nil)
+	mockPostRepository.On("GetPostById", lastPost.Id.String()).Return(lastPost, nil)
+	mockPostRepository.On("GetPostsByMention", mentionedUser.Username, &lastPost, limit).Return(posts, totalCount, nil)
+	mockLikeRepository.On("CountLikes", posts[0].Id.String()).Return(int64(0), nil)
+	mockLikeRepository.On("FindLike", posts[0].Id.String(), currentUsername).Return(&models.Like{}, gorm.ErrRecordNotFound)
+	mockCommentRepository.On("CountComments", posts[0].Id.String()).Return(int64(0), nil)
+
+	// Setup HTTP request
+	url := "/users/" + mentionedUser.Username + "/mentions?postId=" + lastPost.Id.String() + "&limit=" + fmt.Sprint(limit)
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/users/:username/mentions", middleware.AuthorizeUser, feedController.GetPostsByMention)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 ok
+
+	var responsePostFeed models.GeneralFeedDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responsePostFeed)
+	assert.NoError(t, err)
+
+	assert.Len(t, responsePostFeed.Records, 1)
+	assert.Equal(t, posts[0].Id, responsePostFeed.Records[0].PostId)
+	assert.Equal(t, posts[0].Content, responsePostFeed.Records[0].Content)
+	assert.Len(t, responsePostFeed.Records[0].Mentions, 1)
+	assert.Equal(t, mentionedUser.Username, responsePostFeed.Records[0].Mentions[0].Username)
+	assert.Equal(t, mentionedUser.Nickname, responsePostFeed.Records[0].Mentions[0].Nickname)
+
+	assert.Equal(t, limit, responsePostFeed.Pagination.Limit)
+	assert.Equal(t, totalCount, responsePostFeed.Pagination.Records)
+	assert.Equal(t, posts[0].Id.String(), responsePostFeed.Pagination.LastPostId)
+
+	mockUserRepository.AssertExpectations(t)
+	mockPostRepository.AssertExpectations(t)
+	mockLikeRepository.AssertExpectations(t)
+	mockCommentRepository.AssertExpectations(t)
+}
+
+// TestGetPostsByMentionUserNotFound tests if the GetPostsByMention function returns a 404 not found if the user does not exist
+func TestGetPostsByMentionUserNotFound(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockPostRepository := new(repositories.MockPostRepository)
+
+	feedService := services.NewFeedService(
+		mockPostRepository,
+		mockUserRepository,
+		nil,
+		nil,
+	)
+	feedController := controllers.NewFeedController(feedService)
+
+	authenticationToken, err := utils.GenerateAccessToken("testUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockUserRepository.On("FindUserByUsername", "unknownUser").Return(&models.User{}, gorm.ErrRecordNotFound) // User not found
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/users/unknownUser/mentions", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/users/:username/mentions", middleware.AuthorizeUser, feedController.GetPostsByMention)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code) // Expect 404 not found
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.UserNotFound
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockPostRepository.AssertExpectations(t)
+	mockUserRepository.AssertExpectations(t)
+}
+
+// TestGetPostByIdSuccess tests if the GetPostById function returns the post with repost and first page of comments and 200 ok
+func TestGetPostByIdSuccess(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+	mockLikeRepository := new(repositories.MockLikeRepository)
+	mockCommentRepository := new(repositories.MockCommentRepository)
+
+	feedService := services.NewFeedService(
+		mockPostRepository,
+		nil,
+		mockLikeRepository,
+		mockCommentRepository,
+	)
+	feedController := controllers.NewFeedController(feedService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalPost := models.Post{
+		Id:        uuid.New(),
+		Username:  "originalAuthor",
+		User:      models.User{Username: "originalAuthor", Nickname: "Original Author"},
+		Content:   "original post",
+		CreatedAt: time.Now().UTC().Add(time.Hour * -2),
+	}
+	post := models.Post{
+		Id:        uuid.New(),
+		Username:  "postAuthor",
+		User:      models.User{Username: "postAuthor", Nickname: "Post Author"},
+		Content:   "repost",
+		CreatedAt: time.Now().UTC().Add(time.Hour * -1),
+		RepostId:  &originalPost.Id,
+	}
+	comments := []models.Comment{
+		{
+			Id:        uuid.New(),
+			PostID:    post.Id,
+			Username:  "commenter",
+			User:      models.User{Username: "commenter", Nickname: "Commenter"},
+			Content:   "nice post",
+			CreatedAt: time.Now().UTC(),
+		},
+	}
+
+	// Mock expectations
+	mockPostRepository.On("GetPostById", post.Id.String()).Return(post, nil)
+	mockPostRepository.On("GetPostById", originalPost.Id.String()).Return(originalPost, nil)
+	mockLikeRepository.On("FindLike", post.Id.String(), currentUsername).Return(&models.Like{}, nil)
+	mockLikeRepository.On("CountLikes", post.Id.String()).Return(int64(3), nil)
+	mockCommentRepository.On("CountComments", post.Id.String()).Return(int64(11), nil)
+	mockLikeRepository.On("FindLike", originalPost.Id.String(), currentUsername).Return(&models.Like{}, gorm.ErrRecordNotFound)
+	mockLikeRepository.On("CountLikes", originalPost.Id.String()).Return(int64(7), nil)
+	mockCommentRepository.On("CountComments", originalPost.Id.String()).Return(int64(0), nil)
+	mockCommentRepository.On("GetCommentsByPostId", post.Id.String(), 0, 10, false).Return(comments, int64(11), nil)
+	mockCommentRepository.On("CountRepliesByCommentIds", []string{comments[0].Id.String()}).Return(map[string]int64{comments[0].Id.String(): 4}, nil)
+	mockLikeRepository.On("FindCommentLikesByCommentIds", []string{comments[0].Id.String()}, currentUsername).Return([]models.CommentLike{}, nil)
+	mockLikeRepository.On("CountCommentLikesByCommentIds", []string{comments[0].Id.String()}).Return(map[string]int64{comments[0].Id.String(): 2}, nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/posts/"+post.Id.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/posts/:postId", middleware.AuthorizeUser, feedController.GetPostById)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+
+	var response models.PostDetailResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, post.Id, response.PostId)
+	assert.Equal(t, post.Content, response.Content)
+	assert.Equal(t, post.User.Username, response.Author.Username)
+	assert.Equal(t, int64(3), response.Likes)
+	assert.Equal(t, int64(11), response.Comments)
+	assert.True(t, response.Liked)
+	assert.Nil(t, response.EditedAt)
+
+	assert.Equal(t, originalPost.Id, response.Repost.PostId)
+	assert.Equal(t, originalPost.Content, response.Repost.Content)
+	assert.Equal(t, originalPost.User.Username, response.Repost.Author.Username)
+	assert.Equal(t, int64(7), response.Repost.Likes)
+	assert.False(t, response.Repost.Liked)
+	assert.Nil(t, response.Repost.Repost)
+
+	assert.Len(t, response.CommentFeed.Records, 1)
+	assert.Equal(t, comments[0].Id, response.CommentFeed.Records[0].CommentId)
+	assert.Equal(t, comments[0].Content, response.CommentFeed.Records[0].Content)
+	assert.Equal(t, comments[0].User.Username, response.CommentFeed.Records[0].Author.Username)
+	assert.Equal(t, int64(4), response.CommentFeed.Records[0].Replies)
+	assert.Equal(t, int64(2), response.CommentFeed.Records[0].Likes)
+	assert.False(t, response.CommentFeed.Records[0].Liked)
+	assert.Equal(t, 0, response.CommentFeed.Pagination.Offset)
+	assert.Equal(t, 10, response.CommentFeed.Pagination.Limit)
+	assert.Equal(t, int64(11), response.CommentFeed.Pagination.Records)
+
+	mockPostRepository.AssertExpectations(t)
+	mockLikeRepository.AssertExpectations(t)
+	mockCommentRepository.AssertExpectations(t)
+}
+
+// TestGetPostByIdUnauthorized tests if the GetPostById function returns 401 unauthorized if the user is not authenticated
+func TestGetPostByIdUnauthorized(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+
+	feedService := services.NewFeedService(mockPostRepository, nil, nil, nil)
+	feedController := controllers.NewFeedController(feedService)
+
+	// Setup HTTP request without Authorization Header
+	req, _ := http.NewRequest("GET", "/posts/"+uuid.New().String(), nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/posts/:postId", middleware.AuthorizeUser, feedController.GetPostById)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var errorResponse customerrors.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.Unauthorized
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockPostRepository.AssertExpectations(t)
+}
+
+// TestGetPostByIdNotFound tests if the GetPostById function returns 404 not found if the post does not exist
+func TestGetPostByIdNotFound(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+
+	feedService := services.NewFeedService(mockPostRepository, nil, nil, nil)
+	feedController := controllers.NewFeedController(feedService)
+
+	postId := uuid.New().String()
+	authenticationToken, err := utils.GenerateAccessToken("testUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockPostRepository.On("GetPostById", postId).Return(models.Post{}, gorm.ErrRecordNotFound)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("GET", "/posts/"+postId, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/posts/:postId", middleware.AuthorizeUser, feedController.GetPostById)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.PostNotFound
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockPostRepository.AssertExpectations(t)
+}
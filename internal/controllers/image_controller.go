@@ -3,6 +3,7 @@ package controllers
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
+	"github.com/wwi21seb-projekt/server-beta/internal/middleware"
 	"github.com/wwi21seb-projekt/server-beta/internal/services"
 	"net/http"
 )
@@ -26,8 +27,11 @@ func (controller *ImageController) GetImageById(c *gin.Context) {
 	// Read image name from request
 	imageId := c.Param("imageId")
 
+	// Images are public except for images of chats, so the user is optionally logged in
+	currentUsername, _ := middleware.GetLoggedInUsername(c)
+
 	// Get image from service
-	imageDto, serviceErr, httpStatus := controller.imageService.GetImageById(imageId)
+	imageDto, serviceErr, httpStatus := controller.imageService.GetImageById(imageId, currentUsername)
 	if serviceErr != nil {
 		c.JSON(httpStatus, gin.H{
 			"error": serviceErr,
@@ -10,6 +10,7 @@ import (
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
 	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
 	"gorm.io/gorm"
 	"net/http"
 	"net/http/httptest"
@@ -40,7 +41,8 @@ func TestGetImageSuccess(t *testing.T) {
 			Tag:       time.Now().UTC(),
 		}
 
-		mockImageRepo.On("GetImageById", imageId.String()).Return(&image, nil) // Expect image to be found
+		mockImageRepo.On("GetImageById", imageId.String()).Return(&image, nil)                                // Expect image to be found
+		mockImageRepo.On("GetChatByImageId", imageId.String()).Return(&models.Chat{}, gorm.ErrRecordNotFound) // Expect image to be public
 
 		// Setup HTTP request
 		url := "/images/" + imageId.String() + "." + fileType
@@ -174,3 +176,75 @@ func TestGetImageNotFoundWrongFormat(t *testing.T) {
 
 	mockImageRepo.AssertExpectations(t)
 }
+
+// TestGetChatImage tests if images of chats can only be fetched by logged-in participants of the chat
+func TestGetChatImage(t *testing.T) {
+	tests := []struct {
+		username       string
+		expectedStatus int
+		expectedError  *customerrors.CustomError
+	}{
+		{"myUser", http.StatusOK, nil},
+		{"otherUser", http.StatusOK, nil},
+		{"strangerUser", http.StatusNotFound, customerrors.ImageNotFound}, // existence of the image is not revealed
+		{"", http.StatusUnauthorized, customerrors.Unauthorized},
+	}
+
+	for _, test := range tests {
+		// Arrange
+		mockImageRepo := new(repositories.MockImageRepository)
+		imageService := services.NewImageService(mockImageRepo)
+		imageController := controllers.NewImageController(imageService)
+
+		imageId := uuid.New()
+		image := models.Image{
+			Id:        imageId,
+			Format:    "png",
+			ImageData: []byte("test"),
+			Width:     100,
+			Height:    200,
+			Tag:       time.Now().UTC(),
+		}
+		chat := models.Chat{
+			Id: uuid.New(),
+			Users: []models.User{
+				{Username: "myUser"},
+				{Username: "otherUser"},
+			},
+		}
+
+		mockImageRepo.On("GetImageById", imageId.String()).Return(&image, nil)
+		mockImageRepo.On("GetChatByImageId", imageId.String()).Return(&chat, nil) // Expect image to belong to the chat
+
+		// Setup HTTP request
+		req := httptest.NewRequest("GET", "/images/"+imageId.String()+".png", nil)
+		if test.username != "" {
+			authenticationToken, err := utils.GenerateAccessToken(test.username)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		}
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.GET("/images/:imageId", imageController.GetImageById)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, test.expectedStatus, w.Code, test.username)
+		if test.expectedError == nil {
+			assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+			assert.Equal(t, image.ImageData, w.Body.Bytes())
+		} else {
+			var errorResponse customerrors.ErrorResponse
+			err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedError.Code, errorResponse.Error.Code)
+		}
+
+		mockImageRepo.AssertExpectations(t)
+	}
+}
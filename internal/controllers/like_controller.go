@@ -10,6 +10,8 @@ import (
 type LikeControllerInterface interface {
 	PostLike(c *gin.Context)
 	DeleteLike(c *gin.Context)
+	PostCommentLike(c *gin.Context)
+	DeleteCommentLike(c *gin.Context)
 }
 
 type LikeController struct {
@@ -72,3 +74,57 @@ func (controller *LikeController) DeleteLike(c *gin.Context) {
 
 	c.JSON(httpStatus, gin.H{})
 }
+
+// PostCommentLike creates a like for a given comment id and the current logged-in user
+func (controller *LikeController) PostCommentLike(c *gin.Context) {
+	// Get current user from middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Read post and comment id from request
+	postId := c.Param("postId")
+	commentId := c.Param("commentId")
+
+	// Create like
+	serviceErr, httpStatus := controller.likeService.PostCommentLike(postId, commentId, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, gin.H{})
+}
+
+// DeleteCommentLike deletes a like for a given comment id and the current logged-in user
+func (controller *LikeController) DeleteCommentLike(c *gin.Context) {
+	// Get current user from middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Read post and comment id from request
+	postId := c.Param("postId")
+	commentId := c.Param("commentId")
+
+	// Delete like
+	serviceErr, httpStatus := controller.likeService.DeleteCommentLike(postId, commentId, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, gin.H{})
+}
@@ -17,6 +17,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 // TestPostLikeSuccess tests if PostLike creates a like for a given post id and the current logged-in user and returns 204 No Content
@@ -24,8 +25,13 @@ func TestPostLikeSuccess(t *testing.T) {
 	// Arrange
 	mockPostRepo := new(repositories.MockPostRepository)
 	mockLikeRepo := new(repositories.MockLikeRepository)
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
 
-	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, notificationService)
 	likeController := controllers.NewLikeController(likeService)
 
 	currentUsername := "testUser"
@@ -35,11 +41,22 @@ func TestPostLikeSuccess(t *testing.T) {
 	}
 
 	post := models.Post{
-		Id: uuid.New(),
+		Id:       uuid.New(),
+		Username: "postAuthor",
 	}
 
 	// Mock expectations
 	var capturedLike *models.Like
+	var capturedNotification *models.Notification
+	mockNotificationRepo.On("GetNotificationByPostId", "like", post.Username, post.Id.String()).Return(models.Notification{}, gorm.ErrRecordNotFound)     // first like on the post
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
+	mockNotificationRepo.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
+		Run(func(args mock.Arguments) {
+			capturedNotification = args.Get(0).(*models.Notification)
+		}).Return(nil)
+	mockNotificationRepo.On("GetNotificationById", mock.AnythingOfType("string")).Return(models.Notification{}, nil)
+	mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", post.Username).Return([]models.PushSubscription{}, nil)
 	mockPostRepo.On("GetPostById", post.Id.String()).Return(post, nil)                                            // post exists
 	mockLikeRepo.On("FindLike", post.Id.String(), currentUsername).Return(&models.Like{}, gorm.ErrRecordNotFound) // user did not like yet
 	mockLikeRepo.On("CreateLike", mock.AnythingOfType("*models.Like")).
@@ -70,8 +87,163 @@ func TestPostLikeSuccess(t *testing.T) {
 	assert.Equal(t, post.Id, capturedLike.PostId)
 	assert.Equal(t, currentUsername, capturedLike.Username)
 
+	// Author of the post is notified about the like
+	assert.NotNil(t, capturedNotification)
+	assert.Equal(t, "like", capturedNotification.NotificationType)
+	assert.Equal(t, post.Username, capturedNotification.ForUsername)
+	assert.Equal(t, currentUsername, capturedNotification.FromUsername)
+	assert.Equal(t, post.Id, *capturedNotification.PostId)
+	assert.Equal(t, 1, capturedNotification.Count)
+
 	mockLikeRepo.AssertExpectations(t)
 	mockPostRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+// TestPostLikeGroupsNotification tests if PostLike groups a repeated like on the same post into the existing notification
+func TestPostLikeGroupsNotification(t *testing.T) {
+	// Arrange
+	mockPostRepo := new(repositories.MockPostRepository)
+	mockLikeRepo := new(repositories.MockLikeRepository)
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, notificationService)
+	likeController := controllers.NewLikeController(likeService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := models.Post{
+		Id:       uuid.New(),
+		Username: "postAuthor",
+	}
+	existingNotification := models.Notification{
+		Id:               uuid.New(),
+		Timestamp:        time.Now().Add(-time.Hour),
+		NotificationType: "like",
+		ForUsername:      post.Username,
+		FromUsername:     "anotherUser",
+		PostId:           &post.Id,
+		Count:            1,
+		Read:             true,
+	}
+
+	// Mock expectations
+	var capturedTimestamp time.Time
+	mockPostRepo.On("GetPostById", post.Id.String()).Return(post, nil)
+	mockLikeRepo.On("FindLike", post.Id.String(), currentUsername).Return(&models.Like{}, gorm.ErrRecordNotFound)
+	mockLikeRepo.On("CreateLike", mock.AnythingOfType("*models.Like")).Return(nil)
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
+	mockNotificationRepo.On("GetNotificationByPostId", "like", post.Username, post.Id.String()).Return(existingNotification, nil)                         // post was already liked by another user
+	mockNotificationRepo.On("IncrementNotificationCount", existingNotification.Id.String(), currentUsername, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			capturedTimestamp = args.Get(2).(time.Time)
+		}).Return(int64(1), nil)
+	mockNotificationRepo.On("GetNotificationById", existingNotification.Id.String()).Return(existingNotification, nil)
+	mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", post.Username).Return([]models.PushSubscription{}, nil)
+
+	// Setup HTTP request
+	url := "/posts/" + post.Id.String() + "/likes"
+	req, _ := http.NewRequest("POST", url, nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/posts/:postId/likes", middleware.AuthorizeUser, likeController.PostLike)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect 204 No Content
+
+	assert.True(t, capturedTimestamp.After(existingNotification.Timestamp))
+
+	mockNotificationRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertNotCalled(t, "CreateNotification", mock.Anything)
+}
+
+// TestPostLikeGroupedNotificationDeleted tests if PostLike creates a new notification
+// when the grouped notification was deleted after its last like was taken back in the meantime
+func TestPostLikeGroupedNotificationDeleted(t *testing.T) {
+	// Arrange
+	mockPostRepo := new(repositories.MockPostRepository)
+	mockLikeRepo := new(repositories.MockLikeRepository)
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, notificationService)
+	likeController := controllers.NewLikeController(likeService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := models.Post{
+		Id:       uuid.New(),
+		Username: "postAuthor",
+	}
+	deletedNotification := models.Notification{
+		Id:               uuid.New(),
+		NotificationType: "like",
+		ForUsername:      post.Username,
+		FromUsername:     "anotherUser",
+		PostId:           &post.Id,
+		Count:            1,
+	}
+
+	// Mock expectations
+	var capturedNotification *models.Notification
+	mockPostRepo.On("GetPostById", post.Id.String()).Return(post, nil)
+	mockLikeRepo.On("FindLike", post.Id.String(), currentUsername).Return(&models.Like{}, gorm.ErrRecordNotFound)
+	mockLikeRepo.On("CreateLike", mock.AnythingOfType("*models.Like")).Return(nil)
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
+	mockNotificationRepo.On("GetNotificationByPostId", "like", post.Username, post.Id.String()).Return(deletedNotification, nil)
+	mockNotificationRepo.On("IncrementNotificationCount", deletedNotification.Id.String(), currentUsername, mock.AnythingOfType("time.Time")).Return(int64(0), nil) // notification is already deleted
+	mockNotificationRepo.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
+		Run(func(args mock.Arguments) {
+			capturedNotification = args.Get(0).(*models.Notification)
+		}).Return(nil)
+	mockNotificationRepo.On("GetNotificationById", mock.AnythingOfType("string")).Return(models.Notification{}, nil)
+	mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", post.Username).Return([]models.PushSubscription{}, nil)
+
+	// Setup HTTP request
+	url := "/posts/" + post.Id.String() + "/likes"
+	req, _ := http.NewRequest("POST", url, nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/posts/:postId/likes", middleware.AuthorizeUser, likeController.PostLike)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect 204 No Content
+
+	assert.NotNil(t, capturedNotification)
+	assert.NotEqual(t, deletedNotification.Id, capturedNotification.Id)
+	assert.Equal(t, currentUsername, capturedNotification.FromUsername)
+	assert.Equal(t, 1, capturedNotification.Count)
+
+	mockNotificationRepo.AssertExpectations(t)
 }
 
 // TestPostLikeUnauthorized tests if PostLike returns 401 Unauthorized if the user is not logged in
@@ -80,7 +252,7 @@ func TestPostLikeUnauthorized(t *testing.T) {
 	mockPostRepo := new(repositories.MockPostRepository)
 	mockLikeRepo := new(repositories.MockLikeRepository)
 
-	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, nil)
 	likeController := controllers.NewLikeController(likeService)
 
 	// Setup HTTP request
@@ -115,7 +287,7 @@ func TestPostLikePostNotFound(t *testing.T) {
 	mockPostRepo := new(repositories.MockPostRepository)
 	mockLikeRepo := new(repositories.MockLikeRepository)
 
-	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, nil)
 	likeController := controllers.NewLikeController(likeService)
 
 	currentUsername := "testUser"
@@ -162,7 +334,7 @@ func TestPostLikeAlreadyLiked(t *testing.T) {
 	mockPostRepo := new(repositories.MockPostRepository)
 	mockLikeRepo := new(repositories.MockLikeRepository)
 
-	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, nil)
 	likeController := controllers.NewLikeController(likeService)
 
 	currentUsername := "testUser"
@@ -211,8 +383,10 @@ func TestDeleteLikeSuccess(t *testing.T) {
 	// Arrange
 	mockPostRepo := new(repositories.MockPostRepository)
 	mockLikeRepo := new(repositories.MockLikeRepository)
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
 
-	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo)
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, nil, nil)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, notificationService)
 	likeController := controllers.NewLikeController(likeService)
 
 	currentUsername := "testUser"
@@ -222,7 +396,8 @@ func TestDeleteLikeSuccess(t *testing.T) {
 	}
 
 	post := models.Post{
-		Id: uuid.New(),
+		Id:       uuid.New(),
+		Username: "postAuthor",
 	}
 
 	like := models.Like{
@@ -230,9 +405,10 @@ func TestDeleteLikeSuccess(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockPostRepo.On("GetPostById", post.Id.String()).Return(post, nil)                // post exists
-	mockLikeRepo.On("FindLike", post.Id.String(), currentUsername).Return(&like, nil) // user liked
-	mockLikeRepo.On("DeleteLike", like.Id.String()).Return(nil)                       // delete successful
+	mockPostRepo.On("GetPostById", post.Id.String()).Return(post, nil)                                                                                // post exists
+	mockLikeRepo.On("FindLike", post.Id.String(), currentUsername).Return(&like, nil)                                                                 // user liked
+	mockLikeRepo.On("DeleteLike", like.Id.String()).Return(nil)                                                                                       // delete successful
+	mockNotificationRepo.On("GetNotificationByPostId", "like", post.Username, post.Id.String()).Return(models.Notification{}, gorm.ErrRecordNotFound) // like was not stored as notification
 
 	// Setup HTTP request
 	url := "/posts/" + post.Id.String() + "/likes"
@@ -254,6 +430,67 @@ func TestDeleteLikeSuccess(t *testing.T) {
 
 	mockLikeRepo.AssertExpectations(t)
 	mockPostRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+// TestDeleteLikeDecrementsGroupedNotification tests if DeleteLike takes the like back from the grouped like notification of the post author
+func TestDeleteLikeDecrementsGroupedNotification(t *testing.T) {
+	// Arrange
+	mockPostRepo := new(repositories.MockPostRepository)
+	mockLikeRepo := new(repositories.MockLikeRepository)
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, nil, nil)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, notificationService)
+	likeController := controllers.NewLikeController(likeService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := models.Post{
+		Id:       uuid.New(),
+		Username: "postAuthor",
+	}
+	like := models.Like{
+		Id: uuid.New(),
+	}
+	existingNotification := models.Notification{
+		Id:               uuid.New(),
+		Timestamp:        time.Now(),
+		NotificationType: "like",
+		ForUsername:      post.Username,
+		FromUsername:     currentUsername,
+		PostId:           &post.Id,
+		Count:            3,
+	}
+
+	// Mock expectations
+	mockPostRepo.On("GetPostById", post.Id.String()).Return(post, nil)
+	mockLikeRepo.On("FindLike", post.Id.String(), currentUsername).Return(&like, nil)
+	mockLikeRepo.On("DeleteLike", like.Id.String()).Return(nil)
+	mockNotificationRepo.On("GetNotificationByPostId", "like", post.Username, post.Id.String()).Return(existingNotification, nil)
+	mockNotificationRepo.On("DecrementNotificationCount", existingNotification.Id.String()).Return(nil) // database deletes the notification once the count reaches zero
+
+	// Setup HTTP request
+	url := "/posts/" + post.Id.String() + "/likes"
+	req, _ := http.NewRequest("DELETE", url, nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/posts/:postId/likes", middleware.AuthorizeUser, likeController.DeleteLike)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect 204 No Content
+
+	mockNotificationRepo.AssertExpectations(t)
 }
 
 // TestDeleteLikeUnauthorized tests if DeleteLike returns 401 Unauthorized if the user is not logged in
@@ -262,7 +499,7 @@ func TestDeleteLikeUnauthorized(t *testing.T) {
 	mockPostRepo := new(repositories.MockPostRepository)
 	mockLikeRepo := new(repositories.MockLikeRepository)
 
-	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, nil)
 	likeController := controllers.NewLikeController(likeService)
 
 	// Setup HTTP request
@@ -297,7 +534,7 @@ func TestDeleteLikePostNotFound(t *testing.T) {
 	mockPostRepo := new(repositories.MockPostRepository)
 	mockLikeRepo := new(repositories.MockLikeRepository)
 
-	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, nil)
 	likeController := controllers.NewLikeController(likeService)
 
 	currentUsername := "testUser"
@@ -344,7 +581,7 @@ func TestDeleteLikeNotLiked(t *testing.T) {
 	mockPostRepo := new(repositories.MockPostRepository)
 	mockLikeRepo := new(repositories.MockLikeRepository)
 
-	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo)
+	likeService := services.NewLikeService(mockLikeRepo, mockPostRepo, nil, nil)
 	likeController := controllers.NewLikeController(likeService)
 
 	currentUsername := "testUser"
@@ -387,3 +624,253 @@ func TestDeleteLikeNotLiked(t *testing.T) {
 	mockLikeRepo.AssertExpectations(t)
 	mockPostRepo.AssertExpectations(t)
 }
+
+// TestPostCommentLikeSuccess tests if PostCommentLike creates a like for a given comment id and the current logged-in user and returns 204 No Content
+func TestPostCommentLikeSuccess(t *testing.T) {
+	// Arrange
+	mockCommentRepo := new(repositories.MockCommentRepository)
+	mockLikeRepo := new(repositories.MockLikeRepository)
+
+	likeService := services.NewLikeService(mockLikeRepo, nil, mockCommentRepo, nil)
+	likeController := controllers.NewLikeController(likeService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := models.Comment{
+		Id:     uuid.New(),
+		PostID: uuid.New(),
+	}
+
+	// Mock expectations
+	var capturedLike *models.CommentLike
+	mockCommentRepo.On("GetCommentById", comment.Id.String()).Return(comment, nil)                                                 // comment exists
+	mockLikeRepo.On("FindCommentLike", comment.Id.String(), currentUsername).Return(&models.CommentLike{}, gorm.ErrRecordNotFound) // user did not like yet
+	mockLikeRepo.On("CreateCommentLike", mock.AnythingOfType("*models.CommentLike")).
+		Run(func(args mock.Arguments) {
+			capturedLike = args.Get(0).(*models.CommentLike)
+		}).Return(nil)
+
+	// Setup HTTP request
+	url := "/posts/" + comment.PostID.String() + "/comments/" + comment.Id.String() + "/likes"
+	req, _ := http.NewRequest("POST", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/posts/:postId/comments/:commentId/likes", middleware.AuthorizeUser, likeController.PostCommentLike)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect 204 No Content
+
+	assert.NotNil(t, capturedLike)
+	assert.NotEmpty(t, capturedLike.Id)
+	assert.Equal(t, comment.Id, capturedLike.CommentId)
+	assert.Equal(t, currentUsername, capturedLike.Username)
+
+	mockLikeRepo.AssertExpectations(t)
+	mockCommentRepo.AssertExpectations(t)
+}
+
+// TestPostCommentLikeAlreadyLiked tests if PostCommentLike returns 409 Conflict if the user already liked the comment
+func TestPostCommentLikeAlreadyLiked(t *testing.T) {
+	// Arrange
+	mockCommentRepo := new(repositories.MockCommentRepository)
+	mockLikeRepo := new(repositories.MockLikeRepository)
+
+	likeService := services.NewLikeService(mockLikeRepo, nil, mockCommentRepo, nil)
+	likeController := controllers.NewLikeController(likeService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := models.Comment{
+		Id:     uuid.New(),
+		PostID: uuid.New(),
+	}
+
+	// Mock expectations
+	mockCommentRepo.On("GetCommentById", comment.Id.String()).Return(comment, nil)
+	mockLikeRepo.On("FindCommentLike", comment.Id.String(), currentUsername).Return(&models.CommentLike{}, nil) // user already liked
+
+	// Setup HTTP request
+	url := "/posts/" + comment.PostID.String() + "/comments/" + comment.Id.String() + "/likes"
+	req, _ := http.NewRequest("POST", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/posts/:postId/comments/:commentId/likes", middleware.AuthorizeUser, likeController.PostCommentLike)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code) // Expect 409 Conflict
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.CommentAlreadyLiked
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockLikeRepo.AssertExpectations(t)
+	mockCommentRepo.AssertExpectations(t)
+}
+
+// TestPostCommentLikeCommentNotFound tests if PostCommentLike returns 404 Not Found if the comment does not exist
+func TestPostCommentLikeCommentNotFound(t *testing.T) {
+	// Arrange
+	mockCommentRepo := new(repositories.MockCommentRepository)
+	mockLikeRepo := new(repositories.MockLikeRepository)
+
+	likeService := services.NewLikeService(mockLikeRepo, nil, mockCommentRepo, nil)
+	likeController := controllers.NewLikeController(likeService)
+
+	authenticationToken, err := utils.GenerateAccessToken("testUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commentId := uuid.New().String()
+
+	// Mock expectations
+	mockCommentRepo.On("GetCommentById", commentId).Return(models.Comment{}, gorm.ErrRecordNotFound)
+
+	// Setup HTTP request
+	url := "/posts/" + uuid.New().String() + "/comments/" + commentId + "/likes"
+	req, _ := http.NewRequest("POST", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/posts/:postId/comments/:commentId/likes", middleware.AuthorizeUser, likeController.PostCommentLike)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code) // Expect 404 Not Found
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.CommentNotFound
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockLikeRepo.AssertExpectations(t)
+	mockCommentRepo.AssertExpectations(t)
+}
+
+// TestDeleteCommentLikeSuccess tests if DeleteCommentLike deletes the like of the current logged-in user and returns 204 No Content
+func TestDeleteCommentLikeSuccess(t *testing.T) {
+	// Arrange
+	mockCommentRepo := new(repositories.MockCommentRepository)
+	mockLikeRepo := new(repositories.MockLikeRepository)
+
+	likeService := services.NewLikeService(mockLikeRepo, nil, mockCommentRepo, nil)
+	likeController := controllers.NewLikeController(likeService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := models.Comment{
+		Id:     uuid.New(),
+		PostID: uuid.New(),
+	}
+	like := models.CommentLike{
+		Id:        uuid.New(),
+		CommentId: comment.Id,
+		Username:  currentUsername,
+	}
+
+	// Mock expectations
+	mockCommentRepo.On("GetCommentById", comment.Id.String()).Return(comment, nil)
+	mockLikeRepo.On("FindCommentLike", comment.Id.String(), currentUsername).Return(&like, nil)
+	mockLikeRepo.On("DeleteCommentLike", like.Id.String()).Return(nil)
+
+	// Setup HTTP request
+	url := "/posts/" + comment.PostID.String() + "/comments/" + comment.Id.String() + "/likes"
+	req, _ := http.NewRequest("DELETE", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/posts/:postId/comments/:commentId/likes", middleware.AuthorizeUser, likeController.DeleteCommentLike)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect 204 No Content
+
+	mockLikeRepo.AssertExpectations(t)
+	mockCommentRepo.AssertExpectations(t)
+}
+
+// TestDeleteCommentLikeNotLiked tests if DeleteCommentLike returns 409 Conflict if the user did not like the comment
+func TestDeleteCommentLikeNotLiked(t *testing.T) {
+	// Arrange
+	mockCommentRepo := new(repositories.MockCommentRepository)
+	mockLikeRepo := new(repositories.MockLikeRepository)
+
+	likeService := services.NewLikeService(mockLikeRepo, nil, mockCommentRepo, nil)
+	likeController := controllers.NewLikeController(likeService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := models.Comment{
+		Id:     uuid.New(),
+		PostID: uuid.New(),
+	}
+
+	// Mock expectations
+	mockCommentRepo.On("GetCommentById", comment.Id.String()).Return(comment, nil)
+	mockLikeRepo.On("FindCommentLike", comment.Id.String(), currentUsername).Return(&models.CommentLike{}, gorm.ErrRecordNotFound)
+
+	// Setup HTTP request
+	url := "/posts/" + comment.PostID.String() + "/comments/" + comment.Id.String() + "/likes"
+	req, _ := http.NewRequest("DELETE", url, nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/posts/:postId/comments/:commentId/likes", middleware.AuthorizeUser, likeController.DeleteCommentLike)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, w.Code) // Expect 409 Conflict
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.CommentNotLiked
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockLikeRepo.AssertExpectations(t)
+	mockCommentRepo.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
@@ -12,33 +13,154 @@ import (
 	"net/http"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type MessageControllerInterface interface {
 	GetMessagesByChatId(c *gin.Context)
 	HandleWebSocket(c *gin.Context)
+	HandleUserWebSocket(c *gin.Context)
+	UpdateMessage(c *gin.Context)
+	DeleteMessage(c *gin.Context)
+	SetMessageReaction(c *gin.Context)
+	DeleteMessageReaction(c *gin.Context)
 }
 
+const (
+	defaultChatPongWait = 60 * time.Second // connections are closed if the client does not answer a ping within this time
+	chatWriteWait       = 10 * time.Second // maximum time for writing a message to a client
+	chatSendQueueSize   = 256              // messages of the broker that wait for a connection, connections with a full queue are closed
+)
+
 type MessageController struct {
-	messageService services.MessageServiceInterface
+	messageService  services.MessageServiceInterface
+	chatBroker      services.ChatBroker // distributes messages to the connections on all server instances
+	presenceService services.PresenceServiceInterface
 
 	// Websockets:
-	connections     map[string]map[string][]*websocket.Conn // chatId -> username -> []*websocket.Conn, for each user and chat, all connections
+	connections     map[string]map[string][]*chatConnection // chatId -> username -> []*chatConnection, for each user and chat, all connections of this instance
+	chatConnections map[*websocket.Conn]*chatConnection     // all open connections of this instance
+	userConnections map[string][]*chatConnection            // username -> user websockets of this instance
 	connectionsLock sync.RWMutex
 	upgrader        websocket.Upgrader
+	pongWait        time.Duration
+}
+
+// chatConnection is a websocket connection with a unique id to exclude it from broker messages,
+// writes are serialized because a websocket connection does not support concurrent writers
+// Messages of the broker are sent by a goroutine of each connection, so that a slow client does not delay the others
+type chatConnection struct {
+	conn      *websocket.Conn
+	id        string
+	username  string
+	chats     map[string]bool // subscribed chats of a user websocket, nil for the websocket of a single chat
+	writeLock sync.Mutex
+	sendQueue chan func()   // tasks that send messages of the broker to this connection, in the order they were received
+	stopQueue chan struct{} // closed when the connection is closed to stop the goroutine of the send queue
+}
+
+// newChatConnection creates a connection and starts the goroutine of its send queue, stopSending needs to be called when the connection closes
+func newChatConnection(conn *websocket.Conn, chats map[string]bool) *chatConnection {
+	connection := &chatConnection{
+		conn:      conn,
+		id:        uuid.New().String(),
+		chats:     chats,
+		sendQueue: make(chan func(), chatSendQueueSize),
+		stopQueue: make(chan struct{}),
+	}
+	go connection.processSendQueue()
+	return connection
+}
+
+// processSendQueue runs the tasks of the send queue until the connection is closed
+func (connection *chatConnection) processSendQueue() {
+	for {
+		select {
+		case task := <-connection.sendQueue:
+			task()
+		case <-connection.stopQueue:
+			return
+		}
+	}
+}
+
+// stopSending stops the goroutine of the send queue, tasks that are still queued are dropped
+func (connection *chatConnection) stopSending() {
+	close(connection.stopQueue)
+}
+
+// enqueue adds a task to the send queue without blocking, the connection is closed if the client does not keep up,
+// its read loop then stops and removes the connection
+func (connection *chatConnection) enqueue(task func()) {
+	select {
+	case connection.sendQueue <- task:
+	default:
+		fmt.Println("Send queue of connection for", connection.username, "is full, closing connection")
+		_ = connection.conn.Close() // without the write lock, because a stalled write may hold it
+	}
+}
+
+// isUserSocket reports whether the connection is a user websocket that covers multiple chats and uses envelopes
+func (connection *chatConnection) isUserSocket() bool {
+	return connection.chats != nil
+}
+
+// frame wraps a payload in an envelope for user websockets, websockets of a single chat receive the payload unchanged
+func (connection *chatConnection) frame(envelopeType, chatId string, payload []byte) []byte {
+	if !connection.isUserSocket() {
+		return payload
+	}
+	envelope, _ := json.Marshal(models.ChatEnvelopeDTO{
+		Type:    envelopeType,
+		ChatId:  chatId,
+		Payload: payload,
+	})
+	return envelope
+}
+
+// write sends a text message to the client, the write deadline prevents a stalled client from blocking the sender
+func (connection *chatConnection) write(message []byte) error {
+	connection.writeLock.Lock()
+	defer connection.writeLock.Unlock()
+	return connection.writeUnlocked(message)
+}
+
+// writeUnlocked sends a text message to the client, the caller needs to hold the write lock
+func (connection *chatConnection) writeUnlocked(message []byte) error {
+	_ = connection.conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+	return connection.conn.WriteMessage(websocket.TextMessage, message)
+}
+
+// close closes the websocket connection, the write lock prevents writing the close message concurrently to other messages
+func (connection *chatConnection) close() {
+	connection.writeLock.Lock()
+	defer connection.writeLock.Unlock()
+	closeWebsocket(connection.conn)
 }
 
 // NewMessageController creates a new instance of the MessageController
-func NewMessageController(messageService services.MessageServiceInterface) *MessageController {
-	return &MessageController{
-		messageService: messageService,
-		connections:    make(map[string]map[string][]*websocket.Conn),
+func NewMessageController(messageService services.MessageServiceInterface, chatBroker services.ChatBroker, presenceService services.PresenceServiceInterface) *MessageController {
+	controller := &MessageController{
+		messageService:  messageService,
+		chatBroker:      chatBroker,
+		presenceService: presenceService,
+		connections:     make(map[string]map[string][]*chatConnection),
+		chatConnections: make(map[*websocket.Conn]*chatConnection),
+		userConnections: make(map[string][]*chatConnection),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
 			},
 		},
+		pongWait: defaultChatPongWait,
 	}
+	chatBroker.Subscribe(controller.sendToLocalConnections)
+	return controller
+}
+
+// SetPongWait changes the time after which connections without an answer to a ping are closed, pings are sent at 90% of this time
+func (controller *MessageController) SetPongWait(pongWait time.Duration) {
+	controller.pongWait = pongWait
 }
 
 // GetMessagesByChatId retrieves all messages of a chat by its chatId and can be called from the router
@@ -77,10 +199,120 @@ func (controller *MessageController) GetMessagesByChatId(c *gin.Context) {
 	c.JSON(httpStatus, responseDto)
 }
 
-// HandleWebSocket handles WebSocket connections for a given chatId and the logged-in user
+// UpdateMessage changes the content of a message and sends the change to all connections of the chat
+func (controller *MessageController) UpdateMessage(c *gin.Context) {
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	var req models.MessageUpdateRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	chatId := c.Param("chatId")
+	response, serviceErr, httpStatus := controller.messageService.UpdateMessage(chatId, c.Param("messageId"), currentUsername.(string), &req)
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	controller.broadcastMessageChange(chatId, currentUsername.(string), services.ChatEventEdit, response)
+	c.JSON(httpStatus, response)
+}
+
+// DeleteMessage deletes a message and sends the change to all connections of the chat
+func (controller *MessageController) DeleteMessage(c *gin.Context) {
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	chatId := c.Param("chatId")
+	response, serviceErr, httpStatus := controller.messageService.DeleteMessage(chatId, c.Param("messageId"), currentUsername.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	controller.broadcastMessageChange(chatId, currentUsername.(string), services.ChatEventDelete, response)
+	c.Status(httpStatus)
+}
+
+// SetMessageReaction adds or replaces the reaction of the current user to a message and sends the change to all connections of the chat
+func (controller *MessageController) SetMessageReaction(c *gin.Context) {
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	var req models.MessageReactionRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	chatId := c.Param("chatId")
+	response, serviceErr, httpStatus := controller.messageService.SetMessageReaction(chatId, c.Param("messageId"), currentUsername.(string), &req)
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	controller.broadcastMessageChange(chatId, currentUsername.(string), services.ChatEventReaction, response)
+	c.JSON(httpStatus, response)
+}
+
+// DeleteMessageReaction removes the reaction of the current user from a message and sends the change to all connections of the chat
+func (controller *MessageController) DeleteMessageReaction(c *gin.Context) {
+	currentUsername, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	chatId := c.Param("chatId")
+	response, serviceErr, httpStatus := controller.messageService.DeleteMessageReaction(chatId, c.Param("messageId"), currentUsername.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	controller.broadcastMessageChange(chatId, currentUsername.(string), services.ChatEventReaction, response)
+	c.JSON(httpStatus, response)
+}
+
+// HandleWebSocket handles WebSocket connections for a given chatId and the logged-in user,
+// clients that reconnect can pass the sequence number of the last received message as "since" to receive the missed messages first
 func (controller *MessageController) HandleWebSocket(c *gin.Context) {
-	// Read chatId from query parameter
+	// Read chatId and last received sequence number from query parameters
 	chatId := c.Query("chatId")
+	sinceQuery := c.Query("since")
 
 	// Create WebSocket connection
 	// Header needs to be the same as the request header
@@ -88,7 +320,9 @@ func (controller *MessageController) HandleWebSocket(c *gin.Context) {
 	if err != nil {
 		return // return if connection could not be established
 	}
-	defer closeWebsocket(conn) // close connection when function terminates
+	connection := newChatConnection(conn, nil)
+	defer connection.close() // close connection when function terminates
+	defer connection.stopSending()
 
 	// Using Sec-WebSocket-Protocol header for JWT authentication because browsers do not allow custom headers
 	// So middleware was not called and the JWT token needs to be verified here
@@ -106,54 +340,376 @@ func (controller *MessageController) HandleWebSocket(c *gin.Context) {
 		return // return and close connection
 	}
 
-	// Add connection to map
-	controller.addConnection(currentUsername, chatId, conn)
-	defer controller.removeConnection(currentUsername, chatId, conn) // remove connection when function terminates
+	var since int64 = -1
+	if sinceQuery != "" {
+		since, err = strconv.ParseInt(sinceQuery, 10, 64)
+		if err != nil || since < 0 {
+			sendError(conn, customerrors.BadRequest)
+			return // return and close connection
+		}
+	}
+
+	// The last-seen timestamp is updated when the connection opens and closes, in between the user is shown as online
+	controller.updateLastSeen(currentUsername)
+	defer controller.updateLastSeen(currentUsername)
+
+	// Add connection to map, live messages wait for the write lock until the missed messages are replayed
+	connection.username = currentUsername
+	connection.writeLock.Lock()
+	controller.addConnection(chatId, connection)
+	defer controller.removeConnection(chatId, connection) // remove connection when function terminates
 
 	fmt.Println("New connection for", currentUsername, "in chat", chatId)
 
+	// Messages created after registering the connection can be replayed and also received live, clients can ignore them by sequence number
+	if since >= 0 {
+		controller.replayMessages(connection, chatId, since)
+	}
+	connection.writeLock.Unlock()
+	controller.publishPresence(chatId, currentUsername, true, connection)
+
+	stopPings := controller.startHeartbeat(conn)
+	defer close(stopPings)
+
+	// Other participants are told that the user stopped typing if the connection closes while typing
+	typing := false
+	defer func() {
+		if typing {
+			controller.broadcastTypingEvent(chatId, currentUsername, services.ChatEventTypingStop, connection)
+		}
+	}()
+
 	for {
 		// Read message from client
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err) {
-				return
-			}
-			sendError(conn, customerrors.BadRequest)
-			continue // continue to listen for more messages
+			return // read errors are permanent (closed or broken connection or missed pong), so stop listening
 		}
+		_ = conn.SetReadDeadline(time.Now().Add(controller.pongWait)) // every message shows that the client is alive
 
 		// Bind message to DTO
-		var req models.MessageCreateRequestDTO
+		var req models.ChatSocketRequestDTO
 		if err := json.Unmarshal(message, &req); err != nil {
-			sendError(conn, customerrors.BadRequest)
+			sendChatError(connection, chatId, customerrors.BadRequest)
 			continue // continue to listen for more messages
 		}
 
-		// Get users of the chat from map that are currently connected
-		// This is needed to send notifications to all other participants in the service following service function
-		var connectedParticipants []string
-		controller.connectionsLock.RLock()
-		if controller.connections[chatId] != nil {
-			for username := range controller.connections[chatId] {
-				connectedParticipants = append(connectedParticipants, username)
+		typing = controller.handleChatSocketRequest(connection, chatId, currentUsername, &req, typing)
+	}
+}
+
+// HandleUserWebSocket handles the websocket of the logged-in user that covers all chats of the user with a single connection,
+// every message in both directions is an envelope with a type and a chat id, the connection is subscribed to all chats of the user
+// when it opens and to new chats when the user joins them
+func (controller *MessageController) HandleUserWebSocket(c *gin.Context) {
+	// Create WebSocket connection
+	// Header needs to be the same as the request header
+	conn, err := controller.upgrader.Upgrade(c.Writer, c.Request, http.Header{"Sec-WebSocket-Protocol": []string{c.GetHeader("Sec-WebSocket-Protocol")}})
+	if err != nil {
+		return // return if connection could not be established
+	}
+	connection := newChatConnection(conn, make(map[string]bool))
+	defer connection.close() // close connection when function terminates
+	defer connection.stopSending()
+
+	// Verify JWT token of the Sec-WebSocket-Protocol header, see HandleWebSocket
+	jwtToken := c.GetHeader("Sec-WebSocket-Protocol")
+	currentUsername, isRefreshToken, err := utils.VerifyJWTToken(jwtToken)
+	if isRefreshToken || err != nil { // if token is a refresh token or invalid, return Unauthorized error
+		sendError(conn, customerrors.Unauthorized)
+		return // return and close connection
+	}
+
+	chatIds, serviceErr, _ := controller.messageService.GetChatIdsByUsername(currentUsername)
+	if serviceErr != nil {
+		sendError(conn, serviceErr)
+		return // return and close connection
+	}
+
+	// The last-seen timestamp is updated when the connection opens and closes, in between the user is shown as online
+	controller.updateLastSeen(currentUsername)
+	defer controller.updateLastSeen(currentUsername)
+
+	// Register the connection before subscribing, so that it also receives chats that are created in the meantime
+	connection.username = currentUsername
+	controller.addUserConnection(connection)
+	defer controller.removeUserConnection(connection) // remove connection from all chats when function terminates
+
+	fmt.Println("New user connection for", currentUsername)
+
+	for _, chatId := range chatIds {
+		controller.subscribe(connection, chatId, -1)
+	}
+
+	stopPings := controller.startHeartbeat(conn)
+	defer close(stopPings)
+
+	// Other participants are told that the user stopped typing if the connection closes while typing
+	typing := make(map[string]bool) // chatId -> true if the user is typing in the chat
+	defer func() {
+		for chatId := range typing {
+			controller.broadcastTypingEvent(chatId, currentUsername, services.ChatEventTypingStop, connection)
+		}
+	}()
+
+	for {
+		// Read envelope from client
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return // read errors are permanent (closed or broken connection or missed pong), so stop listening
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(controller.pongWait)) // every message shows that the client is alive
+
+		var envelope models.ChatEnvelopeDTO
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			sendChatError(connection, "", customerrors.BadRequest)
+			continue // continue to listen for more messages
+		}
+		chatId := envelope.ChatId
+
+		switch envelope.Type {
+		case services.ChatEnvelopeSubscribe:
+			controller.handleSubscribeRequest(connection, chatId, envelope.Payload)
+		case services.ChatEnvelopeUnsubscribe:
+			if typing[chatId] {
+				controller.broadcastTypingEvent(chatId, currentUsername, services.ChatEventTypingStop, connection)
+				delete(typing, chatId)
+			}
+			controller.removeConnection(chatId, connection)
+			_ = connection.write(connection.frame(services.ChatEnvelopeUnsubscribe, chatId, nil))
+		case services.ChatEnvelopeMessage:
+			// Participation was checked when subscribing, so requests are only accepted for subscribed chats
+			var req models.ChatSocketRequestDTO
+			if !controller.isSubscribed(connection, chatId) || json.Unmarshal(envelope.Payload, &req) != nil {
+				sendChatError(connection, chatId, customerrors.BadRequest)
+				continue
 			}
+			if controller.handleChatSocketRequest(connection, chatId, currentUsername, &req, typing[chatId]) {
+				typing[chatId] = true
+			} else {
+				delete(typing, chatId)
+			}
+		default:
+			sendChatError(connection, chatId, customerrors.BadRequest)
+		}
+	}
+}
+
+// handleSubscribeRequest subscribes a user websocket to a chat of the user, the optional payload contains
+// the sequence number of the last received message to replay the missed messages of the chat
+func (controller *MessageController) handleSubscribeRequest(connection *chatConnection, chatId string, payload json.RawMessage) {
+	var req models.ChatSubscribeRequestDTO
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &req); err != nil || (req.Since != nil && *req.Since < 0) {
+			sendChatError(connection, chatId, customerrors.BadRequest)
+			return
 		}
-		controller.connectionsLock.RUnlock()
+	}
+
+	// Check if chat exists and if user is a participant
+	if _, serviceErr, _ := controller.messageService.GetChatById(chatId, connection.username); serviceErr != nil {
+		sendChatError(connection, chatId, serviceErr)
+		return
+	}
+
+	var since int64 = -1
+	if req.Since != nil {
+		since = *req.Since
+	}
+	controller.subscribe(connection, chatId, since)
+}
 
-		// Call service to save received message to database
-		response, customErr, _ := controller.messageService.CreateMessage(chatId, currentUsername, &req, connectedParticipants)
+// handleChatSocketRequest executes a request of a client in a chat and returns whether the user is typing in the chat afterwards
+func (controller *MessageController) handleChatSocketRequest(connection *chatConnection, chatId, currentUsername string, req *models.ChatSocketRequestDTO, typing bool) bool {
+	switch req.Type {
+	case "", services.ChatEventMessage: // requests without type are messages for compatibility with older clients
+		controller.handleChatMessage(connection, chatId, currentUsername, &models.MessageCreateRequestDTO{Content: req.Content, Picture: req.Picture})
+		return false
+	case services.ChatEventRead:
+		// Send read receipt to the other connections of the chat
+		response, customErr, _ := controller.messageService.MarkChatAsRead(chatId, currentUsername)
 		if customErr != nil {
-			sendError(conn, customErr)
-			continue // continue to listen for more messages
+			sendChatError(connection, chatId, customErr)
+			return typing
+		}
+		if response != nil {
+			responseBytes, _ := json.Marshal(response)
+			controller.broadCastMessageToChat(chatId, string(responseBytes), connection)
+		}
+	case services.ChatEventEdit:
+		response, customErr, _ := controller.messageService.UpdateMessage(chatId, req.MessageId, currentUsername, &models.MessageUpdateRequestDTO{Content: req.Content})
+		controller.handleMessageChange(connection, chatId, currentUsername, req.Type, response, customErr)
+	case services.ChatEventDelete:
+		response, customErr, _ := controller.messageService.DeleteMessage(chatId, req.MessageId, currentUsername)
+		controller.handleMessageChange(connection, chatId, currentUsername, req.Type, response, customErr)
+	case services.ChatEventReaction:
+		var response *models.MessageRecordDTO
+		var customErr *customerrors.CustomError
+		if req.Emoji == "" {
+			response, customErr, _ = controller.messageService.DeleteMessageReaction(chatId, req.MessageId, currentUsername)
+		} else {
+			response, customErr, _ = controller.messageService.SetMessageReaction(chatId, req.MessageId, currentUsername, &models.MessageReactionRequestDTO{Emoji: req.Emoji})
+		}
+		controller.handleMessageChange(connection, chatId, currentUsername, req.Type, response, customErr)
+	case services.ChatEventTypingStart, services.ChatEventTypingStop:
+		controller.broadcastTypingEvent(chatId, currentUsername, req.Type, connection)
+		return req.Type == services.ChatEventTypingStart
+	default:
+		sendChatError(connection, chatId, customerrors.BadRequest)
+	}
+	return typing
+}
+
+// handleChatMessage saves a message received on the websocket and sends it to all connections of the chat
+func (controller *MessageController) handleChatMessage(connection *chatConnection, chatId, currentUsername string, req *models.MessageCreateRequestDTO) {
+	// Get users of the chat that are currently connected to any server instance
+	// This is needed to send notifications to all other participants in the service following service function
+	connectedParticipants := controller.getConnectedUsers(chatId)
+
+	// Call service to save received message to database
+	response, customErr, _ := controller.messageService.CreateMessage(chatId, currentUsername, req, connectedParticipants)
+	if customErr != nil {
+		sendChatError(connection, chatId, customErr)
+		return
+	}
+
+	// Send message to all open connections of the chat (also to the sender as a sending confirmation)
+	responseBytes, _ := json.Marshal(response)
+	controller.broadCastMessageToChat(chatId, string(responseBytes), nil)
+}
+
+// handleMessageChange sends the result of an edit, delete or reaction request on the websocket to all connections of the chat
+func (controller *MessageController) handleMessageChange(connection *chatConnection, chatId, currentUsername, eventType string, message *models.MessageRecordDTO, customErr *customerrors.CustomError) {
+	if customErr != nil {
+		sendChatError(connection, chatId, customErr)
+		return
+	}
+	controller.broadcastMessageChange(chatId, currentUsername, eventType, message)
+}
+
+// broadcastMessageChange sends an event with the changed message to all connections of the chat, including the sender as confirmation
+func (controller *MessageController) broadcastMessageChange(chatId, username, eventType string, message *models.MessageRecordDTO) {
+	event := models.ChatEventDTO{
+		Type:      eventType,
+		Username:  username,
+		MessageId: &message.MessageId,
+		Message:   message,
+		Timestamp: time.Now(),
+	}
+	eventBytes, _ := json.Marshal(event)
+	controller.broadCastMessageToChat(chatId, string(eventBytes), nil)
+}
+
+// broadcastTypingEvent tells the other connections of a chat that a user started or stopped typing
+func (controller *MessageController) broadcastTypingEvent(chatId, username, eventType string, exceptConnection *chatConnection) {
+	event := models.ChatEventDTO{
+		Type:      eventType,
+		Username:  username,
+		Timestamp: time.Now(),
+	}
+	eventBytes, _ := json.Marshal(event)
+	controller.broadCastMessageToChat(chatId, string(eventBytes), exceptConnection)
+}
+
+// subscribe adds a user websocket to a chat and confirms the subscription with the participants that are connected to the chat,
+// the missed messages after since are replayed first if since is not negative
+func (controller *MessageController) subscribe(connection *chatConnection, chatId string, since int64) {
+	connection.writeLock.Lock()
+	added := controller.subscribeUnlocked(connection, chatId, since)
+	connection.writeLock.Unlock()
+
+	// Presence is published without the write lock because other connections may be writing to this one at the same time
+	if added {
+		controller.publishPresence(chatId, connection.username, true, connection)
+	}
+}
+
+// subscribeUnlocked adds a user websocket to a chat and returns whether it was not subscribed before,
+// the caller needs to hold the write lock so that live messages of the chat are sent after the confirmation
+func (controller *MessageController) subscribeUnlocked(connection *chatConnection, chatId string, since int64) bool {
+	added := controller.addConnection(chatId, connection)
+	if since >= 0 {
+		controller.replayMessages(connection, chatId, since)
+	}
+
+	// Participants that hide their presence from the user are left out
+	onlineUsernames, err := controller.presenceService.GetVisibleUsernames(controller.getConnectedUsers(chatId), connection.username)
+	if err != nil {
+		fmt.Println("Error filtering connected users of chat", chatId+":", err)
+		onlineUsernames = []string{connection.username}
+	}
+	subscription, _ := json.Marshal(models.ChatSubscriptionDTO{
+		OnlineUsernames: onlineUsernames,
+	})
+	_ = connection.writeUnlocked(connection.frame(services.ChatEnvelopeSubscribe, chatId, subscription))
+	return added
+}
+
+// isSubscribed checks whether a user websocket is subscribed to a chat
+func (controller *MessageController) isSubscribed(connection *chatConnection, chatId string) bool {
+	controller.connectionsLock.RLock()
+	defer controller.connectionsLock.RUnlock()
+	return connection.chats[chatId]
+}
+
+// replayMessages sends the messages that a reconnecting client missed, the caller needs to hold the write lock of the connection
+func (controller *MessageController) replayMessages(connection *chatConnection, chatId string, since int64) {
+	records, customErr, _ := controller.messageService.GetMessagesSinceSequence(chatId, connection.username, since)
+	if customErr != nil {
+		errMessage, _ := json.Marshal(gin.H{
+			"error": customErr,
+		})
+		_ = connection.writeUnlocked(connection.frame(services.ChatEnvelopeError, chatId, errMessage))
+		return
+	}
+
+	for _, record := range records {
+		recordBytes, _ := json.Marshal(record)
+		if err := connection.writeUnlocked(connection.frame(services.ChatEnvelopeMessage, chatId, recordBytes)); err != nil {
+			return // the read loop notices the broken connection
 		}
+	}
+}
+
+// startHeartbeat sets the read deadline of a new connection and sends pings until the returned channel is closed,
+// clients that do not answer pings are disconnected, e.g. after losing the network without closing the connection
+func (controller *MessageController) startHeartbeat(conn *websocket.Conn) chan struct{} {
+	_ = conn.SetReadDeadline(time.Now().Add(controller.pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(controller.pongWait))
+	})
+	stop := make(chan struct{})
+	go controller.sendPings(conn, stop)
+	return stop
+}
+
+// sendPings regularly sends pings to the client until the connection is closed
+func (controller *MessageController) sendPings(conn *websocket.Conn, stop chan struct{}) {
+	ticker := time.NewTicker(controller.pongWait * 9 / 10)
+	defer ticker.Stop()
 
-		// Send message to all open connections of the chat
-		responseBytes, _ := json.Marshal(response)
-		controller.broadCastMessageToChat(chatId, string(responseBytes))
+	for {
+		select {
+		case <-ticker.C:
+			// Control messages can be written concurrently to other messages
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(chatWriteWait)); err != nil {
+				return // the read loop notices the broken connection through its read deadline
+			}
+		case <-stop:
+			return
+		}
 	}
 }
 
+// sendChatError sends an error message to a registered connection using its write lock,
+// user websockets receive it in an error envelope of the chat
+func sendChatError(connection *chatConnection, chatId string, customErr *customerrors.CustomError) {
+	errMessage, _ := json.Marshal(gin.H{
+		"error": customErr,
+	})
+	_ = connection.write(connection.frame(services.ChatEnvelopeError, chatId, errMessage))
+}
+
 // sendError sends an error message to the client using the given websocket connection
 func sendError(connection *websocket.Conn, customErr *customerrors.CustomError) {
 	errMessage, _ := json.Marshal(gin.H{
@@ -177,57 +733,338 @@ func closeWebsocket(conn *websocket.Conn) {
 	}
 }
 
-// addConnection adds a connection to the map of connections
-func (controller *MessageController) addConnection(username string, chatId string, conn *websocket.Conn) {
-	controller.connectionsLock.Lock()
-	defer controller.connectionsLock.Unlock()
+// addConnection adds a connection to the connections of a chat and registers it at the broker, it returns false without changes
+// if a user websocket is already subscribed to the chat or was closed in the meantime
+func (controller *MessageController) addConnection(chatId string, connection *chatConnection) bool {
+	username := connection.username
 
+	controller.connectionsLock.Lock()
+	if connection.isUserSocket() {
+		if controller.chatConnections[connection.conn] == nil || connection.chats[chatId] {
+			controller.connectionsLock.Unlock()
+			return false
+		}
+		connection.chats[chatId] = true
+	}
 	if controller.connections[chatId] == nil {
-		controller.connections[chatId] = make(map[string][]*websocket.Conn)
+		controller.connections[chatId] = make(map[string][]*chatConnection)
+	}
+	controller.connections[chatId][username] = append(controller.connections[chatId][username], connection)
+	controller.chatConnections[connection.conn] = connection
+	controller.connectionsLock.Unlock()
+
+	// The broker is called after releasing the lock because it may need to access the database
+	if err := controller.chatBroker.Join(chatId, username); err != nil {
+		fmt.Println("Error registering connection for", username, "in chat", chatId+":", err)
 	}
-	controller.connections[chatId][username] = append(controller.connections[chatId][username], conn)
+	return true
 }
 
-// removeConnection removes a connection from the map of websocket connections
-func (controller *MessageController) removeConnection(username string, chatId string, conn *websocket.Conn) {
+// removeConnection removes a connection from the connections of a chat, unregisters it at the broker
+// and tells the other participants if the user has no connection to the chat anymore
+func (controller *MessageController) removeConnection(chatId string, connection *chatConnection) {
+	username := connection.username
+	removed := false
+
 	controller.connectionsLock.Lock()
-	defer controller.connectionsLock.Unlock()
+	if controller.connections[chatId] != nil {
+		connections := controller.connections[chatId][username]
+		for i, c := range connections {
+			if c == connection {
+				fmt.Println("Removed connection for", username, "in chat", chatId)
+				controller.connections[chatId][username] = append(connections[:i], connections[i+1:]...)
+				removed = true
+				break
+			}
+		}
+		// Delete username from connections[chatId] if username has no other connections left
+		if len(controller.connections[chatId][username]) == 0 {
+			delete(controller.connections[chatId], username)
+		}
+		if len(controller.connections[chatId]) == 0 {
+			delete(controller.connections, chatId)
+		}
+	}
+	// User websockets stay open when they are removed from a chat
+	if connection.isUserSocket() {
+		delete(connection.chats, chatId)
+	} else {
+		delete(controller.chatConnections, connection.conn)
+	}
+	controller.connectionsLock.Unlock()
 
-	if controller.connections[chatId] == nil {
-		return
+	// A connection can be removed twice if sending to it failed, but it is only unregistered once
+	if removed {
+		if err := controller.chatBroker.Leave(chatId, username); err != nil {
+			fmt.Println("Error unregistering connection for", username, "in chat", chatId+":", err)
+		}
+		controller.publishPresence(chatId, username, false, connection)
 	}
+}
+
+// addUserConnection registers a user websocket to receive the events of its user
+func (controller *MessageController) addUserConnection(connection *chatConnection) {
+	controller.connectionsLock.Lock()
+	defer controller.connectionsLock.Unlock()
+
+	controller.userConnections[connection.username] = append(controller.userConnections[connection.username], connection)
+	controller.chatConnections[connection.conn] = connection
+}
 
-	connections := controller.connections[chatId][username]
+// removeUserConnection unregisters a user websocket and removes it from all subscribed chats
+func (controller *MessageController) removeUserConnection(connection *chatConnection) {
+	username := connection.username
+
+	controller.connectionsLock.Lock()
+	connections := controller.userConnections[username]
 	for i, c := range connections {
-		if c == conn {
-			fmt.Println("Removed connection for", username, "in chat", chatId)
-			controller.connections[chatId][username] = append(connections[:i], connections[i+1:]...)
+		if c == connection {
+			controller.userConnections[username] = append(connections[:i], connections[i+1:]...)
 			break
 		}
 	}
-	// Delete username from connections[chatId] if username has no other connections left
-	if len(controller.connections[chatId][username]) == 0 {
-		delete(controller.connections[chatId], username)
+	if len(controller.userConnections[username]) == 0 {
+		delete(controller.userConnections, username)
+	}
+	delete(controller.chatConnections, connection.conn)
+
+	chatIds := make([]string, 0, len(connection.chats))
+	for chatId := range connection.chats {
+		chatIds = append(chatIds, chatId)
+	}
+	controller.connectionsLock.Unlock()
+
+	for _, chatId := range chatIds {
+		controller.removeConnection(chatId, connection)
 	}
 }
 
-// broadCastMessageToChat sends a message to all websocket connections of a chat
-func (controller *MessageController) broadCastMessageToChat(chatId, message string) {
-	controller.connectionsLock.RLock()
-	defer controller.connectionsLock.RUnlock()
+// broadCastMessageToChat sends a message to all websocket connections of a chat on all server instances except for the given connection
+func (controller *MessageController) broadCastMessageToChat(chatId, message string, exceptConnection *chatConnection) {
+	brokerMessage := services.ChatBrokerMessage{
+		ChatId:  chatId,
+		Payload: message,
+	}
+	if exceptConnection != nil {
+		brokerMessage.ExceptConnectionId = exceptConnection.id
+	}
+	controller.publish(&brokerMessage)
+}
 
-	connections := controller.connections[chatId]
+// publishPresence tells the user websockets of a chat that a participant connected or disconnected,
+// disconnects are only published if the participant has no other connection to the chat on any instance
+// Participants that only show their presence to their contacts are published to each receiver that is allowed to see it
+func (controller *MessageController) publishPresence(chatId, username string, online bool, exceptConnection *chatConnection) {
+	public, err := controller.presenceService.IsPresencePublic(username)
+	if err != nil {
+		return
+	}
+	var connectedUsernames []string
+	if !online || !public {
+		connectedUsernames = controller.getConnectedUsers(chatId)
+	}
+	if !online {
+		for _, connectedUsername := range connectedUsernames {
+			if connectedUsername == username {
+				return
+			}
+		}
+	}
 
-	// send message to all connections (also to the sender as a sending confirmation)
+	presence, _ := json.Marshal(models.ChatPresenceDTO{
+		Username: username,
+		Online:   online,
+	})
+	brokerMessage := services.ChatBrokerMessage{
+		Type:               services.ChatEnvelopePresence,
+		ChatId:             chatId,
+		Payload:            string(presence),
+		ExceptConnectionId: exceptConnection.id,
+	}
+	if public {
+		controller.publish(&brokerMessage)
+		return
+	}
+
+	for _, receiver := range connectedUsernames {
+		visibleUsernames, err := controller.presenceService.GetVisibleUsernames([]string{username}, receiver)
+		if err != nil {
+			fmt.Println("Error filtering presence receivers of chat", chatId+":", err)
+			return
+		}
+		if len(visibleUsernames) == 0 {
+			continue
+		}
+		receiverMessage := brokerMessage
+		receiverMessage.Username = receiver
+		controller.publish(&receiverMessage)
+	}
+}
+
+// publish sends a message to the connections on all server instances, if publishing fails the broker still sends it to this instance
+func (controller *MessageController) publish(brokerMessage *services.ChatBrokerMessage) {
+	if err := controller.chatBroker.Publish(brokerMessage); err != nil {
+		fmt.Println("Error publishing message to chat", brokerMessage.ChatId+":", err)
+	}
+}
+
+// sendToLocalConnections hands a message of the broker to the send queues of the websocket connections of the chat on this instance,
+// presence events are only sent to user websockets because the websockets of a single chat do not know them
+// Presence events with a username are only sent to the user websockets of this user that are subscribed to the chat
+func (controller *MessageController) sendToLocalConnections(brokerMessage *services.ChatBrokerMessage) {
+	if brokerMessage.Username != "" && brokerMessage.Type != services.ChatEnvelopePresence {
+		controller.sendToUserConnections(brokerMessage)
+		return
+	}
+
+	chatId := brokerMessage.ChatId
+	envelopeType := brokerMessage.Type
+	if envelopeType == "" {
+		envelopeType = services.ChatEnvelopeMessage
+	}
+
+	// Receivers are collected first, writing while holding the lock would block connections that wait for it with their write lock
+	var receivers []*chatConnection
+	controller.connectionsLock.RLock()
 	// iterate through all users of the chat and then all their connections
-	for username, conn := range connections {
-		for _, c := range conn {
-			err := c.WriteMessage(websocket.TextMessage, []byte(message))
+	for username, connections := range controller.connections[chatId] {
+		if brokerMessage.Username != "" && username != brokerMessage.Username {
+			continue
+		}
+		for _, connection := range connections {
+			if connection.id == brokerMessage.ExceptConnectionId || (envelopeType == services.ChatEnvelopePresence && !connection.isUserSocket()) {
+				continue
+			}
+			receivers = append(receivers, connection)
+		}
+	}
+	controller.connectionsLock.RUnlock()
+
+	for _, connection := range receivers {
+		connection := connection
+		message := connection.frame(envelopeType, chatId, []byte(brokerMessage.Payload))
+		connection.enqueue(func() {
+			if err := connection.write(message); err != nil {
+				controller.closeFailedConnection(chatId, connection)
+			}
+		})
+	}
+}
+
+// sendToUserConnections hands an event of a user to the send queues of the user websockets of this user on this instance,
+// the connections are subscribed to new chats and unsubscribed from removed chats, so that they cover exactly the chats of the user
+// Websockets of a single chat are closed when the user is removed from their chat
+func (controller *MessageController) sendToUserConnections(brokerMessage *services.ChatBrokerMessage) {
+	chatId := brokerMessage.ChatId
+
+	if brokerMessage.Type == services.ChatEnvelopeChatRemoved {
+		controller.closeChatConnections(chatId, brokerMessage.Username)
+	}
+
+	controller.connectionsLock.RLock()
+	receivers := append([]*chatConnection(nil), controller.userConnections[brokerMessage.Username]...)
+	controller.connectionsLock.RUnlock()
+
+	// Events only contain the chat id, the record of a new chat is loaded once by the first connection that sends it
+	loadChatRecord := sync.OnceValue(func() []byte {
+		chatRecord, customErr, _ := controller.messageService.GetChatRecordById(chatId, brokerMessage.Username)
+		if customErr != nil {
+			fmt.Println("Error loading new chat", chatId, "of", brokerMessage.Username+":", customErr.Message)
+			return nil
+		}
+		recordBytes, _ := json.Marshal(chatRecord)
+		return recordBytes
+	})
+
+	for _, connection := range receivers {
+		connection := connection
+		connection.enqueue(func() {
+			var err error
+			switch brokerMessage.Type {
+			case services.ChatEnvelopeChatCreated:
+				chatRecord := loadChatRecord()
+				if chatRecord == nil {
+					return // the user left the chat in the meantime or the database failed, the client can still load its chats
+				}
+				// The new chat is sent before the subscription, so that the client knows the chat of the following messages
+				connection.writeLock.Lock()
+				err = connection.writeUnlocked(connection.frame(brokerMessage.Type, chatId, chatRecord))
+				added := err == nil && controller.subscribeUnlocked(connection, chatId, -1)
+				connection.writeLock.Unlock()
+				if added {
+					controller.publishPresence(chatId, connection.username, true, connection)
+				}
+			case services.ChatEnvelopeChatRemoved:
+				controller.removeConnection(chatId, connection)
+				err = connection.write(connection.frame(brokerMessage.Type, chatId, nil))
+			}
+
 			if err != nil {
-				_ = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-				_ = c.Close() // close connection if sending failed
-				controller.removeConnection(username, chatId, c)
+				controller.closeFailedConnection(chatId, connection)
 			}
+		})
+	}
+}
+
+// closeChatConnections closes the websockets of a single chat that a user opened on this instance, e.g. after the user left the chat,
+// the client receives the same error as when connecting to a chat without being a participant
+func (controller *MessageController) closeChatConnections(chatId, username string) {
+	var chatSockets []*chatConnection
+	controller.connectionsLock.RLock()
+	for _, connection := range controller.connections[chatId][username] {
+		if !connection.isUserSocket() {
+			chatSockets = append(chatSockets, connection)
 		}
 	}
+	controller.connectionsLock.RUnlock()
+
+	for _, connection := range chatSockets {
+		connection := connection
+		connection.enqueue(func() {
+			sendChatError(connection, chatId, customerrors.ChatNotFound)
+			connection.close()
+			controller.removeConnection(chatId, connection) // the read loop of the connection stops because of the closed connection
+		})
+	}
+}
+
+// closeFailedConnection closes a connection after sending to it failed and removes it from the connections,
+// the read loop of the connection stops because of the closed connection
+func (controller *MessageController) closeFailedConnection(chatId string, connection *chatConnection) {
+	connection.close()
+
+	if connection.isUserSocket() {
+		controller.removeUserConnection(connection)
+	} else {
+		controller.removeConnection(chatId, connection)
+	}
+}
+
+// updateLastSeen stores the current time as last-seen timestamp of the user, errors are only logged because presence is not essential
+func (controller *MessageController) updateLastSeen(username string) {
+	if err := controller.presenceService.UpdateLastSeen(username); err != nil {
+		fmt.Println("Error updating last seen of", username+":", err)
+	}
+}
+
+// getConnectedUsers returns the users with a connection to the chat on any server instance
+func (controller *MessageController) getConnectedUsers(chatId string) []string {
+	connectedUsernames, err := controller.chatBroker.ConnectedUsers(chatId)
+	if err != nil {
+		fmt.Println("Error getting connected users of chat", chatId+":", err)
+		return controller.getLocalConnectedUsers(chatId) // fall back to the users connected to this instance
+	}
+	return connectedUsernames
+}
+
+// getLocalConnectedUsers returns the users with a connection to the chat on this instance
+func (controller *MessageController) getLocalConnectedUsers(chatId string) []string {
+	controller.connectionsLock.RLock()
+	defer controller.connectionsLock.RUnlock()
+
+	var usernames []string
+	for username := range controller.connections[chatId] {
+		usernames = append(usernames, username)
+	}
+	return usernames
 }
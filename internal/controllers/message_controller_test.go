@@ -1,6 +1,7 @@
 package controllers_test
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -15,8 +16,10 @@ import (
 	"github.com/wwi21seb-projekt/server-beta/internal/services"
 	"github.com/wwi21seb-projekt/server-beta/internal/utils"
 	"gorm.io/gorm"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -30,10 +33,11 @@ func TestGetMessagesByChatIdSuccess(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	currentUsername := "myUser"
 	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
@@ -57,12 +61,17 @@ func TestGetMessagesByChatIdSuccess(t *testing.T) {
 			Username:  currentUsername,
 			Content:   "Test message 1",
 			CreatedAt: time.Now().UTC(),
+			Reactions: []models.MessageReaction{
+				{Username: currentUsername, Emoji: "👍"},
+				{Username: otherUsername, Emoji: "❤️"},
+				{Username: "thirdUser", Emoji: "👍"},
+			},
 		},
 		{
 			Id:       uuid.New(),
 			ChatId:   chatId,
 			Username: otherUsername,
-			Content:  "Test message 2",
+			Deleted:  true,
 		},
 	}
 
@@ -99,11 +108,21 @@ func TestGetMessagesByChatIdSuccess(t *testing.T) {
 	assert.Equal(t, totalRecords, response.Pagination.Records)
 
 	for i, message := range messages {
+		assert.Equal(t, message.Id.String(), response.Records[i].MessageId)
 		assert.Equal(t, message.Content, response.Records[i].Content)
 		assert.Equal(t, message.Username, response.Records[i].Username)
 		assert.True(t, message.CreatedAt.Equal(response.Records[i].CreationDate))
+		assert.Equal(t, message.Deleted, response.Records[i].Deleted)
+		assert.Nil(t, response.Records[i].EditDate)
 	}
 
+	// Reactions are grouped by emoji
+	assert.Equal(t, []models.MessageReactionDTO{
+		{Emoji: "👍", Count: 2, Usernames: []string{currentUsername, "thirdUser"}},
+		{Emoji: "❤️", Count: 1, Usernames: []string{otherUsername}},
+	}, response.Records[0].Reactions)
+	assert.Empty(t, response.Records[1].Reactions)
+
 	mockMessageRepository.AssertExpectations(t)
 	mockChatRepository.AssertExpectations(t)
 	mockPushSubscriptionRepository.AssertExpectations(t)
@@ -117,10 +136,11 @@ func TestGetMessagesByChatIdUnauthorized(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	chatId := uuid.New().String()
 
@@ -159,10 +179,11 @@ func TestGetMessagesByChatIdNoParticipant(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	currentUsername := "myUser"
 	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
@@ -222,10 +243,11 @@ func TestHandleWebSocketSuccess(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	currentUsername := "myUser"
 	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
@@ -258,6 +280,8 @@ func TestHandleWebSocketSuccess(t *testing.T) {
 		}).Return(nil)
 
 	// First other user has no open connection --> expect notification
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
 	mockNotificationRepository.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
 		Run(func(args mock.Arguments) {
 			capturedNotification = args.Get(0).(*models.Notification)
@@ -365,10 +389,11 @@ func TestHandleWebSocketBadRequest(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	currentUsername := "myUser"
 	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
@@ -445,10 +470,11 @@ func TestHandleWebSocketUnauthorized(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	// Create test server
 	gin.SetMode(gin.TestMode)
@@ -491,10 +517,11 @@ func TestHandleWebSocketChatNotFound(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	currentUsername := "myUser"
 	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
@@ -548,10 +575,11 @@ func TestHandleWebSocketNotParticipant(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	currentUsername := "myUser"
 	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
@@ -612,10 +640,11 @@ func TestHandleWebSocketGetNotificationsAfterClosing(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	currentUsername := "myUser"
 	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
@@ -646,6 +675,8 @@ func TestHandleWebSocketGetNotificationsAfterClosing(t *testing.T) {
 		}).Return(nil)
 
 	// Other user has no open connection --> expect notification
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
 	mockNotificationRepository.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
 		Run(func(args mock.Arguments) {
 			capturedNotification = args.Get(0).(*models.Notification)
@@ -686,8 +717,11 @@ func TestHandleWebSocketGetNotificationsAfterClosing(t *testing.T) {
 	// Wait for connections to establish
 	time.Sleep(1 * time.Second)
 
-	// Directly close the connection for other user
-	_ = ws2.Close()
+	// Close the connection for other user and wait until the server closed it as well,
+	// the server removes the connection from the chat before it closes the connection
+	err = ws2.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	assert.NoError(t, err)
+	_, _ = io.Copy(io.Discard, ws2.UnderlyingConn()) // returns at the end of the connection
 
 	// Send message from current user
 	message := models.MessageCreateRequestDTO{
@@ -745,10 +779,11 @@ func TestHandleWebSocketGetNotificationsForOtherChats(t *testing.T) {
 	mockMessageRepository := new(repositories.MockMessageRepository)
 	mockNotificationRepository := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository)
-	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService)
-	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService)
-	messageController := controllers.NewMessageController(messageService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
 
 	currentUsername := "myUser"
 	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
@@ -787,6 +822,8 @@ func TestHandleWebSocketGetNotificationsForOtherChats(t *testing.T) {
 		}).Return(nil)
 
 	// Other user is only connected to secondChat by Websocket --> expect notification for firstChat
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
 	mockNotificationRepository.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
 		Run(func(args mock.Arguments) {
 			capturedNotification = args.Get(0).(*models.Notification)
@@ -878,3 +915,1697 @@ func TestHandleWebSocketGetNotificationsForOtherChats(t *testing.T) {
 	mockPushSubscriptionRepository.AssertExpectations(t)
 	mockNotificationRepository.AssertExpectations(t)
 }
+
+// TestHandleWebSocketReadReceiptsAndTyping tests if read receipts and typing indicators are sent to the other connections of the chat
+func TestHandleWebSocketReadReceiptsAndTyping(t *testing.T) {
+	// Arrange
+	mockChatRepository := new(repositories.MockChatRepository)
+	mockMessageRepository := new(repositories.MockMessageRepository)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, nil, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
+
+	currentUsername := "myUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherUsername := "otherUser"
+	authTokenOther, err := utils.GenerateAccessToken(otherUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chat := models.Chat{
+		Id: uuid.New(),
+		Users: []models.User{
+			{Username: currentUsername},
+			{Username: otherUsername},
+		},
+	}
+	latestMessage := models.Message{
+		Id:        uuid.New(),
+		ChatId:    chat.Id,
+		Username:  otherUsername,
+		Content:   "Hello",
+		CreatedAt: time.Now().UTC(),
+	}
+
+	// Mock expectations
+	mockChatRepository.On("GetChatById", chat.Id.String()).Return(chat, nil)
+	mockMessageRepository.On("GetMessagesByChatId", chat.Id.String(), 0, 1).Return([]models.Message{latestMessage}, int64(1), nil)
+	mockChatRepository.On("UpdateLastReadMessage", chat.Id.String(), currentUsername, latestMessage.Id, latestMessage.CreatedAt).Return(nil)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/chat", messageController.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Create WebSocket connections for both users
+	url := "ws" + server.URL[4:] + "/chat?chatId=" + chat.Id.String()
+	ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	wsOther, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authTokenOther}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsOther)
+	_ = wsOther.SetReadDeadline(time.Now().UTC().Add(10 * time.Second))
+
+	// Wait for connections to establish
+	time.Sleep(1 * time.Second)
+
+	readEvent := func(conn *websocket.Conn) models.ChatEventDTO {
+		_, receivedMessage, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		var event models.ChatEventDTO
+		err = json.Unmarshal(receivedMessage, &event)
+		assert.NoError(t, err)
+		return event
+	}
+
+	// Act & Assert
+	// Typing start is sent to the other user
+	err = ws.WriteMessage(websocket.TextMessage, []byte(`{"type": "typingStart"}`))
+	assert.NoError(t, err)
+	event := readEvent(wsOther)
+	assert.Equal(t, services.ChatEventTypingStart, event.Type)
+	assert.Equal(t, currentUsername, event.Username)
+	assert.Nil(t, event.MessageId)
+
+	// Read receipt is saved and sent to the other user
+	err = ws.WriteMessage(websocket.TextMessage, []byte(`{"type": "read"}`))
+	assert.NoError(t, err)
+	event = readEvent(wsOther)
+	assert.Equal(t, services.ChatEventRead, event.Type)
+	assert.Equal(t, currentUsername, event.Username)
+	assert.NotNil(t, event.MessageId)
+	assert.Equal(t, latestMessage.Id.String(), *event.MessageId)
+
+	// Unknown request types are rejected, the sender did not receive its own events before
+	err = ws.WriteMessage(websocket.TextMessage, []byte(`{"type": "unknown"}`))
+	assert.NoError(t, err)
+	_, receivedMessage, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(receivedMessage, &errorResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, customerrors.BadRequest.Code, errorResponse.Error.Code)
+
+	// Closing the connection while typing sends typing stop
+	_ = ws.Close()
+	event = readEvent(wsOther)
+	assert.Equal(t, services.ChatEventTypingStop, event.Type)
+	assert.Equal(t, currentUsername, event.Username)
+
+	mockMessageRepository.AssertExpectations(t)
+	mockChatRepository.AssertExpectations(t)
+}
+
+// newTestMessageController creates a MessageController with mocked repositories and a chat of two users for the message change tests
+func newTestMessageController() (*controllers.MessageController, *repositories.MockChatRepository, *repositories.MockMessageRepository, models.Chat) {
+	mockChatRepository := new(repositories.MockChatRepository)
+	mockMessageRepository := new(repositories.MockMessageRepository)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, nil, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, services.NewMemoryChatBroker(), newTestPresenceService())
+
+	chat := models.Chat{
+		Id: uuid.New(),
+		Users: []models.User{
+			{Username: "myUser"},
+			{Username: "otherUser"},
+		},
+	}
+	mockChatRepository.On("GetChatById", chat.Id.String()).Return(chat, nil)
+
+	return messageController, mockChatRepository, mockMessageRepository, chat
+}
+
+// newTestPresenceService creates a presence service for websocket tests in which all users show their presence to everyone
+func newTestPresenceService() *services.PresenceService {
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockUserRepository.On("UpdateLastSeen", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	mockUserRepository.On("FindUserByUsername", mock.Anything).Return(&models.User{PresenceVisibility: services.PresenceVisibilityEveryone}, nil)
+	return services.NewPresenceService(mockUserRepository, nil, nil)
+}
+
+// TestUpdateMessageSuccess tests the UpdateMessage function if it returns 200 OK after editing an own message
+func TestUpdateMessageSuccess(t *testing.T) {
+	// Arrange
+	messageController, mockChatRepository, mockMessageRepository, chat := newTestMessageController()
+
+	message := models.Message{
+		Id:        uuid.New(),
+		ChatId:    chat.Id,
+		Username:  "myUser",
+		Content:   "Old content",
+		CreatedAt: time.Now().UTC(),
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	var capturedMessage *models.Message
+	mockMessageRepository.On("GetMessageById", message.Id.String()).Return(message, nil)
+	mockMessageRepository.On("UpdateMessage", mock.AnythingOfType("*models.Message")).
+		Run(func(args mock.Arguments) {
+			capturedMessage = args.Get(0).(*models.Message)
+		}).Return(nil)
+
+	// Setup HTTP request
+	url := "/chats/" + chat.Id.String() + "/messages/" + message.Id.String()
+	req, _ := http.NewRequest("PATCH", url, strings.NewReader(`{"content": " New content "}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PATCH("/chats/:chatId/messages/:messageId", middleware.AuthorizeUser, messageController.UpdateMessage)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+	var response models.MessageRecordDTO
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, message.Id.String(), response.MessageId)
+	assert.Equal(t, "New content", response.Content)
+	assert.NotNil(t, response.EditDate)
+	assert.Equal(t, "New content", capturedMessage.Content)
+	assert.NotNil(t, capturedMessage.EditedAt)
+
+	mockMessageRepository.AssertExpectations(t)
+	mockChatRepository.AssertExpectations(t)
+}
+
+// TestUpdateMessageDeletedConcurrently tests the UpdateMessage function if it returns 404 Not Found when the message is deleted while it is edited
+func TestUpdateMessageDeletedConcurrently(t *testing.T) {
+	// Arrange
+	messageController, _, mockMessageRepository, chat := newTestMessageController()
+
+	message := models.Message{
+		Id:       uuid.New(),
+		ChatId:   chat.Id,
+		Username: "myUser",
+		Content:  "Old content",
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockMessageRepository.On("GetMessageById", message.Id.String()).Return(message, nil)
+	mockMessageRepository.On("UpdateMessage", mock.AnythingOfType("*models.Message")).Return(gorm.ErrRecordNotFound)
+
+	// Setup HTTP request
+	url := "/chats/" + chat.Id.String() + "/messages/" + message.Id.String()
+	req, _ := http.NewRequest("PATCH", url, strings.NewReader(`{"content": "New content"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PATCH("/chats/:chatId/messages/:messageId", middleware.AuthorizeUser, messageController.UpdateMessage)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code) // Expect 404 Not Found
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, customerrors.MessageNotFound.Code, errorResponse.Error.Code)
+
+	mockMessageRepository.AssertExpectations(t)
+}
+
+// TestUpdateMessageErrors tests the UpdateMessage function for messages of other users, deleted messages, messages of other chats and invalid content
+func TestUpdateMessageErrors(t *testing.T) {
+	tests := []struct {
+		message       models.Message
+		body          string
+		expectedError *customerrors.CustomError
+	}{
+		{models.Message{Username: "otherUser", Content: "Hello"}, `{"content": "Edited"}`, customerrors.MessageModificationForbidden},
+		{models.Message{Username: "myUser", Deleted: true}, `{"content": "Edited"}`, customerrors.MessageNotFound},
+		{models.Message{Username: "myUser", ChatId: uuid.New(), Content: "Hello"}, `{"content": "Edited"}`, customerrors.MessageNotFound},
+		{models.Message{Username: "myUser", Content: "Hello"}, `{"content": "` + strings.Repeat("A", 257) + `"}`, customerrors.BadRequest},
+		{models.Message{Username: "myUser", Content: "Hello"}, `{}`, customerrors.BadRequest},
+	}
+
+	for _, test := range tests {
+		// Arrange
+		messageController, _, mockMessageRepository, chat := newTestMessageController()
+
+		test.message.Id = uuid.New()
+		if test.message.ChatId == uuid.Nil {
+			test.message.ChatId = chat.Id
+		}
+
+		authenticationToken, err := utils.GenerateAccessToken("myUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Mock expectations
+		mockMessageRepository.On("GetMessageById", test.message.Id.String()).Return(test.message, nil)
+
+		// Setup HTTP request
+		url := "/chats/" + chat.Id.String() + "/messages/" + test.message.Id.String()
+		req, _ := http.NewRequest("PATCH", url, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.PATCH("/chats/:chatId/messages/:messageId", middleware.AuthorizeUser, messageController.UpdateMessage)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, test.expectedError.HttpStatus, w.Code)
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedError.Code, errorResponse.Error.Code)
+
+		mockMessageRepository.AssertNotCalled(t, "UpdateMessage", mock.Anything)
+	}
+}
+
+// TestDeleteMessageSuccess tests the DeleteMessage function if it returns 204 No Content after deleting an own message
+func TestDeleteMessageSuccess(t *testing.T) {
+	// Arrange
+	messageController, _, mockMessageRepository, chat := newTestMessageController()
+
+	message := models.Message{
+		Id:        uuid.New(),
+		ChatId:    chat.Id,
+		Username:  "myUser",
+		Content:   "Delete me",
+		Reactions: []models.MessageReaction{{Username: "otherUser", Emoji: "😂"}},
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	var capturedMessage *models.Message
+	mockMessageRepository.On("GetMessageById", message.Id.String()).Return(message, nil)
+	mockMessageRepository.On("SoftDeleteMessage", mock.AnythingOfType("*models.Message")).
+		Run(func(args mock.Arguments) {
+			capturedMessage = args.Get(0).(*models.Message)
+		}).Return(nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("DELETE", "/chats/"+chat.Id.String()+"/messages/"+message.Id.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/chats/:chatId/messages/:messageId", middleware.AuthorizeUser, messageController.DeleteMessage)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect 204 No Content
+	assert.Empty(t, w.Body.String())
+	assert.True(t, capturedMessage.Deleted)
+	assert.Empty(t, capturedMessage.Content) // Expect content to be removed
+
+	mockMessageRepository.AssertExpectations(t)
+}
+
+// TestSetMessageReactionSuccess tests the SetMessageReaction function if it returns 200 OK and replaces the previous reaction of the user
+func TestSetMessageReactionSuccess(t *testing.T) {
+	// Arrange
+	messageController, _, mockMessageRepository, chat := newTestMessageController()
+
+	message := models.Message{
+		Id:       uuid.New(),
+		ChatId:   chat.Id,
+		Username: "otherUser", // reactions to messages of other users are allowed
+		Content:  "Hello",
+		Reactions: []models.MessageReaction{
+			{Username: "myUser", Emoji: "😂"},
+			{Username: "otherUser", Emoji: "👍🏽"},
+		},
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	var capturedReaction *models.MessageReaction
+	mockMessageRepository.On("GetMessageById", message.Id.String()).Return(message, nil)
+	mockMessageRepository.On("SetMessageReaction", mock.AnythingOfType("*models.MessageReaction")).
+		Run(func(args mock.Arguments) {
+			capturedReaction = args.Get(0).(*models.MessageReaction)
+		}).Return(nil)
+
+	// Setup HTTP request
+	url := "/chats/" + chat.Id.String() + "/messages/" + message.Id.String() + "/reaction"
+	req, _ := http.NewRequest("PUT", url, strings.NewReader(`{"emoji": "👍🏽"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PUT("/chats/:chatId/messages/:messageId/reaction", middleware.AuthorizeUser, messageController.SetMessageReaction)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect 200 OK
+	var response models.MessageRecordDTO
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, message.Id, capturedReaction.MessageId)
+	assert.Equal(t, "myUser", capturedReaction.Username)
+	assert.Equal(t, "👍🏽", capturedReaction.Emoji)
+	assert.Equal(t, []models.MessageReactionDTO{
+		{Emoji: "👍🏽", Count: 2, Usernames: []string{"myUser", "otherUser"}},
+	}, response.Reactions) // Expect previous reaction of the user to be replaced
+
+	mockMessageRepository.AssertExpectations(t)
+}
+
+// TestSetMessageReactionBadRequest tests the SetMessageReaction function if it returns 400 Bad Request for reactions that are no single emoji
+func TestSetMessageReactionBadRequest(t *testing.T) {
+	invalidBodies := []string{
+		`{}`,
+		`{"emoji": "a"}`,
+		`{"emoji": "hello"}`,
+		`{"emoji": "👍 "}`,
+		`{"emoji": "` + strings.Repeat("👍", 9) + `"}`,
+	}
+
+	for _, body := range invalidBodies {
+		// Arrange
+		messageController, _, mockMessageRepository, chat := newTestMessageController()
+		messageId := uuid.New()
+
+		authenticationToken, err := utils.GenerateAccessToken("myUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Setup HTTP request
+		url := "/chats/" + chat.Id.String() + "/messages/" + messageId.String() + "/reaction"
+		req, _ := http.NewRequest("PUT", url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.PUT("/chats/:chatId/messages/:messageId/reaction", middleware.AuthorizeUser, messageController.SetMessageReaction)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code, body) // Expect 400 Bad Request
+		mockMessageRepository.AssertNotCalled(t, "SetMessageReaction", mock.Anything)
+	}
+}
+
+// TestHandleWebSocketMessageChanges tests if edits, reactions and deletions on the websocket are sent to all connections of the chat
+func TestHandleWebSocketMessageChanges(t *testing.T) {
+	// Arrange
+	messageController, _, mockMessageRepository, chat := newTestMessageController()
+
+	message := models.Message{
+		Id:        uuid.New(),
+		ChatId:    chat.Id,
+		Username:  "myUser",
+		Content:   "Hello",
+		CreatedAt: time.Now().UTC(),
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authTokenOther, err := utils.GenerateAccessToken("otherUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockMessageRepository.On("GetMessageById", message.Id.String()).Return(message, nil)
+	mockMessageRepository.On("UpdateMessage", mock.AnythingOfType("*models.Message")).Return(nil)
+	mockMessageRepository.On("SetMessageReaction", mock.AnythingOfType("*models.MessageReaction")).Return(nil)
+	mockMessageRepository.On("DeleteMessageReaction", message.Id.String(), "otherUser").Return(nil)
+	mockMessageRepository.On("SoftDeleteMessage", mock.AnythingOfType("*models.Message")).Return(nil)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/chat", messageController.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Create WebSocket connections for both users
+	url := "ws" + server.URL[4:] + "/chat?chatId=" + chat.Id.String()
+	ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	wsOther, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authTokenOther}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsOther)
+	_ = wsOther.SetReadDeadline(time.Now().UTC().Add(10 * time.Second))
+
+	// Wait for connections to establish
+	time.Sleep(1 * time.Second)
+
+	readEvent := func(conn *websocket.Conn) models.ChatEventDTO {
+		_, receivedMessage, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		var event models.ChatEventDTO
+		err = json.Unmarshal(receivedMessage, &event)
+		assert.NoError(t, err)
+		if event.Message == nil {
+			t.Fatalf("Expected event with message, got %s", receivedMessage)
+		}
+		return event
+	}
+
+	// Act & Assert
+	// Edit is sent to both users
+	err = ws.WriteMessage(websocket.TextMessage, []byte(`{"type": "edit", "messageId": "`+message.Id.String()+`", "content": "Edited"}`))
+	assert.NoError(t, err)
+	for _, conn := range []*websocket.Conn{ws, wsOther} {
+		event := readEvent(conn)
+		assert.Equal(t, services.ChatEventEdit, event.Type)
+		assert.Equal(t, "myUser", event.Username)
+		assert.Equal(t, message.Id.String(), *event.MessageId)
+		assert.Equal(t, "Edited", event.Message.Content)
+		assert.NotNil(t, event.Message.EditDate)
+	}
+
+	// Reaction of the other user is sent to both users
+	err = wsOther.WriteMessage(websocket.TextMessage, []byte(`{"type": "reaction", "messageId": "`+message.Id.String()+`", "emoji": "🎉"}`))
+	assert.NoError(t, err)
+	for _, conn := range []*websocket.Conn{ws, wsOther} {
+		event := readEvent(conn)
+		assert.Equal(t, services.ChatEventReaction, event.Type)
+		assert.Equal(t, "otherUser", event.Username)
+		assert.Equal(t, []models.MessageReactionDTO{{Emoji: "🎉", Count: 1, Usernames: []string{"otherUser"}}}, event.Message.Reactions)
+	}
+
+	// Reaction without emoji removes the reaction
+	err = wsOther.WriteMessage(websocket.TextMessage, []byte(`{"type": "reaction", "messageId": "`+message.Id.String()+`"}`))
+	assert.NoError(t, err)
+	for _, conn := range []*websocket.Conn{ws, wsOther} {
+		event := readEvent(conn)
+		assert.Equal(t, services.ChatEventReaction, event.Type)
+		assert.Empty(t, event.Message.Reactions)
+	}
+
+	// Deletion by the author is sent to both users
+	err = ws.WriteMessage(websocket.TextMessage, []byte(`{"type": "delete", "messageId": "`+message.Id.String()+`"}`))
+	assert.NoError(t, err)
+	for _, conn := range []*websocket.Conn{ws, wsOther} {
+		event := readEvent(conn)
+		assert.Equal(t, services.ChatEventDelete, event.Type)
+		assert.True(t, event.Message.Deleted)
+		assert.Empty(t, event.Message.Content)
+	}
+
+	// The other user cannot delete the message of the author
+	err = wsOther.WriteMessage(websocket.TextMessage, []byte(`{"type": "delete", "messageId": "`+message.Id.String()+`"}`))
+	assert.NoError(t, err)
+	_, receivedMessage, err := wsOther.ReadMessage()
+	assert.NoError(t, err)
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(receivedMessage, &errorResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, customerrors.MessageModificationForbidden.Code, errorResponse.Error.Code)
+
+	mockMessageRepository.AssertExpectations(t)
+}
+
+// TestHandleWebSocketPictureMessage tests if messages with a picture and without content are saved and sent to all connections of the chat
+func TestHandleWebSocketPictureMessage(t *testing.T) {
+	// Arrange
+	messageController, _, mockMessageRepository, chat := newTestMessageController()
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authTokenOther, err := utils.GenerateAccessToken("otherUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imageData, err := os.ReadFile("../../tests/resources/valid.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidImageData, err := os.ReadFile("../../tests/resources/invalid.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	var capturedMessage *models.Message
+	mockMessageRepository.On("CreateMessage", mock.AnythingOfType("*models.Message")).
+		Run(func(args mock.Arguments) {
+			capturedMessage = args.Get(0).(*models.Message)
+		}).Return(nil)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/chat", messageController.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Create WebSocket connections for both users, so that no notifications are sent
+	url := "ws" + server.URL[4:] + "/chat?chatId=" + chat.Id.String()
+	ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	wsOther, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authTokenOther}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsOther)
+	_ = wsOther.SetReadDeadline(time.Now().UTC().Add(10 * time.Second))
+
+	// Wait for connections to establish
+	time.Sleep(1 * time.Second)
+
+	// Act & Assert
+	// Invalid picture is rejected
+	requestJSON, err := json.Marshal(models.ChatSocketRequestDTO{Picture: base64.StdEncoding.EncodeToString(invalidImageData)})
+	assert.NoError(t, err)
+	err = ws.WriteMessage(websocket.TextMessage, requestJSON)
+	assert.NoError(t, err)
+
+	_, receivedMessage, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(receivedMessage, &errorResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, customerrors.BadRequest.Code, errorResponse.Error.Code)
+
+	// Valid picture without content is sent to both users
+	requestJSON, err = json.Marshal(models.ChatSocketRequestDTO{Picture: base64.StdEncoding.EncodeToString(imageData)})
+	assert.NoError(t, err)
+	err = ws.WriteMessage(websocket.TextMessage, requestJSON)
+	assert.NoError(t, err)
+
+	for _, conn := range []*websocket.Conn{ws, wsOther} {
+		_, receivedMessage, err = conn.ReadMessage()
+		assert.NoError(t, err)
+		var response models.MessageRecordDTO
+		err = json.Unmarshal(receivedMessage, &response)
+		assert.NoError(t, err)
+
+		assert.Empty(t, response.Content)
+		assert.Equal(t, "myUser", response.Username)
+		if assert.NotNil(t, response.Picture) {
+			assert.Equal(t, utils.FormatImageUrl(capturedMessage.ImageId.String(), "png"), response.Picture.Url)
+			assert.Equal(t, capturedMessage.Image.Width, response.Picture.Width)
+			assert.Equal(t, capturedMessage.Image.Height, response.Picture.Height)
+		}
+	}
+
+	assert.NotNil(t, capturedMessage.ImageId)
+	assert.Equal(t, *capturedMessage.ImageId, capturedMessage.Image.Id)
+	assert.Equal(t, "png", capturedMessage.Image.Format)
+	assert.Equal(t, imageData, capturedMessage.Image.ImageData)
+
+	mockMessageRepository.AssertExpectations(t)
+}
+
+// TestHandleWebSocketMultipleInstances tests if messages and typing events reach participants that are connected to another server instance
+// and if only participants without a connection on any instance are notified
+func TestHandleWebSocketMultipleInstances(t *testing.T) {
+	// Arrange
+	mockChatRepository := new(repositories.MockChatRepository)
+	mockMessageRepository := new(repositories.MockMessageRepository)
+	mockNotificationRepository := new(repositories.MockNotificationRepository)
+	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, notificationService, utils.NewValidator())
+
+	// Both controllers share the broker like two server instances share the database
+	chatBroker := services.NewMemoryChatBroker()
+	firstController := controllers.NewMessageController(messageService, chatBroker, newTestPresenceService())
+	secondController := controllers.NewMessageController(messageService, chatBroker, newTestPresenceService())
+
+	currentUsername := "myUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherUsername := "otherUser"
+	authTokenOther, err := utils.GenerateAccessToken(otherUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offlineUsername := "offlineUser"
+
+	chat := models.Chat{
+		Id: uuid.New(),
+		Users: []models.User{
+			{Username: currentUsername},
+			{Username: otherUsername},
+			{Username: offlineUsername},
+		},
+	}
+
+	// Mock expectations
+	var capturedNotifications []*models.Notification
+	mockChatRepository.On("GetChatById", chat.Id.String()).Return(chat, nil)
+	mockMessageRepository.On("CreateMessage", mock.AnythingOfType("*models.Message")).Return(nil)
+
+	// Only the user without connection is notified
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound)
+	mockNotificationRepository.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
+		Run(func(args mock.Arguments) {
+			capturedNotifications = append(capturedNotifications, args.Get(0).(*models.Notification))
+		}).Return(nil)
+	mockNotificationRepository.On("GetNotificationById", mock.AnythingOfType("string")).Return(models.Notification{}, nil)
+	mockPushSubscriptionRepository.On("GetPushSubscriptionsByUsername", offlineUsername).Return([]models.PushSubscription{}, nil)
+
+	// Create a test server for each instance
+	gin.SetMode(gin.TestMode)
+	firstRouter := gin.Default()
+	firstRouter.GET("/chat", firstController.HandleWebSocket)
+	firstServer := httptest.NewServer(firstRouter)
+	defer firstServer.Close()
+
+	secondRouter := gin.Default()
+	secondRouter.GET("/chat", secondController.HandleWebSocket)
+	secondServer := httptest.NewServer(secondRouter)
+	defer secondServer.Close()
+
+	// Current user connects to the first instance, other user to the second instance
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+firstServer.URL[4:]+"/chat?chatId="+chat.Id.String(), http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	wsOther, _, err := websocket.DefaultDialer.Dial("ws"+secondServer.URL[4:]+"/chat?chatId="+chat.Id.String(), http.Header{"Sec-WebSocket-Protocol": []string{authTokenOther}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsOther)
+	_ = wsOther.SetReadDeadline(time.Now().UTC().Add(10 * time.Second))
+
+	// Wait for connections to establish
+	time.Sleep(1 * time.Second)
+
+	connectedUsers, err := chatBroker.ConnectedUsers(chat.Id.String())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{currentUsername, otherUsername}, connectedUsers)
+
+	// Act & Assert
+	// Typing event of the other user reaches the current user on the other instance, but not the sender
+	err = wsOther.WriteMessage(websocket.TextMessage, []byte(`{"type": "typingStart"}`))
+	assert.NoError(t, err)
+
+	_, receivedMessage, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	var typingEvent models.ChatEventDTO
+	err = json.Unmarshal(receivedMessage, &typingEvent)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ChatEventTypingStart, typingEvent.Type)
+	assert.Equal(t, otherUsername, typingEvent.Username)
+
+	// Message of the current user reaches both instances
+	err = ws.WriteMessage(websocket.TextMessage, []byte(`{"content": "Hello from the first instance"}`))
+	assert.NoError(t, err)
+
+	for _, conn := range []*websocket.Conn{ws, wsOther} {
+		_, receivedMessage, err = conn.ReadMessage()
+		assert.NoError(t, err)
+		var response models.MessageRecordDTO
+		err = json.Unmarshal(receivedMessage, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello from the first instance", response.Content)
+		assert.Equal(t, currentUsername, response.Username)
+	}
+
+	// Only the offline user gets a notification, the other user is connected to the second instance
+	if assert.Len(t, capturedNotifications, 1) {
+		assert.Equal(t, offlineUsername, capturedNotifications[0].ForUsername)
+	}
+
+	// Closed connections are removed from the broker
+	_ = wsOther.Close()
+	time.Sleep(500 * time.Millisecond)
+	connectedUsers, err = chatBroker.ConnectedUsers(chat.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{currentUsername}, connectedUsers)
+
+	mockMessageRepository.AssertExpectations(t)
+	mockNotificationRepository.AssertExpectations(t)
+}
+
+// TestHandleWebSocketReplayMissedMessages tests if a reconnecting client receives the messages after the given sequence number before live messages
+func TestHandleWebSocketReplayMissedMessages(t *testing.T) {
+	// Arrange
+	messageController, _, mockMessageRepository, chat := newTestMessageController()
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authTokenOther, err := utils.GenerateAccessToken("otherUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Repository returns the newest messages first
+	missedMessages := []models.Message{
+		{Id: uuid.New(), ChatId: chat.Id, Sequence: 8, Username: "otherUser", Content: "Third missed message", CreatedAt: time.Now().UTC()},
+		{Id: uuid.New(), ChatId: chat.Id, Sequence: 7, Username: "otherUser", Deleted: true, CreatedAt: time.Now().UTC().Add(-time.Minute)},
+		{Id: uuid.New(), ChatId: chat.Id, Sequence: 6, Username: "myUser", Content: "First missed message", CreatedAt: time.Now().UTC().Add(-2 * time.Minute)},
+	}
+
+	// Mock expectations
+	mockMessageRepository.On("GetMessagesByChatIdSinceSequence", chat.Id.String(), int64(5), 100).Return(missedMessages, nil)
+	mockMessageRepository.On("CreateMessage", mock.AnythingOfType("*models.Message")).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Message).Sequence = 9 // repository assigns the next sequence number
+		}).Return(nil)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/chat", messageController.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Other user is connected to receive the live message without notification
+	url := "ws" + server.URL[4:] + "/chat?chatId=" + chat.Id.String()
+	wsOther, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authTokenOther}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsOther)
+	_ = wsOther.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	// Act
+	// Current user reconnects after receiving the message with sequence number 5
+	ws, _, err := websocket.DefaultDialer.Dial(url+"&since=5", http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second))
+
+	// Assert
+	// Missed messages are replayed oldest first
+	for i, expectedSequence := range []int64{6, 7, 8} {
+		_, receivedMessage, err := ws.ReadMessage()
+		assert.NoError(t, err)
+		var response models.MessageRecordDTO
+		err = json.Unmarshal(receivedMessage, &response)
+		assert.NoError(t, err)
+
+		expectedMessage := missedMessages[len(missedMessages)-1-i]
+		assert.Equal(t, expectedSequence, response.Sequence)
+		assert.Equal(t, expectedMessage.Id.String(), response.MessageId)
+		assert.Equal(t, expectedMessage.Content, response.Content)
+		assert.Equal(t, expectedMessage.Deleted, response.Deleted)
+	}
+
+	// Live messages follow the replayed messages with the next sequence number
+	err = wsOther.WriteMessage(websocket.TextMessage, []byte(`{"content": "Live message"}`))
+	assert.NoError(t, err)
+
+	for _, conn := range []*websocket.Conn{ws, wsOther} {
+		_, receivedMessage, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		var response models.MessageRecordDTO
+		err = json.Unmarshal(receivedMessage, &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "Live message", response.Content)
+		assert.Equal(t, int64(9), response.Sequence)
+	}
+
+	mockMessageRepository.AssertExpectations(t)
+}
+
+// TestHandleWebSocketInvalidSince tests if the HandleWebSocket function returns BadRequest custom error for an invalid sequence number
+func TestHandleWebSocketInvalidSince(t *testing.T) {
+	for _, since := range []string{"abc", "-1", "1.5"} {
+		// Arrange
+		messageController, _, mockMessageRepository, chat := newTestMessageController()
+
+		authenticationToken, err := utils.GenerateAccessToken("myUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Create test server
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.GET("/chat", messageController.HandleWebSocket)
+		server := httptest.NewServer(router)
+
+		// Act
+		url := "ws" + server.URL[4:] + "/chat?chatId=" + chat.Id.String() + "&since=" + since
+		ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}})
+		assert.NoError(t, err)
+		_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+		// Assert
+		_, receivedMessage, err := ws.ReadMessage()
+		assert.NoError(t, err)
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(receivedMessage, &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, customerrors.BadRequest.Code, errorResponse.Error.Code, since)
+
+		// Connection is closed afterwards
+		_, _, err = ws.ReadMessage()
+		assert.Error(t, err)
+
+		mockMessageRepository.AssertNotCalled(t, "GetMessagesByChatIdSinceSequence", mock.Anything, mock.Anything, mock.Anything)
+
+		_ = ws.Close()
+		server.Close()
+	}
+}
+
+// TestHandleWebSocketHeartbeat tests if the server sends pings and closes connections of clients that do not answer them
+func TestHandleWebSocketHeartbeat(t *testing.T) {
+	// Arrange
+	mockChatRepository := new(repositories.MockChatRepository)
+	mockMessageRepository := new(repositories.MockMessageRepository)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, nil, utils.NewValidator())
+	chatBroker := services.NewMemoryChatBroker()
+	messageController := controllers.NewMessageController(messageService, chatBroker, newTestPresenceService())
+	messageController.SetPongWait(1 * time.Second)
+
+	chat := models.Chat{
+		Id: uuid.New(),
+		Users: []models.User{
+			{Username: "myUser"},
+			{Username: "otherUser"},
+		},
+	}
+	mockChatRepository.On("GetChatById", chat.Id.String()).Return(chat, nil)
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authTokenOther, err := utils.GenerateAccessToken("otherUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/chat", messageController.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	url := "ws" + server.URL[4:] + "/chat?chatId=" + chat.Id.String()
+
+	// Current user reads from the connection and answers pings
+	ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	pings := make(chan struct{}, 10)
+	ws.SetPingHandler(func(appData string) error {
+		pings <- struct{}{}
+		return ws.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(time.Second))
+	})
+	go func() {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Other user never reads, so pings are not answered
+	wsOther, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": []string{authTokenOther}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsOther)
+
+	time.Sleep(200 * time.Millisecond)
+	connectedUsers, err := chatBroker.ConnectedUsers(chat.Id.String())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"myUser", "otherUser"}, connectedUsers)
+
+	// Act
+	time.Sleep(2500 * time.Millisecond)
+
+	// Assert
+	assert.GreaterOrEqual(t, len(pings), 2) // a ping is sent every 900ms
+
+	connectedUsers, err = chatBroker.ConnectedUsers(chat.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myUser"}, connectedUsers) // connection without pongs is closed
+}
+
+// readChatEnvelope reads the next envelope from a user websocket
+func readChatEnvelope(t *testing.T, ws *websocket.Conn) models.ChatEnvelopeDTO {
+	_, receivedMessage, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	var envelope models.ChatEnvelopeDTO
+	err = json.Unmarshal(receivedMessage, &envelope)
+	assert.NoError(t, err)
+	return envelope
+}
+
+// TestHandleUserWebSocket tests if the user websocket is subscribed to all chats of the user and exchanges messages in envelopes
+func TestHandleUserWebSocket(t *testing.T) {
+	// Arrange
+	messageController, mockChatRepository, mockMessageRepository, chat := newTestMessageController()
+	chatId := chat.Id.String()
+
+	authenticationToken, err := utils.GenerateAccessToken("myUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authTokenOther, err := utils.GenerateAccessToken("otherUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockChatRepository.On("GetChatsByUsername", "myUser").Return([]models.Chat{chat}, nil)
+	mockMessageRepository.On("CreateMessage", mock.AnythingOfType("*models.Message")).Return(nil)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/chat", messageController.HandleWebSocket)
+	router.GET("/chats/ws", messageController.HandleUserWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Current user opens the user websocket, other user the websocket of the chat
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/chats/ws", http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	// Act & Assert
+	// Connection is subscribed to the chat of the user
+	envelope := readChatEnvelope(t, ws)
+	assert.Equal(t, services.ChatEnvelopeSubscribe, envelope.Type)
+	assert.Equal(t, chatId, envelope.ChatId)
+	var subscription models.ChatSubscriptionDTO
+	err = json.Unmarshal(envelope.Payload, &subscription)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myUser"}, subscription.OnlineUsernames)
+
+	wsOther, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/chat?chatId="+chatId, http.Header{"Sec-WebSocket-Protocol": []string{authTokenOther}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsOther)
+	_ = wsOther.SetReadDeadline(time.Now().UTC().Add(10 * time.Second))
+
+	// Current user is told that the other user connected to the chat
+	envelope = readChatEnvelope(t, ws)
+	assert.Equal(t, services.ChatEnvelopePresence, envelope.Type)
+	assert.Equal(t, chatId, envelope.ChatId)
+	var presence models.ChatPresenceDTO
+	err = json.Unmarshal(envelope.Payload, &presence)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ChatPresenceDTO{Username: "otherUser", Online: true}, presence)
+
+	// Message in an envelope reaches both websockets, the websocket of the chat receives it without envelope
+	err = ws.WriteMessage(websocket.TextMessage, []byte(`{"type": "message", "chatId": "`+chatId+`", "payload": {"content": "Hello from the user websocket"}}`))
+	assert.NoError(t, err)
+
+	envelope = readChatEnvelope(t, ws)
+	assert.Equal(t, services.ChatEnvelopeMessage, envelope.Type)
+	assert.Equal(t, chatId, envelope.ChatId)
+	var record models.MessageRecordDTO
+	err = json.Unmarshal(envelope.Payload, &record)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello from the user websocket", record.Content)
+	assert.Equal(t, "myUser", record.Username)
+
+	_, receivedMessage, err := wsOther.ReadMessage()
+	assert.NoError(t, err)
+	record = models.MessageRecordDTO{}
+	err = json.Unmarshal(receivedMessage, &record)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello from the user websocket", record.Content)
+
+	// Invalid envelopes and messages for chats that are not subscribed return errors
+	otherChatId := uuid.New().String()
+	for _, request := range []string{
+		`invalid json`,
+		`{"type": "message", "chatId": "` + otherChatId + `", "payload": {"content": "Hello"}}`,
+		`{"type": "unknown", "chatId": "` + chatId + `"}`,
+	} {
+		err = ws.WriteMessage(websocket.TextMessage, []byte(request))
+		assert.NoError(t, err)
+
+		envelope = readChatEnvelope(t, ws)
+		assert.Equal(t, services.ChatEnvelopeError, envelope.Type, request)
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(envelope.Payload, &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, customerrors.BadRequest.Code, errorResponse.Error.Code)
+	}
+
+	// After unsubscribing, messages of the chat are not received anymore
+	err = ws.WriteMessage(websocket.TextMessage, []byte(`{"type": "unsubscribe", "chatId": "`+chatId+`"}`))
+	assert.NoError(t, err)
+	envelope = readChatEnvelope(t, ws)
+	assert.Equal(t, services.ChatEnvelopeUnsubscribe, envelope.Type)
+	assert.Equal(t, chatId, envelope.ChatId)
+
+	err = wsOther.WriteMessage(websocket.TextMessage, []byte(`{"type": "typingStart"}`))
+	assert.NoError(t, err)
+
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(500 * time.Millisecond))
+	_, _, err = ws.ReadMessage()
+	assert.Error(t, err) // timeout because nothing is received
+
+	mockMessageRepository.AssertNumberOfCalls(t, "CreateMessage", 1)
+}
+
+// TestHandleUserWebSocketChatCreatedAndRemoved tests if the user websocket is subscribed to chats that the user is added to
+// and unsubscribed from chats that the user is removed from
+func TestHandleUserWebSocketChatCreatedAndRemoved(t *testing.T) {
+	// Arrange
+	mockChatRepository := new(repositories.MockChatRepository)
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockMessageRepository := new(repositories.MockMessageRepository)
+
+	// Chat service and message controller share the broker like in the router
+	chatBroker := services.NewMemoryChatBroker()
+	chatController := controllers.NewChatController(services.NewChatService(mockChatRepository, mockUserRepository, nil, nil, chatBroker, services.NewPresenceService(nil, nil, chatBroker)))
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, nil, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, chatBroker, newTestPresenceService())
+
+	chat := newTestGroupChat()
+	chatId := chat.Id.String()
+	updatedChat := newTestGroupChat()
+	updatedChat.Id = chat.Id
+	updatedChat.Users = append(updatedChat.Users, models.User{Username: "newUser"})
+	updatedChat.Members = append(updatedChat.Members, models.ChatUser{ChatId: chat.Id, UserUsername: "newUser", Role: services.ChatRoleMember})
+
+	adminToken, err := utils.GenerateAccessToken("adminUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newUserToken, err := utils.GenerateAccessToken("newUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockChatRepository.On("GetChatsByUsername", "newUser").Return([]models.Chat{}, nil)
+	mockChatRepository.On("GetChatById", chatId).Return(chat, nil).Once()          // adding checks the admin
+	mockChatRepository.On("GetChatById", chatId).Return(updatedChat, nil).Times(3) // record after adding, record for the user websocket and admin check for removing
+	mockChatRepository.On("GetChatById", chatId).Return(chat, nil)                 // record after removing
+	mockUserRepository.On("FindUserByUsername", "newUser").Return(&models.User{Username: "newUser"}, nil)
+	mockChatRepository.On("AddChatMembers", mock.AnythingOfType("[]models.ChatUser")).Return(nil)
+	mockChatRepository.On("RemoveChatMember", chatId, "newUser").Return(nil)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/chats/:chatId/members", middleware.AuthorizeUser, chatController.AddChatMembers)
+	router.DELETE("/chats/:chatId/members/:username", middleware.AuthorizeUser, chatController.RemoveChatMember)
+	router.GET("/chats/ws", messageController.HandleUserWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/chats/ws", http.Header{"Sec-WebSocket-Protocol": []string{newUserToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	// Wait for connection to establish
+	time.Sleep(500 * time.Millisecond)
+
+	// Act & Assert
+	// New member receives the chat and is subscribed to it
+	req, _ := http.NewRequest("POST", server.URL+"/chats/"+chatId+"/members", strings.NewReader(`{"usernames": ["newUser"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	envelope := readChatEnvelope(t, ws)
+	assert.Equal(t, services.ChatEnvelopeChatCreated, envelope.Type)
+	assert.Equal(t, chatId, envelope.ChatId)
+	var chatRecord models.ChatRecordDTO
+	err = json.Unmarshal(envelope.Payload, &chatRecord)
+	assert.NoError(t, err)
+	assert.Equal(t, chatId, chatRecord.ChatId)
+	assert.Equal(t, "Test Group", chatRecord.Name)
+	assert.Len(t, chatRecord.Participants, 3)
+
+	envelope = readChatEnvelope(t, ws)
+	assert.Equal(t, services.ChatEnvelopeSubscribe, envelope.Type)
+	assert.Equal(t, chatId, envelope.ChatId)
+
+	connectedUsers, err := chatBroker.ConnectedUsers(chatId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"newUser"}, connectedUsers)
+
+	// Removed member is unsubscribed from the chat
+	req, _ = http.NewRequest("DELETE", server.URL+"/chats/"+chatId+"/members/newUser", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	envelope = readChatEnvelope(t, ws)
+	assert.Equal(t, services.ChatEnvelopeChatRemoved, envelope.Type)
+	assert.Equal(t, chatId, envelope.ChatId)
+
+	connectedUsers, err = chatBroker.ConnectedUsers(chatId)
+	assert.NoError(t, err)
+	assert.Empty(t, connectedUsers)
+
+	mockChatRepository.AssertExpectations(t)
+	mockUserRepository.AssertExpectations(t)
+}
+
+// TestHandleWebSocketClosedAfterLeavingChat tests if the websockets of a single chat that a user opened on any server instance
+// are closed when the user leaves the chat, while the websockets of other participants stay open
+func TestHandleWebSocketClosedAfterLeavingChat(t *testing.T) {
+	// Arrange
+	mockChatRepository := new(repositories.MockChatRepository)
+	mockMessageRepository := new(repositories.MockMessageRepository)
+
+	// Chat service and both controllers share the broker like two server instances share the database
+	chatBroker := services.NewMemoryChatBroker()
+	chatController := controllers.NewChatController(services.NewChatService(mockChatRepository, nil, nil, nil, chatBroker, nil))
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, nil, utils.NewValidator())
+	firstController := controllers.NewMessageController(messageService, chatBroker, newTestPresenceService())
+	secondController := controllers.NewMessageController(messageService, chatBroker, newTestPresenceService())
+
+	chat := newTestGroupChat()
+	chatId := chat.Id.String()
+
+	adminToken, err := utils.GenerateAccessToken("adminUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memberToken, err := utils.GenerateAccessToken("memberUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockChatRepository.On("GetChatById", chatId).Return(chat, nil)
+	mockChatRepository.On("RemoveChatMember", chatId, "memberUser").Return(nil)
+
+	// Create test servers, the member uses the first instance for the REST API and the second one for the websocket
+	gin.SetMode(gin.TestMode)
+	firstRouter := gin.Default()
+	firstRouter.POST("/chats/:chatId/leave", middleware.AuthorizeUser, chatController.LeaveChat)
+	firstRouter.GET("/chat", firstController.HandleWebSocket)
+	firstServer := httptest.NewServer(firstRouter)
+	defer firstServer.Close()
+	secondRouter := gin.Default()
+	secondRouter.GET("/chat", secondController.HandleWebSocket)
+	secondServer := httptest.NewServer(secondRouter)
+	defer secondServer.Close()
+
+	wsAdmin, _, err := websocket.DefaultDialer.Dial("ws"+firstServer.URL[4:]+"/chat?chatId="+chatId, http.Header{"Sec-WebSocket-Protocol": []string{adminToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsAdmin)
+	wsMember, _, err := websocket.DefaultDialer.Dial("ws"+secondServer.URL[4:]+"/chat?chatId="+chatId, http.Header{"Sec-WebSocket-Protocol": []string{memberToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsMember)
+	_ = wsMember.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	// Wait for connections to establish
+	assert.Eventually(t, func() bool {
+		connectedUsers, _ := chatBroker.ConnectedUsers(chatId)
+		return len(connectedUsers) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Act
+	req, _ := http.NewRequest("POST", firstServer.URL+"/chats/"+chatId+"/leave", nil)
+	req.Header.Set("Authorization", "Bearer "+memberToken)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Member is told that the chat is gone and the connection is closed afterwards
+	_, receivedMessage, err := wsMember.ReadMessage()
+	assert.NoError(t, err)
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(receivedMessage, &errorResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, customerrors.ChatNotFound.Code, errorResponse.Error.Code)
+
+	_, _, err = wsMember.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+
+	connectedUsers, err := chatBroker.ConnectedUsers(chatId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"adminUser"}, connectedUsers)
+
+	mockChatRepository.AssertExpectations(t)
+}
+
+// TestHandleWebSocketPostgresChatBroker tests if the postgres chat broker counts every connection of a user to a chat
+// and if messages still reach the connections of this instance when publishing to the database fails
+func TestHandleWebSocketPostgresChatBroker(t *testing.T) {
+	// Arrange
+	mockChatRepository := new(repositories.MockChatRepository)
+	mockMessageRepository := new(repositories.MockMessageRepository)
+	mockChatBrokerRepository := new(repositories.MockChatBrokerRepository)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, nil, utils.NewValidator())
+
+	// The broker is not started, so it only publishes to the database and does not receive messages of other instances
+	chatBroker := services.NewPostgresChatBroker(mockChatBrokerRepository, "")
+	messageController := controllers.NewMessageController(messageService, chatBroker, newTestPresenceService())
+
+	currentUsername := "myUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chat := models.Chat{
+		Id: uuid.New(),
+		Users: []models.User{
+			{Username: currentUsername},
+			{Username: "otherUser"},
+		},
+	}
+	chatId := chat.Id.String()
+
+	// Mock expectations
+	joined := make(chan string, 2)
+	publishedPayloads := make(chan string, 16)
+	mockChatRepository.On("GetChatById", chatId).Return(chat, nil)
+	mockMessageRepository.On("CreateMessage", mock.AnythingOfType("*models.Message")).Return(nil)
+	mockChatBrokerRepository.On("IncrementChatConnections", mock.AnythingOfType("string"), chatId, currentUsername, mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			joined <- args.String(0)
+		}).Return(nil)
+	mockChatBrokerRepository.On("DecrementChatConnections", mock.AnythingOfType("string"), chatId, currentUsername).Return(nil)
+	mockChatBrokerRepository.On("GetConnectedUsernames", chatId, mock.AnythingOfType("time.Time")).Return([]string{currentUsername, "otherUser"}, nil) // no notifications are sent
+	mockChatBrokerRepository.On("Notify", "chat_messages", mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			publishedPayloads <- args.String(1)
+		}).Return(gorm.ErrInvalidDB) // database is not reachable
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/chat", messageController.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Current user opens two connections to the chat, e.g. on two devices
+	url := "ws" + server.URL[4:] + "/chat?chatId=" + chatId
+	headers := http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}}
+	ws, _, err := websocket.DefaultDialer.Dial(url, headers)
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+	ws2, _, err := websocket.DefaultDialer.Dial(url, headers)
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws2)
+	_ = ws2.SetReadDeadline(time.Now().UTC().Add(10 * time.Second))
+
+	// Wait for connections to establish, both are registered for the same instance
+	assert.Equal(t, <-joined, <-joined)
+
+	// Act
+	err = ws.WriteMessage(websocket.TextMessage, []byte(`{"content": "Hello despite the database"}`))
+	assert.NoError(t, err)
+
+	// Assert
+	// Both connections of this instance receive the message although publishing to the database failed
+	for _, connection := range []*websocket.Conn{ws, ws2} {
+		_, receivedMessage, err := connection.ReadMessage()
+		assert.NoError(t, err)
+		var record models.MessageRecordDTO
+		err = json.Unmarshal(receivedMessage, &record)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello despite the database", record.Content)
+	}
+
+	// Each connection is unregistered once when it is closed, the server removes it before closing the connection
+	for _, connection := range []*websocket.Conn{ws2, ws} {
+		err = connection.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		assert.NoError(t, err)
+		_, _ = io.Copy(io.Discard, connection.UnderlyingConn()) // returns at the end of the connection
+	}
+
+	mockChatBrokerRepository.AssertNumberOfCalls(t, "IncrementChatConnections", 2)
+	mockChatBrokerRepository.AssertNumberOfCalls(t, "DecrementChatConnections", 2)
+	mockChatRepository.AssertExpectations(t)
+	mockMessageRepository.AssertExpectations(t)
+
+	// The message was published to the other instances first
+	close(publishedPayloads)
+	published := false
+	for payload := range publishedPayloads {
+		var brokerMessage services.ChatBrokerMessage
+		err = json.Unmarshal([]byte(payload), &brokerMessage)
+		assert.NoError(t, err)
+		if strings.Contains(brokerMessage.Payload, "Hello despite the database") {
+			assert.Equal(t, chatId, brokerMessage.ChatId)
+			published = true
+		}
+	}
+	assert.True(t, published)
+}
+
+// TestPostgresChatBrokerRefreshConnections tests if the postgres chat broker keeps the connections of its instance active
+// and deletes the connections of instances that stopped refreshing them
+func TestPostgresChatBrokerRefreshConnections(t *testing.T) {
+	// Arrange
+	mockChatBrokerRepository := new(repositories.MockChatBrokerRepository)
+	chatBroker := services.NewPostgresChatBroker(mockChatBrokerRepository, "")
+
+	chatId := uuid.New().String()
+	now := time.Now()
+
+	// Mock expectations
+	var joinedInstanceId string
+	mockChatBrokerRepository.On("IncrementChatConnections", mock.AnythingOfType("string"), chatId, "myUser", mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			joinedInstanceId = args.String(0)
+		}).Return(nil)
+	mockChatBrokerRepository.On("RefreshChatConnections", mock.AnythingOfType("string"), now).Return(gorm.ErrInvalidDB) // refreshing fails
+	mockChatBrokerRepository.On("DeleteStaleChatConnections", mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	err := chatBroker.Join(chatId, "myUser")
+	assert.NoError(t, err)
+	chatBroker.RefreshConnections(now)
+
+	// Assert
+	mockChatBrokerRepository.AssertExpectations(t)
+	mockChatBrokerRepository.AssertCalled(t, "RefreshChatConnections", joinedInstanceId, now) // connections of this instance are refreshed
+
+	// Connections that missed three refreshes of 30 seconds are stale, they are deleted even if refreshing this instance failed
+	staleBefore := mockChatBrokerRepository.Calls[2].Arguments.Get(0).(time.Time)
+	assert.Equal(t, now.Add(-90*time.Second), staleBefore)
+
+	// Online users of an empty list are returned without a query
+	onlineUsernames, err := chatBroker.OnlineUsers([]string{})
+	assert.NoError(t, err)
+	assert.Empty(t, onlineUsernames)
+	mockChatBrokerRepository.AssertNotCalled(t, "GetOnlineUsernames", mock.Anything, mock.Anything)
+}
+
+// TestHandleUserWebSocketLargeGroupChatCreated tests if a new member of a full group chat receives the chat on the user websocket,
+// although the chat record exceeds the payload limit of the postgres broker and publishing to the database fails
+func TestHandleUserWebSocketLargeGroupChatCreated(t *testing.T) {
+	// Arrange
+	mockChatRepository := new(repositories.MockChatRepository)
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockMessageRepository := new(repositories.MockMessageRepository)
+	mockChatBrokerRepository := new(repositories.MockChatBrokerRepository)
+
+	// Chat service and message controller share the broker like in the router, the broker is not started
+	chatBroker := services.NewPostgresChatBroker(mockChatBrokerRepository, "")
+	chatController := controllers.NewChatController(services.NewChatService(mockChatRepository, mockUserRepository, nil, nil, chatBroker, services.NewPresenceService(nil, nil, chatBroker)))
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, nil, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, chatBroker, newTestPresenceService())
+
+	// Group chat with the maximum of 50 members after adding the new user, all members have a long nickname and a picture
+	chat := newTestGroupChat()
+	chatId := chat.Id.String()
+	for i := len(chat.Users); i < 49; i++ {
+		username := "member" + strconv.Itoa(i)
+		chat.Users = append(chat.Users, models.User{Username: username})
+		chat.Members = append(chat.Members, models.ChatUser{ChatId: chat.Id, UserUsername: username, Role: services.ChatRoleMember})
+	}
+	updatedChat := chat
+	updatedChat.Users = append(append([]models.User{}, chat.Users...), models.User{Username: "newUser"})
+	updatedChat.Members = append(append([]models.ChatUser{}, chat.Members...), models.ChatUser{ChatId: chat.Id, UserUsername: "newUser", Role: services.ChatRoleMember})
+	for i := range updatedChat.Users {
+		updatedChat.Users[i].Nickname = strings.Repeat("N", 25)
+		updatedChat.Users[i].Image = models.Image{Id: uuid.New(), Format: "png", Width: 100, Height: 100, Tag: time.Now().UTC()}
+	}
+
+	adminToken, err := utils.GenerateAccessToken("adminUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newUserToken, err := utils.GenerateAccessToken("newUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	joined := make(chan struct{}, 2)
+	var publishedPayloads []string
+	mockChatRepository.On("GetChatsByUsername", "newUser").Return([]models.Chat{}, nil)
+	mockChatRepository.On("GetChatById", chatId).Return(chat, nil).Once() // adding checks the admin
+	mockChatRepository.On("GetChatById", chatId).Return(updatedChat, nil) // record after adding and record for the user websocket
+	mockUserRepository.On("FindUserByUsername", "newUser").Return(&models.User{Username: "newUser"}, nil)
+	mockChatRepository.On("AddChatMembers", mock.AnythingOfType("[]models.ChatUser")).Return(nil)
+	mockChatBrokerRepository.On("GetOnlineUsernames", mock.AnythingOfType("[]string"), mock.AnythingOfType("time.Time")).Return([]string{}, nil) // presence of the members in the response
+	mockChatBrokerRepository.On("IncrementChatConnections", mock.AnythingOfType("string"), chatId, "newUser", mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			joined <- struct{}{}
+		}).Return(nil)
+	mockChatBrokerRepository.On("DecrementChatConnections", mock.AnythingOfType("string"), chatId, "newUser").Return(nil).Maybe()
+	mockChatBrokerRepository.On("GetConnectedUsernames", chatId, mock.AnythingOfType("time.Time")).Return([]string{"newUser"}, nil)
+	mockChatBrokerRepository.On("Notify", "chat_messages", mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			publishedPayloads = append(publishedPayloads, args.String(1))
+		}).Return(gorm.ErrInvalidDB).Once() // database is not reachable when the chat event is published
+	mockChatBrokerRepository.On("Notify", "chat_messages", mock.AnythingOfType("string")).Return(gorm.ErrInvalidDB)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/chats/:chatId/members", middleware.AuthorizeUser, chatController.AddChatMembers)
+	router.GET("/chats/ws", messageController.HandleUserWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/chats/ws", http.Header{"Sec-WebSocket-Protocol": []string{newUserToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	// Wait for connection to establish
+	time.Sleep(500 * time.Millisecond)
+
+	// Act
+	req, _ := http.NewRequest("POST", server.URL+"/chats/"+chatId+"/members", strings.NewReader(`{"usernames": ["newUser"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// Assert
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The chat event only contains the chat id, so it fits into a notification
+	assert.Len(t, publishedPayloads, 1)
+	var brokerMessage services.ChatBrokerMessage
+	err = json.Unmarshal([]byte(publishedPayloads[0]), &brokerMessage)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ChatEnvelopeChatCreated, brokerMessage.Type)
+	assert.Equal(t, chatId, brokerMessage.ChatId)
+	assert.Equal(t, "newUser", brokerMessage.Username)
+	assert.Empty(t, brokerMessage.Payload)
+
+	// The user websocket of this instance receives the complete chat record and is subscribed afterwards
+	_, receivedMessage, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	assert.Greater(t, len(receivedMessage), 8000) // larger than the payload limit of postgres notifications
+	var envelope models.ChatEnvelopeDTO
+	err = json.Unmarshal(receivedMessage, &envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ChatEnvelopeChatCreated, envelope.Type)
+	var chatRecord models.ChatRecordDTO
+	err = json.Unmarshal(envelope.Payload, &chatRecord)
+	assert.NoError(t, err)
+	assert.Equal(t, chatId, chatRecord.ChatId)
+	assert.Len(t, chatRecord.Participants, 50)
+
+	envelope = readChatEnvelope(t, ws)
+	assert.Equal(t, services.ChatEnvelopeSubscribe, envelope.Type)
+	assert.Equal(t, chatId, envelope.ChatId)
+	<-joined
+
+	mockChatRepository.AssertExpectations(t)
+	mockUserRepository.AssertExpectations(t)
+}
+
+// TestHandleUserWebSocketContactsPresence tests if the presence of a participant that only shows it to contacts
+// is sent to the participants the user follows, but not to the other participants
+func TestHandleUserWebSocketContactsPresence(t *testing.T) {
+	// Arrange
+	mockChatRepository := new(repositories.MockChatRepository)
+	mockMessageRepository := new(repositories.MockMessageRepository)
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockSubscriptionRepository := new(repositories.MockSubscriptionRepository)
+	chatBroker := services.NewMemoryChatBroker()
+	presenceService := services.NewPresenceService(mockUserRepository, mockSubscriptionRepository, chatBroker)
+	messageService := services.NewMessageService(mockMessageRepository, mockChatRepository, nil, utils.NewValidator())
+	messageController := controllers.NewMessageController(messageService, chatBroker, presenceService)
+
+	hiddenUser := models.User{Username: "hiddenUser", PresenceVisibility: services.PresenceVisibilityContacts}
+	friendUser := models.User{Username: "friendUser", PresenceVisibility: services.PresenceVisibilityEveryone}
+	chat := models.Chat{
+		Id:      uuid.New(),
+		IsGroup: true,
+		Users:   []models.User{hiddenUser, friendUser, {Username: "strangerUser"}},
+	}
+	chatId := chat.Id.String()
+
+	friendToken, err := utils.GenerateAccessToken("friendUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	strangerToken, err := utils.GenerateAccessToken("strangerUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hiddenToken, err := utils.GenerateAccessToken("hiddenUser")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockChatRepository.On("GetChatById", chatId).Return(chat, nil)
+	mockChatRepository.On("GetChatsByUsername", mock.AnythingOfType("string")).Return([]models.Chat{chat}, nil)
+	mockUserRepository.On("UpdateLastSeen", mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+	mockUserRepository.On("FindUserByUsername", "hiddenUser").Return(&hiddenUser, nil)
+	mockUserRepository.On("FindUserByUsername", mock.AnythingOfType("string")).Return(&friendUser, nil)
+	mockUserRepository.On("FindUsersByUsernames", []string{"friendUser"}).Return([]models.User{friendUser}, nil)
+	mockUserRepository.On("FindUsersByUsernames", []string{"hiddenUser"}).Return([]models.User{hiddenUser}, nil)
+	mockSubscriptionRepository.On("GetFollowerUsernames", "friendUser", []string{"hiddenUser"}).Return([]string{"hiddenUser"}, nil) // hiddenUser follows friendUser
+	mockSubscriptionRepository.On("GetFollowerUsernames", "strangerUser", []string{"hiddenUser"}).Return([]string{}, nil)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/chat", messageController.HandleWebSocket)
+	router.GET("/chats/ws", messageController.HandleUserWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Friend and stranger open the user websocket
+	wsFriend, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/chats/ws", http.Header{"Sec-WebSocket-Protocol": []string{friendToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsFriend)
+	_ = wsFriend.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+	envelope := readChatEnvelope(t, wsFriend)
+	assert.Equal(t, services.ChatEnvelopeSubscribe, envelope.Type)
+
+	wsStranger, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/chats/ws", http.Header{"Sec-WebSocket-Protocol": []string{strangerToken}})
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(wsStranger)
+	_ = wsStranger.SetReadDeadline(time.Now().UTC().Add(10 * time.Second))
+	envelope = readChatEnvelope(t, wsStranger)
+	assert.Equal(t, services.ChatEnvelopeSubscribe, envelope.Type)
+	envelope = readChatEnvelope(t, wsFriend) // presence of the stranger is public
+	assert.Equal(t, services.ChatEnvelopePresence, envelope.Type)
+
+	// Act
+	wsHidden, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:]+"/chat?chatId="+chatId, http.Header{"Sec-WebSocket-Protocol": []string{hiddenToken}})
+	assert.NoError(t, err)
+
+	// Assert
+	// Friend is told that hiddenUser connected and disconnected
+	envelope = readChatEnvelope(t, wsFriend)
+	assert.Equal(t, services.ChatEnvelopePresence, envelope.Type)
+	assert.Equal(t, chatId, envelope.ChatId)
+	var presence models.ChatPresenceDTO
+	err = json.Unmarshal(envelope.Payload, &presence)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ChatPresenceDTO{Username: "hiddenUser", Online: true}, presence)
+
+	_ = wsHidden.Close()
+	envelope = readChatEnvelope(t, wsFriend)
+	assert.Equal(t, services.ChatEnvelopePresence, envelope.Type)
+	err = json.Unmarshal(envelope.Payload, &presence)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ChatPresenceDTO{Username: "hiddenUser", Online: false}, presence)
+
+	// Stranger does not receive the presence of hiddenUser
+	_ = wsStranger.SetReadDeadline(time.Now().UTC().Add(500 * time.Millisecond))
+	_, _, err = wsStranger.ReadMessage()
+	assert.Error(t, err)
+
+	mockSubscriptionRepository.AssertExpectations(t)
+}
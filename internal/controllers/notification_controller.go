@@ -1,27 +1,47 @@
 package controllers
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
 	"net/http"
+	"strconv"
 )
 
 type NotificationControllerInterface interface {
 	GetNotifications(c *gin.Context)
+	GetUnreadNotificationCount(c *gin.Context)
+	MarkNotificationAsRead(c *gin.Context)
+	MarkAllNotificationsAsRead(c *gin.Context)
 	DeleteNotificationById(c *gin.Context)
+	GetNotificationSettings(c *gin.Context)
+	UpdateNotificationSettings(c *gin.Context)
+	HandleWebSocket(c *gin.Context)
 }
 
 type NotificationController struct {
 	notificationService services.NotificationServiceInterface
+	upgrader            websocket.Upgrader
 }
 
 // NewNotificationController can be used as a constructor to create a NotificationController "object"
 func NewNotificationController(notificationService services.NotificationServiceInterface) *NotificationController {
-	return &NotificationController{notificationService: notificationService}
+	return &NotificationController{
+		notificationService: notificationService,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+	}
 }
 
-// GetNotifications is a controller function that gets all notifications for the curent user and can be called from router.go
+// GetNotifications is a controller function that gets the notifications for the current user using cursor pagination and can be called from router.go
 func (controller *NotificationController) GetNotifications(c *gin.Context) {
 	// Get username from request that was set in middleware
 	username, exists := c.Get("username")
@@ -32,8 +52,17 @@ func (controller *NotificationController) GetNotifications(c *gin.Context) {
 		return
 	}
 
+	// Read query parameters for pagination
+	lastNotificationId := c.DefaultQuery("notificationId", "")
+	limitQuery := c.DefaultQuery("limit", "10")
+
+	limit, err := strconv.Atoi(limitQuery)
+	if err != nil {
+		limit = 10
+	}
+
 	// Get notifications
-	notifications, serviceErr, httpStatus := controller.notificationService.GetNotifications(username.(string))
+	notifications, serviceErr, httpStatus := controller.notificationService.GetNotifications(username.(string), lastNotificationId, limit)
 	if serviceErr != nil {
 		c.JSON(httpStatus, gin.H{
 			"error": serviceErr,
@@ -45,6 +74,75 @@ func (controller *NotificationController) GetNotifications(c *gin.Context) {
 	return
 }
 
+// GetUnreadNotificationCount is a controller function that gets the number of unread notifications for the current user and can be called from router.go
+func (controller *NotificationController) GetUnreadNotificationCount(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	unreadCount, serviceErr, httpStatus := controller.notificationService.GetUnreadNotificationCount(username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, unreadCount)
+}
+
+// MarkNotificationAsRead is a controller function that marks a notification as read by its id and can be called from router.go
+func (controller *NotificationController) MarkNotificationAsRead(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Get notificationId from request
+	notificationId := c.Param("notificationId")
+
+	serviceErr, httpStatus := controller.notificationService.MarkNotificationAsRead(notificationId, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, gin.H{})
+}
+
+// MarkAllNotificationsAsRead is a controller function that marks all notifications of the current user as read and can be called from router.go
+func (controller *NotificationController) MarkAllNotificationsAsRead(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	serviceErr, httpStatus := controller.notificationService.MarkAllNotificationsAsRead(username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, gin.H{})
+}
+
 // DeleteNotificationById is a controller function that deletes a notification by its id and can be called from router.go
 func (controller *NotificationController) DeleteNotificationById(c *gin.Context) {
 	// Get username from request that was set in middleware
@@ -71,3 +169,106 @@ func (controller *NotificationController) DeleteNotificationById(c *gin.Context)
 	c.JSON(httpStatus, gin.H{})
 	return
 }
+
+// GetNotificationSettings is a controller function that gets the notification settings and muted users of the current user and can be called from router.go
+func (controller *NotificationController) GetNotificationSettings(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	settings, serviceErr, httpStatus := controller.notificationService.GetNotificationSettings(username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, settings)
+}
+
+// UpdateNotificationSettings is a controller function that replaces the notification settings and muted users of the current user and can be called from router.go
+func (controller *NotificationController) UpdateNotificationSettings(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Read body
+	var settingsUpdateRequestDto models.NotificationSettingsUpdateRequestDTO
+	if c.ShouldBindJSON(&settingsUpdateRequestDto) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	settings, serviceErr, httpStatus := controller.notificationService.UpdateNotificationSettings(&settingsUpdateRequestDto, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, settings)
+}
+
+// HandleWebSocket handles a WebSocket connection that streams every new notification of the logged-in user as soon as it is stored
+func (controller *NotificationController) HandleWebSocket(c *gin.Context) {
+	// Create WebSocket connection
+	// Header needs to be the same as the request header
+	conn, err := controller.upgrader.Upgrade(c.Writer, c.Request, http.Header{"Sec-WebSocket-Protocol": []string{c.GetHeader("Sec-WebSocket-Protocol")}})
+	if err != nil {
+		return // return if connection could not be established
+	}
+	defer closeWebsocket(conn) // close connection when function terminates
+
+	// Using Sec-WebSocket-Protocol header for JWT authentication because browsers do not allow custom headers
+	// So middleware was not called and the JWT token needs to be verified here
+	jwtToken := c.GetHeader("Sec-WebSocket-Protocol") // agreed on no Bearer prefix
+	currentUsername, isRefreshToken, err := utils.VerifyJWTToken(jwtToken)
+	if isRefreshToken || err != nil { // if token is a refresh token or invalid, return Unauthorized error
+		sendError(conn, customerrors.Unauthorized)
+		return // return and close connection
+	}
+
+	// Subscribe to new notifications of the user
+	notifications, unsubscribe := controller.notificationService.SubscribeToNotifications(currentUsername)
+	defer unsubscribe() // remove subscription when function terminates
+
+	fmt.Println("New notification stream for", currentUsername)
+
+	// Clients do not send anything, but reading is needed to notice when the connection is closed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return // read errors are permanent (closed or broken connection), so stop listening
+			}
+		}
+	}()
+
+	for {
+		select {
+		case notification := <-notifications:
+			notificationBytes, _ := json.Marshal(notification)
+			if err := conn.WriteMessage(websocket.TextMessage, notificationBytes); err != nil {
+				return // return and close connection if sending failed
+			}
+		case <-closed:
+			fmt.Println("Closed notification stream for", currentUsername)
+			return
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/wwi21seb-projekt/server-beta/internal/controllers"
 	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
 	"github.com/wwi21seb-projekt/server-beta/internal/middleware"
@@ -16,16 +18,18 @@ import (
 	"gorm.io/gorm"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
 
-// TestGetNotificationsSuccess tests if the GetNotifications returns a list of notifications
+// TestGetNotificationsSuccess tests if the GetNotifications returns a page of notifications with read state and pagination
 func TestGetNotificationsSuccess(t *testing.T) {
 	// Arrange
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
 
-	notificationService := services.NewNotificationService(mockNotificationRepo, nil)
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
 	notificationController := controllers.NewNotificationController(notificationService)
 
 	currentUsername := "testUser"
@@ -50,21 +54,36 @@ func TestGetNotificationsSuccess(t *testing.T) {
 			ForUsername:      currentUsername,
 			NotificationType: "follow",
 			Timestamp:        time.Now().UTC(),
+			Read:             false,
 		},
 		{
 			Id:               uuid.New(),
 			FromUser:         otherUser,
 			ForUsername:      currentUsername,
 			NotificationType: "repost",
-			Timestamp:        time.Now().UTC(),
+			Timestamp:        time.Now().UTC().Add(-time.Minute),
+			Read:             true,
 		},
 	}
+	lastNotification := models.Notification{
+		Id:          uuid.New(),
+		ForUsername: currentUsername,
+		Timestamp:   time.Now().UTC().Add(time.Minute),
+	}
+	limit := 2
+	totalCount := int64(5)
 
 	// Mock expectations
-	mockNotificationRepo.On("GetNotificationsByUsername", currentUsername).Return(foundNotifications, nil)
+	var capturedLastNotification *models.Notification
+	mockNotificationRepo.On("GetNotificationById", lastNotification.Id.String()).Return(lastNotification, nil)
+	mockNotificationRepo.On("GetNotificationsByUsername", currentUsername, &lastNotification, limit).
+		Run(func(args mock.Arguments) {
+			capturedLastNotification = args.Get(1).(*models.Notification)
+		}).Return(foundNotifications, totalCount, nil)
 
 	// Setup HTTP request and recorder
-	req, _ := http.NewRequest(http.MethodGet, "/notifications", nil)
+	url := fmt.Sprintf("/notifications?notificationId=%s&limit=%d", lastNotification.Id.String(), limit)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
 	w := httptest.NewRecorder()
@@ -92,15 +111,74 @@ func TestGetNotificationsSuccess(t *testing.T) {
 		assert.Equal(t, notification.FromUser.Username, responseDto.Records[i].User.Username)
 		assert.Equal(t, notification.FromUser.Nickname, responseDto.Records[i].User.Nickname)
 		assert.True(t, notification.Timestamp.Equal(responseDto.Records[i].Timestamp))
+		assert.Equal(t, notification.Read, responseDto.Records[i].Read)
 	}
+
+	assert.Equal(t, lastNotification.Id, capturedLastNotification.Id)
+	assert.Equal(t, foundNotifications[1].Id.String(), responseDto.Pagination.LastNotificationId)
+	assert.Equal(t, limit, responseDto.Pagination.Limit)
+	assert.Equal(t, totalCount, responseDto.Pagination.Records)
+}
+
+// TestGetNotificationsForeignCursor tests if the GetNotifications returns 400 Bad Request if the cursor is a notification of another user
+func TestGetNotificationsForeignCursor(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Error(err)
+	}
+
+	foreignNotification := models.Notification{
+		Id:          uuid.New(),
+		ForUsername: "otherUser",
+		Timestamp:   time.Now().UTC(),
+	}
+
+	// Mock expectations
+	mockNotificationRepo.On("GetNotificationById", foreignNotification.Id.String()).Return(foreignNotification, nil)
+
+	// Setup HTTP request and recorder
+	url := fmt.Sprintf("/notifications?notificationId=%s&limit=%d", foreignNotification.Id.String(), 10)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/notifications", middleware.AuthorizeUser, notificationController.GetNotifications)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code) // Expect HTTP 400 Bad Request
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.BadRequest
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockNotificationRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertNotCalled(t, "GetNotificationsByUsername", mock.Anything, mock.Anything, mock.Anything)
 }
 
 // TestGetNotificationsUnauthorized tests if the GetNotifications returns an unauthorized error
 func TestGetNotificationsUnauthorized(t *testing.T) {
 	// Arrange
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
 
-	notificationService := services.NewNotificationService(mockNotificationRepo, nil)
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
 	notificationController := controllers.NewNotificationController(notificationService)
 
 	// Setup HTTP request and recorder
@@ -131,8 +209,9 @@ func TestGetNotificationsUnauthorized(t *testing.T) {
 func TestDeleteNotificationByIdSuccess(t *testing.T) {
 	// Arrange
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
 
-	notificationService := services.NewNotificationService(mockNotificationRepo, nil)
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
 	notificationController := controllers.NewNotificationController(notificationService)
 
 	currentUsername := "testUser"
@@ -175,8 +254,9 @@ func TestDeleteNotificationByIdSuccess(t *testing.T) {
 func TestDeleteNotificationByIdUnauthorized(t *testing.T) {
 	// Arrange
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
 
-	notificationService := services.NewNotificationService(mockNotificationRepo, nil)
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
 	notificationController := controllers.NewNotificationController(notificationService)
 
 	notificationId := uuid.New()
@@ -208,8 +288,9 @@ func TestDeleteNotificationByIdUnauthorized(t *testing.T) {
 func TestDeleteNotificationByIdNotFound(t *testing.T) {
 	// Arrange
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
 
-	notificationService := services.NewNotificationService(mockNotificationRepo, nil)
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
 	notificationController := controllers.NewNotificationController(notificationService)
 
 	currentUsername := "testUser"
@@ -252,8 +333,9 @@ func TestDeleteNotificationByIdNotFound(t *testing.T) {
 func TestDeleteNotificationByIdForbidden(t *testing.T) {
 	// Arrange
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
 
-	notificationService := services.NewNotificationService(mockNotificationRepo, nil)
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
 	notificationController := controllers.NewNotificationController(notificationService)
 
 	currentUsername := "testUser"
@@ -296,3 +378,634 @@ func TestDeleteNotificationByIdForbidden(t *testing.T) {
 	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
 	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
 }
+
+// TestGetUnreadNotificationCountSuccess tests if the GetUnreadNotificationCount returns the number of unread notifications
+func TestGetUnreadNotificationCountSuccess(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Mock expectations
+	mockNotificationRepo.On("CountUnreadNotifications", currentUsername).Return(int64(3), nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodGet, "/notifications/unread-count", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/notifications/unread-count", middleware.AuthorizeUser, notificationController.GetUnreadNotificationCount)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+	mockNotificationRepo.AssertExpectations(t)
+
+	var responseDto models.NotificationUnreadCountResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseDto)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), responseDto.UnreadCount)
+}
+
+// TestMarkNotificationAsReadSuccess tests if the MarkNotificationAsRead marks a notification of the current user as read
+func TestMarkNotificationAsReadSuccess(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Error(err)
+	}
+
+	notification := models.Notification{
+		Id:               uuid.New(),
+		ForUsername:      currentUsername,
+		NotificationType: "follow",
+	}
+
+	// Mock expectations
+	mockNotificationRepo.On("GetNotificationById", notification.Id.String()).Return(notification, nil)
+	mockNotificationRepo.On("MarkNotificationAsRead", notification.Id.String()).Return(nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/notifications/%s/read", notification.Id.String()), nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/notifications/:notificationId/read", middleware.AuthorizeUser, notificationController.MarkNotificationAsRead)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect HTTP 204 No Content
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+// TestMarkNotificationAsReadForbidden tests if the MarkNotificationAsRead returns a forbidden error if the notification does not belong to the user
+func TestMarkNotificationAsReadForbidden(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	authenticationToken, err := utils.GenerateAccessToken("testUser")
+	if err != nil {
+		t.Error(err)
+	}
+
+	notification := models.Notification{
+		Id:               uuid.New(),
+		ForUsername:      "otherUser",
+		NotificationType: "follow",
+	}
+
+	// Mock expectations
+	mockNotificationRepo.On("GetNotificationById", notification.Id.String()).Return(notification, nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/notifications/%s/read", notification.Id.String()), nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/notifications/:notificationId/read", middleware.AuthorizeUser, notificationController.MarkNotificationAsRead)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code) // Expect HTTP 403 Forbidden
+	mockNotificationRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertNotCalled(t, "MarkNotificationAsRead", mock.Anything)
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.UpdateNotificationForbidden
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+}
+
+// TestMarkAllNotificationsAsReadSuccess tests if the MarkAllNotificationsAsRead marks all notifications of the current user as read
+func TestMarkAllNotificationsAsReadSuccess(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Mock expectations
+	mockNotificationRepo.On("MarkAllNotificationsAsRead", currentUsername).Return(nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPost, "/notifications/read", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/notifications/read", middleware.AuthorizeUser, notificationController.MarkAllNotificationsAsRead)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect HTTP 204 No Content
+	mockNotificationRepo.AssertExpectations(t)
+}
+
+// TestGetNotificationSettingsSuccess tests if the GetNotificationSettings returns all notification types with defaults and the muted users
+func TestGetNotificationSettingsSuccess(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Error(err)
+	}
+
+	settings := []models.NotificationSetting{
+		{
+			Id:               uuid.New(),
+			Username:         currentUsername,
+			NotificationType: "like",
+			InApp:            true,
+			Push:             false,
+		},
+	}
+	mutes := []models.NotificationMute{
+		{
+			Id:            uuid.New(),
+			Username:      currentUsername,
+			MutedUsername: "mutedUser",
+			MutedUser: models.User{
+				Username: "mutedUser",
+				Nickname: "muted",
+			},
+			CreatedAt: time.Now(),
+		},
+	}
+
+	// Mock expectations
+	mockNotificationSettingRepo.On("GetNotificationSettingsByUsername", currentUsername).Return(settings, nil)
+	mockNotificationSettingRepo.On("GetNotificationMutesByUsername", currentUsername).Return(mutes, nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodGet, "/users/me/notification-settings", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/users/me/notification-settings", middleware.AuthorizeUser, notificationController.GetNotificationSettings)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+	mockNotificationSettingRepo.AssertExpectations(t)
+
+	var responseDto models.NotificationSettingsResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseDto)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, 6, len(responseDto.Settings))
+	for _, setting := range responseDto.Settings {
+		assert.True(t, setting.InApp)
+		assert.Equal(t, setting.NotificationType != "like", setting.Push) // only push of likes is turned off
+	}
+	assert.Equal(t, 1, len(responseDto.MutedUsers))
+	assert.Equal(t, "mutedUser", responseDto.MutedUsers[0].Username)
+	assert.Equal(t, "muted", responseDto.MutedUsers[0].Nickname)
+}
+
+// TestUpdateNotificationSettingsSuccess tests if the UpdateNotificationSettings replaces the settings and muted users of the current user
+func TestUpdateNotificationSettingsSuccess(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	mockUserRepo := new(repositories.MockUserRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, mockUserRepo)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mutedUser := models.User{
+		Username: "mutedUser",
+		Nickname: "muted",
+	}
+	requestBody := `{"settings": [{"notificationType": "follow", "inApp": false, "push": false}], "mutedUsernames": ["mutedUser"]}`
+
+	// Mock expectations
+	var capturedSettings []models.NotificationSetting
+	var capturedMutes []models.NotificationMute
+	mockUserRepo.On("FindUsersByUsernames", []string{mutedUser.Username}).Return([]models.User{mutedUser}, nil)
+	mockNotificationSettingRepo.On("ReplaceNotificationSettings", currentUsername, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			capturedSettings = args.Get(1).([]models.NotificationSetting)
+			capturedMutes = args.Get(2).([]models.NotificationMute)
+		}).Return(nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPut, "/users/me/notification-settings", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PUT("/users/me/notification-settings", middleware.AuthorizeUser, notificationController.UpdateNotificationSettings)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+	mockNotificationSettingRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+
+	assert.Equal(t, 1, len(capturedSettings))
+	assert.Equal(t, currentUsername, capturedSettings[0].Username)
+	assert.Equal(t, "follow", capturedSettings[0].NotificationType)
+	assert.False(t, capturedSettings[0].InApp)
+	assert.False(t, capturedSettings[0].Push)
+	assert.Equal(t, 1, len(capturedMutes))
+	assert.Equal(t, currentUsername, capturedMutes[0].Username)
+	assert.Equal(t, mutedUser.Username, capturedMutes[0].MutedUsername)
+
+	var responseDto models.NotificationSettingsResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseDto)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, 6, len(responseDto.Settings))
+	assert.Equal(t, 1, len(responseDto.MutedUsers))
+	assert.Equal(t, mutedUser.Username, responseDto.MutedUsers[0].Username)
+}
+
+// TestUpdateNotificationSettingsDuplicateMutedUsernames tests if the UpdateNotificationSettings mutes a user only once if the username is given more than once
+func TestUpdateNotificationSettingsDuplicateMutedUsernames(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	mockUserRepo := new(repositories.MockUserRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, mockUserRepo)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Error(err)
+	}
+
+	mutedUser := models.User{
+		Username: "mutedUser",
+		Nickname: "muted",
+	}
+	requestBody := `{"settings": [], "mutedUsernames": ["mutedUser", "mutedUser"]}`
+
+	// Mock expectations
+	var capturedMutes []models.NotificationMute
+	mockUserRepo.On("FindUsersByUsernames", []string{mutedUser.Username}).Return([]models.User{mutedUser}, nil) // duplicate is removed before loading users
+	mockNotificationSettingRepo.On("ReplaceNotificationSettings", currentUsername, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			capturedMutes = args.Get(2).([]models.NotificationMute)
+		}).Return(nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPut, "/users/me/notification-settings", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PUT("/users/me/notification-settings", middleware.AuthorizeUser, notificationController.UpdateNotificationSettings)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+	mockNotificationSettingRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+
+	assert.Equal(t, 1, len(capturedMutes))
+	assert.Equal(t, mutedUser.Username, capturedMutes[0].MutedUsername)
+}
+
+// TestUpdateNotificationSettingsBadRequest tests if the UpdateNotificationSettings returns a bad request error for invalid settings
+func TestUpdateNotificationSettingsBadRequest(t *testing.T) {
+	invalidBodies := []string{
+		`{"settings": [{"notificationType": "unknown", "inApp": true, "push": true}]}`,
+		`{"settings": [{"notificationType": "like", "inApp": true, "push": true}, {"notificationType": "like", "inApp": false, "push": false}]}`,
+		`{"settings": [], "mutedUsernames": ["testUser"]}`,
+		`{"mutedUsernames": []}`,
+	}
+
+	for _, body := range invalidBodies {
+		// Arrange
+		mockNotificationRepo := new(repositories.MockNotificationRepository)
+		mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+		mockUserRepo := new(repositories.MockUserRepository)
+
+		notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, mockUserRepo)
+		notificationController := controllers.NewNotificationController(notificationService)
+
+		currentUsername := "testUser"
+		authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+		if err != nil {
+			t.Error(err)
+		}
+
+		// Setup HTTP request and recorder
+		req, _ := http.NewRequest(http.MethodPut, "/users/me/notification-settings", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.PUT("/users/me/notification-settings", middleware.AuthorizeUser, notificationController.UpdateNotificationSettings)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code) // Expect HTTP 400 Bad Request
+		mockNotificationSettingRepo.AssertNotCalled(t, "ReplaceNotificationSettings", mock.Anything, mock.Anything, mock.Anything)
+
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+
+		expectedCustomError := customerrors.BadRequest
+		assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+		assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+	}
+}
+
+// TestUpdateNotificationSettingsUserNotFound tests if the UpdateNotificationSettings returns a not found error if a muted user does not exist
+func TestUpdateNotificationSettingsUserNotFound(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	mockUserRepo := new(repositories.MockUserRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, mockUserRepo)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Error(err)
+	}
+
+	requestBody := `{"settings": [], "mutedUsernames": ["unknownUser"]}`
+
+	// Mock expectations
+	mockUserRepo.On("FindUsersByUsernames", []string{"unknownUser"}).Return([]models.User{}, nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPut, "/users/me/notification-settings", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PUT("/users/me/notification-settings", middleware.AuthorizeUser, notificationController.UpdateNotificationSettings)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code) // Expect HTTP 404 Not Found
+	mockUserRepo.AssertExpectations(t)
+	mockNotificationSettingRepo.AssertNotCalled(t, "ReplaceNotificationSettings", mock.Anything, mock.Anything, mock.Anything)
+
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.UserNotFound
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+}
+
+// TestCreateNotificationMutedUser tests if no notification is stored or pushed if the receiving user muted the other user
+func TestCreateNotificationMutedUser(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+
+	forUsername := "testUser"
+	fromUsername := "mutedUser"
+
+	// Mock expectations
+	mockNotificationSettingRepo.On("FindNotificationMute", forUsername, fromUsername).Return(&models.NotificationMute{}, nil)
+
+	// Act
+	err := notificationService.CreateNotification("follow", forUsername, fromUsername, nil, nil)
+
+	// Assert
+	assert.NoError(t, err)
+	mockNotificationSettingRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertNotCalled(t, "CreateNotification", mock.Anything)
+	mockPushSubscriptionRepo.AssertNotCalled(t, "GetPushSubscriptionsByUsername", mock.Anything)
+}
+
+// TestCreateNotificationTypeTurnedOff tests if no notification is stored or pushed if the notification type is turned off
+func TestCreateNotificationTypeTurnedOff(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	mockUserRepo := new(repositories.MockUserRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, mockUserRepo)
+
+	forUsername := "testUser"
+	fromUsername := "otherUser"
+	setting := models.NotificationSetting{
+		Id:               uuid.New(),
+		Username:         forUsername,
+		NotificationType: "follow",
+		InApp:            false,
+		Push:             false,
+	}
+
+	// Mock expectations
+	mockNotificationSettingRepo.On("FindNotificationMute", forUsername, fromUsername).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)
+	mockNotificationSettingRepo.On("FindNotificationSetting", forUsername, "follow").Return(&setting, nil)
+
+	// Act
+	err := notificationService.CreateNotification("follow", forUsername, fromUsername, nil, nil)
+
+	// Assert
+	assert.NoError(t, err)
+	mockNotificationSettingRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertNotCalled(t, "CreateNotification", mock.Anything)
+	mockUserRepo.AssertNotCalled(t, "FindUserByUsername", mock.Anything)
+}
+
+// TestHandleNotificationWebSocketSuccess tests if a stored notification is sent to the open notification stream of the user
+func TestHandleNotificationWebSocketSuccess(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	currentUsername := "testUser"
+	authenticationToken, err := utils.GenerateAccessToken(currentUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherUser := models.User{
+		Username: "otherUser",
+		Nickname: "other",
+	}
+
+	storedNotification := models.Notification{
+		Id:               uuid.New(),
+		Timestamp:        time.Now().UTC(),
+		NotificationType: "follow",
+		ForUsername:      currentUsername,
+		FromUsername:     otherUser.Username,
+		FromUser:         otherUser,
+		Count:            1,
+	}
+
+	// Mock expectations
+	mockNotificationSettingRepo.On("FindNotificationMute", currentUsername, otherUser.Username).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound) // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", currentUsername, "follow").Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound)     // default settings are used
+	mockNotificationRepo.On("CreateNotification", mock.AnythingOfType("*models.Notification")).Return(nil)
+	mockNotificationRepo.On("GetNotificationById", mock.AnythingOfType("string")).Return(storedNotification, nil)
+	mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", currentUsername).Return([]models.PushSubscription{}, nil)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/notifications/ws", notificationController.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Create WebSocket connection
+	url := "ws" + server.URL[4:] + "/notifications/ws"
+	headers := http.Header{"Sec-WebSocket-Protocol": []string{authenticationToken}}
+	ws, _, err := websocket.DefaultDialer.Dial(url, headers)
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+	_ = ws.SetReadDeadline(time.Now().UTC().Add(10 * time.Second)) // set read deadline to avoid blocking
+
+	// Wait for connection to establish
+	time.Sleep(1 * time.Second)
+
+	// Act
+	err = notificationService.CreateNotification("follow", currentUsername, otherUser.Username, nil, nil)
+	assert.NoError(t, err)
+
+	// Assert
+	_, receivedMessage, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	var response models.NotificationRecordDTO
+	err = json.Unmarshal(receivedMessage, &response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, storedNotification.Id.String(), response.NotificationId)
+	assert.Equal(t, "follow", response.NotificationType)
+	assert.Equal(t, otherUser.Username, response.User.Username)
+	assert.Equal(t, otherUser.Nickname, response.User.Nickname)
+	assert.False(t, response.Read)
+
+	mockNotificationRepo.AssertExpectations(t)
+	mockNotificationSettingRepo.AssertExpectations(t)
+}
+
+// TestHandleNotificationWebSocketUnauthorized tests if the notification stream returns Unauthorized custom error when the user is not authenticated
+func TestHandleNotificationWebSocketUnauthorized(t *testing.T) {
+	// Arrange
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+
+	notificationService := services.NewNotificationService(mockNotificationRepo, nil, mockNotificationSettingRepo, nil)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	// Create test server
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/notifications/ws", notificationController.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Create WebSocket connection
+	url := "ws" + server.URL[4:] + "/notifications/ws"
+	headers := http.Header{"Sec-WebSocket-Protocol": []string{"invalidToken"}}
+	ws, _, err := websocket.DefaultDialer.Dial(url, headers)
+	assert.NoError(t, err)
+	defer func(ws *websocket.Conn) {
+		_ = ws.Close()
+	}(ws)
+
+	// Read message
+	_, receivedMessage, err := ws.ReadMessage()
+	assert.NoError(t, err)
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(receivedMessage, &errorResponse)
+	assert.NoError(t, err)
+
+	// Assert
+	expectedCustomError := customerrors.Unauthorized
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+}
@@ -10,6 +10,7 @@ import (
 
 type PostControllerInterface interface {
 	CreatePost(c *gin.Context)
+	UpdatePost(c *gin.Context)
 	DeletePost(c *gin.Context)
 }
 
@@ -55,6 +56,37 @@ func (controller *PostController) CreatePost(c *gin.Context) {
 
 }
 
+// UpdatePost is a controller function that changes content and location of a post and can be called from router.go
+func (controller *PostController) UpdatePost(c *gin.Context) {
+	postId := c.Param("postId")
+
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	var postUpdateRequestDTO models.PostUpdateRequestDTO
+	if c.ShouldBindJSON(&postUpdateRequestDTO) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	postDto, serviceErr, httpStatus := controller.postService.UpdatePost(&postUpdateRequestDTO, postId, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, postDto)
+}
+
 func (controller *PostController) DeletePost(c *gin.Context) {
 	postId := c.Param("postId")
 
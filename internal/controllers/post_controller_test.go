@@ -128,6 +128,104 @@ func TestCreatePostSuccess(t *testing.T) {
 	mockHashtagRepository.AssertExpectations(t)
 }
 
+// TestCreatePostWithMentionsSuccess tests if the CreatePost function stores mentions of existing users, notifies them and returns them in the postDto
+func TestCreatePostWithMentionsSuccess(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockPostRepository := new(repositories.MockPostRepository)
+	mockNotificationRepository := new(repositories.MockNotificationRepository)
+	mockPushSubscriptionRepository := new(repositories.MockPushSubscriptionRepository)
+	validator := new(utils.Validator)
+
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepository, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepository, pushSubscriptionService, mockNotificationSettingRepo, nil)
+	postService := services.NewPostService(
+		mockPostRepository,
+		mockUserRepository,
+		nil,
+		validator,
+		nil,
+		nil,
+		notificationService,
+	)
+	postController := controllers.NewPostController(postService)
+
+	user := models.User{
+		Username: "testUser",
+		Nickname: "testNickname",
+	}
+	mentionedUser := models.User{
+		Username: "mentionedUser",
+		Nickname: "mentionedNickname",
+	}
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postCreateRequestDTO := models.PostCreateRequestDTO{
+		Content: "Hello @mentionedUser and @unknownUser, write to mail@example.com",
+	}
+
+	// Mock expectations
+	var capturedPost *models.Post
+	var capturedNotification *models.Notification
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+	mockUserRepository.On("FindUsersByUsernames", []string{"mentionedUser", "unknownUser"}).Return([]models.User{mentionedUser}, nil) // unknown user does not exist
+	mockPostRepository.On("CreatePost", mock.AnythingOfType("*models.Post")).
+		Run(func(args mock.Arguments) {
+			capturedPost = args.Get(0).(*models.Post)
+		}).Return(nil)
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
+	mockNotificationRepository.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
+		Run(func(args mock.Arguments) {
+			capturedNotification = args.Get(0).(*models.Notification)
+		}).Return(nil)
+	mockNotificationRepository.On("GetNotificationById", mock.AnythingOfType("string")).Return(models.Notification{}, nil)
+	mockPushSubscriptionRepository.On("GetPushSubscriptionsByUsername", mentionedUser.Username).Return([]models.PushSubscription{}, nil)
+
+	// Setup HTTP request
+	requestBody, err := json.Marshal(postCreateRequestDTO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", "/posts", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/posts", middleware.AuthorizeUser, postController.CreatePost)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code) // Expect 201 created
+	var responsePost models.PostResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responsePost)
+	assert.NoError(t, err)
+
+	assert.Len(t, capturedPost.Mentions, 1)
+	assert.Equal(t, mentionedUser.Username, capturedPost.Mentions[0].Username)
+
+	assert.Len(t, responsePost.Mentions, 1)
+	assert.Equal(t, mentionedUser.Username, responsePost.Mentions[0].Username)
+	assert.Equal(t, mentionedUser.Nickname, responsePost.Mentions[0].Nickname)
+
+	assert.NotNil(t, capturedNotification)
+	assert.Equal(t, "mention", capturedNotification.NotificationType)
+	assert.Equal(t, mentionedUser.Username, capturedNotification.ForUsername)
+	assert.Equal(t, user.Username, capturedNotification.FromUsername)
+	assert.Equal(t, capturedPost.Id, *capturedNotification.PostId)
+
+	mockUserRepository.AssertExpectations(t)
+	mockPostRepository.AssertExpectations(t)
+	mockNotificationRepository.AssertExpectations(t)
+}
+
 // TestCreatePostWithLocationSuccess tests if the CreatePost function returns a postDto and 201 created if post is created successfully with location
 func TestCreatePostWithLocationSuccess(t *testing.T) {
 	// Arrange
@@ -489,8 +587,9 @@ func TestCreatePostWithRepostSuccess(t *testing.T) {
 
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
-	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
 
 	postService := services.NewPostService(
 		mockPostRepository,
@@ -547,6 +646,8 @@ func TestCreatePostWithRepostSuccess(t *testing.T) {
 	mockLikeRepository.On("CountLikes", originalPost.Id.String()).Return(totalLikesCount, nil)
 	mockLikeRepository.On("FindLike", originalPost.Id.String(), user.Username).Return(&models.Like{}, gorm.ErrRecordNotFound)
 	mockCommentRepository.On("CountComments", originalPost.Id.String()).Return(totalCommentsCount, nil)
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
 	mockNotificationRepo.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
 		Run(func(args mock.Arguments) {
 			capturedNotification = args.Get(0).(*models.Notification) // Save argument to captor
@@ -606,6 +707,7 @@ func TestCreatePostWithRepostSuccess(t *testing.T) {
 	assert.Equal(t, user.Username, capturedNotification.FromUsername)
 	assert.Equal(t, originalPost.Username, capturedNotification.ForUsername)
 	assert.Equal(t, "repost", capturedNotification.NotificationType)
+	assert.Equal(t, originalPost.Id, *capturedNotification.PostId) // notification refers to the original post
 	assert.NotNil(t, capturedNotification.Timestamp)
 
 	mockUserRepository.AssertExpectations(t)
@@ -1034,3 +1136,321 @@ func TestDeletePostNotFound(t *testing.T) {
 
 	mockPostRepository.AssertExpectations(t)
 }
+
+// TestUpdatePostSuccess tests if the UpdatePost function returns the updated post and saves the previous version as revision
+func TestUpdatePostSuccess(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+	mockHashtagRepository := new(repositories.MockHashtagRepository)
+	mockLikeRepository := new(repositories.MockLikeRepository)
+	mockCommentRepository := new(repositories.MockCommentRepository)
+	validator := new(utils.Validator)
+
+	postService := services.NewPostService(
+		mockPostRepository,
+		nil,
+		mockHashtagRepository,
+		validator,
+		mockLikeRepository,
+		mockCommentRepository,
+		nil,
+	)
+	postController := controllers.NewPostController(postService)
+
+	user := models.User{
+		Username: "testUser",
+		Nickname: "testNickname",
+	}
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldLocationId := uuid.New()
+	post := models.Post{
+		Id:         uuid.New(),
+		Username:   user.Username,
+		User:       user,
+		Content:    "This is a tset #typo",
+		CreatedAt:  time.Now().Add(-time.Hour),
+		LocationId: &oldLocationId,
+		Location:   models.Location{Id: oldLocationId, Longitude: 1, Latitude: 2, Accuracy: 3},
+	}
+
+	content := "  This is a test #fixed <script>alert('xss')</script>"
+	longitude := 11.1
+	latitude := 22.2
+	accuracy := uint(33)
+	postUpdateRequestDTO := models.PostUpdateRequestDTO{
+		Content: &content,
+		Location: &models.LocationDTO{
+			Longitude: &longitude,
+			Latitude:  &latitude,
+			Accuracy:  &accuracy,
+		},
+	}
+
+	expectedHashtag := models.Hashtag{
+		Id:   uuid.New(),
+		Name: "fixed",
+	}
+
+	// Mock expectations
+	var capturedPost *models.Post
+	var capturedRevision *models.PostRevision
+	mockPostRepository.On("GetPostById", post.Id.String()).Return(post, nil)
+	mockHashtagRepository.On("FindOrCreateHashtag", expectedHashtag.Name).Return(expectedHashtag, nil)
+	mockPostRepository.On("UpdatePost", mock.AnythingOfType("*models.Post"), mock.AnythingOfType("*models.PostRevision")).
+		Run(func(args mock.Arguments) {
+			capturedPost = args.Get(0).(*models.Post)
+			capturedRevision = args.Get(1).(*models.PostRevision)
+		}).Return(nil)
+	mockLikeRepository.On("FindLike", post.Id.String(), user.Username).Return(&models.Like{}, gorm.ErrRecordNotFound)
+	mockLikeRepository.On("CountLikes", post.Id.String()).Return(int64(5), nil)
+	mockCommentRepository.On("CountComments", post.Id.String()).Return(int64(2), nil)
+
+	// Setup HTTP request
+	requestBody, err := json.Marshal(postUpdateRequestDTO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("PATCH", "/posts/"+post.Id.String(), bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PATCH("/posts/:postId", middleware.AuthorizeUser, postController.UpdatePost)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var responsePost models.PostResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responsePost)
+	assert.NoError(t, err)
+
+	expectedContent := "This is a test #fixed "
+	assert.Equal(t, expectedContent, capturedPost.Content)
+	assert.Len(t, capturedPost.Hashtags, 1)
+	assert.Equal(t, expectedHashtag.Id, capturedPost.Hashtags[0].Id)
+	assert.NotEqual(t, oldLocationId, *capturedPost.LocationId)
+	assert.Equal(t, *capturedPost.LocationId, capturedPost.Location.Id)
+	assert.Equal(t, longitude, capturedPost.Location.Longitude)
+	assert.NotNil(t, capturedPost.EditedAt)
+
+	assert.Equal(t, post.Id, capturedRevision.PostId)
+	assert.Equal(t, post.Content, capturedRevision.Content)
+	assert.Equal(t, oldLocationId, *capturedRevision.LocationId)
+
+	assert.Equal(t, post.Id, responsePost.PostId)
+	assert.Equal(t, expectedContent, responsePost.Content)
+	assert.Equal(t, user.Username, responsePost.Author.Username)
+	assert.True(t, post.CreatedAt.Equal(responsePost.CreationDate))
+	assert.NotNil(t, responsePost.EditedAt)
+	assert.True(t, capturedPost.EditedAt.Equal(*responsePost.EditedAt))
+	assert.Equal(t, longitude, *responsePost.Location.Longitude)
+	assert.Equal(t, latitude, *responsePost.Location.Latitude)
+	assert.Equal(t, accuracy, *responsePost.Location.Accuracy)
+	assert.Equal(t, int64(5), responsePost.Likes)
+	assert.Equal(t, int64(2), responsePost.Comments)
+	assert.False(t, responsePost.Liked)
+	assert.Nil(t, responsePost.Repost)
+
+	mockPostRepository.AssertExpectations(t)
+	mockHashtagRepository.AssertExpectations(t)
+	mockLikeRepository.AssertExpectations(t)
+	mockCommentRepository.AssertExpectations(t)
+}
+
+// TestUpdatePostBadRequest tests if the UpdatePost function returns 400-Bad Request for invalid updates
+func TestUpdatePostBadRequest(t *testing.T) {
+	emptyContent := ""
+	tooLongContent := strings.Repeat("a", 257)
+	invalidLongitude := 200.0
+	latitude := 0.0
+	accuracy := uint(0)
+
+	testCases := []struct {
+		name string
+		body interface{}
+	}{
+		{"Nothing to update", models.PostUpdateRequestDTO{}},
+		{"Empty content without image", models.PostUpdateRequestDTO{Content: &emptyContent}},
+		{"Content too long", models.PostUpdateRequestDTO{Content: &tooLongContent}},
+		{"Invalid location", models.PostUpdateRequestDTO{Location: &models.LocationDTO{Longitude: &invalidLongitude, Latitude: &latitude, Accuracy: &accuracy}}},
+		{"Invalid body", "invalid"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			mockPostRepository := new(repositories.MockPostRepository)
+			validator := new(utils.Validator)
+
+			postService := services.NewPostService(mockPostRepository, nil, nil, validator, nil, nil, nil)
+			postController := controllers.NewPostController(postService)
+
+			username := "testUser"
+			authenticationToken, _ := utils.GenerateAccessToken(username)
+			post := models.Post{
+				Id:       uuid.New(),
+				Username: username,
+				Content:  "Old content",
+			}
+
+			mockPostRepository.On("GetPostById", post.Id.String()).Return(post, nil).Maybe()
+
+			// Setup HTTP request
+			requestBody, err := json.Marshal(tc.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, _ := http.NewRequest("PATCH", "/posts/"+post.Id.String(), bytes.NewBuffer(requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+authenticationToken)
+			w := httptest.NewRecorder()
+
+			// Act
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.PATCH("/posts/:postId", middleware.AuthorizeUser, postController.UpdatePost)
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var errorResponse customerrors.ErrorResponse
+			err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+			assert.NoError(t, err)
+
+			expectedCustomError := customerrors.BadRequest
+			assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+			assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+			mockPostRepository.AssertExpectations(t)
+		})
+	}
+}
+
+// TestUpdatePostUnauthorized tests if the UpdatePost function returns 401-Unauthorized if the user is not authenticated
+func TestUpdatePostUnauthorized(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+
+	postService := services.NewPostService(mockPostRepository, nil, nil, nil, nil, nil, nil)
+	postController := controllers.NewPostController(postService)
+
+	postId := uuid.New()
+	content := "New content"
+	requestBody, _ := json.Marshal(models.PostUpdateRequestDTO{Content: &content})
+
+	// Setup HTTP request without Authorization Header
+	req, _ := http.NewRequest("PATCH", "/posts/"+postId.String(), bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PATCH("/posts/:postId", middleware.AuthorizeUser, postController.UpdatePost)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var errorResponse customerrors.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.Unauthorized
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockPostRepository.AssertExpectations(t)
+}
+
+// TestUpdatePostForbidden tests if the UpdatePost function returns 403-Forbidden if the user is not the author of the post
+func TestUpdatePostForbidden(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+
+	postService := services.NewPostService(mockPostRepository, nil, nil, nil, nil, nil, nil)
+	postController := controllers.NewPostController(postService)
+
+	postId := uuid.New().String()
+	username := "testUser"
+	authenticationToken, _ := utils.GenerateAccessToken(username)
+	content := "New content"
+	requestBody, _ := json.Marshal(models.PostUpdateRequestDTO{Content: &content})
+
+	mockPostRepository.On("GetPostById", postId).Return(models.Post{Username: "anotherUser"}, nil)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("PATCH", "/posts/"+postId, bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PATCH("/posts/:postId", middleware.AuthorizeUser, postController.UpdatePost)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var errorResponse customerrors.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.UpdatePostForbidden
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockPostRepository.AssertExpectations(t)
+}
+
+// TestUpdatePostNotFound tests if the UpdatePost function returns 404-Not Found if the post does not exist
+func TestUpdatePostNotFound(t *testing.T) {
+	// Arrange
+	mockPostRepository := new(repositories.MockPostRepository)
+
+	postService := services.NewPostService(mockPostRepository, nil, nil, nil, nil, nil, nil)
+	postController := controllers.NewPostController(postService)
+
+	postId := uuid.New().String()
+	username := "testUser"
+	authenticationToken, _ := utils.GenerateAccessToken(username)
+	content := "New content"
+	requestBody, _ := json.Marshal(models.PostUpdateRequestDTO{Content: &content})
+
+	mockPostRepository.On("GetPostById", postId).Return(models.Post{}, gorm.ErrRecordNotFound)
+
+	// Setup HTTP request
+	req, _ := http.NewRequest("PATCH", "/posts/"+postId, bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authenticationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PATCH("/posts/:postId", middleware.AuthorizeUser, postController.UpdatePost)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var errorResponse customerrors.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.PostNotFound
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockPostRepository.AssertExpectations(t)
+}
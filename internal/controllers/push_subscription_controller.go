@@ -6,11 +6,15 @@ import (
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"github.com/wwi21seb-projekt/server-beta/internal/services"
 	"net/http"
+	"strconv"
 )
 
 type PushSubscriptionControllerInterface interface {
 	GetVapidKey(c *gin.Context)
 	CreatePushSubscription(c *gin.Context)
+	GetPushSubscriptions(c *gin.Context)
+	DeletePushSubscription(c *gin.Context)
+	GetFailedPushDeliveries(c *gin.Context)
 }
 
 type PushSubscriptionController struct {
@@ -64,8 +68,9 @@ func (controller *PushSubscriptionController) CreatePushSubscription(c *gin.Cont
 		return
 	}
 
-	// Create push subscription
-	responseDto, serviceErr, httpStatus := controller.pushSubscriptionService.CreatePushSubscription(&dto, username.(string))
+	// Create push subscription for the device session of the request
+	sessionId := c.GetString("sessionId")
+	responseDto, serviceErr, httpStatus := controller.pushSubscriptionService.CreatePushSubscription(&dto, username.(string), sessionId)
 	if serviceErr != nil {
 		c.JSON(httpStatus, gin.H{
 			"error": serviceErr,
@@ -76,3 +81,78 @@ func (controller *PushSubscriptionController) CreatePushSubscription(c *gin.Cont
 	c.JSON(httpStatus, responseDto)
 	return
 }
+
+// GetPushSubscriptions is a controller function that returns all push subscriptions of the current user
+func (controller *PushSubscriptionController) GetPushSubscriptions(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	sessionId := c.GetString("sessionId")
+	responseDto, serviceErr, httpStatus := controller.pushSubscriptionService.GetPushSubscriptions(username.(string), sessionId)
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, responseDto)
+}
+
+// DeletePushSubscription is a controller function that unregisters a push subscription of the current user
+func (controller *PushSubscriptionController) DeletePushSubscription(c *gin.Context) {
+	// Get username from request that was set in middleware
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Get subscription id from request
+	subscriptionId := c.Param("subscriptionId")
+
+	serviceErr, httpStatus := controller.pushSubscriptionService.DeletePushSubscription(subscriptionId, username.(string))
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, gin.H{})
+}
+
+// GetFailedPushDeliveries is a controller function that returns push messages that could not be delivered, used for operating the server
+func (controller *PushSubscriptionController) GetFailedPushDeliveries(c *gin.Context) {
+	// Read pagination information from request
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	// Convert limit and offset to int
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	responseDto, serviceErr, httpStatus := controller.pushSubscriptionService.GetFailedPushDeliveries(offset, limit)
+	if serviceErr != nil {
+		c.JSON(httpStatus, gin.H{
+			"error": serviceErr,
+		})
+		return
+	}
+
+	c.JSON(httpStatus, responseDto)
+}
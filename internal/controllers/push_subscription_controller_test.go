@@ -3,7 +3,9 @@ package controllers_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/wwi21seb-projekt/server-beta/internal/controllers"
@@ -13,15 +15,18 @@ import (
 	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
 	"github.com/wwi21seb-projekt/server-beta/internal/services"
 	"github.com/wwi21seb-projekt/server-beta/internal/utils"
+	"gorm.io/gorm"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestGetVapidKeySuccess tests if the function GetVapidKey returns a token if user is authorized
 func TestGetVapidKeySuccess(t *testing.T) {
 	// Arrange
-	pushSubscriptionService := services.NewPushSubscriptionService(nil)
+	pushSubscriptionService := services.NewPushSubscriptionService(nil, nil)
 	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
 
 	authorizationToken, err := utils.GenerateAccessToken("testUser")
@@ -83,11 +88,12 @@ func TestCreatePushSubscriptionWebSuccess(t *testing.T) {
 	// Arrange
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
 
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
 	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
 
 	testUsername := "testUser"
-	authorizationToken, err := utils.GenerateAccessToken(testUsername)
+	sessionId := uuid.New().String()
+	authorizationToken, err := utils.GenerateSessionAccessToken(testUsername, sessionId)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -105,10 +111,12 @@ func TestCreatePushSubscriptionWebSuccess(t *testing.T) {
 
 	// Mock expectations
 	var capturedPushSubscription models.PushSubscription
+	mockPushSubscriptionRepo.On("FindPushSubscription", "web", pushSubscriptionCreateRequest.SubscriptionInfo.Endpoint, "").Return(&models.PushSubscription{}, gorm.ErrRecordNotFound)
 	mockPushSubscriptionRepo.On("CreatePushSubscription", mock.AnythingOfType("*models.PushSubscription")).
 		Run(func(args mock.Arguments) {
 			capturedPushSubscription = *args.Get(0).(*models.PushSubscription)
 		}).Return(nil)
+	mockPushSubscriptionRepo.On("DeletePushSubscriptionsBySessionId", sessionId, mock.AnythingOfType("string")).Return(nil) // older subscriptions of the session are replaced
 
 	// Setup HTTP request and recorder
 	requestBody, err := json.Marshal(pushSubscriptionCreateRequest)
@@ -143,6 +151,7 @@ func TestCreatePushSubscriptionWebSuccess(t *testing.T) {
 	assert.Equal(t, capturedPushSubscription.P256dh, pushSubscriptionCreateRequest.SubscriptionInfo.SubscriptionKeys.P256dh)
 	assert.Equal(t, capturedPushSubscription.Auth, pushSubscriptionCreateRequest.SubscriptionInfo.SubscriptionKeys.Auth)
 	assert.Equal(t, capturedPushSubscription.ExpoToken, "")
+	assert.Equal(t, capturedPushSubscription.SessionId, sessionId)
 
 	mockPushSubscriptionRepo.AssertExpectations(t)
 }
@@ -152,7 +161,7 @@ func TestCreatePushSubscriptionExpoSuccess(t *testing.T) {
 	// Arrange
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
 
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
 	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
 
 	testUsername := "testUser"
@@ -168,6 +177,7 @@ func TestCreatePushSubscriptionExpoSuccess(t *testing.T) {
 
 	// Mock expectations
 	var capturedPushSubscription models.PushSubscription
+	mockPushSubscriptionRepo.On("FindPushSubscription", "expo", "", pushSubscriptionCreateRequest.Token).Return(&models.PushSubscription{}, gorm.ErrRecordNotFound)
 	mockPushSubscriptionRepo.On("CreatePushSubscription", mock.AnythingOfType("*models.PushSubscription")).
 		Run(func(args mock.Arguments) {
 			capturedPushSubscription = *args.Get(0).(*models.PushSubscription)
@@ -208,6 +218,7 @@ func TestCreatePushSubscriptionExpoSuccess(t *testing.T) {
 	assert.Equal(t, capturedPushSubscription.ExpoToken, pushSubscriptionCreateRequest.Token)
 
 	mockPushSubscriptionRepo.AssertExpectations(t)
+	mockPushSubscriptionRepo.AssertNotCalled(t, "DeletePushSubscriptionsBySessionId", mock.Anything, mock.Anything) // token without session
 }
 
 // TestCreatePushSubscriptionUnauthorized tests if the function CreatePushSubscription returns an error if user is not authorized
@@ -243,7 +254,7 @@ func TestCreatePushSubscriptionBadRequest(t *testing.T) {
 	invalidBodies := []string{
 		`{"invalidField": "value"}`,
 		``,
-		`{type: "w", "subscription": {"endpoint": "https://www.example.com", "keys":{"pd256dh": "dGVzdA", "auth": "dGVzdA"}}}`, // invalid type, only "web" or "expo" allowed
+		`{type: "w", "subscription": {"endpoint": "https://www.example.com", "keys":{"pd256dh": "dGVzdA", "auth": "dGVzdA"}}}`, // invalid type, only "web", "expo", "fcm" or "apns" allowed
 		`{type: "web", "subscription": {"endpoint": "no url", "keys":{"pd256dh": "dGVzdA", "auth": "dGVzdA"}}}`,                // invalid endpoint
 		`{type: "web", "subscription": {"endpoint": "https://www.example.com", "keys":{"pd256dh": "t", "auth": "dGVzdA"}}}`,    // no base64 encoded pd256dh
 		`{type: "web", "subscription": {"endpoint": "https://www.example.com", "keys":{"pd256dh": "dGVzdA", "auth": "t"}}}`,    // no base64 encoded auth
@@ -251,12 +262,13 @@ func TestCreatePushSubscriptionBadRequest(t *testing.T) {
 		`{type: "web"}`,                  // subscription info missing for web
 		`{type: "expo"}`,                 // token missing for expo
 		`{type: "expo", "subscription": {"endpoint": "https://www.example.com", "keys":{"pd256dh": "dGVzd, "auth": "d"}}}`, // invalid token
-
+		`{"type": "fcm", "token": "someRegistrationTokenOfAnAndroidApp"}`,                                                  // no fcm provider configured
+		`{"type": "apns", "token": "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789"}`,                    // no apns provider configured
 	}
 
 	for _, body := range invalidBodies {
 		// Arrange
-		pushSubscriptionService := services.NewPushSubscriptionService(nil)
+		pushSubscriptionService := services.NewPushSubscriptionService(nil, nil)
 		pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
 
 		authenticationToken, err := utils.GenerateAccessToken("testUser")
@@ -288,3 +300,444 @@ func TestCreatePushSubscriptionBadRequest(t *testing.T) {
 		assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
 	}
 }
+
+// TestCreatePushSubscriptionExisting tests if the function CreatePushSubscription updates an existing subscription with the same token instead of creating a duplicate
+func TestCreatePushSubscriptionExisting(t *testing.T) {
+	// Arrange
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
+
+	testUsername := "testUser"
+	authorizationToken, err := utils.GenerateAccessToken(testUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushSubscriptionCreateRequest := models.PushSubscriptionRequestDTO{
+		Type:  "expo",
+		Token: "ExponentPushToken[someToken]",
+	}
+	existingPushSubscription := models.PushSubscription{
+		Id:        uuid.New(),
+		Username:  "previousUser", // other user was logged in on the same device before
+		Type:      "expo",
+		ExpoToken: pushSubscriptionCreateRequest.Token,
+	}
+
+	// Mock expectations
+	var capturedPushSubscription models.PushSubscription
+	mockPushSubscriptionRepo.On("FindPushSubscription", "expo", "", pushSubscriptionCreateRequest.Token).Return(&existingPushSubscription, nil)
+	mockPushSubscriptionRepo.On("UpdatePushSubscription", mock.AnythingOfType("*models.PushSubscription")).
+		Run(func(args mock.Arguments) {
+			capturedPushSubscription = *args.Get(0).(*models.PushSubscription)
+		}).Return(nil)
+
+	// Setup HTTP request and recorder
+	requestBody, err := json.Marshal(pushSubscriptionCreateRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "/push/register", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authorizationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/push/register", middleware.AuthorizeUser, pushSubscriptionController.CreatePushSubscription)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+
+	var responseObject models.PushSubscriptionResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseObject)
+	assert.NoError(t, err)
+
+	assert.Equal(t, existingPushSubscription.Id.String(), responseObject.SubscriptionId)
+	assert.Equal(t, existingPushSubscription.Id, capturedPushSubscription.Id)
+	assert.Equal(t, testUsername, capturedPushSubscription.Username)
+
+	mockPushSubscriptionRepo.AssertExpectations(t)
+	mockPushSubscriptionRepo.AssertNotCalled(t, "CreatePushSubscription", mock.Anything)
+}
+
+// TestGetPushSubscriptionsSuccess tests if the function GetPushSubscriptions returns all subscriptions of the user and marks the one of the current session
+func TestGetPushSubscriptionsSuccess(t *testing.T) {
+	// Arrange
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
+
+	testUsername := "testUser"
+	sessionId := uuid.New().String()
+	authorizationToken, err := utils.GenerateSessionAccessToken(testUsername, sessionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushSubscriptions := []models.PushSubscription{
+		{
+			Id:        uuid.New(),
+			Username:  testUsername,
+			Type:      "web",
+			Endpoint:  "https://example.com",
+			P256dh:    "dGVzdA",
+			Auth:      "dGVzdA",
+			SessionId: sessionId,
+		},
+		{
+			Id:        uuid.New(),
+			Username:  testUsername,
+			Type:      "expo",
+			ExpoToken: "ExponentPushToken[someToken]",
+			SessionId: uuid.New().String(),
+		},
+	}
+
+	// Mock expectations
+	mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", testUsername).Return(pushSubscriptions, nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodGet, "/push/subscriptions", nil)
+	req.Header.Set("Authorization", "Bearer "+authorizationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/push/subscriptions", middleware.AuthorizeUser, pushSubscriptionController.GetPushSubscriptions)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+
+	var responseObject models.PushSubscriptionsResponseDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseObject)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(responseObject.Records))
+	assert.Equal(t, pushSubscriptions[0].Id.String(), responseObject.Records[0].SubscriptionId)
+	assert.Equal(t, pushSubscriptions[0].Endpoint, responseObject.Records[0].Endpoint)
+	assert.True(t, responseObject.Records[0].CurrentSession)
+	assert.Equal(t, pushSubscriptions[1].Id.String(), responseObject.Records[1].SubscriptionId)
+	assert.Equal(t, pushSubscriptions[1].ExpoToken, responseObject.Records[1].Token)
+	assert.False(t, responseObject.Records[1].CurrentSession)
+
+	mockPushSubscriptionRepo.AssertExpectations(t)
+}
+
+// TestDeletePushSubscriptionSuccess tests if the function DeletePushSubscription deletes a subscription of the user
+func TestDeletePushSubscriptionSuccess(t *testing.T) {
+	// Arrange
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
+
+	testUsername := "testUser"
+	authorizationToken, err := utils.GenerateAccessToken(testUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushSubscription := models.PushSubscription{
+		Id:       uuid.New(),
+		Username: testUsername,
+		Type:     "web",
+	}
+
+	// Mock expectations
+	mockPushSubscriptionRepo.On("GetPushSubscriptionById", pushSubscription.Id.String()).Return(&pushSubscription, nil)
+	mockPushSubscriptionRepo.On("DeletePushSubscriptionById", pushSubscription.Id.String()).Return(nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/push/subscriptions/%s", pushSubscription.Id.String()), nil)
+	req.Header.Set("Authorization", "Bearer "+authorizationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/push/subscriptions/:subscriptionId", middleware.AuthorizeUser, pushSubscriptionController.DeletePushSubscription)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect HTTP 204 No Content
+	mockPushSubscriptionRepo.AssertExpectations(t)
+}
+
+// TestDeletePushSubscriptionErrors tests if the function DeletePushSubscription returns not found for missing subscriptions, subscriptions of other users and invalid ids
+func TestDeletePushSubscriptionErrors(t *testing.T) {
+	testUsername := "testUser"
+	otherPushSubscription := models.PushSubscription{
+		Id:       uuid.New(),
+		Username: "otherUser",
+		Type:     "web",
+	}
+	missingPushSubscriptionId := uuid.New().String()
+
+	testCases := []struct {
+		subscriptionId string
+		expectedError  *customerrors.CustomError
+	}{
+		{missingPushSubscriptionId, customerrors.PushSubscriptionNotFound},
+		{otherPushSubscription.Id.String(), customerrors.PushSubscriptionNotFound}, // subscriptions of other users are not revealed
+		{"not-a-uuid", customerrors.PushSubscriptionNotFound},                      // invalid ids are rejected before the database is queried
+	}
+
+	for _, testCase := range testCases {
+		// Arrange
+		mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+		pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+		pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
+
+		authorizationToken, err := utils.GenerateAccessToken(testUsername)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Mock expectations
+		mockPushSubscriptionRepo.On("GetPushSubscriptionById", missingPushSubscriptionId).Return(&models.PushSubscription{}, gorm.ErrRecordNotFound)
+		mockPushSubscriptionRepo.On("GetPushSubscriptionById", otherPushSubscription.Id.String()).Return(&otherPushSubscription, nil)
+
+		// Setup HTTP request and recorder
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/push/subscriptions/%s", testCase.subscriptionId), nil)
+		req.Header.Set("Authorization", "Bearer "+authorizationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.DELETE("/push/subscriptions/:subscriptionId", middleware.AuthorizeUser, pushSubscriptionController.DeletePushSubscription)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, testCase.expectedError.HttpStatus, w.Code)
+		mockPushSubscriptionRepo.AssertNotCalled(t, "DeletePushSubscriptionById", mock.Anything)
+
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+
+		assert.Equal(t, testCase.expectedError.Message, errorResponse.Error.Message)
+		assert.Equal(t, testCase.expectedError.Code, errorResponse.Error.Code)
+	}
+}
+
+// TestGetFailedPushDeliveriesSuccess tests if the function GetFailedPushDeliveries returns failed push messages with their attempt log
+func TestGetFailedPushDeliveriesSuccess(t *testing.T) {
+	// Arrange
+	t.Setenv("INTERNAL_API_KEY", "internalKey")
+
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+	mockPushDeliveryRepo := new(repositories.MockPushDeliveryRepository)
+
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, mockPushDeliveryRepo)
+	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
+
+	deliveryId := uuid.New()
+	deliveries := []models.PushDelivery{
+		{
+			Id:             deliveryId,
+			SubscriptionId: uuid.New(),
+			Username:       "testUser",
+			Type:           "web",
+			Status:         services.PushDeliveryStatusFailed,
+			Attempts:       2,
+			CreatedAt:      time.Now(),
+			AttemptLog: []models.PushDeliveryAttempt{
+				{Id: uuid.New(), DeliveryId: deliveryId, AttemptNumber: 1, Status: services.PushDeliveryStatusPending, StatusCode: 503, Error: "unavailable"},
+				{Id: uuid.New(), DeliveryId: deliveryId, AttemptNumber: 2, Status: services.PushDeliveryStatusFailed, StatusCode: 400, Error: "bad request"},
+			},
+		},
+	}
+
+	// Mock expectations
+	mockPushDeliveryRepo.On("GetPushDeliveriesByStatus", services.PushDeliveryStatusFailed, 0, 5).Return(deliveries, int64(1), nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodGet, "/internal/push/deliveries/failed?offset=0&limit=5", nil)
+	req.Header.Set("X-Internal-Api-Key", "internalKey")
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/internal/push/deliveries/failed", middleware.AuthorizeInternal, pushSubscriptionController.GetFailedPushDeliveries)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code) // Expect HTTP 200 OK
+
+	var responseObject models.PushDeliveriesResponseDTO
+	err := json.Unmarshal(w.Body.Bytes(), &responseObject)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(responseObject.Records))
+	assert.Equal(t, deliveryId.String(), responseObject.Records[0].DeliveryId)
+	assert.Equal(t, "testUser", responseObject.Records[0].Username)
+	assert.Equal(t, services.PushDeliveryStatusFailed, responseObject.Records[0].Status)
+	assert.Equal(t, 2, len(responseObject.Records[0].Attempts))
+	assert.Equal(t, 503, responseObject.Records[0].Attempts[0].StatusCode)
+	assert.Equal(t, "bad request", responseObject.Records[0].Attempts[1].Error)
+	assert.Equal(t, int64(1), responseObject.Pagination.Records)
+	assert.Equal(t, 5, responseObject.Pagination.Limit)
+
+	mockPushDeliveryRepo.AssertExpectations(t)
+}
+
+// TestCreatePushSubscriptionApnsSuccess tests if the function CreatePushSubscription saves apns device tokens if an apns provider is configured
+func TestCreatePushSubscriptionApnsSuccess(t *testing.T) {
+	// Arrange
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+	providers := map[string]services.PushProvider{"apns": services.NewFakePushProvider()}
+	pushSubscriptionService := services.NewPushSubscriptionServiceWithProviders(mockPushSubscriptionRepo, nil, providers)
+	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
+
+	testUsername := "testUser"
+	authorizationToken, err := utils.GenerateAccessToken(testUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushSubscriptionCreateRequest := models.PushSubscriptionRequestDTO{
+		Type:  "apns",
+		Token: "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+	}
+
+	// Mock expectations
+	var capturedPushSubscription models.PushSubscription
+	mockPushSubscriptionRepo.On("FindPushSubscription", "apns", "", pushSubscriptionCreateRequest.Token).Return(&models.PushSubscription{}, gorm.ErrRecordNotFound)
+	mockPushSubscriptionRepo.On("CreatePushSubscription", mock.AnythingOfType("*models.PushSubscription")).
+		Run(func(args mock.Arguments) {
+			capturedPushSubscription = *args.Get(0).(*models.PushSubscription)
+		}).Return(nil)
+
+	// Setup HTTP request and recorder
+	requestBody, err := json.Marshal(pushSubscriptionCreateRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "/push/register", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authorizationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/push/register", middleware.AuthorizeUser, pushSubscriptionController.CreatePushSubscription)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code) // Expect HTTP 201 Created
+
+	assert.Equal(t, "apns", capturedPushSubscription.Type)
+	assert.Equal(t, pushSubscriptionCreateRequest.Token, capturedPushSubscription.DeviceToken)
+	assert.Equal(t, "", capturedPushSubscription.ExpoToken)
+	assert.Equal(t, "", capturedPushSubscription.Endpoint)
+
+	mockPushSubscriptionRepo.AssertExpectations(t)
+}
+
+// TestCreatePushSubscriptionFcmSuccess tests if the function CreatePushSubscription saves fcm registration tokens if an fcm provider is configured
+func TestCreatePushSubscriptionFcmSuccess(t *testing.T) {
+	// Arrange
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+
+	providers := map[string]services.PushProvider{"fcm": services.NewFakePushProvider()}
+	pushSubscriptionService := services.NewPushSubscriptionServiceWithProviders(mockPushSubscriptionRepo, nil, providers)
+	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
+
+	testUsername := "testUser"
+	authorizationToken, err := utils.GenerateAccessToken(testUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushSubscriptionCreateRequest := models.PushSubscriptionRequestDTO{
+		Type:  "fcm",
+		Token: "dQw4w9WgXcQ:APA91bH-someRegistrationToken_OfAnAndroidApp" + strings.Repeat("a", 100),
+	}
+
+	// Mock expectations
+	var capturedPushSubscription models.PushSubscription
+	mockPushSubscriptionRepo.On("FindPushSubscription", "fcm", "", pushSubscriptionCreateRequest.Token).Return(&models.PushSubscription{}, gorm.ErrRecordNotFound)
+	mockPushSubscriptionRepo.On("CreatePushSubscription", mock.AnythingOfType("*models.PushSubscription")).
+		Run(func(args mock.Arguments) {
+			capturedPushSubscription = *args.Get(0).(*models.PushSubscription)
+		}).Return(nil)
+
+	// Setup HTTP request and recorder
+	requestBody, err := json.Marshal(pushSubscriptionCreateRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "/push/register", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authorizationToken)
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/push/register", middleware.AuthorizeUser, pushSubscriptionController.CreatePushSubscription)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code) // Expect HTTP 201 Created
+
+	assert.Equal(t, "fcm", capturedPushSubscription.Type)
+	assert.Equal(t, pushSubscriptionCreateRequest.Token, capturedPushSubscription.DeviceToken)
+	assert.Equal(t, "", capturedPushSubscription.ExpoToken)
+	assert.Equal(t, "", capturedPushSubscription.Endpoint)
+
+	mockPushSubscriptionRepo.AssertExpectations(t)
+}
+
+// TestCreatePushSubscriptionFcmBadRequest tests if the function CreatePushSubscription rejects invalid fcm registration tokens
+func TestCreatePushSubscriptionFcmBadRequest(t *testing.T) {
+	invalidTokens := []string{
+		"tooShortToken",                 // less than 20 characters
+		strings.Repeat("a", 4097),       // more than 4096 characters
+		"someRegistrationToken/OfAnApp", // no url-safe string
+	}
+
+	for _, token := range invalidTokens {
+		// Arrange
+		providers := map[string]services.PushProvider{"fcm": services.NewFakePushProvider()}
+		pushSubscriptionService := services.NewPushSubscriptionServiceWithProviders(nil, nil, providers)
+		pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
+
+		authorizationToken, err := utils.GenerateAccessToken("testUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Setup HTTP request and recorder
+		requestBody, err := json.Marshal(models.PushSubscriptionRequestDTO{Type: "fcm", Token: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest(http.MethodPost, "/push/register", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authorizationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.POST("/push/register", middleware.AuthorizeUser, pushSubscriptionController.CreatePushSubscription)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code) // Expect HTTP 400 Bad Request
+	}
+}
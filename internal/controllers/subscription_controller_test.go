@@ -29,8 +29,9 @@ func TestPostSubscriptionSuccess(t *testing.T) {
 	mockUserRepo := new(repositories.MockUserRepository)
 	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
 	mockNotificationRepo := new(repositories.MockNotificationRepository)
-	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo)
-	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, nil)
+	mockNotificationSettingRepo := new(repositories.MockNotificationSettingRepository)
+	notificationService := services.NewNotificationService(mockNotificationRepo, pushSubscriptionService, mockNotificationSettingRepo, nil)
 
 	subscriptionService := services.NewSubscriptionService(mockSubscriptionRepo, mockUserRepo, notificationService)
 	subscriptionController := controllers.NewSubscriptionController(subscriptionService)
@@ -56,6 +57,8 @@ func TestPostSubscriptionSuccess(t *testing.T) {
 	mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", subscriptionCreateRequest.Following).Return([]models.PushSubscription{}, nil) // Expect no push subscriptions to be found
 
 	var capturedNotification models.Notification
+	mockNotificationSettingRepo.On("FindNotificationMute", mock.Anything, mock.Anything).Return(&models.NotificationMute{}, gorm.ErrRecordNotFound)       // sender is not muted
+	mockNotificationSettingRepo.On("FindNotificationSetting", mock.Anything, mock.Anything).Return(&models.NotificationSetting{}, gorm.ErrRecordNotFound) // default settings are used
 	mockNotificationRepo.On("CreateNotification", mock.AnythingOfType("*models.Notification")).
 		Run(func(args mock.Arguments) {
 			capturedNotification = *args.Get(0).(*models.Notification)
@@ -5,6 +5,7 @@ import (
 	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
 	"net/http"
 	"strconv"
 )
@@ -19,6 +20,11 @@ type UserControllerInterface interface {
 	UpdateUserInformation(c *gin.Context)
 	ChangeUserPassword(c *gin.Context)
 	GetUserProfile(c *gin.Context)
+	DeleteUser(c *gin.Context)
+	GetUserLocale(c *gin.Context)
+	UpdateUserLocale(c *gin.Context)
+	GetPrivacySettings(c *gin.Context)
+	UpdatePrivacySettings(c *gin.Context)
 }
 
 type UserController struct {
@@ -42,6 +48,11 @@ func (controller *UserController) CreateUser(c *gin.Context) {
 		return
 	}
 
+	// Use the preferred language of the client if no locale was given
+	if userCreateRequestDTO.Locale == "" {
+		userCreateRequestDTO.Locale = utils.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	}
+
 	// Create userDto
 	userDto, serviceErr, httpStatus := controller.userService.CreateUser(userCreateRequestDTO)
 	if serviceErr != nil {
@@ -303,3 +314,145 @@ func (controller *UserController) GetUserProfile(c *gin.Context) {
 
 	c.JSON(status, userProfileDTO)
 }
+
+// DeleteUser deletes the account of the logged-in user after the password was confirmed
+func (controller *UserController) DeleteUser(c *gin.Context) {
+	// Extract the username from the context
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Bind the JSON request body to the struct
+	var userDeleteRequestDTO models.UserDeleteRequestDTO
+	if err := c.ShouldBindJSON(&userDeleteRequestDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	// Delete the user
+	customErr, status := controller.userService.DeleteUser(&userDeleteRequestDTO, username.(string))
+	if customErr != nil {
+		c.JSON(status, gin.H{
+			"error": customErr,
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{})
+}
+
+// GetUserLocale returns the locale of the current user
+func (controller *UserController) GetUserLocale(c *gin.Context) {
+	// Extract the username from the context
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	localeDto, customErr, status := controller.userService.GetUserLocale(username.(string))
+	if customErr != nil {
+		c.JSON(status, gin.H{
+			"error": customErr,
+		})
+		return
+	}
+
+	c.JSON(status, localeDto)
+}
+
+// UpdateUserLocale changes the locale of the current user to the locale of the body or the Accept-Language header
+func (controller *UserController) UpdateUserLocale(c *gin.Context) {
+	// Extract the username from the context
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	// Body is optional, the Accept-Language header is used if no locale is given
+	var userLocaleDTO models.UserLocaleDTO
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&userLocaleDTO); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": customerrors.BadRequest,
+			})
+			return
+		}
+	}
+	if userLocaleDTO.Locale == "" {
+		userLocaleDTO.Locale = utils.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	}
+
+	localeDto, customErr, status := controller.userService.UpdateUserLocale(&userLocaleDTO, username.(string))
+	if customErr != nil {
+		c.JSON(status, gin.H{
+			"error": customErr,
+		})
+		return
+	}
+
+	c.JSON(status, localeDto)
+}
+
+// GetPrivacySettings returns who can see the presence of the current user
+func (controller *UserController) GetPrivacySettings(c *gin.Context) {
+	// Extract the username from the context
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	settingsDto, customErr, status := controller.userService.GetPrivacySettings(username.(string))
+	if customErr != nil {
+		c.JSON(status, gin.H{
+			"error": customErr,
+		})
+		return
+	}
+
+	c.JSON(status, settingsDto)
+}
+
+// UpdatePrivacySettings changes who can see the presence of the current user
+func (controller *UserController) UpdatePrivacySettings(c *gin.Context) {
+	// Extract the username from the context
+	username, exists := c.Get("username")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	var privacySettingsDTO models.UserPrivacySettingsDTO
+	if err := c.ShouldBindJSON(&privacySettingsDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": customerrors.BadRequest,
+		})
+		return
+	}
+
+	settingsDto, customErr, status := controller.userService.UpdatePrivacySettings(&privacySettingsDTO, username.(string))
+	if customErr != nil {
+		c.JSON(status, gin.H{
+			"error": customErr,
+		})
+		return
+	}
+
+	c.JSON(status, settingsDto)
+}
@@ -41,6 +41,7 @@ func TestCreateUserSuccess(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -147,6 +148,7 @@ func TestCreateUserSuccessWithImage(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -281,6 +283,7 @@ func TestCreateUserBadRequest(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 		)
 
 		userController := controllers.NewUserController(userService)
@@ -332,6 +335,7 @@ func TestCreateUserUsernameExists(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -403,6 +407,7 @@ func TestCreateUserEmailExists(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -473,6 +478,7 @@ func TestCreateEmailExistsRollback(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -543,6 +549,7 @@ func TestCreateUserEmailUnreachable(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -608,6 +615,7 @@ func TestCreateUserInternalServerErrorDatabase(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -680,6 +688,7 @@ func TestCreateUserInternalServerErrorServer(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -754,6 +763,7 @@ func TestLoginSuccess(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -877,6 +887,7 @@ func TestLoginInvalidCredentialsUserNotFound(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -940,6 +951,7 @@ func TestLoginInvalidCredentialsPasswordIncorrect(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1019,6 +1031,7 @@ func TestLoginUserNotActivated(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1108,6 +1121,7 @@ func TestLoginUserNotActivatedExpiredToken(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1199,6 +1213,7 @@ func TestActivateUserSuccess(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1335,6 +1350,7 @@ func TestActivateUserAlreadyReported(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1411,6 +1427,7 @@ func TestActivateUserNotFound(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1470,6 +1487,7 @@ func TestActivateUserTokenNotFound(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1547,6 +1565,7 @@ func TestActivateUserTokenExpired(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1634,6 +1653,7 @@ func TestResendActivationTokenSuccess(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1697,6 +1717,7 @@ func TestResendActivationTokenAlreadyReported(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1764,6 +1785,7 @@ func TestResendActivationTokenUserNotfound(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -1813,6 +1835,7 @@ func TestRefreshTokenSuccess(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -1914,7 +1937,7 @@ func TestRefreshTokenInvalidToken(t *testing.T) {
 
 	for _, token := range invalidTokens {
 		service := services.NewUserService(nil, nil, nil, nil, nil, nil,
-			nil)
+			nil, nil)
 		controller := controllers.NewUserController(service)
 
 		gin.SetMode(gin.TestMode)
@@ -1964,6 +1987,7 @@ func TestSearchUserSuccess(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	userController := controllers.NewUserController(userService)
@@ -2127,6 +2151,7 @@ func TestUpdateUserInformationSuccess(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 		)
 		userController := controllers.NewUserController(userService)
 
@@ -2218,6 +2243,7 @@ func TestUpdateUserInformationSuccessWithNewPicture(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -2320,6 +2346,7 @@ func TestUpdateUserInformationSuccessWithChangePicture(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -2432,6 +2459,7 @@ func TestUpdateUserInformationSuccessWithDeletePicture(t *testing.T) {
 		nil,
 		mockImageRepository,
 		nil,
+		nil,
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -2531,6 +2559,7 @@ func TestUpdateUserInformationBadRequest(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 		)
 
 		userController := controllers.NewUserController(userService)
@@ -2591,6 +2620,7 @@ func TestUpdateUserInformationUnauthorized(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 		)
 
 		userController := controllers.NewUserController(userService)
@@ -2645,6 +2675,7 @@ func TestChangePasswordSuccess(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -2724,6 +2755,7 @@ func TestChangePasswordBadRequest(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 		)
 		userController := controllers.NewUserController(userService)
 
@@ -2779,6 +2811,7 @@ func TestChangePasswordUnauthorized(t *testing.T) {
 			nil,
 			nil,
 			nil,
+			nil,
 		)
 
 		userController := controllers.NewUserController(userService)
@@ -2834,6 +2867,7 @@ func TestChangePasswordOldPasswordIncorrect(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -2903,6 +2937,7 @@ func TestGetUserProfileSuccess(t *testing.T) {
 		mockPostRepository,
 		nil,
 		mockSubscriptionRepository,
+		services.NewPresenceService(mockUserRepository, mockSubscriptionRepository, services.NewMemoryChatBroker()),
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -3001,6 +3036,7 @@ func TestGetUserProfileSuccessNoSubscription(t *testing.T) {
 		mockPostRepository,
 		nil,
 		mockSubscriptionRepository,
+		services.NewPresenceService(mockUserRepository, mockSubscriptionRepository, services.NewMemoryChatBroker()),
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -3078,6 +3114,7 @@ func TestGetUserProfileUnauthorized(t *testing.T) {
 			mockPostRepository,
 			nil,
 			nil,
+			nil,
 		)
 		userController := controllers.NewUserController(userService)
 
@@ -3122,6 +3159,7 @@ func TestGetUserProfileUserNotFound(t *testing.T) {
 		mockPostRepository,
 		nil,
 		nil,
+		nil,
 	)
 	userController := controllers.NewUserController(userService)
 
@@ -3162,3 +3200,750 @@ func TestGetUserProfileUserNotFound(t *testing.T) {
 	mockUserRepository.AssertExpectations(t)
 	mockPostRepository.AssertExpectations(t)
 }
+
+// TestDeleteUserSuccess tests if DeleteUser returns 204-No Content and deletes posts and user in one transaction
+func TestDeleteUserSuccess(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockPostRepository := new(repositories.MockPostRepository)
+	userService := services.NewUserService(
+		mockUserRepository,
+		nil,
+		nil,
+		nil,
+		mockPostRepository,
+		nil,
+		nil,
+		nil,
+	)
+	userController := controllers.NewUserController(userService)
+
+	password := "Password123!"
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := models.User{
+		Username:     "testUser",
+		PasswordHash: hashedPassword,
+		Activated:    true,
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockTx := new(gorm.DB)
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+	mockUserRepository.On("BeginTx").Return(mockTx)
+	mockPostRepository.On("DeletePostsByUsernameTx", user.Username, mockTx).Return(nil)
+	mockUserRepository.On("DeleteUserTx", user.Username, mockTx).Return(nil)
+	mockUserRepository.On("CommitTx", mockTx).Return(nil)
+
+	// Setup HTTP request and recorder
+	requestBody, err := json.Marshal(models.UserDeleteRequestDTO{Password: password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodDelete, "/users", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/users", middleware.AuthorizeUser, userController.DeleteUser)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code) // Expect HTTP 204 No Content status
+
+	mockUserRepository.AssertExpectations(t)
+	mockPostRepository.AssertExpectations(t)
+}
+
+// TestDeleteUserBadRequest tests if DeleteUser returns 400-Bad Request when no password is given
+func TestDeleteUserBadRequest(t *testing.T) {
+	invalidBodies := []string{
+		`{"invalidField": "value"}`, // invalid field
+		`{}`,                        // empty body
+		`{password: }`,              // invalid json
+	}
+
+	for _, body := range invalidBodies {
+		// Arrange
+		mockUserRepository := new(repositories.MockUserRepository)
+		userService := services.NewUserService(
+			mockUserRepository,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
+		)
+		userController := controllers.NewUserController(userService)
+
+		authenticationToken, err := utils.GenerateAccessToken("testUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Setup HTTP request and recorder
+		req, _ := http.NewRequest(http.MethodDelete, "/users", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.DELETE("/users", middleware.AuthorizeUser, userController.DeleteUser)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code) // Expect HTTP 400 Bad Request status
+		var errorResponse customerrors.ErrorResponse
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+
+		expectedCustomError := customerrors.BadRequest
+		assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+		assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+		mockUserRepository.AssertExpectations(t)
+	}
+}
+
+// TestDeleteUserUnauthorized tests if DeleteUser returns 401-Unauthorized when user is not authorized
+func TestDeleteUserUnauthorized(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	userService := services.NewUserService(
+		mockUserRepository,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	userController := controllers.NewUserController(userService)
+
+	// Setup HTTP request and recorder
+	requestBody, err := json.Marshal(models.UserDeleteRequestDTO{Password: "Password123!"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodDelete, "/users", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer invalidToken")
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/users", middleware.AuthorizeUser, userController.DeleteUser)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code) // Expect HTTP 401 Unauthorized status
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.Unauthorized
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockUserRepository.AssertExpectations(t)
+}
+
+// TestDeleteUserPasswordIncorrect tests if DeleteUser returns 403-Forbidden and deletes nothing when the password is incorrect
+func TestDeleteUserPasswordIncorrect(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockPostRepository := new(repositories.MockPostRepository)
+	userService := services.NewUserService(
+		mockUserRepository,
+		nil,
+		nil,
+		nil,
+		mockPostRepository,
+		nil,
+		nil,
+		nil,
+	)
+	userController := controllers.NewUserController(userService)
+
+	hashedPassword, err := utils.HashPassword("Password123!")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := models.User{
+		Username:     "testUser",
+		PasswordHash: hashedPassword,
+		Activated:    true,
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+
+	// Setup HTTP request and recorder
+	requestBody, err := json.Marshal(models.UserDeleteRequestDTO{Password: "WrongPassword123!"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodDelete, "/users", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/users", middleware.AuthorizeUser, userController.DeleteUser)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code) // Expect HTTP 403 Forbidden status
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.InvalidCredentials
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockUserRepository.AssertExpectations(t)
+	mockPostRepository.AssertExpectations(t)
+}
+
+// TestDeleteUserRollback tests if DeleteUser rolls back the transaction and returns 500-Internal Server Error when deleting fails
+func TestDeleteUserRollback(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockPostRepository := new(repositories.MockPostRepository)
+	userService := services.NewUserService(
+		mockUserRepository,
+		nil,
+		nil,
+		nil,
+		mockPostRepository,
+		nil,
+		nil,
+		nil,
+	)
+	userController := controllers.NewUserController(userService)
+
+	password := "Password123!"
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := models.User{
+		Username:     "testUser",
+		PasswordHash: hashedPassword,
+		Activated:    true,
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockTx := new(gorm.DB)
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+	mockUserRepository.On("BeginTx").Return(mockTx)
+	mockPostRepository.On("DeletePostsByUsernameTx", user.Username, mockTx).Return(nil)
+	mockUserRepository.On("DeleteUserTx", user.Username, mockTx).Return(gorm.ErrInvalidTransaction)
+	mockUserRepository.On("RollbackTx", mockTx).Return(nil)
+
+	// Setup HTTP request and recorder
+	requestBody, err := json.Marshal(models.UserDeleteRequestDTO{Password: password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodDelete, "/users", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.DELETE("/users", middleware.AuthorizeUser, userController.DeleteUser)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code) // Expect HTTP 500 Internal Server Error status
+	var errorResponse customerrors.ErrorResponse
+	err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+	assert.NoError(t, err)
+
+	expectedCustomError := customerrors.DatabaseError
+	assert.Equal(t, expectedCustomError.Message, errorResponse.Error.Message)
+	assert.Equal(t, expectedCustomError.Code, errorResponse.Error.Code)
+
+	mockUserRepository.AssertExpectations(t)
+	mockPostRepository.AssertExpectations(t)
+}
+
+// TestCreateUserLocaleFromAcceptLanguage tests if CreateUser stores the locale of the Accept-Language header and sends a German activation email
+func TestCreateUserLocaleFromAcceptLanguage(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	mockActivationTokenRepository := new(repositories.MockActivationTokenRepository)
+	mockMailService := new(services.MockMailService)
+	mockValidator := new(utils.MockValidator)
+
+	userService := services.NewUserService(
+		mockUserRepository,
+		mockActivationTokenRepository,
+		mockMailService,
+		mockValidator,
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+	userController := controllers.NewUserController(userService)
+
+	userRequest := models.UserCreateRequestDTO{
+		Username: "testUser",
+		Password: "Password123!",
+		Nickname: "Test User",
+		Email:    "test@domain.com",
+	}
+
+	// Mock expectations
+	mockTx := new(gorm.DB)
+	mockUserRepository.On("BeginTx").Return(mockTx)
+	mockUserRepository.On("CommitTx", mockTx).Return(nil)
+	mockUserRepository.On("CheckEmailExistsForUpdate", userRequest.Email, mockTx).Return(false, nil)
+	mockUserRepository.On("CheckUsernameExistsForUpdate", userRequest.Username, mockTx).Return(false, nil)
+	var capturedEmailBody string
+	mockMailService.On("SendMail", userRequest.Email, "Bestätige dein Konto", mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			capturedEmailBody = args.String(2)
+		}).Return(nil) // Send German mail successfully
+	mockValidator.On("ValidateEmailExistance", userRequest.Email).Return(true)
+
+	var capturedUser *models.User
+	mockUserRepository.On("CreateUserTx", mock.AnythingOfType("*models.User"), mockTx).
+		Run(func(args mock.Arguments) {
+			capturedUser = args.Get(0).(*models.User)
+		}).Return(nil)
+	mockActivationTokenRepository.On("CreateActivationTokenTx", mock.AnythingOfType("*models.ActivationToken"), mockTx).Return(nil)
+
+	// Setup HTTP request and recorder
+	requestBody, err := json.Marshal(userRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/users", userController.CreateUser)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "de", capturedUser.Locale)                // Expect locale of the header to be saved to database
+	assert.Contains(t, capturedEmailBody, "Bestätigungscode") // Expect email to be German
+
+	mockUserRepository.AssertExpectations(t)
+	mockMailService.AssertExpectations(t)
+}
+
+// TestGetUserLocaleSuccess tests if GetUserLocale returns 200-OK with the locale of the current user
+func TestGetUserLocaleSuccess(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	userService := services.NewUserService(mockUserRepository, nil, nil, nil, nil, nil, nil, nil)
+	userController := controllers.NewUserController(userService)
+
+	user := models.User{
+		Username: "testUser",
+		Locale:   "de",
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodGet, "/users/me/locale", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/users/me/locale", middleware.AuthorizeUser, userController.GetUserLocale)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responseLocale models.UserLocaleDTO
+	err = json.Unmarshal(w.Body.Bytes(), &responseLocale)
+	assert.NoError(t, err)
+	assert.Equal(t, "de", responseLocale.Locale)
+
+	mockUserRepository.AssertExpectations(t)
+}
+
+// TestUpdateUserLocaleSuccess tests if UpdateUserLocale returns 200-OK and uses the locale of the body or the Accept-Language header
+func TestUpdateUserLocaleSuccess(t *testing.T) {
+	tests := []struct {
+		body           string
+		acceptLanguage string
+		expectedLocale string
+	}{
+		{`{"locale": "de"}`, "", "de"},
+		{`{"locale": "en"}`, "de", "en"}, // body is preferred over the header
+		{"", "de-AT,en;q=0.5", "de"},
+		{`{}`, "en-GB", "en"},
+	}
+
+	for _, test := range tests {
+		// Arrange
+		mockUserRepository := new(repositories.MockUserRepository)
+		userService := services.NewUserService(mockUserRepository, nil, nil, nil, nil, nil, nil, nil)
+		userController := controllers.NewUserController(userService)
+
+		user := models.User{
+			Username: "testUser",
+			Locale:   "en",
+		}
+
+		authenticationToken, err := utils.GenerateAccessToken(user.Username)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Mock expectations
+		var capturedUpdatedUser *models.User
+		mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+		mockUserRepository.On("UpdateUser", mock.AnythingOfType("*models.User")).
+			Run(func(args mock.Arguments) {
+				capturedUpdatedUser = args.Get(0).(*models.User)
+			}).Return(nil)
+
+		// Setup HTTP request and recorder
+		req, _ := http.NewRequest(http.MethodPut, "/users/me/locale", bytes.NewBufferString(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", test.acceptLanguage)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.PUT("/users/me/locale", middleware.AuthorizeUser, userController.UpdateUserLocale)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var responseLocale models.UserLocaleDTO
+		err = json.Unmarshal(w.Body.Bytes(), &responseLocale)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedLocale, responseLocale.Locale)
+		assert.Equal(t, test.expectedLocale, capturedUpdatedUser.Locale)
+
+		mockUserRepository.AssertExpectations(t)
+	}
+}
+
+// TestUpdateUserLocaleBadRequest tests if UpdateUserLocale returns 400-Bad Request when the locale is not supported or the body is invalid
+func TestUpdateUserLocaleBadRequest(t *testing.T) {
+	invalidBodies := []string{
+		`{"locale": "fr"}`, // unsupported locale
+		`{"locale": 1}`,    // invalid type
+		`{locale: "de"}`,   // invalid json
+	}
+
+	for _, body := range invalidBodies {
+		// Arrange
+		mockUserRepository := new(repositories.MockUserRepository)
+		userService := services.NewUserService(mockUserRepository, nil, nil, nil, nil, nil, nil, nil)
+		userController := controllers.NewUserController(userService)
+
+		authenticationToken, err := utils.GenerateAccessToken("testUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Setup HTTP request and recorder
+		req, _ := http.NewRequest(http.MethodPut, "/users/me/locale", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.PUT("/users/me/locale", middleware.AuthorizeUser, userController.UpdateUserLocale)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var errorResponse struct {
+			Error customerrors.CustomError `json:"error"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, customerrors.BadRequest.Code, errorResponse.Error.Code)
+
+		mockUserRepository.AssertNotCalled(t, "UpdateUser", mock.Anything)
+	}
+}
+
+// TestGetUserProfilePresence tests if GetUserProfile returns the presence of the user unless it is hidden from the current user
+func TestGetUserProfilePresence(t *testing.T) {
+	lastSeen := time.Now().UTC().Add(-time.Hour)
+	tests := []struct {
+		name            string
+		visibility      string
+		currentUsername string
+		followsCurrent  bool
+		expectedVisible bool
+	}{
+		{"visible to everyone", services.PresenceVisibilityEveryone, "currentUser", false, true},
+		{"visible to contact", services.PresenceVisibilityContacts, "currentUser", true, true},
+		{"hidden from other user", services.PresenceVisibilityContacts, "currentUser", false, false},
+		{"visible on own profile", services.PresenceVisibilityContacts, "testUser", false, true},
+	}
+
+	for _, test := range tests {
+		// Arrange
+		mockUserRepository := new(repositories.MockUserRepository)
+		mockPostRepository := new(repositories.MockPostRepository)
+		mockSubscriptionRepository := new(repositories.MockSubscriptionRepository)
+		chatBroker := services.NewMemoryChatBroker()
+		presenceService := services.NewPresenceService(mockUserRepository, mockSubscriptionRepository, chatBroker)
+		userService := services.NewUserService(mockUserRepository, nil, nil, nil, mockPostRepository, nil, mockSubscriptionRepository, presenceService)
+		userController := controllers.NewUserController(userService)
+
+		user := models.User{
+			Username:           "testUser",
+			LastSeenAt:         &lastSeen,
+			PresenceVisibility: test.visibility,
+		}
+		_ = chatBroker.Join(uuid.New().String(), user.Username) // user is connected to a chat
+
+		authenticationToken, err := utils.GenerateAccessToken(test.currentUsername)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Mock expectations
+		mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+		mockPostRepository.On("GetPostCountByUsername", user.Username).Return(int64(0), nil)
+		mockSubscriptionRepository.On("GetSubscriptionCountByUsername", user.Username).Return(int64(0), int64(0), nil)
+		mockSubscriptionRepository.On("GetSubscriptionByUsernames", test.currentUsername, user.Username).Return(&models.Subscription{}, gorm.ErrRecordNotFound)
+		if test.followsCurrent {
+			mockSubscriptionRepository.On("GetFollowerUsernames", test.currentUsername, []string{user.Username}).Return([]string{user.Username}, nil)
+		} else {
+			mockSubscriptionRepository.On("GetFollowerUsernames", test.currentUsername, []string{user.Username}).Return([]string{}, nil)
+		}
+
+		// Setup HTTP request and recorder
+		req, _ := http.NewRequest(http.MethodGet, "/users/"+user.Username, nil)
+		req.Header.Set("Authorization", "Bearer "+authenticationToken)
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.GET("/users/:username", middleware.AuthorizeUser, userController.GetUserProfile)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code, test.name)
+
+		var responseDto models.UserProfileResponseDTO
+		err = json.Unmarshal(w.Body.Bytes(), &responseDto)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedVisible, responseDto.Online, test.name)
+		if test.expectedVisible {
+			if assert.NotNil(t, responseDto.LastSeen, test.name) {
+				assert.True(t, lastSeen.Equal(*responseDto.LastSeen), test.name)
+			}
+		} else {
+			assert.Nil(t, responseDto.LastSeen, test.name)
+		}
+	}
+}
+
+// TestGetPrivacySettingsSuccess tests if GetPrivacySettings returns the presence visibility and "everyone" for users without setting
+func TestGetPrivacySettingsSuccess(t *testing.T) {
+	tests := []struct {
+		visibility         string
+		expectedVisibility string
+	}{
+		{services.PresenceVisibilityContacts, services.PresenceVisibilityContacts},
+		{"", services.PresenceVisibilityEveryone},
+	}
+
+	for _, test := range tests {
+		// Arrange
+		mockUserRepository := new(repositories.MockUserRepository)
+		userService := services.NewUserService(mockUserRepository, nil, nil, nil, nil, nil, nil, nil)
+		userController := controllers.NewUserController(userService)
+
+		user := models.User{
+			Username:           "testUser",
+			PresenceVisibility: test.visibility,
+		}
+
+		authenticationToken, err := utils.GenerateAccessToken(user.Username)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Mock expectations
+		mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+
+		// Setup HTTP request and recorder
+		req, _ := http.NewRequest(http.MethodGet, "/users/me/privacy-settings", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.GET("/users/me/privacy-settings", middleware.AuthorizeUser, userController.GetPrivacySettings)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response models.UserPrivacySettingsDTO
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expectedVisibility, response.PresenceVisibility)
+
+		mockUserRepository.AssertExpectations(t)
+	}
+}
+
+// TestUpdatePrivacySettingsSuccess tests if UpdatePrivacySettings returns 200 OK and saves the new presence visibility
+func TestUpdatePrivacySettingsSuccess(t *testing.T) {
+	// Arrange
+	mockUserRepository := new(repositories.MockUserRepository)
+	userService := services.NewUserService(mockUserRepository, nil, nil, nil, nil, nil, nil, nil)
+	userController := controllers.NewUserController(userService)
+
+	user := models.User{
+		Username:           "testUser",
+		PresenceVisibility: services.PresenceVisibilityEveryone,
+	}
+
+	authenticationToken, err := utils.GenerateAccessToken(user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mock expectations
+	var capturedUpdatedUser *models.User
+	mockUserRepository.On("FindUserByUsername", user.Username).Return(&user, nil)
+	mockUserRepository.On("UpdateUser", mock.AnythingOfType("*models.User")).
+		Run(func(args mock.Arguments) {
+			capturedUpdatedUser = args.Get(0).(*models.User)
+		}).Return(nil)
+
+	// Setup HTTP request and recorder
+	req, _ := http.NewRequest(http.MethodPut, "/users/me/privacy-settings", bytes.NewBufferString(`{"presenceVisibility": "contacts"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+	w := httptest.NewRecorder()
+
+	// Act
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.PUT("/users/me/privacy-settings", middleware.AuthorizeUser, userController.UpdatePrivacySettings)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.UserPrivacySettingsDTO
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, services.PresenceVisibilityContacts, response.PresenceVisibility)
+	assert.Equal(t, services.PresenceVisibilityContacts, capturedUpdatedUser.PresenceVisibility)
+
+	mockUserRepository.AssertExpectations(t)
+}
+
+// TestUpdatePrivacySettingsBadRequest tests if UpdatePrivacySettings returns 400 Bad Request for unknown visibilities and invalid bodies
+func TestUpdatePrivacySettingsBadRequest(t *testing.T) {
+	invalidBodies := []string{
+		`{"presenceVisibility": "nobody"}`, // unknown visibility
+		`{}`,                               // missing visibility
+		`{presenceVisibility: "contacts"}`, // invalid json
+	}
+
+	for _, body := range invalidBodies {
+		// Arrange
+		mockUserRepository := new(repositories.MockUserRepository)
+		userService := services.NewUserService(mockUserRepository, nil, nil, nil, nil, nil, nil, nil)
+		userController := controllers.NewUserController(userService)
+
+		authenticationToken, err := utils.GenerateAccessToken("testUser")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Setup HTTP request and recorder
+		req, _ := http.NewRequest(http.MethodPut, "/users/me/privacy-settings", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authenticationToken))
+		w := httptest.NewRecorder()
+
+		// Act
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.PUT("/users/me/privacy-settings", middleware.AuthorizeUser, userController.UpdatePrivacySettings)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+
+		var errorResponse struct {
+			Error customerrors.CustomError `json:"error"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &errorResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, customerrors.BadRequest.Code, errorResponse.Error.Code)
+
+		mockUserRepository.AssertNotCalled(t, "UpdateUser", mock.Anything)
+	}
+}
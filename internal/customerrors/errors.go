@@ -176,4 +176,94 @@ var (
 		Code:       "ERR-028",
 		HttpStatus: 404,
 	}
+	DataExportNotFound = &CustomError{
+		Title:      "DataExportNotFound",
+		Message:    "The data export was not found or has expired. Please request a new export and try again.",
+		Code:       "ERR-029",
+		HttpStatus: 404,
+	}
+	DataExportNotReady = &CustomError{
+		Title:      "DataExportNotReady",
+		Message:    "The data export is not ready yet. Please wait for the email and try again.",
+		Code:       "ERR-030",
+		HttpStatus: 409,
+	}
+	UpdatePostForbidden = &CustomError{
+		Title:      "UpdatePostForbidden",
+		Message:    "You can only edit your own posts.",
+		Code:       "ERR-031",
+		HttpStatus: 403,
+	}
+	CommentNotFound = &CustomError{
+		Title:      "CommentNotFound",
+		Message:    "The comment was not found. Please check the comment ID and try again.",
+		Code:       "ERR-032",
+		HttpStatus: 404,
+	}
+	UpdateCommentForbidden = &CustomError{
+		Title:      "UpdateCommentForbidden",
+		Message:    "You can only edit your own comments.",
+		Code:       "ERR-033",
+		HttpStatus: 403,
+	}
+	DeleteCommentForbidden = &CustomError{
+		Title:      "DeleteCommentForbidden",
+		Message:    "You can only delete your own comments or comments under your own posts.",
+		Code:       "ERR-034",
+		HttpStatus: 403,
+	}
+	CommentDepthExceeded = &CustomError{
+		Title:      "CommentDepthExceeded",
+		Message:    "The comment cannot be replied to, because the maximum reply depth is reached.",
+		Code:       "ERR-035",
+		HttpStatus: 400,
+	}
+	CommentAlreadyLiked = &CustomError{
+		Title:      "CommentAlreadyLiked",
+		Message:    "You have already liked this comment.",
+		Code:       "ERR-036",
+		HttpStatus: 409,
+	}
+	CommentNotLiked = &CustomError{
+		Title:      "CommentNotLiked",
+		Message:    "You can't unlike a comment you haven't liked.",
+		Code:       "ERR-037",
+		HttpStatus: 409,
+	}
+	UpdateNotificationForbidden = &CustomError{
+		Title:      "UpdateNotificationForbidden",
+		Message:    "You can only mark your own notifications as read.",
+		Code:       "ERR-038",
+		HttpStatus: 403,
+	}
+	DigestSettingNotFound = &CustomError{
+		Title:      "DigestSettingNotFound",
+		Message:    "The unsubscribe link is invalid.",
+		Code:       "ERR-039",
+		HttpStatus: 404,
+	}
+	PushSubscriptionNotFound = &CustomError{
+		Title:      "PushSubscriptionNotFound",
+		Message:    "The push subscription was not found.",
+		Code:       "ERR-040",
+		HttpStatus: 404,
+	}
+	ChatAdminRequired = &CustomError{
+		Title:      "ChatAdminRequired",
+		Message:    "Only admins of the group chat can manage its members.",
+		Code:       "ERR-042",
+		HttpStatus: 403,
+	}
+	MessageNotFound = &CustomError{
+		Title:      "MessageNotFound",
+		Message:    "The message was not found.",
+		Code:       "ERR-043",
+		HttpStatus: 404,
+	}
+	MessageModificationForbidden = &CustomError{
+		Title:      "MessageModificationForbidden",
+		Message:    "You can only edit or delete your own messages.",
+		Code:       "ERR-044",
+		HttpStatus: 403,
+	}
 )
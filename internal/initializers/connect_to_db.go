@@ -13,6 +13,18 @@ var DB *gorm.DB // Global variable for database
 func ConnectToDb() {
 	var err error
 
+	DB, err = gorm.Open(postgres.Open(DatabaseDsn()))
+
+	if err != nil {
+		panic("Failed to connect to db")
+	}
+
+	fmt.Println("Connection to database successful...")
+
+}
+
+// DatabaseDsn returns the connection string of the database, it is also needed for connections outside of gorm
+func DatabaseDsn() string {
 	dbHost := os.Getenv("DB_HOST")
 	dbUser := os.Getenv("DB_USER")
 	dbPassword := os.Getenv("DB_PASSWORD")
@@ -20,17 +32,8 @@ func ConnectToDb() {
 	dbPort := os.Getenv("DB_PORT")
 	dbSSLMode := os.Getenv("DB_SSL_MODE")
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
 		dbHost, dbUser, dbPassword, dbName, dbPort, dbSSLMode)
-
-	DB, err = gorm.Open(postgres.Open(dsn))
-
-	if err != nil {
-		panic("Failed to connect to db")
-	}
-
-	fmt.Println("Connection to database successful...")
-
 }
 
 // CloseDbConnection can be called when program execution is stopped, to close database connection
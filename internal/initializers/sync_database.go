@@ -3,6 +3,7 @@ package initializers
 import (
 	"fmt"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"gorm.io/gorm"
 )
 
 // SyncDatabase synchronizes the database tables with the model definitions and creates extensions if necessary
@@ -22,15 +23,26 @@ func SyncDatabase() {
 		&models.Location{},
 		&models.ActivationToken{},
 		&models.Post{},
+		&models.PostRevision{},
 		&models.Comment{},
 		&models.Like{},
+		&models.CommentLike{},
 		&models.Hashtag{},
 		&models.Subscription{},
 		&models.Notification{},
+		&models.NotificationSetting{},
+		&models.NotificationMute{},
 		&models.PushSubscription{},
+		&models.PushDelivery{},
+		&models.PushDeliveryAttempt{},
 		&models.Chat{},
+		&models.ChatUser{},
 		&models.Message{},
+		&models.MessageReaction{},
+		&models.ChatConnection{},
 		&models.PasswordResetToken{},
+		&models.DataExport{},
+		&models.DigestSetting{},
 	}
 
 	for _, model := range modelsToMigrate {
@@ -39,5 +51,28 @@ func SyncDatabase() {
 		}
 	}
 
+	if err := migrateMessageSequences(); err != nil {
+		panic(fmt.Sprintf("Failed to migrate message sequences: %v", err))
+	}
+
 	fmt.Println("Synchronizing database successful...")
 }
+
+// migrateMessageSequences numbers messages that were created before messages had sequence numbers
+// and creates the unique index afterwards, because it cannot be created while these messages share the number 0
+func migrateMessageSequences() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`UPDATE messages SET sequence = numbered.sequence
+			FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY chat_id ORDER BY created_at, id) AS sequence FROM messages) AS numbered
+			WHERE messages.id = numbered.id AND messages.chat_id IN (SELECT chat_id FROM messages WHERE sequence = 0)`).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`UPDATE chats SET last_sequence = (SELECT COALESCE(MAX(sequence), 0) FROM messages WHERE messages.chat_id = chats.id)
+			WHERE last_sequence = 0`).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_chat_sequence ON messages (chat_id, sequence)").Error
+	})
+}
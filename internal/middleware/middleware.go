@@ -1,43 +1,67 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"github.com/gin-gonic/gin"
 	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
 	"github.com/wwi21seb-projekt/server-beta/internal/utils"
 	"net/http"
+	"os"
 	"strings"
 )
 
-// AuthorizeUser validates token and attaches username of user to request, if token invalid aborts with error
+// AuthorizeUser validates token and attaches username and device session of user to request, if token invalid aborts with error
 func AuthorizeUser(c *gin.Context) {
-	username, ok := GetLoggedInUsername(c)
+	username, sessionId, ok := getLoggedInSession(c)
 	if !ok {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 			"error": customerrors.Unauthorized,
 		})
 	}
 
-	c.Set("username", username) // Attach username to request
-	c.Next()                    // Execute main function
+	c.Set("username", username)   // Attach username to request
+	c.Set("sessionId", sessionId) // Attach device session to request, empty if token has no session
+	c.Next()                      // Execute main function
+}
+
+// AuthorizeInternal validates the internal api key for endpoints that are only used for operating the server,
+// if no key is configured the endpoints cannot be used
+func AuthorizeInternal(c *gin.Context) {
+	internalApiKey := os.Getenv("INTERNAL_API_KEY")
+	requestApiKey := c.GetHeader("X-Internal-Api-Key")
+	if internalApiKey == "" || subtle.ConstantTimeCompare([]byte(internalApiKey), []byte(requestApiKey)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": customerrors.Unauthorized,
+		})
+		return
+	}
+
+	c.Next()
 }
 
 // GetLoggedInUsername returns the username of the logged-in user and true if the user is logged in
 func GetLoggedInUsername(c *gin.Context) (string, bool) {
+	username, _, ok := getLoggedInSession(c)
+	return username, ok
+}
+
+// getLoggedInSession returns the username and device session id of the logged-in user and true if the user is logged in
+func getLoggedInSession(c *gin.Context) (string, string, bool) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		return "", false
+		return "", "", false
 	}
 
 	const bearerSchema = "Bearer "
 	if !strings.HasPrefix(authHeader, bearerSchema) {
-		return "", false
+		return "", "", false
 	}
 
 	tokenString := strings.TrimPrefix(authHeader, bearerSchema)
-	username, isRefresh, err := utils.VerifyJWTToken(tokenString)
+	username, sessionId, isRefresh, err := utils.VerifySessionJWTToken(tokenString)
 	if err != nil || isRefresh {
-		return "", false
+		return "", "", false
 	}
 
-	return username, true
+	return username, sessionId, true
 }
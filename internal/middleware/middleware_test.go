@@ -113,3 +113,37 @@ func TestGetLoggedInUsernameInvalidToken(t *testing.T) {
 		assert.Equal(t, "", username)
 	}
 }
+
+// TestAuthorizeInternal tests the AuthorizeInternal function if it only continues with the configured internal api key
+func TestAuthorizeInternal(t *testing.T) {
+	testCases := []struct {
+		configuredKey string
+		requestKey    string
+		expectedCode  int
+	}{
+		{"internalKey", "internalKey", http.StatusOK},
+		{"internalKey", "wrongKey", http.StatusUnauthorized},
+		{"internalKey", "", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized}, // endpoints are disabled if no key is configured
+	}
+
+	for _, testCase := range testCases {
+		// Setup
+		t.Setenv("INTERNAL_API_KEY", testCase.configuredKey)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+		router.GET("/test", middleware.AuthorizeInternal, func(c *gin.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+
+		// Act
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Internal-Api-Key", testCase.requestKey)
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, testCase.expectedCode, w.Code)
+	}
+}
@@ -6,14 +6,38 @@ import (
 )
 
 type Chat struct {
-	Id        uuid.UUID `gorm:"column:id;primary_key"`
-	Users     []User    `gorm:"many2many:chat_users;onDelete:CASCADE"` // gorm handles the join table
-	CreatedAt time.Time `gorm:"column:created_at;not_null"`
+	Id        uuid.UUID  `gorm:"column:id;primary_key"`
+	IsGroup   bool       `gorm:"column:is_group;not_null;default:false"`
+	Name      string     `gorm:"column:name;type:varchar(50)"` // only used for group chats
+	ImageId   *uuid.UUID `gorm:"column:image_id;null"`         // optional picture of a group chat
+	Image     Image      `gorm:"foreignKey:image_id;references:id"`
+	Users     []User     `gorm:"many2many:chat_users;onDelete:CASCADE"` // gorm handles the join table
+	Members   []ChatUser `gorm:"foreignKey:chat_id;references:id"`      // entries of the join table with the role of each user
+	CreatedAt time.Time  `gorm:"column:created_at;not_null"`
+	// LastSequence is the sequence number of the newest message, messages of a chat are numbered consecutively starting with 1
+	LastSequence int64 `gorm:"column:last_sequence;not_null;default:0"`
+}
+
+// ChatUser is an entry of the chat_users join table and stores the role of a user in a chat
+type ChatUser struct {
+	ChatId            uuid.UUID  `gorm:"column:chat_id;primary_key"`
+	UserUsername      string     `gorm:"column:user_username;primary_key;type:varchar(20)"`
+	Role              string     `gorm:"column:role;type:varchar(10);default:'member'"` // "admin" or "member"
+	JoinedAt          time.Time  `gorm:"column:joined_at"`
+	LastReadMessageId *uuid.UUID `gorm:"column:last_read_message_id;type:uuid;null"`
+	LastReadAt        *time.Time `gorm:"column:last_read_at;null"` // creation date of the last read message, newer messages are unread
+}
+
+func (ChatUser) TableName() string {
+	return "chat_users"
 }
 
 type ChatCreateRequestDTO struct {
-	Content  string `json:"content" binding:"required"`
-	Username string `json:"username" binding:"required"`
+	Content   string   `json:"content" binding:"required"`
+	Username  string   `json:"username"`  // other user of a direct chat
+	Usernames []string `json:"usernames"` // other users of a group chat
+	Name      string   `json:"name"`      // name of a group chat
+	Picture   string   `json:"picture"`   // optional base64 encoded picture of a group chat
 }
 
 type ChatCreateResponseDTO struct {
@@ -21,11 +45,43 @@ type ChatCreateResponseDTO struct {
 	Message *MessageRecordDTO `json:"message"`
 }
 
+type ChatParticipantDTO struct {
+	Username          string            `json:"username"`
+	Nickname          string            `json:"nickname"`
+	Picture           *ImageMetadataDTO `json:"picture"`
+	Role              string            `json:"role"`
+	LastReadMessageId *string           `json:"lastReadMessageId"`
+	Online            bool              `json:"online"`
+	LastSeen          *time.Time        `json:"lastSeen"` // null if the participant hides the presence from the current user
+}
+
 type ChatRecordDTO struct {
-	ChatId string   `json:"chatId"`
-	User   *UserDTO `json:"user"`
+	ChatId       string               `json:"chatId"`
+	IsGroup      bool                 `json:"isGroup"`
+	Name         string               `json:"name"`
+	Picture      *ImageMetadataDTO    `json:"picture"`
+	Participants []ChatParticipantDTO `json:"participants"`
+	UnreadCount  int64                `json:"unreadCount"`
 }
 
 type ChatsResponseDTO struct {
 	Records []ChatRecordDTO `json:"records"`
 }
+
+type ChatMembersAddRequestDTO struct {
+	Usernames []string `json:"usernames" binding:"required"`
+}
+
+type ChatMemberRoleUpdateRequestDTO struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ChatConnection counts the websocket connections of a user to a chat on one server instance,
+// so that all instances know which participants are connected to a chat
+type ChatConnection struct {
+	InstanceId  string    `gorm:"column:instance_id;primary_key;type:varchar(36)"`
+	ChatId      uuid.UUID `gorm:"column:chat_id;primary_key"`
+	Username    string    `gorm:"column:username;primary_key;type:varchar(20)"`
+	Connections int       `gorm:"column:connections;not_null"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;not_null"` // refreshed regularly by the instance, rows of crashed instances become stale
+}
@@ -6,24 +6,39 @@ import (
 )
 
 type Comment struct {
-	Id        uuid.UUID `gorm:"column:id;primary_key"`
-	PostID    uuid.UUID `gorm:"column:post_id"`
-	Post      Post      `gorm:"foreignKey:post_id;references:id"`
-	Username  string    `gorm:"column:username_fk;type:varchar(20)"`
-	User      User      `gorm:"foreignKey:username_fk;references:username"`
-	Content   string    `gorm:"column:content;type:varchar(128);not_null"`
-	CreatedAt time.Time `gorm:"column:created_at;not_null"`
+	Id              uuid.UUID  `gorm:"column:id;primary_key"`
+	PostID          uuid.UUID  `gorm:"column:post_id"`
+	Post            Post       `gorm:"foreignKey:post_id;references:id"`
+	Username        string     `gorm:"column:username_fk;type:varchar(20)"`
+	User            User       `gorm:"foreignKey:username_fk;references:username"`
+	Content         string     `gorm:"column:content;type:varchar(128);not_null"`
+	CreatedAt       time.Time  `gorm:"column:created_at;not_null"`
+	EditedAt        *time.Time `gorm:"column:edited_at;null"`                       // nil if the comment was never edited
+	ParentCommentId *uuid.UUID `gorm:"column:parent_comment_id;null"`               // nil for top-level comments, replies are deleted together with their parent
+	Depth           int        `gorm:"column:depth;not_null;default:0"`             // 0 for top-level comments, parent depth + 1 for replies
+	Mentions        []User     `gorm:"many2many:comment_mentions;onDelete:CASCADE"` // users mentioned with @username in the content, gorm handles the join table
 }
 
 type CommentCreateRequestDTO struct {
+	Content         string `json:"content" binding:"required"`
+	ParentCommentId string `json:"parentCommentId"` // optional, set to reply to another comment
+}
+
+type CommentUpdateRequestDTO struct {
 	Content string `json:"content" binding:"required"`
 }
 
 type CommentResponseDTO struct {
-	CommentId    uuid.UUID `json:"commentId"`
-	Content      string    `json:"content"`
-	Author       *UserDTO  `json:"author"`
-	CreationDate time.Time `json:"creationDate"`
+	CommentId       uuid.UUID  `json:"commentId"`
+	Content         string     `json:"content"`
+	Author          *UserDTO   `json:"author"`
+	CreationDate    time.Time  `json:"creationDate"`
+	EditedAt        *time.Time `json:"editedAt"`
+	ParentCommentId *uuid.UUID `json:"parentCommentId"`
+	Replies         int64      `json:"replies"`
+	Likes           int64      `json:"likes"`
+	Liked           bool       `json:"liked"`
+	Mentions        []UserDTO  `json:"mentions"`
 }
 
 type CommentFeedResponseDTO struct {
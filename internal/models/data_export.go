@@ -0,0 +1,124 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type DataExport struct {
+	Id             uuid.UUID `gorm:"column:id;primary_key"`
+	Username       string    `gorm:"column:username_fk;type:varchar(20)"`
+	User           User      `gorm:"foreignKey:username_fk;references:username"`
+	Status         string    `gorm:"column:status;type:varchar(10);not_null"` // either "pending", "ready" or "failed"
+	Token          string    `gorm:"column:token;type:varchar(64);not_null"`  // secret token that is needed to download the archive
+	Data           []byte    `gorm:"column:data;type:bytea"`                  // zip archive, only set when status is "ready"
+	CreatedAt      time.Time `gorm:"column:created_at;not_null"`
+	ExpirationTime time.Time `gorm:"column:expiration_time;not_null"`
+}
+
+type DataExportResponseDTO struct {
+	ExportId     string    `json:"exportId"`
+	Status       string    `json:"status"`
+	CreationDate time.Time `json:"creationDate"`
+}
+
+type UserExportData struct { // to be used for collecting all data of a user from the database
+	User                 User
+	DigestSetting        *DigestSetting // nil if the user never changed the digest frequency
+	Posts                []Post
+	PostRevisions        []PostRevision
+	Comments             []Comment
+	Likes                []Like
+	CommentLikes         []CommentLike
+	Subscriptions        []Subscription
+	Notifications        []Notification
+	NotificationSettings []NotificationSetting
+	NotificationMutes    []NotificationMute
+	PushSubscriptions    []PushSubscription
+	Messages             []Message
+}
+
+type ExportProfileDTO struct {
+	Username           string     `json:"username"`
+	Nickname           string     `json:"nickname"`
+	Email              string     `json:"email"`
+	Status             string     `json:"status"`
+	CreationDate       time.Time  `json:"creationDate"`
+	Picture            string     `json:"picture"` // file name of the picture in the archive
+	DigestFrequency    string     `json:"digestFrequency"`
+	Locale             string     `json:"locale"`
+	PresenceVisibility string     `json:"presenceVisibility"`
+	LastSeen           *time.Time `json:"lastSeen"`
+}
+
+type ExportPostDTO struct {
+	PostId         string                  `json:"postId"`
+	Content        string                  `json:"content"`
+	CreationDate   time.Time               `json:"creationDate"`
+	Hashtags       []string                `json:"hashtags"`
+	Location       *LocationDTO            `json:"location"`
+	Picture        string                  `json:"picture"` // file name of the picture in the archive
+	RepostedPostId string                  `json:"repostedPostId"`
+	Revisions      []ExportPostRevisionDTO `json:"revisions"` // previous versions of the post, newest first
+}
+
+type ExportPostRevisionDTO struct {
+	Content      string       `json:"content"`
+	Location     *LocationDTO `json:"location"`
+	CreationDate time.Time    `json:"creationDate"` // time at which this version was replaced
+}
+
+type ExportCommentDTO struct {
+	CommentId    string    `json:"commentId"`
+	PostId       string    `json:"postId"`
+	Content      string    `json:"content"`
+	CreationDate time.Time `json:"creationDate"`
+}
+
+type ExportLikeDTO struct {
+	PostId string `json:"postId"`
+}
+
+type ExportCommentLikeDTO struct {
+	CommentId string `json:"commentId"`
+}
+
+type ExportSubscriptionDTO struct {
+	SubscriptionId   string    `json:"subscriptionId"`
+	SubscriptionDate time.Time `json:"subscriptionDate"`
+	Follower         string    `json:"follower"`
+	Following        string    `json:"following"`
+}
+
+type ExportNotificationDTO struct {
+	NotificationId   string    `json:"notificationId"`
+	Timestamp        time.Time `json:"timestamp"`
+	NotificationType string    `json:"notificationType"`
+	ForUsername      string    `json:"forUsername"`
+	FromUsername     string    `json:"fromUsername"`
+}
+
+type ExportNotificationSettingsDTO struct {
+	Settings   []NotificationTypeSettingDTO `json:"settings"` // notification types that are not listed are enabled
+	MutedUsers []ExportMutedUserDTO         `json:"mutedUsers"`
+}
+
+type ExportMutedUserDTO struct {
+	Username  string    `json:"username"`
+	MutedDate time.Time `json:"mutedDate"`
+}
+
+type ExportPushSubscriptionDTO struct {
+	SubscriptionId string    `json:"subscriptionId"`
+	Type           string    `json:"type"`
+	Token          string    `json:"token"` // endpoint of web subscriptions, token of all other types
+	CreationDate   time.Time `json:"creationDate"`
+}
+
+type ExportMessageDTO struct {
+	ChatId       string    `json:"chatId"`
+	Username     string    `json:"username"`
+	Content      string    `json:"content"`
+	Picture      string    `json:"picture"` // file name of the picture in the archive
+	CreationDate time.Time `json:"creationDate"`
+}
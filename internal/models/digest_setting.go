@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type DigestSetting struct {
+	Id               uuid.UUID  `gorm:"column:id;primary_key"`
+	Username         string     `gorm:"column:username_fk;type:varchar(20);uniqueIndex"`
+	User             User       `gorm:"foreignKey:username_fk;references:username"`
+	Frequency        string     `gorm:"column:frequency;type:varchar(10);not_null"`         // either "daily", "weekly" or "off"
+	UnsubscribeToken string     `gorm:"column:unsubscribe_token;type:varchar(64);not_null"` // secret token for the one-click unsubscribe link in every digest
+	LastSentAt       *time.Time `gorm:"column:last_sent_at;null"`
+}
+
+type DigestSettingUpdateRequestDTO struct {
+	Frequency string `json:"frequency" binding:"required"`
+}
+
+type DigestSettingResponseDTO struct {
+	Frequency string `json:"frequency"`
+}
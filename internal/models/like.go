@@ -11,3 +11,11 @@ type Like struct {
 	Username string    `gorm:"column:username_fk"`
 	User     User      `gorm:"foreignKey:username_fk;references:username"`
 }
+
+type CommentLike struct {
+	Id        uuid.UUID `gorm:"column:id;primary_key"`
+	CommentId uuid.UUID `gorm:"column:comment_id"`
+	Comment   Comment   `gorm:"foreignKey:comment_id;references:id"`
+	Username  string    `gorm:"column:username_fk"`
+	User      User      `gorm:"foreignKey:username_fk;references:username"`
+}
@@ -1,24 +1,52 @@
 package models
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
 type Message struct {
-	Id        uuid.UUID `gorm:"column:id;primary_key"`
-	ChatId    uuid.UUID `gorm:"column:chat_id"`
-	Chat      Chat      `gorm:"foreignKey:chat_id;references:id"`
-	Username  string    `gorm:"column:username_fk;type:varchar(20)"`
+	Id        uuid.UUID         `gorm:"column:id;primary_key"`
+	ChatId    uuid.UUID         `gorm:"column:chat_id"`
+	Chat      Chat              `gorm:"foreignKey:chat_id;references:id"`
+	Sequence  int64             `gorm:"column:sequence;not_null;default:0"` // consecutive number of the message in its chat, unique per chat
+	Username  string            `gorm:"column:username_fk;type:varchar(20)"`
+	User      User              `gorm:"foreignKey:username_fk;references:username"`
+	Content   string            `gorm:"column:content;type:varchar(256);null"`
+	ImageId   *uuid.UUID        `gorm:"column:image_id;null"` // optional picture attached to the message
+	Image     Image             `gorm:"foreignKey:image_id;references:id"`
+	CreatedAt time.Time         `gorm:"column:created_at;not_null"`
+	EditedAt  *time.Time        `gorm:"column:edited_at;null"`
+	Deleted   bool              `gorm:"column:deleted;not_null;default:false"` // content of deleted messages is removed, the message is shown as deleted
+	Reactions []MessageReaction `gorm:"foreignKey:message_id;references:id"`
+}
+
+// MessageReaction is an emoji reaction of a user to a message, every user can react with one emoji per message
+type MessageReaction struct {
+	MessageId uuid.UUID `gorm:"column:message_id;primary_key"`
+	Username  string    `gorm:"column:username_fk;primary_key;type:varchar(20)"`
 	User      User      `gorm:"foreignKey:username_fk;references:username"`
-	Content   string    `gorm:"column:content;type:varchar(256);null"`
-	CreatedAt time.Time `gorm:"column:created_at;not_null"`
+	Emoji     string    `gorm:"column:emoji;type:varchar(32)"`
+	CreatedAt time.Time `gorm:"column:created_at"`
 }
 
 type MessageRecordDTO struct {
-	Content      string    `json:"content"`
-	Username     string    `json:"username"`
-	CreationDate time.Time `json:"creationDate"`
+	MessageId    string               `json:"messageId"`
+	Sequence     int64                `json:"sequence"`
+	Content      string               `json:"content"`
+	Picture      *ImageMetadataDTO    `json:"picture"`
+	Username     string               `json:"username"`
+	CreationDate time.Time            `json:"creationDate"`
+	EditDate     *time.Time           `json:"editDate"`
+	Deleted      bool                 `json:"deleted"`
+	Reactions    []MessageReactionDTO `json:"reactions"`
+}
+
+type MessageReactionDTO struct {
+	Emoji     string   `json:"emoji"`
+	Count     int      `json:"count"`
+	Usernames []string `json:"usernames"`
 }
 
 type MessagesResponseDTO struct {
@@ -26,6 +54,58 @@ type MessagesResponseDTO struct {
 	Pagination *OffsetPaginationDTO `json:"pagination"`
 }
 
+// MessageCreateRequestDTO needs a content, a picture or both
 type MessageCreateRequestDTO struct {
+	Content string `json:"content"`
+	Picture string `json:"picture"` // optional base64 encoded picture
+}
+
+type MessageUpdateRequestDTO struct {
 	Content string `json:"content" binding:"required"`
 }
+
+type MessageReactionRequestDTO struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+// ChatSocketRequestDTO is sent by clients on the chat websocket, requests without type create a message
+type ChatSocketRequestDTO struct {
+	Type      string `json:"type"`
+	Content   string `json:"content"`
+	Picture   string `json:"picture"`   // optional base64 encoded picture of a new message
+	MessageId string `json:"messageId"` // message that is edited, deleted or reacted to
+	Emoji     string `json:"emoji"`     // reaction to the message, an empty emoji removes the reaction
+}
+
+// ChatEventDTO is sent to the participants of a chat for read receipts, typing indicators and changes of messages
+type ChatEventDTO struct {
+	Type      string            `json:"type"`
+	Username  string            `json:"username"`
+	MessageId *string           `json:"messageId"` // last read message of read receipts or the changed message
+	Message   *MessageRecordDTO `json:"message"`   // changed message of edit, delete and reaction events
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// ChatEnvelopeDTO is sent in both directions on the user websocket that covers all chats of a user,
+// the payload depends on the type, e.g. a ChatSocketRequestDTO for messages from the client
+type ChatEnvelopeDTO struct {
+	Type    string          `json:"type"`
+	ChatId  string          `json:"chatId"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ChatSubscribeRequestDTO is the optional payload of a subscription, missed messages after the sequence number are replayed
+type ChatSubscribeRequestDTO struct {
+	Since *int64 `json:"since"`
+}
+
+// ChatSubscriptionDTO confirms a subscription with the participants that are currently connected to the chat
+type ChatSubscriptionDTO struct {
+	OnlineUsernames []string `json:"onlineUsernames"`
+}
+
+// ChatPresenceDTO is sent if a participant connects to or disconnects from a chat
+type ChatPresenceDTO struct {
+	Username string `json:"username"`
+	Online   bool   `json:"online"`
+}
@@ -6,22 +6,35 @@ import (
 )
 
 type Notification struct {
-	Id               uuid.UUID `gorm:"column:id;primaryKey"`
-	Timestamp        time.Time `gorm:"column:timestamp"`
-	NotificationType string    `gorm:"column:notification_type"`
-	ForUsername      string    `gorm:"column:for_username"` // the user that the notification is for
-	ForUser          User      `gorm:"foreignKey:for_username;references:username"`
-	FromUsername     string    `gorm:"column:from_username"` // the user that created the notification by following or reposting
-	FromUser         User      `gorm:"foreignKey:from_username;references:username"`
+	Id               uuid.UUID  `gorm:"column:id;primaryKey"`
+	Timestamp        time.Time  `gorm:"column:timestamp"`
+	NotificationType string     `gorm:"column:notification_type"`
+	ForUsername      string     `gorm:"column:for_username"` // the user that the notification is for
+	ForUser          User       `gorm:"foreignKey:for_username;references:username"`
+	FromUsername     string     `gorm:"column:from_username"` // the user that created the notification by following or reposting
+	FromUser         User       `gorm:"foreignKey:from_username;references:username"`
+	PostId           *uuid.UUID `gorm:"column:post_id;null"`             // the post the notification refers to, e.g. the liked or commented post
+	CommentId        *uuid.UUID `gorm:"column:comment_id;null"`          // the comment the notification refers to
+	Count            int        `gorm:"column:count;not_null;default:1"` // number of grouped events, e.g. likes on the same post
+	Read             bool       `gorm:"column:read;not_null;default:false"`
 }
 
 type NotificationRecordDTO struct {
-	NotificationId   string    `json:"notificationId"`
-	Timestamp        time.Time `json:"timestamp"`
-	NotificationType string    `json:"notificationType"`
-	User             *UserDTO  `json:"user"`
+	NotificationId   string     `json:"notificationId"`
+	Timestamp        time.Time  `json:"timestamp"`
+	NotificationType string     `json:"notificationType"`
+	User             *UserDTO   `json:"user"`
+	PostId           *uuid.UUID `json:"postId"`
+	CommentId        *uuid.UUID `json:"commentId"`
+	Count            int        `json:"count"`
+	Read             bool       `json:"read"`
 }
 
 type NotificationsResponseDTO struct {
-	Records []NotificationRecordDTO `json:"records"`
+	Records    []NotificationRecordDTO          `json:"records"`
+	Pagination *NotificationCursorPaginationDTO `json:"pagination"`
+}
+
+type NotificationUnreadCountResponseDTO struct {
+	UnreadCount int64 `json:"unreadCount"`
 }
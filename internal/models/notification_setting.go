@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type NotificationSetting struct { // missing settings of a user mean that the notification type is enabled for in-app and push
+	Id               uuid.UUID `gorm:"column:id;primary_key"`
+	Username         string    `gorm:"column:username_fk;type:varchar(20);uniqueIndex:idx_notification_settings_user_type"`
+	User             User      `gorm:"foreignKey:username_fk;references:username"`
+	NotificationType string    `gorm:"column:notification_type;type:varchar(20);uniqueIndex:idx_notification_settings_user_type"`
+	InApp            bool      `gorm:"column:in_app;not_null"` // notification is stored and shown in the app
+	Push             bool      `gorm:"column:push;not_null"`   // notification is sent to the push subscriptions of the user
+}
+
+type NotificationMute struct {
+	Id            uuid.UUID `gorm:"column:id;primary_key"`
+	Username      string    `gorm:"column:username_fk;type:varchar(20);uniqueIndex:idx_notification_mutes_user_muted"` // the user that muted another user
+	User          User      `gorm:"foreignKey:username_fk;references:username"`
+	MutedUsername string    `gorm:"column:muted_username;type:varchar(20);uniqueIndex:idx_notification_mutes_user_muted"` // the user whose actions do not create notifications anymore
+	MutedUser     User      `gorm:"foreignKey:muted_username;references:username"`
+	CreatedAt     time.Time `gorm:"column:created_at;not_null"`
+}
+
+type NotificationTypeSettingDTO struct {
+	NotificationType string `json:"notificationType"`
+	InApp            bool   `json:"inApp"`
+	Push             bool   `json:"push"`
+}
+
+type NotificationSettingsResponseDTO struct {
+	Settings   []NotificationTypeSettingDTO `json:"settings"`
+	MutedUsers []UserDTO                    `json:"mutedUsers"`
+}
+
+type NotificationSettingsUpdateRequestDTO struct { // replaces all settings, types that are not included are enabled again
+	Settings       []NotificationTypeSettingDTO `json:"settings" binding:"required"`
+	MutedUsernames []string                     `json:"mutedUsernames"`
+}
@@ -11,3 +11,9 @@ type PostCursorPaginationDTO struct {
 	Limit      int    `json:"limit"`
 	Records    int64  `json:"records"`
 }
+
+type NotificationCursorPaginationDTO struct {
+	LastNotificationId string `json:"lastNotificationId"`
+	Limit              int    `json:"limit"`
+	Records            int64  `json:"records"`
+}
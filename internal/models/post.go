@@ -13,10 +13,12 @@ type Post struct {
 	ImageId    *uuid.UUID `gorm:"column:image_id;null"`
 	Image      Image      `gorm:"foreignKey:image_id;references:id"`
 	Hashtags   []Hashtag  `gorm:"many2many:post_hashtags;onDelete:CASCADE"` // gorm handles the join table, onDelete:CASCADE deletes the hashtags if the post is deleted
+	Mentions   []User     `gorm:"many2many:post_mentions;onDelete:CASCADE"` // users mentioned with @username in the content, gorm handles the join table
 	CreatedAt  time.Time  `gorm:"column:created_at;not_null"`
 	LocationId *uuid.UUID `gorm:"column:location_id;null"`
 	Location   Location   `gorm:"foreignKey:location_id;references:id"`
 	RepostId   *uuid.UUID `gorm:"column:repost_id;null"` // no foreign key constraint, original post may be deleted without affecting repost
+	EditedAt   *time.Time `gorm:"column:edited_at;null"` // nil if the post was never edited
 }
 
 type PostCreateRequestDTO struct {
@@ -26,6 +28,11 @@ type PostCreateRequestDTO struct {
 	RepostedPostId string       `json:"repostedPostId"`
 }
 
+type PostUpdateRequestDTO struct { // fields that are nil are not changed
+	Content  *string      `json:"content"`
+	Location *LocationDTO `json:"location"`
+}
+
 type PostResponseDTO struct {
 	PostId       uuid.UUID         `json:"postId"`
 	Author       *UserDTO          `json:"author"`
@@ -37,6 +44,13 @@ type PostResponseDTO struct {
 	Liked        bool              `json:"liked"`
 	Location     *LocationDTO      `json:"location"`
 	Repost       *PostResponseDTO  `json:"repost"`
+	EditedAt     *time.Time        `json:"editedAt"`
+	Mentions     []UserDTO         `json:"mentions"`
+}
+
+type PostDetailResponseDTO struct { // to be used for response to single post request, contains the first page of comments
+	PostResponseDTO
+	CommentFeed *CommentFeedResponseDTO `json:"commentFeed"`
 }
 
 type GeneralFeedDTO struct { // to be used for response to general feed request
@@ -59,4 +73,6 @@ type UserFeedRecordDTO struct { // Post response dto without author for user fee
 	Liked        bool              `json:"liked"`
 	Location     *LocationDTO      `json:"location"`
 	Repost       *PostResponseDTO  `json:"repost"`
+	EditedAt     *time.Time        `json:"editedAt"`
+	Mentions     []UserDTO         `json:"mentions"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type PostRevision struct { // previous version of a post, created each time the post is edited
+	Id         uuid.UUID  `gorm:"column:id;primary_key"`
+	PostId     uuid.UUID  `gorm:"column:post_id"`
+	Post       Post       `gorm:"foreignKey:post_id;references:id"`
+	Content    string     `gorm:"column:content;type:varchar(256);null"`
+	LocationId *uuid.UUID `gorm:"column:location_id;null"`
+	Location   Location   `gorm:"foreignKey:location_id;references:id"`
+	CreatedAt  time.Time  `gorm:"column:created_at;not_null"` // time at which this version was replaced
+}
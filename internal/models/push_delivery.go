@@ -0,0 +1,59 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PushDelivery is an entry of the push outbox, every push message is stored before it is sent to a subscription
+type PushDelivery struct {
+	Id             uuid.UUID             `gorm:"column:id;primary_key"`
+	SubscriptionId uuid.UUID             `gorm:"column:subscription_id;type:uuid;index"` // no foreign key, so that the log is kept when the subscription is deleted
+	Username       string                `gorm:"column:username_fk;type:varchar(20)"`
+	User           User                  `gorm:"foreignKey:username_fk;references:username"`
+	Type           string                `gorm:"column:type;type:varchar(4)"` // "web", "expo", "fcm" or "apns"
+	Title          string                `gorm:"column:title;type:text"`      // notification title in the locale of the user
+	Payload        string                `gorm:"column:payload;type:text"`    // notification as json string
+	Text           string                `gorm:"column:text;type:text"`       // notification text, not used for web
+	Status         string                `gorm:"column:status;type:varchar(10);index"`
+	Attempts       int                   `gorm:"column:attempts"`
+	NextAttemptAt  time.Time             `gorm:"column:next_attempt_at;index"`
+	LastError      string                `gorm:"column:last_error;type:text"`
+	CreatedAt      time.Time             `gorm:"column:created_at"`
+	UpdatedAt      time.Time             `gorm:"column:updated_at"`
+	AttemptLog     []PushDeliveryAttempt `gorm:"foreignKey:delivery_id;references:id"`
+}
+
+// PushDeliveryAttempt logs the result of a single try to send a push delivery
+type PushDeliveryAttempt struct {
+	Id            uuid.UUID `gorm:"column:id;primary_key"`
+	DeliveryId    uuid.UUID `gorm:"column:delivery_id;type:uuid;index"`
+	AttemptNumber int       `gorm:"column:attempt_number"`
+	Status        string    `gorm:"column:status;type:varchar(10)"` // status of the delivery after the attempt
+	StatusCode    int       `gorm:"column:status_code"`             // http status code of the push service, 0 if no response was received
+	Error         string    `gorm:"column:error;type:text"`
+	CreatedAt     time.Time `gorm:"column:created_at"`
+}
+
+type PushDeliveryAttemptDTO struct {
+	AttemptNumber int       `json:"attemptNumber"`
+	Status        string    `json:"status"`
+	StatusCode    int       `json:"statusCode"`
+	Error         string    `json:"error"`
+	CreationDate  time.Time `json:"creationDate"`
+}
+
+type PushDeliveryRecordDTO struct {
+	DeliveryId     string                   `json:"deliveryId"`
+	SubscriptionId string                   `json:"subscriptionId"`
+	Username       string                   `json:"username"`
+	Type           string                   `json:"type"`
+	Status         string                   `json:"status"`
+	Attempts       []PushDeliveryAttemptDTO `json:"attempts"`
+	CreationDate   time.Time                `json:"creationDate"`
+}
+
+type PushDeliveriesResponseDTO struct {
+	Records    []PushDeliveryRecordDTO `json:"records"`
+	Pagination *OffsetPaginationDTO    `json:"pagination"`
+}
@@ -1,16 +1,22 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"time"
+)
 
 type PushSubscription struct {
-	Id        uuid.UUID `gorm:"column:id;primary_key"`
-	Username  string    `gorm:"column:username_fk;type:varchar(20)"`
-	User      User      `gorm:"foreignKey:username_fk;references:username"`
-	Type      string    `gorm:"column:type;type:varchar(4)"` // either "web" or "expo"
-	Endpoint  string    `gorm:"column:endpoint;type:text"`   // for web only
-	P256dh    string    `gorm:"column:p256dh;type:text"`     // for web only
-	Auth      string    `gorm:"column:auth;type:text"`       // for web only
-	ExpoToken string    `gorm:"column:expo_token;type:text"` // for expo only
+	Id          uuid.UUID `gorm:"column:id;primary_key"`
+	Username    string    `gorm:"column:username_fk;type:varchar(20)"`
+	User        User      `gorm:"foreignKey:username_fk;references:username"`
+	Type        string    `gorm:"column:type;type:varchar(4)"`        // "web", "expo", "fcm" or "apns"
+	Endpoint    string    `gorm:"column:endpoint;type:text"`          // for web only
+	P256dh      string    `gorm:"column:p256dh;type:text"`            // for web only
+	Auth        string    `gorm:"column:auth;type:text"`              // for web only
+	ExpoToken   string    `gorm:"column:expo_token;type:text"`        // for expo only
+	DeviceToken string    `gorm:"column:device_token;type:text"`      // for fcm and apns only
+	SessionId   string    `gorm:"column:session_id;type:varchar(36)"` // device session that registered the subscription, empty for old tokens
+	CreatedAt   time.Time `gorm:"column:created_at"`
 }
 
 type VapidKeyResponseDTO struct {
@@ -30,9 +36,22 @@ type SubscriptionInfo struct {
 type PushSubscriptionRequestDTO struct {
 	Type             string            `json:"type" binding:"required"`
 	SubscriptionInfo *SubscriptionInfo `json:"subscription"` // subscription info for web push notifications
-	Token            string            `json:"token"`        // token for expo push notifications
+	Token            string            `json:"token"`        // token for expo, fcm or apns push notifications
 }
 
 type PushSubscriptionResponseDTO struct {
 	SubscriptionId string `json:"subscriptionId"`
 }
+
+type PushSubscriptionRecordDTO struct {
+	SubscriptionId string    `json:"subscriptionId"`
+	Type           string    `json:"type"`
+	Endpoint       string    `json:"endpoint,omitempty"` // for web only
+	Token          string    `json:"token,omitempty"`    // for expo, fcm and apns only
+	CreationDate   time.Time `json:"creationDate"`
+	CurrentSession bool      `json:"currentSession"` // true if the subscription was registered by the device session of the request
+}
+
+type PushSubscriptionsResponseDTO struct {
+	Records []PushSubscriptionRecordDTO `json:"records"`
+}
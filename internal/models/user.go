@@ -15,7 +15,11 @@ type User struct {
 	ImageId      *uuid.UUID `gorm:"column:image_id;null"`
 	Image        Image      `gorm:"foreignKey:image_id;references:id"`
 	Status       string     `gorm:"column:status;type:varchar(128)"`
-	Chats        []Chat     `gorm:"many2many:chat_users;onDelete:CASCADE"` // gorm handles the join table
+	Locale       string     `gorm:"column:locale;type:varchar(5);default:'en'"` // language of push notifications and emails
+	Chats        []Chat     `gorm:"many2many:chat_users;onDelete:CASCADE"`      // gorm handles the join table
+	LastSeenAt   *time.Time `gorm:"column:last_seen_at;null"`                   // last time a chat websocket of the user was opened or closed
+	// PresenceVisibility is either "everyone" or "contacts", who can see if the user is online and when the user was last seen
+	PresenceVisibility string `gorm:"column:presence_visibility;type:varchar(10);default:'everyone'"`
 }
 
 type UserDTO struct { // General dto for user, also used as author dto
@@ -30,6 +34,7 @@ type UserCreateRequestDTO struct {
 	Nickname       string `json:"nickname"`
 	ProfilePicture string `json:"profilePicture"`
 	Email          string `json:"email" binding:"required"`
+	Locale         string `json:"locale"` // optional, the Accept-Language header is used if empty
 }
 
 type UserCreateResponseDTO struct {
@@ -74,6 +79,10 @@ type ChangePasswordDTO struct {
 	NewPassword string `json:"newPassword" binding:"required"`
 }
 
+type UserDeleteRequestDTO struct {
+	Password string `json:"password" binding:"required"`
+}
+
 type UserSearchResponseDTO struct {
 	Records    []UserDTO            `json:"records"`
 	Pagination *OffsetPaginationDTO `json:"pagination"`
@@ -88,4 +97,20 @@ type UserProfileResponseDTO struct {
 	Following      int64             `json:"following"`
 	Posts          int64             `json:"posts"`
 	SubscriptionId *string           `json:"subscriptionId"`
+	Online         bool              `json:"online"`
+	LastSeen       *time.Time        `json:"lastSeen"` // null if the user hides the presence from the current user
+}
+
+type UserLocaleDTO struct {
+	Locale string `json:"locale"`
+}
+
+type UserPrivacySettingsDTO struct {
+	PresenceVisibility string `json:"presenceVisibility" binding:"required"`
+}
+
+// UserPresenceDTO shows if a user is connected to a chat websocket and when the user was last seen
+type UserPresenceDTO struct {
+	Online   bool       `json:"online"`
+	LastSeen *time.Time `json:"lastSeen"`
 }
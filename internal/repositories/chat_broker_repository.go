@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ChatBrokerRepositoryInterface interface {
+	Notify(channel string, payload string) error
+	IncrementChatConnections(instanceId, chatId, username string, now time.Time) error
+	DecrementChatConnections(instanceId, chatId, username string) error
+	GetConnectedUsernames(chatId string, activeSince time.Time) ([]string, error)
+	GetOnlineUsernames(usernames []string, activeSince time.Time) ([]string, error)
+	RefreshChatConnections(instanceId string, now time.Time) error
+	DeleteStaleChatConnections(before time.Time) error
+}
+
+type ChatBrokerRepository struct {
+	DB *gorm.DB
+}
+
+// NewChatBrokerRepository can be used as a constructor to create a ChatBrokerRepository "object"
+func NewChatBrokerRepository(db *gorm.DB) *ChatBrokerRepository {
+	return &ChatBrokerRepository{DB: db}
+}
+
+// Notify sends the payload to all database sessions that listen on the channel
+func (repo *ChatBrokerRepository) Notify(channel string, payload string) error {
+	return repo.DB.Exec("SELECT pg_notify(?, ?)", channel, payload).Error
+}
+
+func (repo *ChatBrokerRepository) IncrementChatConnections(instanceId, chatId, username string, now time.Time) error {
+	return repo.DB.Exec(`INSERT INTO chat_connections (instance_id, chat_id, username, connections, updated_at) VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT (instance_id, chat_id, username) DO UPDATE SET connections = chat_connections.connections + 1, updated_at = EXCLUDED.updated_at`,
+		instanceId, chatId, username, now).Error
+}
+
+func (repo *ChatBrokerRepository) DecrementChatConnections(instanceId, chatId, username string) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.ChatConnection{}).
+			Where("instance_id = ? AND chat_id = ? AND username = ?", instanceId, chatId, username).
+			Update("connections", gorm.Expr("connections - 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("instance_id = ? AND chat_id = ? AND username = ? AND connections <= 0", instanceId, chatId, username).
+			Delete(&models.ChatConnection{}).Error
+	})
+}
+
+// GetConnectedUsernames returns the users with a connection to the chat on an instance that was active since the given time
+func (repo *ChatBrokerRepository) GetConnectedUsernames(chatId string, activeSince time.Time) ([]string, error) {
+	var usernames []string
+	err := repo.DB.Model(&models.ChatConnection{}).
+		Distinct("username").
+		Where("chat_id = ? AND updated_at >= ?", chatId, activeSince).
+		Pluck("username", &usernames).Error
+	return usernames, err
+}
+
+// GetOnlineUsernames returns the given users that have a connection to any chat on an instance that was active since the given time
+func (repo *ChatBrokerRepository) GetOnlineUsernames(usernames []string, activeSince time.Time) ([]string, error) {
+	var onlineUsernames []string
+	err := repo.DB.Model(&models.ChatConnection{}).
+		Distinct("username").
+		Where("username IN ? AND updated_at >= ?", usernames, activeSince).
+		Pluck("username", &onlineUsernames).Error
+	return onlineUsernames, err
+}
+
+// RefreshChatConnections marks all connections of the instance as still active
+func (repo *ChatBrokerRepository) RefreshChatConnections(instanceId string, now time.Time) error {
+	return repo.DB.Model(&models.ChatConnection{}).Where("instance_id = ?", instanceId).Update("updated_at", now).Error
+}
+
+// DeleteStaleChatConnections removes the connections of instances that stopped without removing them, e.g. after a crash
+func (repo *ChatBrokerRepository) DeleteStaleChatConnections(before time.Time) error {
+	return repo.DB.Where("updated_at < ?", before).Delete(&models.ChatConnection{}).Error
+}
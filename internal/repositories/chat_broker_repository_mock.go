@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockChatBrokerRepository struct {
+	mock.Mock
+}
+
+func (m *MockChatBrokerRepository) Notify(channel string, payload string) error {
+	args := m.Called(channel, payload)
+	return args.Error(0)
+}
+
+func (m *MockChatBrokerRepository) IncrementChatConnections(instanceId, chatId, username string, now time.Time) error {
+	args := m.Called(instanceId, chatId, username, now)
+	return args.Error(0)
+}
+
+func (m *MockChatBrokerRepository) DecrementChatConnections(instanceId, chatId, username string) error {
+	args := m.Called(instanceId, chatId, username)
+	return args.Error(0)
+}
+
+func (m *MockChatBrokerRepository) GetConnectedUsernames(chatId string, activeSince time.Time) ([]string, error) {
+	args := m.Called(chatId, activeSince)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockChatBrokerRepository) GetOnlineUsernames(usernames []string, activeSince time.Time) ([]string, error) {
+	args := m.Called(usernames, activeSince)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockChatBrokerRepository) RefreshChatConnections(instanceId string, now time.Time) error {
+	args := m.Called(instanceId, now)
+	return args.Error(0)
+}
+
+func (m *MockChatBrokerRepository) DeleteStaleChatConnections(before time.Time) error {
+	args := m.Called(before)
+	return args.Error(0)
+}
@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"github.com/google/uuid"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 type ChatRepositoryInterface interface {
@@ -10,6 +12,11 @@ type ChatRepositoryInterface interface {
 	GetChatByUsernames(currentUsername, otherUsername string) (models.Chat, error)
 	GetChatsByUsername(username string) ([]models.Chat, error)
 	GetChatById(chatId string) (models.Chat, error)
+	AddChatMembers(members []models.ChatUser) error
+	RemoveChatMember(chatId string, username string) error
+	UpdateChatMemberRole(chatId string, username string, role string) error
+	UpdateLastReadMessage(chatId string, username string, messageId uuid.UUID, readAt time.Time) error
+	GetUnreadMessageCounts(username string) (map[string]int64, error)
 }
 
 type ChatRepository struct {
@@ -22,11 +29,13 @@ func NewChatRepository(db *gorm.DB) *ChatRepository { return &ChatRepository{DB:
 func (repo *ChatRepository) CreateChatWithFirstMessage(chat models.Chat, message models.Message) error {
 	// Create a transaction to ensure that both the chat and the message are created
 	return repo.DB.Transaction(func(tx *gorm.DB) error {
-		// Save chat
-		if err := tx.Create(chat).Error; err != nil {
+		// Save chat with its members, the users already exist so only the join table entries with roles are created
+		chat.LastSequence = 1
+		if err := tx.Omit("Users").Create(&chat).Error; err != nil {
 			return err
 		}
 		message.ChatId = chat.Id
+		message.Sequence = 1
 		// Save message
 		if err := tx.Create(message).Error; err != nil {
 			return err
@@ -43,6 +52,7 @@ func (repo *ChatRepository) GetChatByUsernames(currentUsername, otherUsername st
 		Joins("JOIN users u1 ON cu1.user_username = u1.username").
 		Joins("JOIN users u2 ON cu2.user_username = u2.username").
 		Where("u1.username = ? AND u2.username = ?", currentUsername, otherUsername).
+		Where("chats.is_group = ?", false). // group chats with both users do not count as their direct chat
 		First(&chat)
 	return chat, query.Error
 }
@@ -60,6 +70,8 @@ func (repo *ChatRepository) GetChatsByUsername(username string) ([]models.Chat,
 		Where("chat_users.user_username = ?", username).
 		Preload("Users").
 		Preload("Users.Image").
+		Preload("Members").
+		Preload("Image").
 		Order("latest_messages.last_message_date DESC"). // Order chats by latest message date
 		Find(&chats).Error
 	return chats, err
@@ -67,6 +79,99 @@ func (repo *ChatRepository) GetChatsByUsername(username string) ([]models.Chat,
 
 func (repo *ChatRepository) GetChatById(chatId string) (models.Chat, error) {
 	var chat models.Chat
-	err := repo.DB.Where("id = ?", chatId).Preload("Users").Preload("Users.Image").First(&chat).Error
+	err := repo.DB.Where("id = ?", chatId).Preload("Users").Preload("Users.Image").Preload("Members").Preload("Image").First(&chat).Error
 	return chat, err
 }
+
+func (repo *ChatRepository) AddChatMembers(members []models.ChatUser) error {
+	return repo.DB.Create(&members).Error
+}
+
+func (repo *ChatRepository) RemoveChatMember(chatId string, username string) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		return removeChatMemberTx(tx, chatId, username)
+	})
+}
+
+func (repo *ChatRepository) UpdateChatMemberRole(chatId string, username string, role string) error {
+	return repo.DB.Model(&models.ChatUser{}).
+		Where("chat_id = ? AND user_username = ?", chatId, username).
+		Update("role", role).Error
+}
+
+func (repo *ChatRepository) UpdateLastReadMessage(chatId string, username string, messageId uuid.UUID, readAt time.Time) error {
+	// Only move the read position forward, e.g. if an older client sends a read receipt after a newer one
+	return repo.DB.Model(&models.ChatUser{}).
+		Where("chat_id = ? AND user_username = ?", chatId, username).
+		Where("last_read_at IS NULL OR last_read_at < ?", readAt).
+		Updates(map[string]interface{}{"last_read_message_id": messageId, "last_read_at": readAt}).Error
+}
+
+func (repo *ChatRepository) GetUnreadMessageCounts(username string) (map[string]int64, error) {
+	var results []struct {
+		ChatId uuid.UUID
+		Count  int64
+	}
+
+	// Count messages of other users that are newer than the last read message of the user in each chat, deleted messages are not counted
+	err := repo.DB.Table("messages").
+		Select("messages.chat_id, COUNT(*) as count").
+		Joins("JOIN chat_users ON chat_users.chat_id = messages.chat_id AND chat_users.user_username = ?", username).
+		Where("messages.username_fk <> ?", username).
+		Where("messages.deleted = ?", false).
+		Where("chat_users.last_read_at IS NULL OR messages.created_at > chat_users.last_read_at").
+		Group("messages.chat_id").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(results))
+	for _, result := range results {
+		counts[result.ChatId.String()] = result.Count
+	}
+	return counts, nil
+}
+
+// removeChatMemberTx removes a user from a chat using the given transaction
+// If no admin is left, the member that joined first becomes admin, and if no member is left, the chat is deleted with all messages
+func removeChatMemberTx(tx *gorm.DB, chatId string, username string) error {
+	if err := tx.Where("chat_id = ? AND user_username = ?", chatId, username).Delete(&models.ChatUser{}).Error; err != nil {
+		return err
+	}
+
+	var remainingMembers []models.ChatUser
+	if err := tx.Where("chat_id = ?", chatId).Order("joined_at asc").Find(&remainingMembers).Error; err != nil {
+		return err
+	}
+
+	// Delete chat with all messages and its picture if it is empty
+	if len(remainingMembers) == 0 {
+		var chat models.Chat
+		if err := tx.Where("id = ?", chatId).First(&chat).Error; err != nil {
+			return err
+		}
+		if err := deleteMessagesTx(tx, "chat_id = ?", chatId); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", chatId).Delete(&models.Chat{}).Error; err != nil {
+			return err
+		}
+		if chat.ImageId != nil {
+			if err := tx.Where("id = ?", chat.ImageId.String()).Delete(&models.Image{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Promote the longest member if the last admin was removed
+	for _, member := range remainingMembers {
+		if member.Role == "admin" {
+			return nil
+		}
+	}
+	return tx.Model(&models.ChatUser{}).
+		Where("chat_id = ? AND user_username = ?", chatId, remainingMembers[0].UserUsername).
+		Update("role", "admin").Error
+}
@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"time"
 )
 
 type MockChatRepository struct {
@@ -28,3 +30,28 @@ func (m *MockChatRepository) GetChatByUsernames(currentUsername, otherUsername s
 	args := m.Called(currentUsername, otherUsername)
 	return args.Get(0).(models.Chat), args.Error(1)
 }
+
+func (m *MockChatRepository) AddChatMembers(members []models.ChatUser) error {
+	args := m.Called(members)
+	return args.Error(0)
+}
+
+func (m *MockChatRepository) RemoveChatMember(chatId string, username string) error {
+	args := m.Called(chatId, username)
+	return args.Error(0)
+}
+
+func (m *MockChatRepository) UpdateChatMemberRole(chatId string, username string, role string) error {
+	args := m.Called(chatId, username, role)
+	return args.Error(0)
+}
+
+func (m *MockChatRepository) UpdateLastReadMessage(chatId string, username string, messageId uuid.UUID, readAt time.Time) error {
+	args := m.Called(chatId, username, messageId, readAt)
+	return args.Error(0)
+}
+
+func (m *MockChatRepository) GetUnreadMessageCounts(username string) (map[string]int64, error) {
+	args := m.Called(username)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
@@ -1,14 +1,20 @@
 package repositories
 
 import (
+	"github.com/google/uuid"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"gorm.io/gorm"
 )
 
 type CommentRepositoryInterface interface {
 	CreateComment(comment *models.Comment) error
-	GetCommentsByPostId(postId string, offset, limit int) ([]models.Comment, int64, error)
+	GetCommentsByPostId(postId string, offset, limit int, sortByLikes bool) ([]models.Comment, int64, error)
 	CountComments(postId string) (int64, error)
+	GetCommentById(commentId string) (models.Comment, error)
+	UpdateComment(comment *models.Comment) error
+	DeleteCommentById(commentId string) error
+	GetRepliesByCommentId(commentId string, offset, limit int) ([]models.Comment, int64, error)
+	CountRepliesByCommentIds(commentIds []string) (map[string]int64, error)
 }
 
 type CommentRepository struct {
@@ -25,25 +31,34 @@ func (repo *CommentRepository) CreateComment(comment *models.Comment) error {
 	return err
 }
 
-func (repo *CommentRepository) GetCommentsByPostId(postId string, offset, limit int) ([]models.Comment, int64, error) {
+func (repo *CommentRepository) GetCommentsByPostId(postId string, offset, limit int, sortByLikes bool) ([]models.Comment, int64, error) {
 	var comments []models.Comment
 	var count int64
 
-	baseQuery := repo.DB.Model(&models.Comment{}).Where("post_id = ?", postId)
+	// Replies are loaded separately for each comment
+	baseQuery := repo.DB.Model(&models.Comment{}).Where("post_id = ? AND parent_comment_id IS NULL", postId)
 
-	// Count number of comments based on post id
+	// Count number of top-level comments based on post id
 	err := baseQuery.Count(&count).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
+	// Most liked comments first if requested, newest first otherwise
+	order := "created_at desc, id desc"
+	if sortByLikes {
+		order = "(SELECT COUNT(*) FROM comment_likes WHERE comment_likes.comment_id = comments.id) desc, " + order
+	}
+
 	// Get comments using pagination information
 	err = baseQuery.
 		Offset(offset).
 		Limit(limit).
-		Order("created_at desc, id desc").
+		Order(order).
 		Preload("User").
 		Preload("User.Image").
+		Preload("Mentions").
+		Preload("Mentions.Image").
 		Find(&comments).Error
 	if err != nil {
 		return nil, 0, err
@@ -58,3 +73,113 @@ func (repo *CommentRepository) CountComments(postId string) (int64, error) {
 	err := query.Count(&count).Error
 	return count, err
 }
+
+func (repo *CommentRepository) GetCommentById(commentId string) (models.Comment, error) {
+	var comment models.Comment
+	err := repo.DB.Model(&models.Comment{}).
+		Preload("Post").
+		Preload("User").
+		Preload("User.Image").
+		Preload("Mentions").
+		Preload("Mentions.Image").
+		Where("id = ?", commentId).First(&comment).Error
+	return comment, err
+}
+
+// UpdateComment updates content and edit time of a comment and resyncs its mentions
+func (repo *CommentRepository) UpdateComment(comment *models.Comment) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Comment{Id: comment.Id}).Updates(map[string]interface{}{
+			"content":   comment.Content,
+			"edited_at": comment.EditedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if len(comment.Mentions) == 0 {
+			return tx.Model(&models.Comment{Id: comment.Id}).Association("Mentions").Clear()
+		}
+		return tx.Model(&models.Comment{Id: comment.Id}).Association("Mentions").Replace(comment.Mentions)
+	})
+}
+
+func (repo *CommentRepository) DeleteCommentById(commentId string) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		return deleteCommentsWithRepliesTx(tx, "id = ?", commentId)
+	})
+}
+
+func (repo *CommentRepository) GetRepliesByCommentId(commentId string, offset, limit int) ([]models.Comment, int64, error) {
+	var replies []models.Comment
+	var count int64
+
+	baseQuery := repo.DB.Model(&models.Comment{}).Where("parent_comment_id = ?", commentId)
+
+	// Count number of direct replies
+	err := baseQuery.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Get replies using pagination information, oldest first so that the conversation can be followed
+	err = baseQuery.
+		Offset(offset).
+		Limit(limit).
+		Order("created_at asc, id asc").
+		Preload("User").
+		Preload("User.Image").
+		Preload("Mentions").
+		Preload("Mentions.Image").
+		Find(&replies).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return replies, count, nil
+}
+
+func (repo *CommentRepository) CountRepliesByCommentIds(commentIds []string) (map[string]int64, error) {
+	var results []struct {
+		ParentCommentId uuid.UUID
+		Count           int64
+	}
+
+	// Count the direct replies of all comments with one query
+	err := repo.DB.Model(&models.Comment{}).
+		Select("parent_comment_id, COUNT(*) as count").
+		Where("parent_comment_id IN ?", commentIds).
+		Group("parent_comment_id").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(results))
+	for _, result := range results {
+		counts[result.ParentCommentId.String()] = result.Count
+	}
+	return counts, nil
+}
+
+// deleteCommentsWithRepliesTx deletes all comments matching the query together with all nested replies, their likes, mentions and notifications,
+// the query and its arguments are passed to Where, so the arguments are sent as parameters
+func deleteCommentsWithRepliesTx(tx *gorm.DB, query interface{}, args ...interface{}) error {
+	comments := tx.Model(&models.Comment{}).Select("id").Where(query, args...)
+	thread := tx.Raw(`WITH RECURSIVE thread AS (
+		?
+		UNION ALL
+		SELECT comments.id FROM comments JOIN thread ON comments.parent_comment_id = thread.id
+	) SELECT id FROM thread`, comments)
+
+	if err := tx.Where("comment_id IN (?)", thread).Delete(&models.CommentLike{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM comment_mentions WHERE comment_id IN (?)", thread).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("comment_id IN (?)", thread).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN (?)", thread).Delete(&models.Comment{}).Error
+}
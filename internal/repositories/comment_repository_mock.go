@@ -14,8 +14,8 @@ func (m *MockCommentRepository) CreateComment(comment *models.Comment) error {
 	return args.Error(0)
 }
 
-func (m *MockCommentRepository) GetCommentsByPostId(postId string, offset, limit int) ([]models.Comment, int64, error) {
-	args := m.Called(postId, offset, limit)
+func (m *MockCommentRepository) GetCommentsByPostId(postId string, offset, limit int, sortByLikes bool) ([]models.Comment, int64, error) {
+	args := m.Called(postId, offset, limit, sortByLikes)
 	return args.Get(0).([]models.Comment), args.Get(1).(int64), args.Error(2)
 }
 
@@ -23,3 +23,28 @@ func (m *MockCommentRepository) CountComments(postId string) (int64, error) {
 	args := m.Called(postId)
 	return args.Get(0).(int64), args.Error(1)
 }
+
+func (m *MockCommentRepository) GetCommentById(commentId string) (models.Comment, error) {
+	args := m.Called(commentId)
+	return args.Get(0).(models.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) UpdateComment(comment *models.Comment) error {
+	args := m.Called(comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) DeleteCommentById(commentId string) error {
+	args := m.Called(commentId)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetRepliesByCommentId(commentId string, offset, limit int) ([]models.Comment, int64, error) {
+	args := m.Called(commentId, offset, limit)
+	return args.Get(0).([]models.Comment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCommentRepository) CountRepliesByCommentIds(commentIds []string) (map[string]int64, error) {
+	args := m.Called(commentIds)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type DataExportRepositoryInterface interface {
+	CreateDataExport(dataExport *models.DataExport) error
+	UpdateDataExport(dataExport *models.DataExport) error
+	GetDataExportById(exportId string) (*models.DataExport, error)
+	GetPendingDataExportByUsername(username string) (*models.DataExport, error)
+	DeleteExpiredDataExports() (int64, error)
+	GetUserExportData(username string) (*models.UserExportData, error)
+}
+
+type DataExportRepository struct {
+	DB *gorm.DB
+}
+
+// NewDataExportRepository can be used as a constructor to create a DataExportRepository "object"
+func NewDataExportRepository(db *gorm.DB) *DataExportRepository {
+	return &DataExportRepository{DB: db}
+}
+
+func (repo *DataExportRepository) CreateDataExport(dataExport *models.DataExport) error {
+	return repo.DB.Create(dataExport).Error
+}
+
+func (repo *DataExportRepository) UpdateDataExport(dataExport *models.DataExport) error {
+	return repo.DB.Save(dataExport).Error
+}
+
+func (repo *DataExportRepository) GetDataExportById(exportId string) (*models.DataExport, error) {
+	var dataExport models.DataExport
+	err := repo.DB.Where("id = ?", exportId).First(&dataExport).Error
+	return &dataExport, err
+}
+
+func (repo *DataExportRepository) GetPendingDataExportByUsername(username string) (*models.DataExport, error) {
+	var dataExport models.DataExport
+	err := repo.DB.Where("username_fk = ? AND status = ? AND expiration_time > ?", username, "pending", time.Now()).
+		Order("created_at desc").
+		First(&dataExport).Error
+	return &dataExport, err
+}
+
+func (repo *DataExportRepository) DeleteExpiredDataExports() (int64, error) {
+	result := repo.DB.Where("expiration_time < ?", time.Now()).Delete(&models.DataExport{})
+	return result.RowsAffected, result.Error
+}
+
+func (repo *DataExportRepository) GetUserExportData(username string) (*models.UserExportData, error) {
+	var data models.UserExportData
+
+	// Profile with picture
+	if err := repo.DB.Where("username = ?", username).Preload("Image").First(&data.User).Error; err != nil {
+		return nil, err
+	}
+
+	// Digest setting, most users do not have one
+	var digestSettings []models.DigestSetting
+	if err := repo.DB.Where("username_fk = ?", username).Limit(1).Find(&digestSettings).Error; err != nil {
+		return nil, err
+	}
+	if len(digestSettings) > 0 {
+		data.DigestSetting = &digestSettings[0]
+	}
+
+	// Posts with hashtags, location and picture
+	if err := repo.DB.Where("username_fk = ?", username).
+		Order("created_at desc").
+		Preload("Hashtags").
+		Preload("Location").
+		Preload("Image").
+		Find(&data.Posts).Error; err != nil {
+		return nil, err
+	}
+
+	// Previous versions of the posts
+	if err := repo.DB.
+		Joins("JOIN posts ON posts.id = post_revisions.post_id").
+		Where("posts.username_fk = ?", username).
+		Order("post_revisions.created_at desc").
+		Preload("Location").
+		Find(&data.PostRevisions).Error; err != nil {
+		return nil, err
+	}
+
+	// Comments and likes
+	if err := repo.DB.Where("username_fk = ?", username).Order("created_at desc").Find(&data.Comments).Error; err != nil {
+		return nil, err
+	}
+	if err := repo.DB.Where("username_fk = ?", username).Find(&data.Likes).Error; err != nil {
+		return nil, err
+	}
+	if err := repo.DB.Where("username_fk = ?", username).Find(&data.CommentLikes).Error; err != nil {
+		return nil, err
+	}
+
+	// Subscriptions in both directions
+	if err := repo.DB.Where("follower = ? OR following = ?", username, username).
+		Order("subscription_date desc").
+		Find(&data.Subscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	// Notifications the user received or created
+	if err := repo.DB.Where("for_username = ? OR from_username = ?", username, username).
+		Order("timestamp desc").
+		Find(&data.Notifications).Error; err != nil {
+		return nil, err
+	}
+
+	// Notification settings and muted users
+	if err := repo.DB.Where("username_fk = ?", username).
+		Order("notification_type").
+		Find(&data.NotificationSettings).Error; err != nil {
+		return nil, err
+	}
+	if err := repo.DB.Where("username_fk = ?", username).
+		Order("created_at desc").
+		Find(&data.NotificationMutes).Error; err != nil {
+		return nil, err
+	}
+
+	// Devices that receive push notifications
+	if err := repo.DB.Where("username_fk = ?", username).
+		Order("created_at desc").
+		Find(&data.PushSubscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	// Messages of all chats the user is a participant of
+	if err := repo.DB.
+		Joins("JOIN chat_users ON chat_users.chat_id = messages.chat_id").
+		Where("chat_users.user_username = ?", username).
+		Order("messages.chat_id, messages.created_at").
+		Preload("Image").
+		Find(&data.Messages).Error; err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+)
+
+type MockDataExportRepository struct {
+	mock.Mock
+}
+
+func (m *MockDataExportRepository) CreateDataExport(dataExport *models.DataExport) error {
+	args := m.Called(dataExport)
+	return args.Error(0)
+}
+
+func (m *MockDataExportRepository) UpdateDataExport(dataExport *models.DataExport) error {
+	args := m.Called(dataExport)
+	return args.Error(0)
+}
+
+func (m *MockDataExportRepository) GetDataExportById(exportId string) (*models.DataExport, error) {
+	args := m.Called(exportId)
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepository) GetPendingDataExportByUsername(username string) (*models.DataExport, error) {
+	args := m.Called(username)
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepository) DeleteExpiredDataExports() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDataExportRepository) GetUserExportData(username string) (*models.UserExportData, error) {
+	args := m.Called(username)
+	return args.Get(0).(*models.UserExportData), args.Error(1)
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"gorm.io/gorm"
+)
+
+type DigestRepositoryInterface interface {
+	CreateDigestSetting(setting *models.DigestSetting) error
+	UpdateDigestSetting(setting *models.DigestSetting) error
+	GetDigestSettingByUsername(username string) (*models.DigestSetting, error)
+	GetDigestSettingByToken(token string) (*models.DigestSetting, error)
+	GetDigestSettingsByFrequency(frequency string) ([]models.DigestSetting, error)
+}
+
+type DigestRepository struct {
+	DB *gorm.DB
+}
+
+// NewDigestRepository can be used as a constructor to create a DigestRepository "object"
+func NewDigestRepository(db *gorm.DB) *DigestRepository {
+	return &DigestRepository{DB: db}
+}
+
+func (repo *DigestRepository) CreateDigestSetting(setting *models.DigestSetting) error {
+	return repo.DB.Omit("User").Create(setting).Error
+}
+
+func (repo *DigestRepository) UpdateDigestSetting(setting *models.DigestSetting) error {
+	return repo.DB.Omit("User").Save(setting).Error
+}
+
+func (repo *DigestRepository) GetDigestSettingByUsername(username string) (*models.DigestSetting, error) {
+	var setting models.DigestSetting
+	err := repo.DB.Where("username_fk = ?", username).First(&setting).Error
+	return &setting, err
+}
+
+func (repo *DigestRepository) GetDigestSettingByToken(token string) (*models.DigestSetting, error) {
+	var setting models.DigestSetting
+	err := repo.DB.Where("unsubscribe_token = ?", token).First(&setting).Error
+	return &setting, err
+}
+
+// GetDigestSettingsByFrequency returns all digest settings of activated users with the given frequency including the user
+func (repo *DigestRepository) GetDigestSettingsByFrequency(frequency string) ([]models.DigestSetting, error) {
+	var settings []models.DigestSetting
+	err := repo.DB.Model(&models.DigestSetting{}).
+		Joins("JOIN users ON users.username = digest_settings.username_fk").
+		Where("digest_settings.frequency = ? AND users.activated = ?", frequency, true).
+		Preload("User").
+		Find(&settings).Error
+	return settings, err
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+)
+
+type MockDigestRepository struct {
+	mock.Mock
+}
+
+func (m *MockDigestRepository) CreateDigestSetting(setting *models.DigestSetting) error {
+	args := m.Called(setting)
+	return args.Error(0)
+}
+
+func (m *MockDigestRepository) UpdateDigestSetting(setting *models.DigestSetting) error {
+	args := m.Called(setting)
+	return args.Error(0)
+}
+
+func (m *MockDigestRepository) GetDigestSettingByUsername(username string) (*models.DigestSetting, error) {
+	args := m.Called(username)
+	return args.Get(0).(*models.DigestSetting), args.Error(1)
+}
+
+func (m *MockDigestRepository) GetDigestSettingByToken(token string) (*models.DigestSetting, error) {
+	args := m.Called(token)
+	return args.Get(0).(*models.DigestSetting), args.Error(1)
+}
+
+func (m *MockDigestRepository) GetDigestSettingsByFrequency(frequency string) ([]models.DigestSetting, error) {
+	args := m.Called(frequency)
+	return args.Get(0).([]models.DigestSetting), args.Error(1)
+}
@@ -8,6 +8,7 @@ import (
 type ImageRepositoryInterface interface {
 	GetImageById(id string) (*models.Image, error)
 	DeleteImageById(id string) error
+	GetChatByImageId(id string) (*models.Chat, error)
 }
 
 type ImageRepository struct {
@@ -29,3 +30,12 @@ func (repo *ImageRepository) DeleteImageById(id string) error {
 	err := repo.DB.Where("id = ?", id).Delete(&models.Image{}).Error
 	return err
 }
+
+// GetChatByImageId returns the chat with its users that owns the image as group chat picture or as picture of a message,
+// returns gorm.ErrRecordNotFound if the image is not owned by a chat
+func (repo *ImageRepository) GetChatByImageId(id string) (*models.Chat, error) {
+	var chat models.Chat
+	messageChatIds := repo.DB.Model(&models.Message{}).Select("chat_id").Where("image_id = ?", id)
+	err := repo.DB.Where("image_id = ? OR id IN (?)", id, messageChatIds).Preload("Users").First(&chat).Error
+	return &chat, err
+}
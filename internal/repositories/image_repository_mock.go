@@ -18,3 +18,8 @@ func (m *MockImageRepository) DeleteImageById(id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+
+func (m *MockImageRepository) GetChatByImageId(id string) (*models.Chat, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.Chat), args.Error(1)
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"github.com/google/uuid"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"gorm.io/gorm"
 )
@@ -10,6 +11,11 @@ type LikeRepositoryInterface interface {
 	DeleteLike(likeId string) error
 	FindLike(postId string, currentUsername string) (*models.Like, error)
 	CountLikes(postId string) (int64, error)
+	CreateCommentLike(like *models.CommentLike) error
+	DeleteCommentLike(likeId string) error
+	FindCommentLike(commentId string, currentUsername string) (*models.CommentLike, error)
+	FindCommentLikesByCommentIds(commentIds []string, currentUsername string) ([]models.CommentLike, error)
+	CountCommentLikesByCommentIds(commentIds []string) (map[string]int64, error)
 }
 
 type LikeRepository struct {
@@ -40,3 +46,46 @@ func (repo *LikeRepository) CountLikes(postId string) (int64, error) {
 	err := query.Count(&count).Error
 	return count, err
 }
+
+func (repo *LikeRepository) CreateCommentLike(like *models.CommentLike) error {
+	return repo.DB.Create(like).Error
+}
+
+func (repo *LikeRepository) DeleteCommentLike(likeId string) error {
+	return repo.DB.Delete(&models.CommentLike{}, "id = ?", likeId).Error
+}
+
+func (repo *LikeRepository) FindCommentLike(commentId string, currentUsername string) (*models.CommentLike, error) {
+	var like models.CommentLike
+	err := repo.DB.Where("comment_id = ? AND username_fk = ?", commentId, currentUsername).First(&like).Error
+	return &like, err
+}
+
+func (repo *LikeRepository) FindCommentLikesByCommentIds(commentIds []string, currentUsername string) ([]models.CommentLike, error) {
+	var likes []models.CommentLike
+	err := repo.DB.Where("comment_id IN ? AND username_fk = ?", commentIds, currentUsername).Find(&likes).Error
+	return likes, err
+}
+
+func (repo *LikeRepository) CountCommentLikesByCommentIds(commentIds []string) (map[string]int64, error) {
+	var results []struct {
+		CommentId uuid.UUID
+		Count     int64
+	}
+
+	// Count the likes of all comments with one query
+	err := repo.DB.Model(&models.CommentLike{}).
+		Select("comment_id, COUNT(*) as count").
+		Where("comment_id IN ?", commentIds).
+		Group("comment_id").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(results))
+	for _, result := range results {
+		counts[result.CommentId.String()] = result.Count
+	}
+	return counts, nil
+}
@@ -28,3 +28,28 @@ func (m *MockLikeRepository) CountLikes(postId string) (int64, error) {
 	args := m.Called(postId)
 	return args.Get(0).(int64), args.Error(1)
 }
+
+func (m *MockLikeRepository) CreateCommentLike(like *models.CommentLike) error {
+	args := m.Called(like)
+	return args.Error(0)
+}
+
+func (m *MockLikeRepository) DeleteCommentLike(likeId string) error {
+	args := m.Called(likeId)
+	return args.Error(0)
+}
+
+func (m *MockLikeRepository) FindCommentLike(commentId string, currentUsername string) (*models.CommentLike, error) {
+	args := m.Called(commentId, currentUsername)
+	return args.Get(0).(*models.CommentLike), args.Error(1)
+}
+
+func (m *MockLikeRepository) FindCommentLikesByCommentIds(commentIds []string, currentUsername string) ([]models.CommentLike, error) {
+	args := m.Called(commentIds, currentUsername)
+	return args.Get(0).([]models.CommentLike), args.Error(1)
+}
+
+func (m *MockLikeRepository) CountCommentLikesByCommentIds(commentIds []string) (map[string]int64, error) {
+	args := m.Called(commentIds)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
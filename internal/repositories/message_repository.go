@@ -3,11 +3,18 @@ package repositories
 import (
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MessageRepositoryInterface interface {
 	GetMessagesByChatId(chatId string, offset int, limit int) ([]models.Message, int64, error)
+	GetMessagesByChatIdSinceSequence(chatId string, sequence int64, limit int) ([]models.Message, error)
 	CreateMessage(message *models.Message) error
+	GetMessageById(messageId string) (models.Message, error)
+	UpdateMessage(message *models.Message) error
+	SoftDeleteMessage(message *models.Message) error
+	SetMessageReaction(reaction *models.MessageReaction) error
+	DeleteMessageReaction(messageId string, username string) error
 }
 
 type MessageRepository struct {
@@ -32,7 +39,9 @@ func (repo *MessageRepository) GetMessagesByChatId(chatId string, offset int, li
 	if err != nil {
 		return nil, 0, err
 	}
-	err = baseQuery.Offset(offset).Limit(limit).Preload("User").Find(&messages).Error
+	err = baseQuery.Offset(offset).Limit(limit).Preload("User").Preload("Image").Preload("Reactions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at asc")
+	}).Find(&messages).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -40,6 +49,113 @@ func (repo *MessageRepository) GetMessagesByChatId(chatId string, offset int, li
 	return messages, count, err
 }
 
+// GetMessagesByChatIdSinceSequence returns the newest messages of a chat with a higher sequence number than the given one, newest first
+func (repo *MessageRepository) GetMessagesByChatIdSinceSequence(chatId string, sequence int64, limit int) ([]models.Message, error) {
+	var messages []models.Message
+	err := repo.DB.
+		Where("chat_id = ? AND sequence > ?", chatId, sequence).
+		Order("sequence desc").
+		Limit(limit).
+		Preload("User").
+		Preload("Image").
+		Preload("Reactions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at asc")
+		}).
+		Find(&messages).Error
+	return messages, err
+}
+
+// CreateMessage saves a message with the next sequence number of its chat
 func (repo *MessageRepository) CreateMessage(message *models.Message) error {
-	return repo.DB.Create(message).Error
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		// Incrementing the counter locks the chat row, so concurrent messages of the chat get consecutive numbers
+		result := tx.Raw("UPDATE chats SET last_sequence = last_sequence + 1 WHERE id = ? RETURNING last_sequence", message.ChatId).
+			Scan(&message.Sequence)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(message).Error
+	})
+}
+
+func (repo *MessageRepository) GetMessageById(messageId string) (models.Message, error) {
+	var message models.Message
+	err := repo.DB.Where("id = ?", messageId).Preload("Image").Preload("Reactions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at asc")
+	}).First(&message).Error
+	return message, err
+}
+
+// UpdateMessage saves the edited content of a message, a message that was deleted in the meantime is not changed
+func (repo *MessageRepository) UpdateMessage(message *models.Message) error {
+	result := repo.DB.Model(&models.Message{}).
+		Where("id = ? AND deleted = ?", message.Id, false).
+		Updates(map[string]interface{}{
+			"content":   message.Content,
+			"edited_at": message.EditedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (repo *MessageRepository) SoftDeleteMessage(message *models.Message) error {
+	// Remove the content, the picture and all reactions, the message itself is kept so that it can be shown as deleted
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", message.Id).Delete(&models.MessageReaction{}).Error; err != nil {
+			return err
+		}
+		imageId := message.ImageId
+		message.Reactions = nil
+		message.ImageId = nil
+		message.Image = models.Image{}
+		if err := tx.Omit("Reactions", "Image").Save(message).Error; err != nil {
+			return err
+		}
+		if imageId != nil {
+			return tx.Where("id = ?", imageId.String()).Delete(&models.Image{}).Error
+		}
+		return nil
+	})
+}
+
+func (repo *MessageRepository) SetMessageReaction(reaction *models.MessageReaction) error {
+	// Replace the reaction if the user already reacted to the message
+	return repo.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}, {Name: "username_fk"}},
+		DoUpdates: clause.AssignmentColumns([]string{"emoji", "created_at"}),
+	}).Create(reaction).Error
+}
+
+func (repo *MessageRepository) DeleteMessageReaction(messageId string, username string) error {
+	return repo.DB.Where("message_id = ? AND username_fk = ?", messageId, username).Delete(&models.MessageReaction{}).Error
+}
+
+// deleteMessagesTx deletes all messages matching the query with their reactions and pictures using the given transaction
+func deleteMessagesTx(tx *gorm.DB, query string, args ...interface{}) error {
+	var imageIds []string
+	if err := tx.Model(&models.Message{}).Where(query, args...).Where("image_id IS NOT NULL").Pluck("image_id", &imageIds).Error; err != nil {
+		return err
+	}
+
+	messageIds := tx.Model(&models.Message{}).Select("id").Where(query, args...)
+	if err := tx.Where("message_id IN (?)", messageIds).Delete(&models.MessageReaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where(query, args...).Delete(&models.Message{}).Error; err != nil {
+		return err
+	}
+
+	// Pictures can only be deleted after the messages because they are referenced by them
+	if len(imageIds) > 0 {
+		return tx.Where("id IN ?", imageIds).Delete(&models.Image{}).Error
+	}
+	return nil
 }
@@ -18,3 +18,33 @@ func (m *MockMessageRepository) CreateMessage(message *models.Message) error {
 	args := m.Called(message)
 	return args.Error(0)
 }
+
+func (m *MockMessageRepository) GetMessageById(messageId string) (models.Message, error) {
+	args := m.Called(messageId)
+	return args.Get(0).(models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) UpdateMessage(message *models.Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) SoftDeleteMessage(message *models.Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) SetMessageReaction(reaction *models.MessageReaction) error {
+	args := m.Called(reaction)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) DeleteMessageReaction(messageId string, username string) error {
+	args := m.Called(messageId, username)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) GetMessagesByChatIdSinceSequence(chatId string, sequence int64, limit int) ([]models.Message, error) {
+	args := m.Called(chatId, sequence, limit)
+	return args.Get(0).([]models.Message), args.Error(1)
+}
@@ -1,14 +1,23 @@
 package repositories
 
 import (
+	"github.com/google/uuid"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 type NotificationRepositoryInterface interface {
 	CreateNotification(notification *models.Notification) error
-	GetNotificationsByUsername(username string) ([]models.Notification, error)
+	GetNotificationsByUsername(username string, lastNotification *models.Notification, limit int) ([]models.Notification, int64, error)
 	GetNotificationById(notificationId string) (models.Notification, error)
+	GetNotificationByPostId(notificationType, forUsername, postId string) (models.Notification, error)
+	IncrementNotificationCount(notificationId string, fromUsername string, timestamp time.Time) (int64, error)
+	DecrementNotificationCount(notificationId string) error
+	MarkNotificationAsRead(notificationId string) error
+	MarkAllNotificationsAsRead(username string) error
+	CountUnreadNotifications(username string) (int64, error)
+	GetUnreadNotificationsByUsername(username string, since time.Time, limit int) ([]models.Notification, int64, error)
 	DeleteNotificationById(notificationId string) error
 }
 
@@ -26,27 +35,119 @@ func (repo *NotificationRepository) CreateNotification(notification *models.Noti
 	return err
 }
 
-func (repo *NotificationRepository) GetNotificationsByUsername(username string) ([]models.Notification, error) {
+func (repo *NotificationRepository) GetNotificationsByUsername(username string, lastNotification *models.Notification, limit int) ([]models.Notification, int64, error) {
 	var notifications []models.Notification
-	err := repo.DB.
-		Where("for_username = ?", username).
-		Order("timestamp desc").
+	var count int64
+
+	baseQuery := repo.DB.Model(&models.Notification{}).Where("for_username = ?", username)
+
+	// Number of notifications of the user
+	err := baseQuery.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if lastNotification.Id != uuid.Nil {
+		baseQuery = baseQuery.Where("(timestamp < ?) OR (timestamp = ? AND id < ?)", lastNotification.Timestamp, lastNotification.Timestamp, lastNotification.Id)
+	}
+
+	// Notifications subset based on pagination
+	err = baseQuery.
+		Order("timestamp desc, id desc").
+		Limit(limit).
 		Preload("FromUser").
 		Preload("FromUser.Image").
 		Find(&notifications).Error
-	return notifications, err
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, count, nil
 }
 
 func (repo *NotificationRepository) GetNotificationById(notificationId string) (models.Notification, error) {
 	var notification models.Notification
 	err := repo.DB.
 		Where("id = ?", notificationId).
-		First(&notification).
 		Preload("FromUser").
-		Preload("FromUser.Image").Error
+		Preload("FromUser.Image").
+		First(&notification).Error
 	return notification, err
 }
 
+func (repo *NotificationRepository) GetNotificationByPostId(notificationType, forUsername, postId string) (models.Notification, error) {
+	var notification models.Notification
+	err := repo.DB.
+		Where("notification_type = ? AND for_username = ? AND post_id = ?", notificationType, forUsername, postId).
+		First(&notification).Error
+	return notification, err
+}
+
+// IncrementNotificationCount adds an action of the given user to a grouped notification and marks it as unread again,
+// the count is increased by the database, so that concurrent actions are not lost, returns the number of updated notifications
+func (repo *NotificationRepository) IncrementNotificationCount(notificationId string, fromUsername string, timestamp time.Time) (int64, error) {
+	result := repo.DB.Model(&models.Notification{}).Where("id = ?", notificationId).Updates(map[string]interface{}{
+		"timestamp":     timestamp,
+		"from_username": fromUsername,
+		"count":         gorm.Expr("count + 1"),
+		"read":          false,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// DecrementNotificationCount takes back an action from a grouped notification and deletes the notification once no action is left
+func (repo *NotificationRepository) DecrementNotificationCount(notificationId string) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Notification{}).Where("id = ?", notificationId).Update("count", gorm.Expr("count - 1")).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND count <= ?", notificationId, 0).Delete(&models.Notification{}).Error
+	})
+}
+
+func (repo *NotificationRepository) MarkNotificationAsRead(notificationId string) error {
+	err := repo.DB.Model(&models.Notification{}).Where("id = ?", notificationId).Update("read", true).Error
+	return err
+}
+
+func (repo *NotificationRepository) MarkAllNotificationsAsRead(username string) error {
+	err := repo.DB.Model(&models.Notification{}).Where("for_username = ? AND read = ?", username, false).Update("read", true).Error
+	return err
+}
+
+func (repo *NotificationRepository) CountUnreadNotifications(username string) (int64, error) {
+	var count int64
+	err := repo.DB.Model(&models.Notification{}).Where("for_username = ? AND read = ?", username, false).Count(&count).Error
+	return count, err
+}
+
+// GetUnreadNotificationsByUsername returns the newest unread notifications of the user since the given time including the user that created them
+// and the number of all unread notifications since that time
+func (repo *NotificationRepository) GetUnreadNotificationsByUsername(username string, since time.Time, limit int) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var count int64
+
+	baseQuery := repo.DB.Model(&models.Notification{}).
+		Where("for_username = ? AND read = ? AND timestamp >= ?", username, false, since)
+
+	// Count results
+	err := baseQuery.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = baseQuery.
+		Order("timestamp desc, id desc").
+		Limit(limit).
+		Preload("FromUser").
+		Find(&notifications).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, count, nil
+}
+
 func (repo *NotificationRepository) DeleteNotificationById(notificationId string) error {
 	err := repo.DB.Where("id = ?", notificationId).Delete(&models.Notification{}).Error
 	return err
@@ -3,6 +3,7 @@ package repositories
 import (
 	"github.com/stretchr/testify/mock"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"time"
 )
 
 type MockNotificationRepository struct {
@@ -14,9 +15,9 @@ func (m *MockNotificationRepository) CreateNotification(notification *models.Not
 	return args.Error(0)
 }
 
-func (m *MockNotificationRepository) GetNotificationsByUsername(username string) ([]models.Notification, error) {
-	args := m.Called(username)
-	return args.Get(0).([]models.Notification), args.Error(1)
+func (m *MockNotificationRepository) GetNotificationsByUsername(username string, lastNotification *models.Notification, limit int) ([]models.Notification, int64, error) {
+	args := m.Called(username, lastNotification, limit)
+	return args.Get(0).([]models.Notification), args.Get(1).(int64), args.Error(2)
 }
 
 func (m *MockNotificationRepository) GetNotificationById(notificationId string) (models.Notification, error) {
@@ -24,6 +25,41 @@ func (m *MockNotificationRepository) GetNotificationById(notificationId string)
 	return args.Get(0).(models.Notification), args.Error(1)
 }
 
+func (m *MockNotificationRepository) GetNotificationByPostId(notificationType, forUsername, postId string) (models.Notification, error) {
+	args := m.Called(notificationType, forUsername, postId)
+	return args.Get(0).(models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) IncrementNotificationCount(notificationId string, fromUsername string, timestamp time.Time) (int64, error) {
+	args := m.Called(notificationId, fromUsername, timestamp)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepository) DecrementNotificationCount(notificationId string) error {
+	args := m.Called(notificationId)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) MarkNotificationAsRead(notificationId string) error {
+	args := m.Called(notificationId)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) MarkAllNotificationsAsRead(username string) error {
+	args := m.Called(username)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) CountUnreadNotifications(username string) (int64, error) {
+	args := m.Called(username)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepository) GetUnreadNotificationsByUsername(username string, since time.Time, limit int) ([]models.Notification, int64, error) {
+	args := m.Called(username, since, limit)
+	return args.Get(0).([]models.Notification), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockNotificationRepository) DeleteNotificationById(notificationId string) error {
 	args := m.Called(notificationId)
 	return args.Error(0)
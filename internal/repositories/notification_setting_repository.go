@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"gorm.io/gorm"
+)
+
+type NotificationSettingRepositoryInterface interface {
+	GetNotificationSettingsByUsername(username string) ([]models.NotificationSetting, error)
+	FindNotificationSetting(username string, notificationType string) (*models.NotificationSetting, error)
+	GetNotificationMutesByUsername(username string) ([]models.NotificationMute, error)
+	FindNotificationMute(username string, mutedUsername string) (*models.NotificationMute, error)
+	ReplaceNotificationSettings(username string, settings []models.NotificationSetting, mutes []models.NotificationMute) error
+}
+
+type NotificationSettingRepository struct {
+	DB *gorm.DB
+}
+
+// NewNotificationSettingRepository can be used as a constructor to create a NotificationSettingRepository "object"
+func NewNotificationSettingRepository(db *gorm.DB) *NotificationSettingRepository {
+	return &NotificationSettingRepository{DB: db}
+}
+
+func (repo *NotificationSettingRepository) GetNotificationSettingsByUsername(username string) ([]models.NotificationSetting, error) {
+	var settings []models.NotificationSetting
+	err := repo.DB.Where("username_fk = ?", username).Find(&settings).Error
+	return settings, err
+}
+
+func (repo *NotificationSettingRepository) FindNotificationSetting(username string, notificationType string) (*models.NotificationSetting, error) {
+	var setting models.NotificationSetting
+	err := repo.DB.Where("username_fk = ? AND notification_type = ?", username, notificationType).First(&setting).Error
+	return &setting, err
+}
+
+func (repo *NotificationSettingRepository) GetNotificationMutesByUsername(username string) ([]models.NotificationMute, error) {
+	var mutes []models.NotificationMute
+	err := repo.DB.
+		Where("username_fk = ?", username).
+		Order("created_at asc").
+		Preload("MutedUser").
+		Preload("MutedUser.Image").
+		Find(&mutes).Error
+	return mutes, err
+}
+
+func (repo *NotificationSettingRepository) FindNotificationMute(username string, mutedUsername string) (*models.NotificationMute, error) {
+	var mute models.NotificationMute
+	err := repo.DB.Where("username_fk = ? AND muted_username = ?", username, mutedUsername).First(&mute).Error
+	return &mute, err
+}
+
+// ReplaceNotificationSettings replaces all notification settings and muted users of a user in one transaction
+func (repo *NotificationSettingRepository) ReplaceNotificationSettings(username string, settings []models.NotificationSetting, mutes []models.NotificationMute) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username_fk = ?", username).Delete(&models.NotificationSetting{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("username_fk = ?", username).Delete(&models.NotificationMute{}).Error; err != nil {
+			return err
+		}
+		if len(settings) > 0 {
+			if err := tx.Omit("User").Create(&settings).Error; err != nil {
+				return err
+			}
+		}
+		if len(mutes) > 0 {
+			if err := tx.Omit("User", "MutedUser").Create(&mutes).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
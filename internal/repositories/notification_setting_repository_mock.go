@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+)
+
+type MockNotificationSettingRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationSettingRepository) GetNotificationSettingsByUsername(username string) ([]models.NotificationSetting, error) {
+	args := m.Called(username)
+	return args.Get(0).([]models.NotificationSetting), args.Error(1)
+}
+
+func (m *MockNotificationSettingRepository) FindNotificationSetting(username string, notificationType string) (*models.NotificationSetting, error) {
+	args := m.Called(username, notificationType)
+	return args.Get(0).(*models.NotificationSetting), args.Error(1)
+}
+
+func (m *MockNotificationSettingRepository) GetNotificationMutesByUsername(username string) ([]models.NotificationMute, error) {
+	args := m.Called(username)
+	return args.Get(0).([]models.NotificationMute), args.Error(1)
+}
+
+func (m *MockNotificationSettingRepository) FindNotificationMute(username string, mutedUsername string) (*models.NotificationMute, error) {
+	args := m.Called(username, mutedUsername)
+	return args.Get(0).(*models.NotificationMute), args.Error(1)
+}
+
+func (m *MockNotificationSettingRepository) ReplaceNotificationSettings(username string, settings []models.NotificationSetting, mutes []models.NotificationMute) error {
+	args := m.Called(username, settings, mutes)
+	return args.Error(0)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 type PostRepositoryInterface interface {
@@ -14,8 +15,12 @@ type PostRepositoryInterface interface {
 	GetPostById(postId string) (models.Post, error)
 	GetPostsGlobalFeed(lastPost *models.Post, limit int) ([]models.Post, int64, error)
 	GetPostsPersonalFeed(username string, lastPost *models.Post, limit int) ([]models.Post, int64, error)
+	UpdatePost(post *models.Post, revision *models.PostRevision) error
 	DeletePostById(postId string) error
+	DeletePostsByUsernameTx(username string, tx *gorm.DB) error
 	GetPostsByHashtag(hashtag string, lastPost *models.Post, limit int) ([]models.Post, int64, error)
+	GetPostsByMention(username string, lastPost *models.Post, limit int) ([]models.Post, int64, error)
+	GetTopPostsOfFollowedUsers(username string, since time.Time, limit int) ([]models.Post, error)
 }
 
 type PostRepository struct {
@@ -57,6 +62,8 @@ func (repo *PostRepository) GetPostsByUsername(username string, offset, limit in
 		Order("created_at desc, id desc").
 		Preload("Image").
 		Preload("Location").
+		Preload("Mentions").
+		Preload("Mentions.Image").
 		Find(&posts).Error
 	if err != nil {
 		return nil, 0, err
@@ -72,6 +79,8 @@ func (repo *PostRepository) GetPostById(postId string) (models.Post, error) {
 		Preload("Image").
 		Preload("User").
 		Preload("User.Image").
+		Preload("Mentions").
+		Preload("Mentions.Image").
 		Where("id = ?", postId).First(&post).Error
 	return post, err
 }
@@ -101,6 +110,8 @@ func (repo *PostRepository) GetPostsGlobalFeed(lastPost *models.Post, limit int)
 		Preload("Image").
 		Preload("User").
 		Preload("User.Image").
+		Preload("Mentions").
+		Preload("Mentions.Image").
 		Find(&posts).Error
 	if err != nil {
 		return nil, 0, err
@@ -135,6 +146,8 @@ func (repo *PostRepository) GetPostsPersonalFeed(username string, lastPost *mode
 		Preload("Image").
 		Preload("User").
 		Preload("User.Image").
+		Preload("Mentions").
+		Preload("Mentions.Image").
 		Find(&posts).Error
 	if err != nil {
 		return nil, 0, err
@@ -143,56 +156,151 @@ func (repo *PostRepository) GetPostsPersonalFeed(username string, lastPost *mode
 	return posts, count, nil
 }
 
-func (repo *PostRepository) DeletePostById(postId string) error {
+// UpdatePost saves the previous version of a post as revision and updates content, location, edit time, hashtags and mentions of the post
+func (repo *PostRepository) UpdatePost(post *models.Post, revision *models.PostRevision) error {
 	return repo.DB.Transaction(func(tx *gorm.DB) error {
-
-		var post models.Post
-		result := tx.First(&post, "id = ?", postId)
-		if result.Error != nil {
-			return result.Error
+		// Save previous version, the old location stays referenced by the revision
+		if err := tx.Create(revision).Error; err != nil {
+			return err
 		}
 
-		// Delete comments
-		if err := tx.Where("post_id = ?", post.Id).Delete(&models.Comment{}).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
+		// Create new location if it was changed
+		if post.LocationId != nil && (revision.LocationId == nil || *post.LocationId != *revision.LocationId) {
+			if err := tx.Create(&post.Location).Error; err != nil {
 				return err
 			}
 		}
 
-		// Delete likes
-		if err := tx.Where("post_id = ?", postId).Delete(&models.Like{}).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				return err
-			}
+		// Update post
+		err := tx.Model(&models.Post{Id: post.Id}).Updates(map[string]interface{}{
+			"content":     post.Content,
+			"location_id": post.LocationId,
+			"edited_at":   post.EditedAt,
+		}).Error
+		if err != nil {
+			return err
 		}
 
-		// Delete post
-		if err := tx.Where("id = ?", postId).Delete(&models.Post{}).Error; err != nil {
+		// Resync hashtag associations
+		hashtagAssociation := tx.Model(&models.Post{Id: post.Id}).Association("Hashtags")
+		if len(post.Hashtags) == 0 {
+			err = hashtagAssociation.Clear()
+		} else {
+			err = hashtagAssociation.Replace(post.Hashtags)
+		}
+		if err != nil {
 			return err
 		}
 
-		// Delete hashtag associations
-		if err := tx.Model(&models.Post{Id: post.Id}).Association("Hashtags").Clear(); err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				return err
-			}
+		// Resync mention associations
+		if len(post.Mentions) == 0 {
+			return tx.Model(&models.Post{Id: post.Id}).Association("Mentions").Clear()
 		}
+		return tx.Model(&models.Post{Id: post.Id}).Association("Mentions").Replace(post.Mentions)
+	})
+}
 
-		// Delete location
-		if err := tx.Where("id = ?", post.LocationId).Delete(&models.Location{}).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				return err
-			}
+func (repo *PostRepository) DeletePostById(postId string) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		return repo.deletePostByIdTx(postId, tx)
+	})
+}
+
+func (repo *PostRepository) DeletePostsByUsernameTx(username string, tx *gorm.DB) error {
+	// Find all posts of the user and delete them one by one to also clean up comments, likes, hashtags, etc.
+	var posts []models.Post
+	if err := tx.Where("username_fk = ?", username).Find(&posts).Error; err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		if err := repo.deletePostByIdTx(post.Id.String(), tx); err != nil {
+			return err
 		}
-		// Delete image
-		if err := tx.Where("id = ?", post.ImageId).Delete(&models.Image{}).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				return err
-			}
+	}
+
+	return nil
+}
+
+// deletePostByIdTx deletes a post with its comments, likes, hashtag and mention associations, location and image using the given transaction
+func (repo *PostRepository) deletePostByIdTx(postId string, tx *gorm.DB) error {
+	var post models.Post
+	result := tx.First(&post, "id = ?", postId)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// Delete comments with their likes and mentions
+	if err := tx.Where("comment_id IN (?)", tx.Model(&models.Comment{}).Select("id").Where("post_id = ?", post.Id)).Delete(&models.CommentLike{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM comment_mentions WHERE comment_id IN (SELECT id FROM comments WHERE post_id = ?)", post.Id).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ?", post.Id).Delete(&models.Comment{}).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
 		}
+	}
 
-		return nil
-	})
+	// Delete likes
+	if err := tx.Where("post_id = ?", postId).Delete(&models.Like{}).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	// Delete notifications referring to the post
+	if err := tx.Where("post_id = ?", postId).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+
+	// Delete revisions, their locations are deleted after the post
+	var revisionLocationIds []uuid.UUID
+	if err := tx.Model(&models.PostRevision{}).Where("post_id = ? AND location_id IS NOT NULL", postId).Pluck("location_id", &revisionLocationIds).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ?", postId).Delete(&models.PostRevision{}).Error; err != nil {
+		return err
+	}
+
+	// Delete post
+	if err := tx.Where("id = ?", postId).Delete(&models.Post{}).Error; err != nil {
+		return err
+	}
+
+	// Delete hashtag associations
+	if err := tx.Model(&models.Post{Id: post.Id}).Association("Hashtags").Clear(); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	// Delete mention associations
+	if err := tx.Model(&models.Post{Id: post.Id}).Association("Mentions").Clear(); err != nil {
+		return err
+	}
+
+	// Delete location
+	if err := tx.Where("id = ?", post.LocationId).Delete(&models.Location{}).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	if len(revisionLocationIds) > 0 {
+		if err := tx.Where("id IN ?", revisionLocationIds).Delete(&models.Location{}).Error; err != nil {
+			return err
+		}
+	}
+
+	// Delete image
+	if err := tx.Where("id = ?", post.ImageId).Delete(&models.Image{}).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (repo *PostRepository) GetPostsByHashtag(hashtag string, lastPost *models.Post, limit int) ([]models.Post, int64, error) {
@@ -223,6 +331,8 @@ func (repo *PostRepository) GetPostsByHashtag(hashtag string, lastPost *models.P
 		Preload("Image").
 		Preload("User").
 		Preload("User.Image").
+		Preload("Mentions").
+		Preload("Mentions.Image").
 		Find(&posts).Error
 	if err != nil {
 		return nil, 0, err
@@ -230,3 +340,53 @@ func (repo *PostRepository) GetPostsByHashtag(hashtag string, lastPost *models.P
 
 	return posts, count, err
 }
+
+func (repo *PostRepository) GetPostsByMention(username string, lastPost *models.Post, limit int) ([]models.Post, int64, error) {
+	var posts []models.Post
+	var count int64
+	var err error
+
+	baseQuery := repo.DB.Model(&models.Post{}).
+		Joins("JOIN post_mentions ON post_mentions.post_id = posts.id").
+		Where("post_mentions.user_username = ?", username)
+
+	// Number of posts mentioning the user
+	err = baseQuery.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if lastPost.Id != uuid.Nil {
+		baseQuery = baseQuery.Where("(posts.created_at < ?) OR (posts.created_at = ? AND posts.id < ?)", lastPost.CreatedAt, lastPost.CreatedAt, lastPost.Id)
+	}
+
+	// Posts subset based on pagination
+	err = baseQuery.
+		Order("posts.created_at desc, posts.id desc").
+		Limit(limit).
+		Preload("Location").
+		Preload("Image").
+		Preload("User").
+		Preload("User.Image").
+		Preload("Mentions").
+		Preload("Mentions.Image").
+		Find(&posts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, count, err
+}
+
+// GetTopPostsOfFollowedUsers returns the most liked posts that users followed by the given user created since the given time
+func (repo *PostRepository) GetTopPostsOfFollowedUsers(username string, since time.Time, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	err := repo.DB.Model(&models.Post{}).
+		Joins("JOIN subscriptions ON subscriptions.following = posts.username_fk").
+		Where("subscriptions.follower = ? AND posts.created_at >= ?", username, since).
+		Order("(SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id) desc, posts.created_at desc, posts.id desc").
+		Limit(limit).
+		Preload("User").
+		Find(&posts).Error
+	return posts, err
+}
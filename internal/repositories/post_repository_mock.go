@@ -3,6 +3,8 @@ package repositories
 import (
 	"github.com/stretchr/testify/mock"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"gorm.io/gorm"
+	"time"
 )
 
 type MockPostRepository struct {
@@ -39,12 +41,32 @@ func (m *MockPostRepository) GetPostsPersonalFeed(username string, lastPost *mod
 	return args.Get(0).([]models.Post), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockPostRepository) UpdatePost(post *models.Post, revision *models.PostRevision) error {
+	args := m.Called(post, revision)
+	return args.Error(0)
+}
+
 func (m *MockPostRepository) DeletePostById(postId string) error {
 	args := m.Called(postId)
 	return args.Error(0)
 }
 
+func (m *MockPostRepository) DeletePostsByUsernameTx(username string, tx *gorm.DB) error {
+	args := m.Called(username, tx)
+	return args.Error(0)
+}
+
 func (m *MockPostRepository) GetPostsByHashtag(hashtag string, lastPost *models.Post, limit int) ([]models.Post, int64, error) {
 	args := m.Called(hashtag, lastPost, limit)
 	return args.Get(0).([]models.Post), args.Get(1).(int64), args.Error(2)
 }
+
+func (m *MockPostRepository) GetPostsByMention(username string, lastPost *models.Post, limit int) ([]models.Post, int64, error) {
+	args := m.Called(username, lastPost, limit)
+	return args.Get(0).([]models.Post), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPostRepository) GetTopPostsOfFollowedUsers(username string, since time.Time, limit int) ([]models.Post, error) {
+	args := m.Called(username, since, limit)
+	return args.Get(0).([]models.Post), args.Error(1)
+}
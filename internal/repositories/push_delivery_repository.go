@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type PushDeliveryRepositoryInterface interface {
+	CreatePushDelivery(delivery *models.PushDelivery) error
+	UpdatePushDelivery(delivery *models.PushDelivery, attempt *models.PushDeliveryAttempt) error
+	ClaimDuePushDeliveries(now time.Time, lease time.Duration, limit int) ([]models.PushDelivery, error)
+	GetPushDeliveriesByStatus(status string, offset, limit int) ([]models.PushDelivery, int64, error)
+	DeleteFinishedPushDeliveries(before time.Time) (int64, error)
+}
+
+type PushDeliveryRepository struct {
+	DB *gorm.DB
+}
+
+// NewPushDeliveryRepository can be used as a constructor to create a PushDeliveryRepository "object"
+func NewPushDeliveryRepository(db *gorm.DB) *PushDeliveryRepository {
+	return &PushDeliveryRepository{DB: db}
+}
+
+func (repo *PushDeliveryRepository) CreatePushDelivery(delivery *models.PushDelivery) error {
+	return repo.DB.Omit("User", "AttemptLog").Create(delivery).Error
+}
+
+// UpdatePushDelivery saves the new state of a delivery together with the log entry of the attempt
+func (repo *PushDeliveryRepository) UpdatePushDelivery(delivery *models.PushDelivery, attempt *models.PushDeliveryAttempt) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "AttemptLog").Save(delivery).Error; err != nil {
+			return err
+		}
+		return tx.Create(attempt).Error
+	})
+}
+
+// ClaimDuePushDeliveries returns pending deliveries whose next attempt is due and postpones them by the lease,
+// so that other workers or server instances do not pick them up while they are sent
+// If a worker crashes, the delivery is retried after the lease has expired
+func (repo *PushDeliveryRepository) ClaimDuePushDeliveries(now time.Time, lease time.Duration, limit int) ([]models.PushDelivery, error) {
+	var deliveries []models.PushDelivery
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).
+			Order("next_attempt_at asc").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]string, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.Id.String())
+		}
+		return tx.Model(&models.PushDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return deliveries, err
+}
+
+// GetPushDeliveriesByStatus returns deliveries with the given status including their attempt log, newest first
+func (repo *PushDeliveryRepository) GetPushDeliveriesByStatus(status string, offset, limit int) ([]models.PushDelivery, int64, error) {
+	var deliveries []models.PushDelivery
+	var count int64
+
+	baseQuery := repo.DB.Model(&models.PushDelivery{}).Where("status = ?", status)
+	if err := baseQuery.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := baseQuery.
+		Offset(offset).
+		Limit(limit).
+		Order("updated_at desc, id desc").
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempt_number asc")
+		}).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, count, nil
+}
+
+// DeleteFinishedPushDeliveries deletes sent, failed and gone deliveries that were last updated before the given time
+// together with their attempt log, pending deliveries are kept until they are finished
+func (repo *PushDeliveryRepository) DeleteFinishedPushDeliveries(before time.Time) (int64, error) {
+	var count int64
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		finished := tx.Model(&models.PushDelivery{}).Select("id").Where("status <> ? AND updated_at < ?", "pending", before)
+		if err := tx.Where("delivery_id IN (?)", finished).Delete(&models.PushDeliveryAttempt{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("status <> ? AND updated_at < ?", "pending", before).Delete(&models.PushDelivery{})
+		count = result.RowsAffected
+		return result.Error
+	})
+	return count, err
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"time"
+)
+
+type MockPushDeliveryRepository struct {
+	mock.Mock
+}
+
+func (m *MockPushDeliveryRepository) CreatePushDelivery(delivery *models.PushDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockPushDeliveryRepository) UpdatePushDelivery(delivery *models.PushDelivery, attempt *models.PushDeliveryAttempt) error {
+	args := m.Called(delivery, attempt)
+	return args.Error(0)
+}
+
+func (m *MockPushDeliveryRepository) ClaimDuePushDeliveries(now time.Time, lease time.Duration, limit int) ([]models.PushDelivery, error) {
+	args := m.Called(now, lease, limit)
+	return args.Get(0).([]models.PushDelivery), args.Error(1)
+}
+
+func (m *MockPushDeliveryRepository) GetPushDeliveriesByStatus(status string, offset, limit int) ([]models.PushDelivery, int64, error) {
+	args := m.Called(status, offset, limit)
+	return args.Get(0).([]models.PushDelivery), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPushDeliveryRepository) DeleteFinishedPushDeliveries(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -9,6 +9,10 @@ type PushSubscriptionRepositoryInterface interface {
 	CreatePushSubscription(pushSubscription *models.PushSubscription) error
 	GetPushSubscriptionsByUsername(username string) ([]models.PushSubscription, error)
 	DeletePushSubscriptionById(pushSubscriptionId string) error
+	GetPushSubscriptionById(pushSubscriptionId string) (*models.PushSubscription, error)
+	FindPushSubscription(subscriptionType string, endpoint string, expoToken string) (*models.PushSubscription, error)
+	UpdatePushSubscription(pushSubscription *models.PushSubscription) error
+	DeletePushSubscriptionsBySessionId(sessionId string, exceptPushSubscriptionId string) error
 }
 
 type PushSubscriptionRepository struct {
@@ -28,7 +32,9 @@ func (repo *PushSubscriptionRepository) CreatePushSubscription(pushSubscription
 func (repo *PushSubscriptionRepository) GetPushSubscriptionsByUsername(username string) ([]models.PushSubscription, error) {
 	var pushSubscriptions []models.PushSubscription
 	err := repo.DB.
+		Preload("User").
 		Where("username_fk = ?", username).
+		Order("created_at desc").
 		Find(&pushSubscriptions).Error
 	return pushSubscriptions, err
 }
@@ -37,3 +43,33 @@ func (repo *PushSubscriptionRepository) DeletePushSubscriptionById(pushSubscript
 	err := repo.DB.Where("id = ?", pushSubscriptionId).Delete(&models.PushSubscription{}).Error
 	return err
 }
+
+func (repo *PushSubscriptionRepository) GetPushSubscriptionById(pushSubscriptionId string) (*models.PushSubscription, error) {
+	var pushSubscription models.PushSubscription
+	err := repo.DB.Where("id = ?", pushSubscriptionId).First(&pushSubscription).Error
+	return &pushSubscription, err
+}
+
+// FindPushSubscription finds a web subscription by its endpoint or an expo, fcm or apns subscription by its token
+func (repo *PushSubscriptionRepository) FindPushSubscription(subscriptionType string, endpoint string, token string) (*models.PushSubscription, error) {
+	var pushSubscription models.PushSubscription
+	query := repo.DB.Where("type = ?", subscriptionType)
+	if subscriptionType == "web" {
+		query = query.Where("endpoint = ?", endpoint)
+	} else if subscriptionType == "expo" {
+		query = query.Where("expo_token = ?", token)
+	} else {
+		query = query.Where("device_token = ?", token)
+	}
+	err := query.First(&pushSubscription).Error
+	return &pushSubscription, err
+}
+
+func (repo *PushSubscriptionRepository) UpdatePushSubscription(pushSubscription *models.PushSubscription) error {
+	return repo.DB.Omit("User").Save(pushSubscription).Error
+}
+
+// DeletePushSubscriptionsBySessionId deletes all subscriptions of a device session except the given one
+func (repo *PushSubscriptionRepository) DeletePushSubscriptionsBySessionId(sessionId string, exceptPushSubscriptionId string) error {
+	return repo.DB.Where("session_id = ? AND id <> ?", sessionId, exceptPushSubscriptionId).Delete(&models.PushSubscription{}).Error
+}
@@ -23,3 +23,23 @@ func (m *MockPushSubscriptionRepository) DeletePushSubscriptionById(subscription
 	args := m.Called(subscriptionId)
 	return args.Error(0)
 }
+
+func (m *MockPushSubscriptionRepository) GetPushSubscriptionById(pushSubscriptionId string) (*models.PushSubscription, error) {
+	args := m.Called(pushSubscriptionId)
+	return args.Get(0).(*models.PushSubscription), args.Error(1)
+}
+
+func (m *MockPushSubscriptionRepository) FindPushSubscription(subscriptionType string, endpoint string, token string) (*models.PushSubscription, error) {
+	args := m.Called(subscriptionType, endpoint, token)
+	return args.Get(0).(*models.PushSubscription), args.Error(1)
+}
+
+func (m *MockPushSubscriptionRepository) UpdatePushSubscription(pushSubscription *models.PushSubscription) error {
+	args := m.Called(pushSubscription)
+	return args.Error(0)
+}
+
+func (m *MockPushSubscriptionRepository) DeletePushSubscriptionsBySessionId(sessionId string, exceptPushSubscriptionId string) error {
+	args := m.Called(sessionId, exceptPushSubscriptionId)
+	return args.Error(0)
+}
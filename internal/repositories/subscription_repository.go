@@ -9,6 +9,7 @@ type SubscriptionRepositoryInterface interface {
 	CreateSubscription(subscription *models.Subscription) error
 	DeleteSubscription(subscriptionId string) error
 	GetSubscriptionByUsernames(follower, following string) (*models.Subscription, error)
+	GetFollowerUsernames(following string, usernames []string) ([]string, error)
 	GetSubscriptionById(subscriptionId string) (*models.Subscription, error)
 	GetSubscriptionCountByUsername(username string) (int64, int64, error)
 	GetFollowers(limit int, offset int, username string, currentUsername string) ([]models.UserSubscriptionSQLRecordDTO, int64, error)
@@ -37,6 +38,15 @@ func (repo *SubscriptionRepository) GetSubscriptionByUsernames(follower, followi
 	return &subscription, err
 }
 
+// GetFollowerUsernames returns the given usernames of the users that follow the user
+func (repo *SubscriptionRepository) GetFollowerUsernames(following string, usernames []string) ([]string, error) {
+	var followerUsernames []string
+	err := repo.DB.Model(&models.Subscription{}).
+		Where("following = ? AND follower IN ?", following, usernames).
+		Pluck("follower", &followerUsernames).Error
+	return followerUsernames, err
+}
+
 func (repo *SubscriptionRepository) GetSubscriptionById(subscriptionId string) (*models.Subscription, error) {
 	var subscription models.Subscription
 	err := repo.DB.Where("id = ?", subscriptionId).First(&subscription).Error
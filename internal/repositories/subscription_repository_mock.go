@@ -24,6 +24,11 @@ func (m *MockSubscriptionRepository) GetSubscriptionByUsernames(follower, follow
 	return args.Get(0).(*models.Subscription), args.Error(1)
 }
 
+func (m *MockSubscriptionRepository) GetFollowerUsernames(following string, usernames []string) ([]string, error) {
+	args := m.Called(following, usernames)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *MockSubscriptionRepository) GetSubscriptionById(subscriptionId string) (*models.Subscription, error) {
 	args := m.Called(subscriptionId)
 	return args.Get(0).(*models.Subscription), args.Error(1)
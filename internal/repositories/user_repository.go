@@ -4,10 +4,12 @@ import (
 	"errors"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 type UserRepositoryInterface interface {
 	FindUserByUsername(username string) (*models.User, error)
+	FindUsersByUsernames(usernames []string) ([]models.User, error)
 	BeginTx() *gorm.DB
 	CommitTx(tx *gorm.DB) error
 	RollbackTx(tx *gorm.DB)
@@ -15,9 +17,11 @@ type UserRepositoryInterface interface {
 	CheckEmailExistsForUpdate(email string, tx *gorm.DB) (bool, error)
 	CheckUsernameExistsForUpdate(username string, tx *gorm.DB) (bool, error)
 	UpdateUser(user *models.User) error
+	UpdateLastSeen(username string, lastSeen time.Time) error
 	SearchUser(username string, limit int, offset int, currentUsername string) ([]models.User, int64, error)
 	GetUnactivatedUsers() ([]models.User, error)
 	DeleteUserByUsername(username string) error
+	DeleteUserTx(username string, tx *gorm.DB) error
 }
 
 type UserRepository struct {
@@ -35,6 +39,12 @@ func (repo *UserRepository) FindUserByUsername(username string) (*models.User, e
 	return &user, err
 }
 
+func (repo *UserRepository) FindUsersByUsernames(usernames []string) ([]models.User, error) {
+	var users []models.User
+	err := repo.DB.Where("username IN ?", usernames).Preload("Image").Find(&users).Error
+	return users, err
+}
+
 func (repo *UserRepository) BeginTx() *gorm.DB {
 	return repo.DB.Begin()
 }
@@ -88,6 +98,11 @@ func (repo *UserRepository) UpdateUser(user *models.User) error {
 	})
 }
 
+// UpdateLastSeen only changes the last-seen timestamp, so that it does not overwrite concurrent changes of the user
+func (repo *UserRepository) UpdateLastSeen(username string, lastSeen time.Time) error {
+	return repo.DB.Model(&models.User{}).Where("username = ?", username).Update("last_seen_at", lastSeen).Error
+}
+
 func (repo *UserRepository) SearchUser(username string, limit int, offset int, currentUsername string) ([]models.User, int64, error) {
 	var users []models.User
 	var count int64
@@ -168,8 +183,11 @@ func (repo *UserRepository) DeleteUserByUsername(username string) error {
 			}
 		}
 
-		// Delete messages
-		if err := tx.Where("username_fk = ?", username).Delete(&models.Message{}).Error; err != nil {
+		// Delete reactions and messages of the user
+		if err := tx.Where("username_fk = ?", username).Delete(&models.MessageReaction{}).Error; err != nil {
+			return err
+		}
+		if err := deleteMessagesTx(tx, "username_fk = ?", username); err != nil {
 			if !errors.Is(err, gorm.ErrRecordNotFound) {
 				return err
 			}
@@ -183,7 +201,7 @@ func (repo *UserRepository) DeleteUserByUsername(username string) error {
 
 		// Delete all messages in each chat
 		for _, chat := range chats {
-			if err := tx.Where("chat_id = ?", chat.Id).Delete(&models.Message{}).Error; err != nil {
+			if err := deleteMessagesTx(tx, "chat_id = ?", chat.Id); err != nil {
 				return err
 			}
 		}
@@ -203,3 +221,124 @@ func (repo *UserRepository) DeleteUserByUsername(username string) error {
 		return nil
 	})
 }
+
+func (repo *UserRepository) DeleteUserTx(username string, tx *gorm.DB) error {
+	// This function deletes an activated user with all related data except for the posts
+	// Posts have to be deleted beforehand using the same transaction, so that comments, likes, etc. of the posts are cleaned up
+	var user models.User
+	if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
+		return err
+	}
+
+	// Delete comments and likes of the user on other posts
+	// Replies of other users to these comments are deleted as well on purpose, the same as when the author deletes a comment:
+	// replies are only shown below their parent comment and would be unreachable without it
+	if err := deleteCommentsWithRepliesTx(tx, "username_fk = ?", username); err != nil {
+		return err
+	}
+	if err := tx.Where("username_fk = ?", username).Delete(&models.Like{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("username_fk = ?", username).Delete(&models.CommentLike{}).Error; err != nil {
+		return err
+	}
+
+	// Delete subscriptions in both directions
+	if err := tx.Where("following = ? OR follower = ?", username, username).Delete(&models.Subscription{}).Error; err != nil {
+		return err
+	}
+
+	// Delete mentions of the user in posts and comments of other users
+	if err := tx.Exec("DELETE FROM post_mentions WHERE user_username = ?", username).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM comment_mentions WHERE user_username = ?", username).Error; err != nil {
+		return err
+	}
+
+	// Delete push subscriptions and the push outbox with its attempt log
+	if err := tx.Where("username_fk = ?", username).Delete(&models.PushSubscription{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("delivery_id IN (?)", tx.Model(&models.PushDelivery{}).Select("id").Where("username_fk = ?", username)).Delete(&models.PushDeliveryAttempt{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("username_fk = ?", username).Delete(&models.PushDelivery{}).Error; err != nil {
+		return err
+	}
+
+	// Delete notifications the user received or created
+	if err := tx.Where("for_username = ? OR from_username = ?", username, username).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+
+	// Delete notification settings and mutes of the user or for the user
+	if err := tx.Where("username_fk = ?", username).Delete(&models.NotificationSetting{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("username_fk = ? OR muted_username = ?", username, username).Delete(&models.NotificationMute{}).Error; err != nil {
+		return err
+	}
+
+	// Delete activation and password reset tokens
+	if err := tx.Where("username_fk = ?", username).Delete(&models.ActivationToken{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("username_fk = ?", username).Delete(&models.PasswordResetToken{}).Error; err != nil {
+		return err
+	}
+
+	// Delete data exports and digest setting
+	if err := tx.Where("username_fk = ?", username).Delete(&models.DataExport{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("username_fk = ?", username).Delete(&models.DigestSetting{}).Error; err != nil {
+		return err
+	}
+
+	// Delete reactions of the user to messages
+	if err := tx.Where("username_fk = ?", username).Delete(&models.MessageReaction{}).Error; err != nil {
+		return err
+	}
+
+	// Find chats where the user is a participant and delete direct chats with all messages
+	// The user only leaves group chats, their messages in these chats are deleted
+	var chats []models.Chat
+	if err := tx.Model(&models.Chat{}).Joins("JOIN chat_users ON chat_users.chat_id = chats.id").Where("chat_users.user_username = ?", username).Find(&chats).Error; err != nil {
+		return err
+	}
+	for _, chat := range chats {
+		if chat.IsGroup {
+			if err := deleteMessagesTx(tx, "chat_id = ? AND username_fk = ?", chat.Id, username); err != nil {
+				return err
+			}
+			if err := removeChatMemberTx(tx, chat.Id.String(), username); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := deleteMessagesTx(tx, "chat_id = ?", chat.Id); err != nil {
+			return err
+		}
+		if err := tx.Model(&chat).Association("Users").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", chat.Id).Delete(&models.Chat{}).Error; err != nil {
+			return err
+		}
+	}
+
+	// Delete user
+	if err := tx.Where("username = ?", username).Delete(&models.User{}).Error; err != nil {
+		return err
+	}
+
+	// Delete profile picture after the user, because the user references the image
+	if user.ImageId != nil {
+		if err := tx.Where("id = ?", user.ImageId.String()).Delete(&models.Image{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
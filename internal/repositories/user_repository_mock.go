@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 // MockUserRepository is a mock implementation of the UserRepositoryInterface
@@ -16,6 +17,11 @@ func (m *MockUserRepository) FindUserByUsername(username string) (*models.User,
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindUsersByUsernames(usernames []string) ([]models.User, error) {
+	args := m.Called(usernames)
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) BeginTx() *gorm.DB {
 	args := m.Called()
 	return args.Get(0).(*gorm.DB)
@@ -51,6 +57,11 @@ func (m *MockUserRepository) UpdateUser(user *models.User) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdateLastSeen(username string, lastSeen time.Time) error {
+	args := m.Called(username, lastSeen)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) SearchUser(username string, limit int, offset int, currentUsername string) ([]models.User, int64, error) {
 	args := m.Called(username, limit, offset, currentUsername)
 	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
@@ -65,3 +76,8 @@ func (m *MockUserRepository) DeleteUserByUsername(username string) error {
 	args := m.Called(username)
 	return args.Error(0)
 }
+
+func (m *MockUserRepository) DeleteUserTx(username string, tx *gorm.DB) error {
+	args := m.Called(username, tx)
+	return args.Error(0)
+}
@@ -30,7 +30,6 @@ func SetupRouter() *gin.Engine {
 	err := r.SetTrustedProxies([]string{os.Getenv("PROXY_HOST")})
 	if err != nil {
 		panic(err)
-		return nil
 	}
 
 	// Recover from panics and return 500 Internal Server Error
@@ -52,26 +51,35 @@ func SetupRouter() *gin.Engine {
 	subscriptionRepo := repositories.NewSubscriptionRepository(initializers.DB)
 	likeRepo := repositories.NewLikeRepository(initializers.DB)
 	notificationRepo := repositories.NewNotificationRepository(initializers.DB)
+	notificationSettingRepo := repositories.NewNotificationSettingRepository(initializers.DB)
 	pushSubscriptionRepo := repositories.NewPushSubscriptionRepository(initializers.DB)
+	pushDeliveryRepo := repositories.NewPushDeliveryRepository(initializers.DB)
 	passwordResetRepo := repositories.NewPasswordResetRepository(initializers.DB)
 	chatRepo := repositories.NewChatRepository(initializers.DB)
 	messageRepo := repositories.NewMessageRepository(initializers.DB)
 	imageRepo := repositories.NewImageRepository(initializers.DB)
+	dataExportRepo := repositories.NewDataExportRepository(initializers.DB)
+	digestRepo := repositories.NewDigestRepository(initializers.DB)
+	chatBrokerRepo := repositories.NewChatBrokerRepository(initializers.DB)
 
 	validator := utils.NewValidator()
+	chatBroker := services.NewChatBrokerFromEnv(chatBrokerRepo, initializers.DatabaseDsn())
+	presenceService := services.NewPresenceService(userRepo, subscriptionRepo, chatBroker)
 	mailService := services.NewMailService()
 	imageService := services.NewImageService(imageRepo)
-	userService := services.NewUserService(userRepo, activationTokenRepo, mailService, validator, postRepo, imageRepo, subscriptionRepo)
+	userService := services.NewUserService(userRepo, activationTokenRepo, mailService, validator, postRepo, imageRepo, subscriptionRepo, presenceService)
 	feedService := services.NewFeedService(postRepo, userRepo, likeRepo, commentRepo)
-	likeService := services.NewLikeService(likeRepo, postRepo)
-	pushSubscriptionService := services.NewPushSubscriptionService(pushSubscriptionRepo)
-	notificationService := services.NewNotificationService(notificationRepo, pushSubscriptionService)
+	pushSubscriptionService := services.NewPushSubscriptionService(pushSubscriptionRepo, pushDeliveryRepo)
+	notificationService := services.NewNotificationService(notificationRepo, pushSubscriptionService, notificationSettingRepo, userRepo)
+	likeService := services.NewLikeService(likeRepo, postRepo, commentRepo, notificationService)
 	subscriptionService := services.NewSubscriptionService(subscriptionRepo, userRepo, notificationService)
-	commentService := services.NewCommentService(commentRepo, postRepo, userRepo)
+	commentService := services.NewCommentService(commentRepo, postRepo, userRepo, likeRepo, notificationService)
 	postService := services.NewPostService(postRepo, userRepo, hashtagRepo, validator, likeRepo, commentRepo, notificationService)
 	passwordResetService := services.NewPasswordResetService(userRepo, passwordResetRepo, mailService, validator)
-	chatService := services.NewChatService(chatRepo, userRepo, notificationService)
-	messageService := services.NewMessageService(messageRepo, chatRepo, notificationService)
+	chatService := services.NewChatService(chatRepo, userRepo, notificationService, validator, chatBroker, presenceService)
+	messageService := services.NewMessageService(messageRepo, chatRepo, notificationService, validator)
+	dataExportService := services.NewDataExportService(dataExportRepo, userRepo, mailService)
+	digestService := services.NewDigestService(digestRepo)
 
 	imprintController := controllers.NewImprintController()
 	userController := controllers.NewUserController(userService)
@@ -80,12 +88,14 @@ func SetupRouter() *gin.Engine {
 	imageController := controllers.NewImageController(imageService)
 	likeController := controllers.NewLikeController(likeService)
 	chatController := controllers.NewChatController(chatService)
-	messageController := controllers.NewMessageController(messageService)
+	messageController := controllers.NewMessageController(messageService, chatBroker, presenceService)
 	passwordResetController := controllers.NewPasswordResetController(passwordResetService)
 	notificationController := controllers.NewNotificationController(notificationService)
 	pushSubscriptionController := controllers.NewPushSubscriptionController(pushSubscriptionService)
 	commentController := controllers.NewCommentController(commentService)
 	subscriptionController := controllers.NewSubscriptionController(subscriptionService)
+	dataExportController := controllers.NewDataExportController(dataExportService)
+	digestController := controllers.NewDigestController(digestService)
 
 	// Empty route for standard information
 	r.GET("/", func(c *gin.Context) {
@@ -109,11 +119,27 @@ func SetupRouter() *gin.Engine {
 	api.GET("/users", middleware.AuthorizeUser, userController.SearchUser)
 	api.PUT("/users", middleware.AuthorizeUser, userController.UpdateUserInformation)
 	api.PATCH("/users", middleware.AuthorizeUser, userController.ChangeUserPassword)
+	api.DELETE("/users", middleware.AuthorizeUser, userController.DeleteUser)
 	api.GET("/users/:username", middleware.AuthorizeUser, userController.GetUserProfile)
 	api.GET("/users/:username/feed", middleware.AuthorizeUser, feedController.GetPostsByUserUsername)
+	api.GET("/users/:username/mentions", middleware.AuthorizeUser, feedController.GetPostsByMention)
+	api.GET("/users/me/export", middleware.AuthorizeUser, dataExportController.CreateDataExport)
+	api.GET("/users/me/notification-settings", middleware.AuthorizeUser, notificationController.GetNotificationSettings)
+	api.PUT("/users/me/notification-settings", middleware.AuthorizeUser, notificationController.UpdateNotificationSettings)
+	api.GET("/exports/:exportId", dataExportController.GetDataExportArchive) // authenticated with token from mail
+	api.GET("/users/me/digest-settings", middleware.AuthorizeUser, digestController.GetDigestSetting)
+	api.PUT("/users/me/digest-settings", middleware.AuthorizeUser, digestController.UpdateDigestSetting)
+	api.GET("/users/me/locale", middleware.AuthorizeUser, userController.GetUserLocale)
+	api.PUT("/users/me/locale", middleware.AuthorizeUser, userController.UpdateUserLocale)
+	api.GET("/users/me/privacy-settings", middleware.AuthorizeUser, userController.GetPrivacySettings)
+	api.PUT("/users/me/privacy-settings", middleware.AuthorizeUser, userController.UpdatePrivacySettings)
+	api.GET("/digests/unsubscribe", digestController.GetUnsubscribePage)
+	api.POST("/digests/unsubscribe", digestController.Unsubscribe) // authenticated with token from mail
 
 	// Post
 	api.POST("/posts", middleware.AuthorizeUser, postController.CreatePost)
+	api.GET("/posts/:postId", middleware.AuthorizeUser, feedController.GetPostById)
+	api.PATCH("/posts/:postId", middleware.AuthorizeUser, postController.UpdatePost)
 	api.DELETE("/posts/:postId", middleware.AuthorizeUser, postController.DeletePost)
 	api.GET("/feed", feedController.GetPostFeed)
 	api.GET("/posts", middleware.AuthorizeUser, feedController.GetPostsByHashtag)
@@ -133,20 +159,41 @@ func SetupRouter() *gin.Engine {
 	// Comment
 	api.POST("/posts/:postId/comments", middleware.AuthorizeUser, commentController.CreateComment)
 	api.GET("/posts/:postId/comments", middleware.AuthorizeUser, commentController.GetCommentsByPostId)
+	api.PATCH("/posts/:postId/comments/:commentId", middleware.AuthorizeUser, commentController.UpdateComment)
+	api.DELETE("/posts/:postId/comments/:commentId", middleware.AuthorizeUser, commentController.DeleteComment)
+	api.GET("/posts/:postId/comments/:commentId/replies", middleware.AuthorizeUser, commentController.GetRepliesByCommentId)
+	api.POST("/posts/:postId/comments/:commentId/likes", middleware.AuthorizeUser, likeController.PostCommentLike)
+	api.DELETE("/posts/:postId/comments/:commentId/likes", middleware.AuthorizeUser, likeController.DeleteCommentLike)
 
 	// Notification
 	api.GET("/notifications", middleware.AuthorizeUser, notificationController.GetNotifications)
+	api.GET("/notifications/unread-count", middleware.AuthorizeUser, notificationController.GetUnreadNotificationCount)
+	api.POST("/notifications/read", middleware.AuthorizeUser, notificationController.MarkAllNotificationsAsRead)
+	api.POST("/notifications/:notificationId/read", middleware.AuthorizeUser, notificationController.MarkNotificationAsRead)
 	api.DELETE("/notifications/:notificationId", middleware.AuthorizeUser, notificationController.DeleteNotificationById)
+	api.GET("/notifications/ws", notificationController.HandleWebSocket) // Websocket endpoint, authenticated with Sec-WebSocket-Protocol header
 
 	// Push subscription (for web or mobile push notifications)
 	api.GET("/push/vapid", middleware.AuthorizeUser, pushSubscriptionController.GetVapidKey)
 	api.POST("/push/register", middleware.AuthorizeUser, pushSubscriptionController.CreatePushSubscription)
+	api.GET("/push/subscriptions", middleware.AuthorizeUser, pushSubscriptionController.GetPushSubscriptions)
+	api.DELETE("/push/subscriptions/:subscriptionId", middleware.AuthorizeUser, pushSubscriptionController.DeletePushSubscription)
+	api.GET("/internal/push/deliveries/failed", middleware.AuthorizeInternal, pushSubscriptionController.GetFailedPushDeliveries) // authenticated with internal api key
 
 	// Chat
 	api.POST("/chats", middleware.AuthorizeUser, chatController.CreateChat)
 	api.GET("/chats", middleware.AuthorizeUser, chatController.GetChats)
 	api.GET("/chats/:chatId", middleware.AuthorizeUser, messageController.GetMessagesByChatId)
-	api.GET("/chat", messageController.HandleWebSocket) // Websocket endpoint
+	api.POST("/chats/:chatId/members", middleware.AuthorizeUser, chatController.AddChatMembers)
+	api.PATCH("/chats/:chatId/members/:username", middleware.AuthorizeUser, chatController.UpdateChatMemberRole)
+	api.DELETE("/chats/:chatId/members/:username", middleware.AuthorizeUser, chatController.RemoveChatMember)
+	api.POST("/chats/:chatId/leave", middleware.AuthorizeUser, chatController.LeaveChat)
+	api.PATCH("/chats/:chatId/messages/:messageId", middleware.AuthorizeUser, messageController.UpdateMessage)
+	api.DELETE("/chats/:chatId/messages/:messageId", middleware.AuthorizeUser, messageController.DeleteMessage)
+	api.PUT("/chats/:chatId/messages/:messageId/reaction", middleware.AuthorizeUser, messageController.SetMessageReaction)
+	api.DELETE("/chats/:chatId/messages/:messageId/reaction", middleware.AuthorizeUser, messageController.DeleteMessageReaction)
+	api.GET("/chat", messageController.HandleWebSocket)         // Websocket endpoint
+	api.GET("/chats/ws", messageController.HandleUserWebSocket) // Websocket endpoint for all chats of the user, authenticated with Sec-WebSocket-Protocol header
 
 	// Reset Password
 	api.POST("/users/:username/reset-password", passwordResetController.InitiatePasswordReset)
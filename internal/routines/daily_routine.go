@@ -3,7 +3,10 @@ package routines
 import (
 	"fmt"
 	"github.com/wwi21seb-projekt/server-beta/internal/initializers"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
 	"time"
 )
 
@@ -12,6 +15,12 @@ import (
 func StartDailyRoutines() {
 	// Arrange
 	userRepo := repositories.NewUserRepository(initializers.DB)
+	dataExportRepo := repositories.NewDataExportRepository(initializers.DB)
+	digestRepo := repositories.NewDigestRepository(initializers.DB)
+	notificationRepo := repositories.NewNotificationRepository(initializers.DB)
+	postRepo := repositories.NewPostRepository(initializers.DB)
+	pushDeliveryRepo := repositories.NewPushDeliveryRepository(initializers.DB)
+	mailService := services.NewMailService()
 
 	for {
 		now := time.Now()
@@ -25,6 +34,15 @@ func StartDailyRoutines() {
 
 		// Will be called daily at 3 AM to delete users that did not verify their email address
 		DeleteUnactivatedUsers(userRepo)
+
+		// Delete data exports whose download links have expired
+		DeleteExpiredDataExports(dataExportRepo)
+
+		// Delete finished push deliveries and their attempt log after the retention period
+		DeleteOldPushDeliveries(pushDeliveryRepo)
+
+		// Send daily digests and on mondays weekly digests
+		SendEmailDigests(digestRepo, notificationRepo, postRepo, mailService, time.Now())
 	}
 }
 
@@ -55,3 +73,109 @@ func DeleteUnactivatedUsers(userRepo repositories.UserRepositoryInterface) {
 
 	fmt.Println("Deleted ", counter, " unactivated users")
 }
+
+// DeleteExpiredDataExports deletes all data exports whose download links have expired
+func DeleteExpiredDataExports(dataExportRepo repositories.DataExportRepositoryInterface) {
+	fmt.Println("Delete expired data exports...")
+
+	count, err := dataExportRepo.DeleteExpiredDataExports()
+	if err != nil {
+		fmt.Println("Error deleting expired data exports: ", err)
+		return
+	}
+
+	fmt.Println("Deleted ", count, " expired data exports")
+}
+
+// pushDeliveryRetention is how long finished push deliveries are kept for debugging push problems
+const pushDeliveryRetention = 14 * 24 * time.Hour
+
+// DeleteOldPushDeliveries deletes all sent, failed and gone push deliveries that were finished longer than the retention period ago
+func DeleteOldPushDeliveries(pushDeliveryRepo repositories.PushDeliveryRepositoryInterface) {
+	fmt.Println("Delete old push deliveries...")
+
+	count, err := pushDeliveryRepo.DeleteFinishedPushDeliveries(time.Now().Add(-pushDeliveryRetention))
+	if err != nil {
+		fmt.Println("Error deleting old push deliveries: ", err)
+		return
+	}
+
+	fmt.Println("Deleted ", count, " old push deliveries")
+}
+
+// SendEmailDigests sends an email with unread notifications and top posts of followed users to all users
+// that chose the daily digest and on mondays also to all users that chose the weekly digest
+func SendEmailDigests(
+	digestRepo repositories.DigestRepositoryInterface,
+	notificationRepo repositories.NotificationRepositoryInterface,
+	postRepo repositories.PostRepositoryInterface,
+	mailService services.MailServiceInterface,
+	now time.Time) {
+	fmt.Println("Send email digests...")
+
+	periods := map[string]time.Duration{"daily": 24 * time.Hour}
+	if now.Weekday() == time.Monday {
+		periods["weekly"] = 7 * 24 * time.Hour
+	}
+
+	counter := 0
+	for frequency, period := range periods {
+		settings, err := digestRepo.GetDigestSettingsByFrequency(frequency)
+		if err != nil {
+			fmt.Println("Failed loading digest settings from database: ", err)
+			continue
+		}
+
+		for _, setting := range settings {
+			// Skip users that already received a digest in this period, e.g. if the server was restarted
+			if setting.LastSentAt != nil && now.Sub(*setting.LastSentAt) < period-time.Hour {
+				continue
+			}
+
+			if sendEmailDigest(&setting, period, notificationRepo, postRepo, mailService, now) {
+				setting.LastSentAt = &now
+				if err := digestRepo.UpdateDigestSetting(&setting); err != nil {
+					fmt.Println("Error updating digest setting: ", setting.Username, err)
+				}
+				counter++
+			}
+		}
+	}
+
+	fmt.Println("Sent ", counter, " email digests")
+}
+
+// sendEmailDigest collects the digest content of one user and sends it via mail, returns false if nothing was sent
+func sendEmailDigest(
+	setting *models.DigestSetting,
+	period time.Duration,
+	notificationRepo repositories.NotificationRepositoryInterface,
+	postRepo repositories.PostRepositoryInterface,
+	mailService services.MailServiceInterface,
+	now time.Time) bool {
+	// Only notifications of this period are listed, so that older unread notifications are not repeated in every digest
+	notifications, unreadCount, err := notificationRepo.GetUnreadNotificationsByUsername(setting.Username, now.Add(-period), 10)
+	if err != nil {
+		fmt.Println("Error loading unread notifications: ", setting.Username, err)
+		return false
+	}
+	posts, err := postRepo.GetTopPostsOfFollowedUsers(setting.Username, now.Add(-period), 5)
+	if err != nil {
+		fmt.Println("Error loading top posts: ", setting.Username, err)
+		return false
+	}
+
+	// Do not send empty digests
+	if len(notifications) == 0 && len(posts) == 0 {
+		return false
+	}
+
+	subject := utils.Translate(setting.User.Locale, "email.digest.subject."+setting.Frequency)
+	body := utils.GetDigestEmailBody(setting.User.Locale, setting.Username, setting.Frequency, unreadCount, notifications, posts, utils.FormatDigestUnsubscribeUrl(setting.UnsubscribeToken))
+	if err := mailService.SendMail(setting.User.Email, subject, body); err != nil {
+		fmt.Println("Error sending email digest: ", setting.Username, err)
+		return false
+	}
+
+	return true
+}
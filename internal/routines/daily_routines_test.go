@@ -1,9 +1,13 @@
 package routines_test
 
 import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
 	"github.com/wwi21seb-projekt/server-beta/internal/routines"
+	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"strings"
 	"testing"
 	"time"
 )
@@ -37,3 +41,129 @@ func TestDeleteUnactivatedUsersSuccess(t *testing.T) {
 	// Assert
 	mockUserRepo.AssertExpectations(t)
 }
+
+// TestDeleteExpiredDataExportsSuccess tests the DeleteExpiredDataExports function to delete data exports with expired download links
+func TestDeleteExpiredDataExportsSuccess(t *testing.T) {
+	// Arrange
+	mockDataExportRepo := new(repositories.MockDataExportRepository)
+
+	// Mock expectations
+	mockDataExportRepo.On("DeleteExpiredDataExports").Return(int64(2), nil)
+
+	// Act
+	routines.DeleteExpiredDataExports(mockDataExportRepo)
+
+	// Assert
+	mockDataExportRepo.AssertExpectations(t)
+}
+
+// TestDeleteOldPushDeliveriesSuccess tests the DeleteOldPushDeliveries function to delete finished push deliveries after the retention period
+func TestDeleteOldPushDeliveriesSuccess(t *testing.T) {
+	// Arrange
+	mockPushDeliveryRepo := new(repositories.MockPushDeliveryRepository)
+
+	// Mock expectations
+	var capturedBefore time.Time
+	mockPushDeliveryRepo.On("DeleteFinishedPushDeliveries", mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			capturedBefore = args.Get(0).(time.Time)
+		}).Return(int64(3), nil)
+
+	// Act
+	routines.DeleteOldPushDeliveries(mockPushDeliveryRepo)
+
+	// Assert
+	mockPushDeliveryRepo.AssertExpectations(t)
+	assert.True(t, capturedBefore.Before(time.Now().Add(-13*24*time.Hour))) // only deliveries older than the retention period are deleted
+	assert.True(t, capturedBefore.After(time.Now().Add(-15*24*time.Hour)))
+}
+
+// TestSendEmailDigestsSuccess tests the SendEmailDigests function to send daily and weekly digests on mondays
+func TestSendEmailDigestsSuccess(t *testing.T) {
+	// Arrange
+	mockDigestRepo := new(repositories.MockDigestRepository)
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockPostRepo := new(repositories.MockPostRepository)
+	mockMailService := new(services.MockMailService)
+
+	now := time.Date(2024, time.June, 3, 3, 0, 0, 0, time.Local) // monday
+	recentlySent := now.Add(-2 * time.Hour)
+
+	dailySetting := models.DigestSetting{
+		Username:         "dailyUser",
+		User:             models.User{Username: "dailyUser", Email: "daily@domain.com"},
+		Frequency:        "daily",
+		UnsubscribeToken: "dailyToken",
+	}
+	alreadySentSetting := models.DigestSetting{
+		Username:   "alreadySentUser",
+		User:       models.User{Username: "alreadySentUser", Email: "sent@domain.com"},
+		Frequency:  "daily",
+		LastSentAt: &recentlySent,
+	}
+	weeklySetting := models.DigestSetting{
+		Username:  "weeklyUser",
+		User:      models.User{Username: "weeklyUser", Email: "weekly@domain.com"},
+		Frequency: "weekly",
+	}
+	notifications := []models.Notification{{NotificationType: "follow", FromUsername: "follower"}}
+	posts := []models.Post{{Username: "author", Content: "Top post"}}
+
+	// Mock expectations
+	var capturedBody string
+	mockDigestRepo.On("GetDigestSettingsByFrequency", "daily").Return([]models.DigestSetting{dailySetting, alreadySentSetting}, nil)
+	mockDigestRepo.On("GetDigestSettingsByFrequency", "weekly").Return([]models.DigestSetting{weeklySetting}, nil)
+	mockDigestRepo.On("UpdateDigestSetting", mock.AnythingOfType("*models.DigestSetting")).Return(nil)
+
+	// Daily user has unread notifications and new posts
+	mockNotificationRepo.On("GetUnreadNotificationsByUsername", dailySetting.Username, now.Add(-24*time.Hour), 10).Return(notifications, int64(1), nil)
+	mockPostRepo.On("GetTopPostsOfFollowedUsers", dailySetting.Username, now.Add(-24*time.Hour), 5).Return(posts, nil)
+	mockMailService.On("SendMail", dailySetting.User.Email, "Your daily digest", mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			capturedBody = args.String(2)
+		}).Return(nil)
+
+	// Weekly user has nothing new, so no mail is sent
+	mockNotificationRepo.On("GetUnreadNotificationsByUsername", weeklySetting.Username, now.Add(-7*24*time.Hour), 10).Return([]models.Notification{}, int64(0), nil)
+	mockPostRepo.On("GetTopPostsOfFollowedUsers", weeklySetting.Username, now.Add(-7*24*time.Hour), 5).Return([]models.Post{}, nil)
+
+	// Act
+	routines.SendEmailDigests(mockDigestRepo, mockNotificationRepo, mockPostRepo, mockMailService, now)
+
+	// Assert
+	mockDigestRepo.AssertExpectations(t)
+	mockNotificationRepo.AssertExpectations(t)
+	mockPostRepo.AssertExpectations(t)
+	mockMailService.AssertExpectations(t)
+
+	assert.True(t, strings.Contains(capturedBody, "follower started following you"))
+	assert.True(t, strings.Contains(capturedBody, "Top post"))
+	assert.True(t, strings.Contains(capturedBody, "/api/digests/unsubscribe?token="+dailySetting.UnsubscribeToken))
+
+	mockDigestRepo.AssertNumberOfCalls(t, "UpdateDigestSetting", 1) // only the daily user received a digest
+	mockMailService.AssertNumberOfCalls(t, "SendMail", 1)
+	mockNotificationRepo.AssertNotCalled(t, "GetUnreadNotificationsByUsername", alreadySentSetting.Username, mock.Anything, mock.Anything)
+	mockDigestRepo.AssertNotCalled(t, "CreateDigestSetting", mock.Anything) // users without setting do not receive digests
+}
+
+// TestSendEmailDigestsNotMonday tests the SendEmailDigests function to not send weekly digests on other days than monday
+func TestSendEmailDigestsNotMonday(t *testing.T) {
+	// Arrange
+	mockDigestRepo := new(repositories.MockDigestRepository)
+	mockNotificationRepo := new(repositories.MockNotificationRepository)
+	mockPostRepo := new(repositories.MockPostRepository)
+	mockMailService := new(services.MockMailService)
+
+	now := time.Date(2024, time.June, 4, 3, 0, 0, 0, time.Local) // tuesday
+
+	// Mock expectations
+	mockDigestRepo.On("GetDigestSettingsByFrequency", "daily").Return([]models.DigestSetting{}, nil)
+
+	// Act
+	routines.SendEmailDigests(mockDigestRepo, mockNotificationRepo, mockPostRepo, mockMailService, now)
+
+	// Assert
+	mockDigestRepo.AssertExpectations(t)
+	mockDigestRepo.AssertNotCalled(t, "GetDigestSettingsByFrequency", "weekly")
+	mockMailService.AssertNotCalled(t, "SendMail", mock.Anything, mock.Anything, mock.Anything)
+}
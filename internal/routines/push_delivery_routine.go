@@ -0,0 +1,67 @@
+package routines
+
+import (
+	"fmt"
+	"github.com/wwi21seb-projekt/server-beta/internal/initializers"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"sync"
+	"time"
+)
+
+const (
+	pushDeliveryWorkers      = 10              // number of push messages that are sent in parallel
+	pushDeliveryBatchSize    = 100             // maximum number of push messages claimed from the outbox at once
+	pushDeliveryPollInterval = time.Second     // time to wait if the outbox has no due push messages
+	pushDeliveryLease        = 5 * time.Minute // claimed push messages are retried after this time if a worker crashed
+)
+
+// StartPushDeliveryWorkers can be called when starting the server to send the push messages of the push outbox
+// called with: `go StartPushDeliveryWorkers()`
+func StartPushDeliveryWorkers() {
+	// Arrange
+	pushSubscriptionRepo := repositories.NewPushSubscriptionRepository(initializers.DB)
+	pushDeliveryRepo := repositories.NewPushDeliveryRepository(initializers.DB)
+	pushSubscriptionService := services.NewPushSubscriptionService(pushSubscriptionRepo, pushDeliveryRepo)
+
+	for {
+		// Continue immediately if the batch was full, as more push messages might be due
+		if ProcessDuePushDeliveries(pushDeliveryRepo, pushSubscriptionService, time.Now()) < pushDeliveryBatchSize {
+			time.Sleep(pushDeliveryPollInterval)
+		}
+	}
+}
+
+// ProcessDuePushDeliveries claims the due push messages of the outbox and sends them using a pool of workers,
+// returns the number of processed push messages
+func ProcessDuePushDeliveries(
+	pushDeliveryRepo repositories.PushDeliveryRepositoryInterface,
+	pushSubscriptionService services.PushSubscriptionServiceInterface,
+	now time.Time) int {
+	deliveries, err := pushDeliveryRepo.ClaimDuePushDeliveries(now, pushDeliveryLease, pushDeliveryBatchSize)
+	if err != nil {
+		fmt.Println("Failed loading push deliveries from database: ", err)
+		return 0
+	}
+
+	jobs := make(chan *models.PushDelivery)
+	var wg sync.WaitGroup
+	for i := 0; i < pushDeliveryWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range jobs {
+				pushSubscriptionService.ProcessPushDelivery(delivery, now)
+			}
+		}()
+	}
+
+	for i := range deliveries {
+		jobs <- &deliveries[i]
+	}
+	close(jobs)
+	wg.Wait()
+
+	return len(deliveries)
+}
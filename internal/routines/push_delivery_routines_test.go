@@ -0,0 +1,509 @@
+package routines_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/SherClockHolmes/webpush-go"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"github.com/wwi21seb-projekt/server-beta/internal/routines"
+	"github.com/wwi21seb-projekt/server-beta/internal/services"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newWebPushSubscription creates a web push subscription with valid keys for the given endpoint and sets the VAPID keys of the server
+func newWebPushSubscription(t *testing.T, endpoint string) models.PushSubscription {
+	vapidPrivateKey, vapidPublicKey, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VAPID_PRIVATE_KEY", vapidPrivateKey)
+	t.Setenv("VAPID_PUBLIC_KEY", vapidPublicKey)
+	t.Setenv("EMAIL_ADDRESS", "service@example.com")
+
+	return models.PushSubscription{
+		Id:       uuid.New(),
+		Username: "testUser",
+		Type:     "web",
+		Endpoint: endpoint,
+		P256dh:   vapidPublicKey, // any P-256 public key can be used by the client
+		Auth:     base64.RawURLEncoding.EncodeToString([]byte("0123456789abcdef")),
+	}
+}
+
+// TestSendPushMessagesQueuesDeliveries tests if SendPushMessages stores a push message for every subscription of the user in the outbox
+func TestSendPushMessagesQueuesDeliveries(t *testing.T) {
+	// Arrange
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+	mockPushDeliveryRepo := new(repositories.MockPushDeliveryRepository)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, mockPushDeliveryRepo)
+
+	pushSubscriptions := []models.PushSubscription{
+		{Id: uuid.New(), Username: "testUser", Type: "web", Endpoint: "https://example.com"},
+		{Id: uuid.New(), Username: "testUser", Type: "expo", ExpoToken: "ExponentPushToken[someToken]"},
+	}
+	notification := models.NotificationRecordDTO{
+		NotificationId:   uuid.New().String(),
+		NotificationType: "follow",
+		User:             &models.UserDTO{Username: "otherUser"},
+	}
+
+	// Mock expectations
+	var capturedDeliveries []*models.PushDelivery
+	mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", "testUser").Return(pushSubscriptions, nil)
+	mockPushDeliveryRepo.On("CreatePushDelivery", mock.AnythingOfType("*models.PushDelivery")).
+		Run(func(args mock.Arguments) {
+			capturedDeliveries = append(capturedDeliveries, args.Get(0).(*models.PushDelivery))
+		}).Return(nil)
+
+	// Act
+	pushSubscriptionService.SendPushMessages(&notification, "testUser")
+
+	// Assert
+	mockPushDeliveryRepo.AssertExpectations(t)
+	assert.Equal(t, 2, len(capturedDeliveries))
+	for i, delivery := range capturedDeliveries {
+		assert.Equal(t, pushSubscriptions[i].Id, delivery.SubscriptionId)
+		assert.Equal(t, pushSubscriptions[i].Type, delivery.Type)
+		assert.Equal(t, services.PushDeliveryStatusPending, delivery.Status)
+		assert.Equal(t, 0, delivery.Attempts)
+		assert.Contains(t, delivery.Payload, notification.NotificationId)
+	}
+	assert.Equal(t, "", capturedDeliveries[0].Text)
+	assert.Equal(t, "otherUser started following you", capturedDeliveries[1].Text)
+}
+
+// TestProcessDuePushDeliveries tests if ProcessDuePushDeliveries sends push messages and updates the outbox depending on the response of the push service
+func TestProcessDuePushDeliveries(t *testing.T) {
+	testCases := []struct {
+		name               string
+		responseStatus     int
+		previousAttempts   int
+		expectedStatus     string
+		expectedNextDelay  time.Duration
+		expectUnsubscribed bool
+	}{
+		{"sent", http.StatusCreated, 0, services.PushDeliveryStatusSent, 0, false},
+		{"retry first attempt", http.StatusInternalServerError, 0, services.PushDeliveryStatusPending, 30 * time.Second, false},
+		{"retry with backoff", http.StatusTooManyRequests, 3, services.PushDeliveryStatusPending, 4 * time.Minute, false},
+		{"retries exhausted", http.StatusServiceUnavailable, 5, services.PushDeliveryStatusFailed, 0, false},
+		{"permanent error", http.StatusBadRequest, 0, services.PushDeliveryStatusFailed, 0, false},
+		{"subscription expired", http.StatusGone, 0, services.PushDeliveryStatusGone, 0, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			pushServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testCase.responseStatus)
+			}))
+			defer pushServer.Close()
+
+			pushSubscription := newWebPushSubscription(t, pushServer.URL)
+
+			mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+			mockPushDeliveryRepo := new(repositories.MockPushDeliveryRepository)
+			pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, mockPushDeliveryRepo)
+
+			now := time.Now()
+			deliveries := []models.PushDelivery{
+				{
+					Id:             uuid.New(),
+					SubscriptionId: pushSubscription.Id,
+					Username:       pushSubscription.Username,
+					Type:           "web",
+					Payload:        `{"notificationType":"follow"}`,
+					Status:         services.PushDeliveryStatusPending,
+					Attempts:       testCase.previousAttempts,
+					NextAttemptAt:  now,
+				},
+			}
+
+			// Mock expectations
+			var capturedDelivery *models.PushDelivery
+			var capturedAttempt *models.PushDeliveryAttempt
+			mockPushDeliveryRepo.On("ClaimDuePushDeliveries", now, mock.AnythingOfType("time.Duration"), mock.AnythingOfType("int")).Return(deliveries, nil)
+			mockPushSubscriptionRepo.On("GetPushSubscriptionById", pushSubscription.Id.String()).Return(&pushSubscription, nil)
+			mockPushSubscriptionRepo.On("DeletePushSubscriptionById", pushSubscription.Id.String()).Return(nil)
+			mockPushDeliveryRepo.On("UpdatePushDelivery", mock.AnythingOfType("*models.PushDelivery"), mock.AnythingOfType("*models.PushDeliveryAttempt")).
+				Run(func(args mock.Arguments) {
+					capturedDelivery = args.Get(0).(*models.PushDelivery)
+					capturedAttempt = args.Get(1).(*models.PushDeliveryAttempt)
+				}).Return(nil)
+
+			// Act
+			processed := routines.ProcessDuePushDeliveries(mockPushDeliveryRepo, pushSubscriptionService, now)
+
+			// Assert
+			assert.Equal(t, 1, processed)
+			assert.Equal(t, testCase.expectedStatus, capturedDelivery.Status)
+			assert.Equal(t, testCase.previousAttempts+1, capturedDelivery.Attempts)
+			if testCase.expectedNextDelay > 0 {
+				assert.Equal(t, now.Add(testCase.expectedNextDelay), capturedDelivery.NextAttemptAt)
+			}
+
+			assert.Equal(t, capturedDelivery.Id, capturedAttempt.DeliveryId)
+			assert.Equal(t, capturedDelivery.Attempts, capturedAttempt.AttemptNumber)
+			assert.Equal(t, testCase.responseStatus, capturedAttempt.StatusCode)
+			assert.Equal(t, testCase.expectedStatus, capturedAttempt.Status)
+			assert.Equal(t, testCase.expectedStatus == services.PushDeliveryStatusSent, capturedAttempt.Error == "")
+
+			if testCase.expectUnsubscribed {
+				mockPushSubscriptionRepo.AssertCalled(t, "DeletePushSubscriptionById", pushSubscription.Id.String())
+			} else {
+				mockPushSubscriptionRepo.AssertNotCalled(t, "DeletePushSubscriptionById", mock.Anything)
+			}
+		})
+	}
+}
+
+// TestProcessDuePushDeliveriesSubscriptionDeleted tests if ProcessDuePushDeliveries does not retry push messages of unregistered subscriptions
+func TestProcessDuePushDeliveriesSubscriptionDeleted(t *testing.T) {
+	// Arrange
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+	mockPushDeliveryRepo := new(repositories.MockPushDeliveryRepository)
+	pushSubscriptionService := services.NewPushSubscriptionService(mockPushSubscriptionRepo, mockPushDeliveryRepo)
+
+	now := time.Now()
+	deliveries := []models.PushDelivery{
+		{
+			Id:             uuid.New(),
+			SubscriptionId: uuid.New(),
+			Username:       "testUser",
+			Type:           "expo",
+			Status:         services.PushDeliveryStatusPending,
+			NextAttemptAt:  now,
+		},
+	}
+
+	// Mock expectations
+	var capturedDelivery *models.PushDelivery
+	mockPushDeliveryRepo.On("ClaimDuePushDeliveries", now, mock.AnythingOfType("time.Duration"), mock.AnythingOfType("int")).Return(deliveries, nil)
+	mockPushSubscriptionRepo.On("GetPushSubscriptionById", deliveries[0].SubscriptionId.String()).Return(&models.PushSubscription{}, gorm.ErrRecordNotFound)
+	mockPushDeliveryRepo.On("UpdatePushDelivery", mock.AnythingOfType("*models.PushDelivery"), mock.AnythingOfType("*models.PushDeliveryAttempt")).
+		Run(func(args mock.Arguments) {
+			capturedDelivery = args.Get(0).(*models.PushDelivery)
+		}).Return(nil)
+
+	// Act
+	processed := routines.ProcessDuePushDeliveries(mockPushDeliveryRepo, pushSubscriptionService, now)
+
+	// Assert
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, services.PushDeliveryStatusGone, capturedDelivery.Status)
+	assert.Equal(t, 1, capturedDelivery.Attempts)
+	mockPushDeliveryRepo.AssertExpectations(t)
+}
+
+// processPushDeliveryWithProvider sends one push message to the subscription with the given provider and returns the updated delivery
+func processPushDeliveryWithProvider(t *testing.T, provider services.PushProvider, pushSubscription models.PushSubscription) (*models.PushDelivery, *models.PushDeliveryAttempt, *repositories.MockPushSubscriptionRepository) {
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+	mockPushDeliveryRepo := new(repositories.MockPushDeliveryRepository)
+	providers := map[string]services.PushProvider{pushSubscription.Type: provider}
+	pushSubscriptionService := services.NewPushSubscriptionServiceWithProviders(mockPushSubscriptionRepo, mockPushDeliveryRepo, providers)
+
+	now := time.Now()
+	deliveries := []models.PushDelivery{
+		{
+			Id:             uuid.New(),
+			SubscriptionId: pushSubscription.Id,
+			Username:       pushSubscription.Username,
+			Type:           pushSubscription.Type,
+			Payload:        `{"notificationType":"follow"}`,
+			Text:           "otherUser started following you",
+			Status:         services.PushDeliveryStatusPending,
+			NextAttemptAt:  now,
+		},
+	}
+
+	// Mock expectations
+	var capturedDelivery *models.PushDelivery
+	var capturedAttempt *models.PushDeliveryAttempt
+	mockPushDeliveryRepo.On("ClaimDuePushDeliveries", now, mock.AnythingOfType("time.Duration"), mock.AnythingOfType("int")).Return(deliveries, nil)
+	mockPushSubscriptionRepo.On("GetPushSubscriptionById", pushSubscription.Id.String()).Return(&pushSubscription, nil)
+	mockPushSubscriptionRepo.On("DeletePushSubscriptionById", pushSubscription.Id.String()).Return(nil)
+	mockPushDeliveryRepo.On("UpdatePushDelivery", mock.AnythingOfType("*models.PushDelivery"), mock.AnythingOfType("*models.PushDeliveryAttempt")).
+		Run(func(args mock.Arguments) {
+			capturedDelivery = args.Get(0).(*models.PushDelivery)
+			capturedAttempt = args.Get(1).(*models.PushDeliveryAttempt)
+		}).Return(nil)
+
+	// Act
+	routines.ProcessDuePushDeliveries(mockPushDeliveryRepo, pushSubscriptionService, now)
+
+	return capturedDelivery, capturedAttempt, mockPushSubscriptionRepo
+}
+
+// TestFakePushProvider tests if push messages are sent with the provider of the subscription type and failures of the provider are retried
+func TestFakePushProvider(t *testing.T) {
+	pushSubscription := models.PushSubscription{
+		Id:          uuid.New(),
+		Username:    "testUser",
+		Type:        "fcm",
+		DeviceToken: "someRegistrationToken",
+	}
+
+	// Provider accepts the message
+	fakeProvider := services.NewFakePushProvider()
+	delivery, _, _ := processPushDeliveryWithProvider(t, fakeProvider, pushSubscription)
+
+	assert.Equal(t, services.PushDeliveryStatusSent, delivery.Status)
+	assert.Equal(t, 1, len(fakeProvider.Sent()))
+	assert.Equal(t, pushSubscription.DeviceToken, fakeProvider.Sent()[0].Subscription.DeviceToken)
+	assert.Equal(t, "otherUser started following you", fakeProvider.Sent()[0].Message.Body)
+	assert.Equal(t, `{"notificationType":"follow"}`, fakeProvider.Sent()[0].Message.Data)
+
+	// Provider fails temporarily
+	fakeProvider = services.NewFakePushProvider()
+	fakeProvider.StatusCode = http.StatusServiceUnavailable
+	fakeProvider.Retryable = true
+	fakeProvider.Err = errors.New("unavailable")
+	delivery, attempt, _ := processPushDeliveryWithProvider(t, fakeProvider, pushSubscription)
+
+	assert.Equal(t, services.PushDeliveryStatusPending, delivery.Status)
+	assert.Equal(t, "unavailable", attempt.Error)
+	assert.Equal(t, http.StatusServiceUnavailable, attempt.StatusCode)
+}
+
+// TestSendPushMessagesWithoutProvider tests if SendPushMessages skips subscriptions of types without a configured push provider
+func TestSendPushMessagesWithoutProvider(t *testing.T) {
+	// Arrange
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+	mockPushDeliveryRepo := new(repositories.MockPushDeliveryRepository)
+	providers := map[string]services.PushProvider{"apns": services.NewFakePushProvider()}
+	pushSubscriptionService := services.NewPushSubscriptionServiceWithProviders(mockPushSubscriptionRepo, mockPushDeliveryRepo, providers)
+
+	pushSubscriptions := []models.PushSubscription{
+		{Id: uuid.New(), Username: "testUser", Type: "fcm", DeviceToken: "someRegistrationToken"},
+		{Id: uuid.New(), Username: "testUser", Type: "apns", DeviceToken: "abcdef"},
+	}
+
+	// Mock expectations
+	mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", "testUser").Return(pushSubscriptions, nil)
+	mockPushDeliveryRepo.On("CreatePushDelivery", mock.MatchedBy(func(delivery *models.PushDelivery) bool {
+		return delivery.SubscriptionId == pushSubscriptions[1].Id && delivery.Text == "New notification"
+	})).Return(nil)
+
+	// Act
+	pushSubscriptionService.SendPushMessages(&models.NotificationRecordDTO{NotificationType: "unknown", User: &models.UserDTO{}}, "testUser")
+
+	// Assert
+	mockPushDeliveryRepo.AssertExpectations(t)
+	mockPushDeliveryRepo.AssertNumberOfCalls(t, "CreatePushDelivery", 1)
+}
+
+// TestSendPushMessagesLocalised tests if push messages are queued with the title and text in the locale of the receiving user
+func TestSendPushMessagesLocalised(t *testing.T) {
+	// Arrange
+	mockPushSubscriptionRepo := new(repositories.MockPushSubscriptionRepository)
+	mockPushDeliveryRepo := new(repositories.MockPushDeliveryRepository)
+	fakeProvider := services.NewFakePushProvider()
+	providers := map[string]services.PushProvider{"apns": fakeProvider}
+	pushSubscriptionService := services.NewPushSubscriptionServiceWithProviders(mockPushSubscriptionRepo, mockPushDeliveryRepo, providers)
+
+	pushSubscription := models.PushSubscription{
+		Id:          uuid.New(),
+		Username:    "testUser",
+		User:        models.User{Username: "testUser", Locale: "de"},
+		Type:        "apns",
+		DeviceToken: "abcdef",
+	}
+
+	// Mock expectations
+	var capturedDelivery *models.PushDelivery
+	mockPushSubscriptionRepo.On("GetPushSubscriptionsByUsername", "testUser").Return([]models.PushSubscription{pushSubscription}, nil)
+	mockPushDeliveryRepo.On("CreatePushDelivery", mock.AnythingOfType("*models.PushDelivery")).
+		Run(func(args mock.Arguments) {
+			capturedDelivery = args.Get(0).(*models.PushDelivery)
+		}).Return(nil)
+	mockPushSubscriptionRepo.On("GetPushSubscriptionById", pushSubscription.Id.String()).Return(&pushSubscription, nil)
+	mockPushDeliveryRepo.On("UpdatePushDelivery", mock.AnythingOfType("*models.PushDelivery"), mock.AnythingOfType("*models.PushDeliveryAttempt")).Return(nil)
+
+	// Act
+	notification := models.NotificationRecordDTO{NotificationType: "follow", User: &models.UserDTO{Username: "otherUser"}}
+	pushSubscriptionService.SendPushMessages(&notification, "testUser")
+	pushSubscriptionService.ProcessPushDelivery(capturedDelivery, time.Now())
+
+	// Assert
+	assert.Equal(t, "Benachrichtigung von Server Beta", capturedDelivery.Title)
+	assert.Equal(t, "otherUser folgt dir jetzt", capturedDelivery.Text)
+
+	sent := fakeProvider.Sent()
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "Benachrichtigung von Server Beta", sent[0].Message.Title)
+	assert.Equal(t, "otherUser folgt dir jetzt", sent[0].Message.Body)
+	assert.Equal(t, services.PushDeliveryStatusSent, capturedDelivery.Status)
+}
+
+// TestExpoPushProvider tests if the expo provider sends messages to the configured url and removes unregistered devices
+func TestExpoPushProvider(t *testing.T) {
+	for _, expoError := range []string{"", "DeviceNotRegistered"} {
+		// Arrange
+		var receivedBody map[string]interface{}
+		expoServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&receivedBody)
+			if expoError != "" {
+				_, _ = w.Write([]byte(`{"data":{"status":"error","message":"not registered","details":{"error":"` + expoError + `"}}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"status":"ok","id":"someTicket"}}`))
+		}))
+
+		pushSubscription := models.PushSubscription{
+			Id:        uuid.New(),
+			Username:  "testUser",
+			Type:      "expo",
+			ExpoToken: "ExponentPushToken[someToken]",
+		}
+
+		// Act
+		delivery, _, mockPushSubscriptionRepo := processPushDeliveryWithProvider(t, services.NewExpoPushProvider(expoServer.URL), pushSubscription)
+		expoServer.Close()
+
+		// Assert
+		assert.Equal(t, pushSubscription.ExpoToken, receivedBody["to"])
+		assert.Equal(t, "otherUser started following you", receivedBody["body"])
+		if expoError == "" {
+			assert.Equal(t, services.PushDeliveryStatusSent, delivery.Status)
+			mockPushSubscriptionRepo.AssertNotCalled(t, "DeletePushSubscriptionById", mock.Anything)
+		} else {
+			assert.Equal(t, services.PushDeliveryStatusGone, delivery.Status)
+			mockPushSubscriptionRepo.AssertCalled(t, "DeletePushSubscriptionById", pushSubscription.Id.String())
+		}
+	}
+}
+
+// TestFcmPushProvider tests if the fcm provider authenticates with a service account and removes unregistered tokens
+func TestFcmPushProvider(t *testing.T) {
+	// Arrange
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKeyBytes, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenRequests := 0
+	var receivedAuthorization string
+	var receivedBody map[string]map[string]interface{}
+	fcmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+			assert.NotEmpty(t, r.FormValue("assertion"))
+			_, _ = w.Write([]byte(`{"access_token":"someAccessToken","expires_in":3600}`))
+			return
+		}
+
+		assert.Equal(t, "/v1/projects/someProject/messages:send", r.URL.Path)
+		receivedAuthorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&receivedBody)
+		if receivedBody["message"]["token"] == "unregisteredToken" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found","details":[{"errorCode":"UNREGISTERED"}]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"projects/someProject/messages/1"}`))
+	}))
+	defer fcmServer.Close()
+
+	credentials := services.FcmCredentials{
+		ClientEmail: "server@someProject.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rsaKeyBytes})),
+		TokenUri:    fcmServer.URL + "/token",
+	}
+	fcmProvider, err := services.NewFcmPushProvider("someProject", credentials, fcmServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushSubscription := models.PushSubscription{
+		Id:          uuid.New(),
+		Username:    "testUser",
+		Type:        "fcm",
+		DeviceToken: "someRegistrationToken",
+	}
+
+	// Act and assert, token is registered
+	delivery, _, _ := processPushDeliveryWithProvider(t, fcmProvider, pushSubscription)
+	assert.Equal(t, services.PushDeliveryStatusSent, delivery.Status)
+	assert.Equal(t, "Bearer someAccessToken", receivedAuthorization)
+	assert.Equal(t, pushSubscription.DeviceToken, receivedBody["message"]["token"])
+
+	// Act and assert, token is not registered anymore
+	pushSubscription.DeviceToken = "unregisteredToken"
+	delivery, attempt, mockPushSubscriptionRepo := processPushDeliveryWithProvider(t, fcmProvider, pushSubscription)
+	assert.Equal(t, services.PushDeliveryStatusGone, delivery.Status)
+	assert.Equal(t, http.StatusNotFound, attempt.StatusCode)
+	mockPushSubscriptionRepo.AssertCalled(t, "DeletePushSubscriptionById", pushSubscription.Id.String())
+
+	assert.Equal(t, 1, tokenRequests) // access token is cached
+}
+
+// TestApnsPushProvider tests if the apns provider authenticates with a provider token and removes unregistered device tokens
+func TestApnsPushProvider(t *testing.T) {
+	// Arrange
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKeyBytes, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var receivedRequest *http.Request
+	apnsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = r
+		if strings.HasSuffix(r.URL.Path, "/unregistered") {
+			w.WriteHeader(http.StatusGone)
+			_, _ = w.Write([]byte(`{"reason":"Unregistered"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer apnsServer.Close()
+
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecKeyBytes})
+	apnsProvider, err := services.NewApnsPushProvider(keyPem, "someKeyId", "someTeamId", "com.example.app", apnsServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushSubscription := models.PushSubscription{
+		Id:          uuid.New(),
+		Username:    "testUser",
+		Type:        "apns",
+		DeviceToken: "abcdef0123456789",
+	}
+
+	// Act and assert, device is registered
+	delivery, _, _ := processPushDeliveryWithProvider(t, apnsProvider, pushSubscription)
+	assert.Equal(t, services.PushDeliveryStatusSent, delivery.Status)
+	assert.Equal(t, "/3/device/"+pushSubscription.DeviceToken, receivedRequest.URL.Path)
+	assert.Equal(t, "com.example.app", receivedRequest.Header.Get("apns-topic"))
+	assert.True(t, strings.HasPrefix(receivedRequest.Header.Get("Authorization"), "bearer "))
+
+	// Act and assert, device is not registered anymore
+	pushSubscription.DeviceToken = "unregistered"
+	delivery, _, mockPushSubscriptionRepo := processPushDeliveryWithProvider(t, apnsProvider, pushSubscription)
+	assert.Equal(t, services.PushDeliveryStatusGone, delivery.Status)
+	mockPushSubscriptionRepo.AssertCalled(t, "DeletePushSubscriptionById", pushSubscription.Id.String())
+}
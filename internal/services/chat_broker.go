@@ -0,0 +1,141 @@
+package services
+
+import (
+	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"os"
+	"sync"
+)
+
+// Types of the envelopes on the user websocket, the same types are used for broker messages
+const (
+	ChatEnvelopeSubscribe   = "subscribe"
+	ChatEnvelopeUnsubscribe = "unsubscribe"
+	ChatEnvelopeMessage     = "message"     // messages and events of a chat, the payload is the same as on the chat websocket
+	ChatEnvelopeChatCreated = "chatCreated" // the user became a participant of a chat
+	ChatEnvelopeChatRemoved = "chatRemoved" // the user is not a participant of the chat anymore
+	ChatEnvelopePresence    = "presence"
+	ChatEnvelopeError       = "error"
+)
+
+// ChatBrokerMessage is a websocket message that is sent to the connections of a chat on all server instances,
+// messages with a username are sent to the user websockets of this user instead
+type ChatBrokerMessage struct {
+	Type               string `json:"type"` // type of the envelope on user websockets, an empty type is a message of the chat
+	ChatId             string `json:"chatId"`
+	Username           string `json:"username"`
+	Payload            string `json:"payload"`            // json message that is written to the websocket connections
+	ExceptConnectionId string `json:"exceptConnectionId"` // connection that does not receive the message, e.g. the sender of a typing event
+}
+
+// ChatBroker distributes chat messages between all server instances and tracks which users are connected to a chat on any instance
+type ChatBroker interface {
+	// Publish sends the message to the subscribers of all server instances, including the publishing instance,
+	// if an error is returned, the message was only sent to the subscribers of the publishing instance
+	Publish(message *ChatBrokerMessage) error
+	// Subscribe registers a handler that is called for every published message, handlers should not block
+	Subscribe(handler func(message *ChatBrokerMessage))
+	// Join marks one more connection of the user to the chat on this instance
+	Join(chatId, username string) error
+	// Leave removes one connection of the user to the chat on this instance
+	Leave(chatId, username string) error
+	// ConnectedUsers returns the usernames that have at least one connection to the chat on any instance
+	ConnectedUsers(chatId string) ([]string, error)
+	// OnlineUsers returns the given usernames that have at least one connection to any chat on any instance
+	OnlineUsers(usernames []string) ([]string, error)
+}
+
+// NewChatBrokerFromEnv creates the chat broker that is configured in the environment,
+// the in-memory broker is used if CHAT_BROKER is not set to "postgres" because it only needs a single server instance
+func NewChatBrokerFromEnv(brokerRepo repositories.ChatBrokerRepositoryInterface, dsn string) ChatBroker {
+	if os.Getenv("CHAT_BROKER") == "postgres" {
+		broker := NewPostgresChatBroker(brokerRepo, dsn)
+		broker.Start()
+		return broker
+	}
+	return NewMemoryChatBroker()
+}
+
+type MemoryChatBroker struct {
+	handlers    []func(message *ChatBrokerMessage)
+	connections map[string]map[string]int // chatId -> username -> number of connections
+	lock        sync.RWMutex
+}
+
+// NewMemoryChatBroker can be used as a constructor to create a MemoryChatBroker "object",
+// all controllers that share the broker receive each other's messages like separate server instances
+func NewMemoryChatBroker() *MemoryChatBroker {
+	return &MemoryChatBroker{connections: make(map[string]map[string]int)}
+}
+
+// Publish calls all handlers directly, so messages are delivered in the order they are published
+func (broker *MemoryChatBroker) Publish(message *ChatBrokerMessage) error {
+	broker.lock.RLock()
+	handlers := broker.handlers
+	broker.lock.RUnlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+func (broker *MemoryChatBroker) Subscribe(handler func(message *ChatBrokerMessage)) {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+	broker.handlers = append(broker.handlers, handler)
+}
+
+func (broker *MemoryChatBroker) Join(chatId, username string) error {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+
+	if broker.connections[chatId] == nil {
+		broker.connections[chatId] = make(map[string]int)
+	}
+	broker.connections[chatId][username]++
+	return nil
+}
+
+func (broker *MemoryChatBroker) Leave(chatId, username string) error {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+
+	if broker.connections[chatId] == nil {
+		return nil
+	}
+	broker.connections[chatId][username]--
+	if broker.connections[chatId][username] <= 0 {
+		delete(broker.connections[chatId], username)
+	}
+	if len(broker.connections[chatId]) == 0 {
+		delete(broker.connections, chatId)
+	}
+	return nil
+}
+
+func (broker *MemoryChatBroker) ConnectedUsers(chatId string) ([]string, error) {
+	broker.lock.RLock()
+	defer broker.lock.RUnlock()
+
+	usernames := make([]string, 0, len(broker.connections[chatId]))
+	for username := range broker.connections[chatId] {
+		usernames = append(usernames, username)
+	}
+	return usernames, nil
+}
+
+func (broker *MemoryChatBroker) OnlineUsers(usernames []string) ([]string, error) {
+	broker.lock.RLock()
+	defer broker.lock.RUnlock()
+
+	onlineUsernames := make([]string, 0)
+	for _, username := range usernames {
+		for _, chatConnections := range broker.connections {
+			if chatConnections[username] > 0 {
+				onlineUsernames = append(onlineUsernames, username)
+				break
+			}
+		}
+	}
+	return onlineUsernames, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"sync"
+	"time"
+)
+
+const (
+	chatBrokerChannel           = "chat_messages"
+	chatBrokerReconnectDelay    = 5 * time.Second
+	chatConnectionRefreshPeriod = 30 * time.Second
+	chatConnectionStaleAfter    = 3 * chatConnectionRefreshPeriod // connections of an instance that missed three refreshes are ignored
+)
+
+// PostgresChatBroker distributes chat messages between server instances with LISTEN/NOTIFY of the shared database
+// and stores the chat connections of all instances in the chat_connections table
+// Messages that are published while the listening connection is interrupted are not received by this instance
+type PostgresChatBroker struct {
+	brokerRepo repositories.ChatBrokerRepositoryInterface
+	dsn        string
+	instanceId string // identifies the chat connections of this instance
+	handlers   []func(message *ChatBrokerMessage)
+	lock       sync.RWMutex
+}
+
+// NewPostgresChatBroker can be used as a constructor to create a PostgresChatBroker "object",
+// Start needs to be called to receive messages of other instances and to keep the chat connections of the instance active
+func NewPostgresChatBroker(brokerRepo repositories.ChatBrokerRepositoryInterface, dsn string) *PostgresChatBroker {
+	return &PostgresChatBroker{
+		brokerRepo: brokerRepo,
+		dsn:        dsn,
+		instanceId: uuid.New().String(),
+	}
+}
+
+// Start begins listening for messages and refreshing the chat connections of the instance in the background
+func (broker *PostgresChatBroker) Start() {
+	go broker.listen()
+	go broker.refreshConnections()
+}
+
+// Publish sends the message as json payload of a notification, payloads are limited to 8000 bytes by postgres
+// If the notification cannot be sent, the message is passed to the handlers of this instance directly
+func (broker *PostgresChatBroker) Publish(message *ChatBrokerMessage) error {
+	payload, err := json.Marshal(message)
+	if err == nil {
+		err = broker.brokerRepo.Notify(chatBrokerChannel, string(payload))
+	}
+	if err != nil {
+		broker.handle(message) // at least the connections of this instance receive the message
+		return err
+	}
+	return nil
+}
+
+func (broker *PostgresChatBroker) Subscribe(handler func(message *ChatBrokerMessage)) {
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+	broker.handlers = append(broker.handlers, handler)
+}
+
+func (broker *PostgresChatBroker) Join(chatId, username string) error {
+	return broker.brokerRepo.IncrementChatConnections(broker.instanceId, chatId, username, time.Now())
+}
+
+func (broker *PostgresChatBroker) Leave(chatId, username string) error {
+	return broker.brokerRepo.DecrementChatConnections(broker.instanceId, chatId, username)
+}
+
+func (broker *PostgresChatBroker) ConnectedUsers(chatId string) ([]string, error) {
+	return broker.brokerRepo.GetConnectedUsernames(chatId, time.Now().Add(-chatConnectionStaleAfter))
+}
+
+func (broker *PostgresChatBroker) OnlineUsers(usernames []string) ([]string, error) {
+	if len(usernames) == 0 {
+		return []string{}, nil
+	}
+	return broker.brokerRepo.GetOnlineUsernames(usernames, time.Now().Add(-chatConnectionStaleAfter))
+}
+
+// listen receives the notifications of all instances and reconnects to the database if the connection is lost
+func (broker *PostgresChatBroker) listen() {
+	for {
+		err := broker.listenUntilError()
+		fmt.Println("Chat broker stopped listening, reconnecting:", err)
+		time.Sleep(chatBrokerReconnectDelay)
+	}
+}
+
+// listenUntilError opens a dedicated database connection for LISTEN and passes every notification to the handlers,
+// the handlers must not block because they are called on the listening goroutine, e.g. the message controller only queues the messages
+func (broker *PostgresChatBroker) listenUntilError() error {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, broker.dsn)
+	if err != nil {
+		return err
+	}
+	defer func(conn *pgx.Conn) {
+		_ = conn.Close(ctx)
+	}(conn)
+
+	if _, err := conn.Exec(ctx, "LISTEN "+chatBrokerChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var message ChatBrokerMessage
+		if err := json.Unmarshal([]byte(notification.Payload), &message); err != nil {
+			continue // ignore payloads that were not sent by a chat broker
+		}
+		broker.handle(&message)
+	}
+}
+
+// handle passes a message to all handlers of this instance
+func (broker *PostgresChatBroker) handle(message *ChatBrokerMessage) {
+	broker.lock.RLock()
+	handlers := broker.handlers
+	broker.lock.RUnlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+}
+
+// refreshConnections regularly marks the chat connections of this instance as active and removes those of crashed instances
+func (broker *PostgresChatBroker) refreshConnections() {
+	ticker := time.NewTicker(chatConnectionRefreshPeriod)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		broker.RefreshConnections(now)
+	}
+}
+
+// RefreshConnections marks the chat connections of this instance as active and removes the connections
+// of instances that did not refresh them for three refresh periods
+func (broker *PostgresChatBroker) RefreshConnections(now time.Time) {
+	if err := broker.brokerRepo.RefreshChatConnections(broker.instanceId, now); err != nil {
+		fmt.Println("Error refreshing chat connections:", err)
+	}
+	if err := broker.brokerRepo.DeleteStaleChatConnections(now.Add(-chatConnectionStaleAfter)); err != nil {
+		fmt.Println("Error deleting stale chat connections:", err)
+	}
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
@@ -17,12 +19,30 @@ import (
 type ChatServiceInterface interface {
 	CreateChat(req *models.ChatCreateRequestDTO, currentUsername string) (*models.ChatCreateResponseDTO, *customerrors.CustomError, int)
 	GetChatsByUsername(username string) (*models.ChatsResponseDTO, *customerrors.CustomError, int)
+	AddChatMembers(chatId string, req *models.ChatMembersAddRequestDTO, currentUsername string) (*models.ChatRecordDTO, *customerrors.CustomError, int)
+	RemoveChatMember(chatId string, username string, currentUsername string) (*models.ChatRecordDTO, *customerrors.CustomError, int)
+	UpdateChatMemberRole(chatId string, username string, req *models.ChatMemberRoleUpdateRequestDTO, currentUsername string) (*models.ChatRecordDTO, *customerrors.CustomError, int)
+	LeaveChat(chatId string, currentUsername string) (*customerrors.CustomError, int)
 }
 
+// Roles of the users in a chat, only admins can manage the members of a group chat
+const (
+	ChatRoleAdmin  = "admin"
+	ChatRoleMember = "member"
+)
+
+const (
+	maxGroupChatNameLength = 50
+	maxGroupChatMembers    = 50
+)
+
 type ChatService struct {
 	chatRepo            repositories.ChatRepositoryInterface
 	userRepo            repositories.UserRepositoryInterface
 	notificationService NotificationServiceInterface
+	validator           utils.ValidatorInterface
+	chatBroker          ChatBroker // tells the user websockets about new and removed chats
+	presenceService     PresenceServiceInterface
 	policy              *bluemonday.Policy
 }
 
@@ -30,11 +50,23 @@ type ChatService struct {
 func NewChatService(
 	chatRepo repositories.ChatRepositoryInterface,
 	userRepo repositories.UserRepositoryInterface,
-	notificationService NotificationServiceInterface) *ChatService {
-	return &ChatService{chatRepo: chatRepo, userRepo: userRepo, notificationService: notificationService, policy: bluemonday.UGCPolicy()}
+	notificationService NotificationServiceInterface,
+	validator utils.ValidatorInterface,
+	chatBroker ChatBroker,
+	presenceService PresenceServiceInterface) *ChatService {
+	return &ChatService{
+		chatRepo:            chatRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		validator:           validator,
+		chatBroker:          chatBroker,
+		presenceService:     presenceService,
+		policy:              bluemonday.UGCPolicy(),
+	}
 }
 
-// CreateChat creates a chat for a given post id, username and the current logged-in user
+// CreateChat creates a direct chat with another user or a group chat with a name and multiple users,
+// the current logged-in user sends the first message
 func (service *ChatService) CreateChat(req *models.ChatCreateRequestDTO, currentUsername string) (*models.ChatCreateResponseDTO, *customerrors.CustomError, int) {
 	// Sanitize message content because it is a free text field
 	req.Content = strings.Trim(req.Content, " ") // remove leading and trailing whitespaces
@@ -45,12 +77,21 @@ func (service *ChatService) CreateChat(req *models.ChatCreateRequestDTO, current
 		return nil, customerrors.BadRequest, http.StatusBadRequest
 	}
 
+	// Requests with a name or a list of usernames create a group chat
+	if req.Name != "" || len(req.Usernames) > 0 {
+		return service.createGroupChat(req, currentUsername)
+	}
+	if req.Username == "" || req.Picture != "" {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
 	// Check if user exists
 	otherUser, err := service.userRepo.FindUserByUsername(req.Username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, customerrors.UserNotFound, http.StatusNotFound
 		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
 	// Check if that chat already exists
@@ -71,43 +112,146 @@ func (service *ChatService) CreateChat(req *models.ChatCreateRequestDTO, current
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
-	// Create chat with first message
-	currentTime := time.Now()
+	// Create chat, both users are members of a direct chat
 	newChat := models.Chat{
 		Id:        uuid.New(),
 		Users:     []models.User{*currentUser, *otherUser},
+		CreatedAt: time.Now(),
+	}
+	newChat.Members = []models.ChatUser{
+		{ChatId: newChat.Id, UserUsername: currentUser.Username, Role: ChatRoleMember, JoinedAt: newChat.CreatedAt},
+		{ChatId: newChat.Id, UserUsername: otherUser.Username, Role: ChatRoleMember, JoinedAt: newChat.CreatedAt},
+	}
+
+	return service.createChatWithFirstMessage(newChat, req.Content, currentUsername)
+}
+
+// createGroupChat creates a group chat with a name, an optional picture and the current user as admin
+func (service *ChatService) createGroupChat(req *models.ChatCreateRequestDTO, currentUsername string) (*models.ChatCreateResponseDTO, *customerrors.CustomError, int) {
+	// Sanitize and validate name
+	req.Name = strings.Trim(req.Name, " ")
+	req.Name = service.policy.Sanitize(req.Name)
+	if len(req.Name) <= 0 || len(req.Name) > maxGroupChatNameLength || req.Username != "" {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	// Remove duplicates and the current user from the other users
+	var otherUsernames []string
+	for _, username := range req.Usernames {
+		if username != currentUsername && !contains(otherUsernames, username) {
+			otherUsernames = append(otherUsernames, username)
+		}
+	}
+	if len(otherUsernames) == 0 || len(otherUsernames)+1 > maxGroupChatMembers {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	// Validate and create image object
+	var image *models.Image
+	if req.Picture != "" {
+		var serviceErr *customerrors.CustomError
+		image, serviceErr = service.createChatImage(req.Picture)
+		if serviceErr != nil {
+			return nil, serviceErr, http.StatusBadRequest
+		}
+	}
+
+	// Get current user and check if the other users exist
+	currentUser, err := service.userRepo.FindUserByUsername(currentUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.Unauthorized, http.StatusUnauthorized
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	currentTime := time.Now()
+	newChat := models.Chat{
+		Id:        uuid.New(),
+		IsGroup:   true,
+		Name:      req.Name,
+		Users:     []models.User{*currentUser},
 		CreatedAt: currentTime,
 	}
+	newChat.Members = []models.ChatUser{{ChatId: newChat.Id, UserUsername: currentUsername, Role: ChatRoleAdmin, JoinedAt: currentTime}}
+	if image != nil {
+		newChat.ImageId = &image.Id
+		newChat.Image = *image
+	}
+
+	for _, username := range otherUsernames {
+		user, err := service.userRepo.FindUserByUsername(username)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, customerrors.UserNotFound, http.StatusNotFound
+			}
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+		newChat.Users = append(newChat.Users, *user)
+		newChat.Members = append(newChat.Members, models.ChatUser{ChatId: newChat.Id, UserUsername: username, Role: ChatRoleMember, JoinedAt: currentTime})
+	}
+
+	return service.createChatWithFirstMessage(newChat, req.Content, currentUsername)
+}
 
+// createChatWithFirstMessage saves a new chat with the first message of the current user and notifies the other users
+func (service *ChatService) createChatWithFirstMessage(newChat models.Chat, content string, currentUsername string) (*models.ChatCreateResponseDTO, *customerrors.CustomError, int) {
 	firstMessage := models.Message{
 		Id:        uuid.New(),
 		ChatId:    newChat.Id,
+		Sequence:  1,
 		Username:  currentUsername,
-		Content:   req.Content,
-		CreatedAt: currentTime,
+		Content:   content,
+		CreatedAt: newChat.CreatedAt,
 	}
 
-	err = service.chatRepo.CreateChatWithFirstMessage(newChat, firstMessage)
+	err := service.chatRepo.CreateChatWithFirstMessage(newChat, firstMessage)
 	if err != nil {
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
-	// Send notification to other user
-	_ = service.notificationService.CreateNotification("message", req.Username, currentUsername) // ignore creation/sending error for current user
+	// Send notification to other users
+	for _, user := range newChat.Users {
+		if user.Username != currentUsername {
+			_ = service.notificationService.CreateNotification("message", user.Username, currentUsername, nil, nil) // ignore creation/sending error for current user
+		}
+	}
+
+	// Tell the user websockets of all participants about the new chat, also the other devices of the current user
+	for _, user := range newChat.Users {
+		service.publishUserChatEvent(user.Username, ChatEnvelopeChatCreated, newChat.Id.String())
+	}
 
 	// Create response
 	response := &models.ChatCreateResponseDTO{
-		ChatId: newChat.Id.String(),
-		Message: &models.MessageRecordDTO{
-			Content:      firstMessage.Content,
-			Username:     firstMessage.Username,
-			CreationDate: firstMessage.CreatedAt,
-		},
+		ChatId:  newChat.Id.String(),
+		Message: generateMessageRecordDTO(&firstMessage),
 	}
 
 	return response, nil, http.StatusCreated
 }
 
+// createChatImage decodes and validates a base64 encoded picture of a group chat
+func (service *ChatService) createChatImage(picture string) (*models.Image, *customerrors.CustomError) {
+	imageBytes, err := base64.StdEncoding.DecodeString(picture)
+	if err != nil {
+		return nil, customerrors.BadRequest
+	}
+	valid, format, width, height := service.validator.ValidateImage(imageBytes)
+	if !valid {
+		return nil, customerrors.BadRequest
+	}
+
+	return &models.Image{
+		Id:        uuid.New(),
+		Format:    format,
+		ImageData: imageBytes,
+		Width:     width,
+		Height:    height,
+		Tag:       time.Now().UTC(),
+	}, nil
+}
+
 // GetChatsByUsername retrieves all chats of a user by its username
 func (service *ChatService) GetChatsByUsername(username string) (*models.ChatsResponseDTO, *customerrors.CustomError, int) {
 	// Get Chats by username
@@ -116,30 +260,298 @@ func (service *ChatService) GetChatsByUsername(username string) (*models.ChatsRe
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
+	// Get number of unread messages in each chat
+	unreadCounts, err := service.chatRepo.GetUnreadMessageCounts(username)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Get presence of all participants at once, users can be participants of multiple chats
+	var participants []models.User
+	addedParticipants := make(map[string]bool)
+	for _, chat := range chats {
+		for _, user := range chat.Users {
+			if !addedParticipants[user.Username] {
+				addedParticipants[user.Username] = true
+				participants = append(participants, user)
+			}
+		}
+	}
+	presences, err := service.presenceService.GetPresences(participants, username)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
 	// Create response
 	chatDTOs := make([]models.ChatRecordDTO, 0)
 	for _, chat := range chats {
+		chatDTO := generateChatRecordDTO(&chat)
+		chatDTO.UnreadCount = unreadCounts[chat.Id.String()]
+		setParticipantPresences(chatDTO, presences)
+		chatDTOs = append(chatDTOs, *chatDTO)
+	}
 
-		// Currently, we only have two users in a chat --> find the other user
-		var chatUserDto models.UserDTO
-		for _, user := range chat.Users {
-			if user.Username != username {
-				chatUserDto = *utils.GenerateUserDTOFromUser(&user)
-				break
+	response := models.ChatsResponseDTO{
+		Records: chatDTOs,
+	}
+
+	return &response, nil, http.StatusOK
+}
+
+// AddChatMembers adds users to a group chat, only admins of the chat can add members
+func (service *ChatService) AddChatMembers(chatId string, req *models.ChatMembersAddRequestDTO, currentUsername string) (*models.ChatRecordDTO, *customerrors.CustomError, int) {
+	chat, serviceErr, httpStatus := service.getGroupChatAsAdmin(chatId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	// Users that are already members are ignored
+	currentTime := time.Now()
+	var newMembers []models.ChatUser
+	for _, username := range req.Usernames {
+		if getChatRole(chat, username) != "" || containsChatMember(newMembers, username) {
+			continue
+		}
+
+		_, err := service.userRepo.FindUserByUsername(username)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, customerrors.UserNotFound, http.StatusNotFound
+			}
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+		newMembers = append(newMembers, models.ChatUser{ChatId: chat.Id, UserUsername: username, Role: ChatRoleMember, JoinedAt: currentTime})
+	}
+	if len(chat.Users)+len(newMembers) > maxGroupChatMembers {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	if len(newMembers) > 0 {
+		if err := service.chatRepo.AddChatMembers(newMembers); err != nil {
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+	}
+
+	chatRecord, serviceErr, httpStatus := service.getChatRecord(chatId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+	for _, member := range newMembers {
+		service.publishUserChatEvent(member.UserUsername, ChatEnvelopeChatCreated, chatId)
+	}
+
+	return chatRecord, nil, httpStatus
+}
+
+// RemoveChatMember removes another user from a group chat, only admins of the chat can remove members
+func (service *ChatService) RemoveChatMember(chatId string, username string, currentUsername string) (*models.ChatRecordDTO, *customerrors.CustomError, int) {
+	chat, serviceErr, httpStatus := service.getGroupChatAsAdmin(chatId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	// Admins leave the chat instead of removing themselves
+	if username == currentUsername {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+	if getChatRole(chat, username) == "" {
+		return nil, customerrors.UserNotFound, http.StatusNotFound
+	}
+
+	if err := service.chatRepo.RemoveChatMember(chatId, username); err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	service.publishUserChatEvent(username, ChatEnvelopeChatRemoved, chatId)
+
+	return service.getChatRecord(chatId, currentUsername)
+}
+
+// UpdateChatMemberRole makes a member of a group chat an admin or a normal member, only admins of the chat can change roles
+func (service *ChatService) UpdateChatMemberRole(chatId string, username string, req *models.ChatMemberRoleUpdateRequestDTO, currentUsername string) (*models.ChatRecordDTO, *customerrors.CustomError, int) {
+	if req.Role != ChatRoleAdmin && req.Role != ChatRoleMember {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	chat, serviceErr, httpStatus := service.getGroupChatAsAdmin(chatId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	currentRole := getChatRole(chat, username)
+	if currentRole == "" {
+		return nil, customerrors.UserNotFound, http.StatusNotFound
+	}
+
+	// A group chat always needs at least one admin
+	if currentRole == ChatRoleAdmin && req.Role == ChatRoleMember {
+		admins := 0
+		for _, member := range chat.Members {
+			if member.Role == ChatRoleAdmin {
+				admins++
 			}
 		}
+		if admins <= 1 {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+	}
 
-		chatDTO := models.ChatRecordDTO{
-			ChatId: chat.Id.String(),
-			User:   &chatUserDto,
+	if currentRole != req.Role {
+		if err := service.chatRepo.UpdateChatMemberRole(chatId, username, req.Role); err != nil {
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
 		}
+	}
+
+	return service.getChatRecord(chatId, currentUsername)
+}
 
-		chatDTOs = append(chatDTOs, chatDTO)
+// LeaveChat removes the current user from a group chat, the chat is deleted if no members are left
+func (service *ChatService) LeaveChat(chatId string, currentUsername string) (*customerrors.CustomError, int) {
+	chat, serviceErr, httpStatus := service.getChat(chatId, currentUsername)
+	if serviceErr != nil {
+		return serviceErr, httpStatus
+	}
+	if !chat.IsGroup {
+		return customerrors.BadRequest, http.StatusBadRequest // direct chats cannot be left
 	}
 
-	response := models.ChatsResponseDTO{
-		Records: chatDTOs,
+	if err := service.chatRepo.RemoveChatMember(chatId, currentUsername); err != nil {
+		return customerrors.DatabaseError, http.StatusInternalServerError
 	}
+	service.publishUserChatEvent(currentUsername, ChatEnvelopeChatRemoved, chatId)
 
-	return &response, nil, http.StatusOK
+	return nil, http.StatusNoContent
+}
+
+// getChat retrieves a chat by its id and returns 404 if the current user is not a member of the chat
+func (service *ChatService) getChat(chatId string, currentUsername string) (*models.Chat, *customerrors.CustomError, int) {
+	chat, err := service.chatRepo.GetChatById(chatId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.ChatNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	if getChatRole(&chat, currentUsername) == "" {
+		return nil, customerrors.ChatNotFound, http.StatusNotFound
+	}
+
+	return &chat, nil, http.StatusOK
+}
+
+// getGroupChatAsAdmin retrieves a group chat by its id and checks if the current user is an admin of the chat
+func (service *ChatService) getGroupChatAsAdmin(chatId string, currentUsername string) (*models.Chat, *customerrors.CustomError, int) {
+	chat, serviceErr, httpStatus := service.getChat(chatId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+	if !chat.IsGroup {
+		return nil, customerrors.BadRequest, http.StatusBadRequest // members of direct chats cannot be changed
+	}
+	if getChatRole(chat, currentUsername) != ChatRoleAdmin {
+		return nil, customerrors.ChatAdminRequired, http.StatusForbidden
+	}
+
+	return chat, nil, http.StatusOK
+}
+
+// getChatRecord retrieves a chat after its members were changed and returns it as DTO with the presence of the participants
+func (service *ChatService) getChatRecord(chatId string, currentUsername string) (*models.ChatRecordDTO, *customerrors.CustomError, int) {
+	chat, err := service.chatRepo.GetChatById(chatId)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	presences, err := service.presenceService.GetPresences(chat.Users, currentUsername)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	chatDTO := generateChatRecordDTO(&chat)
+	setParticipantPresences(chatDTO, presences)
+	return chatDTO, nil, http.StatusOK
+}
+
+// setParticipantPresences sets the online status and last-seen timestamp of the participants of a chat DTO
+func setParticipantPresences(chatDTO *models.ChatRecordDTO, presences map[string]models.UserPresenceDTO) {
+	for i := range chatDTO.Participants {
+		presence := presences[chatDTO.Participants[i].Username]
+		chatDTO.Participants[i].Online = presence.Online
+		chatDTO.Participants[i].LastSeen = presence.LastSeen
+	}
+}
+
+// publishUserChatEvent sends a new or removed chat to the user websockets of a user on all server instances,
+// only the chat id is published because the chat record of a large group would exceed the payload limit of the broker,
+// the instances load the record of new chats themselves
+// Errors are ignored because the user can still load the chats
+func (service *ChatService) publishUserChatEvent(username, eventType, chatId string) {
+	err := service.chatBroker.Publish(&ChatBrokerMessage{
+		Type:     eventType,
+		ChatId:   chatId,
+		Username: username,
+	})
+	if err != nil {
+		fmt.Println("Error publishing chat event for", username+":", err)
+	}
+}
+
+// getChatRole returns the role of a user in a chat or an empty string if the user is not a member
+// Users without an entry in the members list (e.g. chats created before roles were introduced) are normal members
+func getChatRole(chat *models.Chat, username string) string {
+	for _, member := range chat.Members {
+		if member.UserUsername == username {
+			if member.Role == "" {
+				return ChatRoleMember
+			}
+			return member.Role
+		}
+	}
+	for _, user := range chat.Users {
+		if user.Username == username {
+			return ChatRoleMember
+		}
+	}
+	return ""
+}
+
+// containsChatMember checks if a list of chat members contains a specific user
+func containsChatMember(members []models.ChatUser, username string) bool {
+	for _, member := range members {
+		if member.UserUsername == username {
+			return true
+		}
+	}
+	return false
+}
+
+// generateChatRecordDTO creates the DTO of a chat with all participants and their roles
+func generateChatRecordDTO(chat *models.Chat) *models.ChatRecordDTO {
+	lastReadMessageIds := make(map[string]string)
+	for _, member := range chat.Members {
+		if member.LastReadMessageId != nil {
+			lastReadMessageIds[member.UserUsername] = member.LastReadMessageId.String()
+		}
+	}
+
+	participants := make([]models.ChatParticipantDTO, 0, len(chat.Users))
+	for _, user := range chat.Users {
+		participant := models.ChatParticipantDTO{
+			Username: user.Username,
+			Nickname: user.Nickname,
+			Picture:  utils.GenerateImageMetadataDTOFromImage(&user.Image),
+			Role:     getChatRole(chat, user.Username),
+		}
+		if lastReadMessageId, ok := lastReadMessageIds[user.Username]; ok {
+			participant.LastReadMessageId = &lastReadMessageId
+		}
+		participants = append(participants, participant)
+	}
+
+	return &models.ChatRecordDTO{
+		ChatId:       chat.Id.String(),
+		IsGroup:      chat.IsGroup,
+		Name:         chat.Name,
+		Picture:      utils.GenerateImageMetadataDTOFromImage(&chat.Image),
+		Participants: participants,
+	}
 }
@@ -16,19 +16,27 @@ import (
 
 type CommentServiceInterface interface {
 	CreateComment(req *models.CommentCreateRequestDTO, postId, currentUsername string) (*models.CommentResponseDTO, *customerrors.CustomError, int)
-	GetCommentsByPostId(postId string, offset, limit int) (*models.CommentFeedResponseDTO, *customerrors.CustomError, int)
+	GetCommentsByPostId(postId string, offset, limit int, sortByLikes bool, currentUsername string) (*models.CommentFeedResponseDTO, *customerrors.CustomError, int)
+	UpdateComment(req *models.CommentUpdateRequestDTO, postId, commentId, currentUsername string) (*models.CommentResponseDTO, *customerrors.CustomError, int)
+	DeleteComment(postId, commentId, currentUsername string) (*customerrors.CustomError, int)
+	GetRepliesByCommentId(postId, commentId string, offset, limit int, currentUsername string) (*models.CommentFeedResponseDTO, *customerrors.CustomError, int)
 }
 
+// maxCommentDepth is the maximum nesting depth of replies, top-level comments have depth 0
+const maxCommentDepth = 3
+
 type CommentService struct {
-	commentRepo repositories.CommentRepositoryInterface
-	postRepo    repositories.PostRepositoryInterface
-	userRepo    repositories.UserRepositoryInterface
-	policy      *bluemonday.Policy
+	commentRepo         repositories.CommentRepositoryInterface
+	postRepo            repositories.PostRepositoryInterface
+	userRepo            repositories.UserRepositoryInterface
+	likeRepo            repositories.LikeRepositoryInterface
+	notificationService NotificationServiceInterface
+	policy              *bluemonday.Policy
 }
 
 // NewCommentService can be used as a constructor to create a CommentService "object"
-func NewCommentService(commentRepo repositories.CommentRepositoryInterface, postRepo repositories.PostRepositoryInterface, userRepo repositories.UserRepositoryInterface) *CommentService {
-	return &CommentService{commentRepo: commentRepo, postRepo: postRepo, userRepo: userRepo, policy: bluemonday.UGCPolicy()}
+func NewCommentService(commentRepo repositories.CommentRepositoryInterface, postRepo repositories.PostRepositoryInterface, userRepo repositories.UserRepositoryInterface, likeRepo repositories.LikeRepositoryInterface, notificationService NotificationServiceInterface) *CommentService {
+	return &CommentService{commentRepo: commentRepo, postRepo: postRepo, userRepo: userRepo, likeRepo: likeRepo, notificationService: notificationService, policy: bluemonday.UGCPolicy()}
 }
 
 // CreateComment creates a new comment for a given post id using the provided request data
@@ -51,6 +59,21 @@ func (service *CommentService) CreateComment(req *models.CommentCreateRequestDTO
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
+	// Check parent comment if the comment is a reply
+	var parentCommentId *uuid.UUID
+	depth := 0
+	if req.ParentCommentId != "" {
+		parentComment, serviceErr, httpStatus := getCommentOfPost(service.commentRepo, postId, req.ParentCommentId)
+		if serviceErr != nil {
+			return nil, serviceErr, httpStatus
+		}
+		if parentComment.Depth >= maxCommentDepth {
+			return nil, customerrors.CommentDepthExceeded, http.StatusBadRequest
+		}
+		parentCommentId = &parentComment.Id
+		depth = parentComment.Depth + 1
+	}
+
 	// Get user by username
 	user, err := service.userRepo.FindUserByUsername(currentUsername)
 	if err != nil {
@@ -60,13 +83,22 @@ func (service *CommentService) CreateComment(req *models.CommentCreateRequestDTO
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
+	// Find mentioned users, mentions of non-existing users are ignored
+	mentions, err := findMentionedUsers(service.userRepo, req.Content)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
 	// Create comment
 	comment := &models.Comment{
-		Id:        uuid.New(),
-		PostID:    post.Id,
-		Username:  currentUsername,
-		Content:   req.Content,
-		CreatedAt: time.Now(),
+		Id:              uuid.New(),
+		PostID:          post.Id,
+		Username:        currentUsername,
+		Content:         req.Content,
+		CreatedAt:       time.Now(),
+		ParentCommentId: parentCommentId,
+		Depth:           depth,
+		Mentions:        mentions,
 	}
 
 	err = service.commentRepo.CreateComment(comment)
@@ -74,12 +106,23 @@ func (service *CommentService) CreateComment(req *models.CommentCreateRequestDTO
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
+	// Create notification for author of the post
+	_ = service.notificationService.CreateNotification("comment", post.Username, currentUsername, &post.Id, &comment.Id)
+
+	// Create notifications for mentioned users
+	notifyMentionedUsers(service.notificationService, nil, comment.Mentions, currentUsername, &post.Id, &comment.Id)
+
 	// Prepare response
 	responseDto := &models.CommentResponseDTO{
-		CommentId:    comment.Id,
-		Content:      comment.Content,
-		Author:       utils.GenerateUserDTOFromUser(user),
-		CreationDate: comment.CreatedAt,
+		CommentId:       comment.Id,
+		Content:         comment.Content,
+		Author:          utils.GenerateUserDTOFromUser(user),
+		CreationDate:    comment.CreatedAt,
+		ParentCommentId: comment.ParentCommentId,
+		Replies:         0, // no replies and likes yet
+		Likes:           0,
+		Liked:           false,
+		Mentions:        utils.GenerateUserDTOsFromUsers(comment.Mentions),
 	}
 
 	return responseDto, nil, http.StatusCreated
@@ -87,7 +130,7 @@ func (service *CommentService) CreateComment(req *models.CommentCreateRequestDTO
 }
 
 // GetCommentsByPostId retrieves comments for a given post id using the provided pagination information
-func (service *CommentService) GetCommentsByPostId(postId string, offset, limit int) (*models.CommentFeedResponseDTO, *customerrors.CustomError, int) {
+func (service *CommentService) GetCommentsByPostId(postId string, offset, limit int, sortByLikes bool, currentUsername string) (*models.CommentFeedResponseDTO, *customerrors.CustomError, int) {
 	// Check if post exists
 	_, err := service.postRepo.GetPostById(postId)
 	if err != nil {
@@ -98,20 +141,15 @@ func (service *CommentService) GetCommentsByPostId(postId string, offset, limit
 	}
 
 	// Get comments using pagination information
-	comments, count, err := service.commentRepo.GetCommentsByPostId(postId, offset, limit)
+	comments, count, err := service.commentRepo.GetCommentsByPostId(postId, offset, limit, sortByLikes)
 	if err != nil {
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
 	// Prepare response
-	var commentRecords []models.CommentResponseDTO
-	for _, comment := range comments {
-		commentRecords = append(commentRecords, models.CommentResponseDTO{
-			CommentId:    comment.Id,
-			Content:      comment.Content,
-			Author:       utils.GenerateUserDTOFromUser(&comment.User),
-			CreationDate: comment.CreatedAt,
-		})
+	commentRecords, err := createCommentResponseDtos(comments, currentUsername, service.commentRepo, service.likeRepo)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
 	paginationDto := &models.OffsetPaginationDTO{
@@ -127,3 +165,165 @@ func (service *CommentService) GetCommentsByPostId(postId string, offset, limit
 
 	return responseDto, nil, http.StatusOK
 }
+
+// UpdateComment changes the content of a comment, only the author of the comment is allowed to do this
+func (service *CommentService) UpdateComment(req *models.CommentUpdateRequestDTO, postId, commentId, currentUsername string) (*models.CommentResponseDTO, *customerrors.CustomError, int) {
+	// Sanitize content because it is a free text field
+	req.Content = strings.Trim(req.Content, " ") // remove leading and trailing whitespaces
+	req.Content = service.policy.Sanitize(req.Content)
+
+	// Content must not be empty or exceed 128 characters
+	if len(req.Content) <= 0 || len(req.Content) > 128 {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	comment, serviceErr, httpStatus := getCommentOfPost(service.commentRepo, postId, commentId)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	// Only the author may edit a comment
+	if comment.Username != currentUsername {
+		return nil, customerrors.UpdateCommentForbidden, http.StatusForbidden
+	}
+
+	// Find mentioned users again, only newly mentioned users are notified
+	previousMentions := comment.Mentions
+	mentions, err := findMentionedUsers(service.userRepo, req.Content)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	editedAt := time.Now()
+	comment.Content = req.Content
+	comment.EditedAt = &editedAt
+	comment.Mentions = mentions
+
+	err = service.commentRepo.UpdateComment(comment)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	notifyMentionedUsers(service.notificationService, previousMentions, comment.Mentions, currentUsername, &comment.PostID, &comment.Id)
+
+	commentRecords, err := createCommentResponseDtos([]models.Comment{*comment}, currentUsername, service.commentRepo, service.likeRepo)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return &commentRecords[0], nil, http.StatusOK
+}
+
+// DeleteComment deletes a comment, the author of the comment and the author of the post are allowed to do this
+func (service *CommentService) DeleteComment(postId, commentId, currentUsername string) (*customerrors.CustomError, int) {
+	comment, serviceErr, httpStatus := getCommentOfPost(service.commentRepo, postId, commentId)
+	if serviceErr != nil {
+		return serviceErr, httpStatus
+	}
+
+	// Post owners can moderate comments under their posts
+	if comment.Username != currentUsername && comment.Post.Username != currentUsername {
+		return customerrors.DeleteCommentForbidden, http.StatusForbidden
+	}
+
+	err := service.commentRepo.DeleteCommentById(commentId)
+	if err != nil {
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return nil, http.StatusNoContent
+}
+
+// GetRepliesByCommentId retrieves the direct replies to a comment using the provided pagination information
+func (service *CommentService) GetRepliesByCommentId(postId, commentId string, offset, limit int, currentUsername string) (*models.CommentFeedResponseDTO, *customerrors.CustomError, int) {
+	// Check if comment exists under the given post
+	_, serviceErr, httpStatus := getCommentOfPost(service.commentRepo, postId, commentId)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	// Get replies using pagination information
+	replies, count, err := service.commentRepo.GetRepliesByCommentId(commentId, offset, limit)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Prepare response
+	replyRecords, err := createCommentResponseDtos(replies, currentUsername, service.commentRepo, service.likeRepo)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	responseDto := &models.CommentFeedResponseDTO{
+		Records: replyRecords,
+		Pagination: &models.OffsetPaginationDTO{
+			Offset:  offset,
+			Limit:   limit,
+			Records: count,
+		},
+	}
+
+	return responseDto, nil, http.StatusOK
+}
+
+// getCommentOfPost returns the comment with the given id and checks that it belongs to the given post
+func getCommentOfPost(commentRepo repositories.CommentRepositoryInterface, postId, commentId string) (*models.Comment, *customerrors.CustomError, int) {
+	comment, err := commentRepo.GetCommentById(commentId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.CommentNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	if comment.PostID.String() != postId {
+		return nil, customerrors.CommentNotFound, http.StatusNotFound
+	}
+
+	return &comment, nil, http.StatusOK
+}
+
+// createCommentResponseDtos creates response dtos for a list of comments including the number of direct replies and like information of each comment
+func createCommentResponseDtos(comments []models.Comment, currentUsername string, commentRepo repositories.CommentRepositoryInterface, likeRepo repositories.LikeRepositoryInterface) ([]models.CommentResponseDTO, error) {
+	commentRecords := make([]models.CommentResponseDTO, 0)
+	if len(comments) == 0 {
+		return commentRecords, nil
+	}
+
+	// Reply counts and like information of all comments are loaded at once
+	commentIds := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		commentIds = append(commentIds, comment.Id.String())
+	}
+	replyCounts, err := commentRepo.CountRepliesByCommentIds(commentIds)
+	if err != nil {
+		return nil, err
+	}
+	likeCounts, err := likeRepo.CountCommentLikesByCommentIds(commentIds)
+	if err != nil {
+		return nil, err
+	}
+	currentUserLikes, err := likeRepo.FindCommentLikesByCommentIds(commentIds, currentUsername)
+	if err != nil {
+		return nil, err
+	}
+	likedByCurrentUser := make(map[string]bool, len(currentUserLikes))
+	for _, like := range currentUserLikes {
+		likedByCurrentUser[like.CommentId.String()] = true
+	}
+
+	for _, comment := range comments {
+		commentRecords = append(commentRecords, models.CommentResponseDTO{
+			CommentId:       comment.Id,
+			Content:         comment.Content,
+			Author:          utils.GenerateUserDTOFromUser(&comment.User),
+			CreationDate:    comment.CreatedAt,
+			EditedAt:        comment.EditedAt,
+			ParentCommentId: comment.ParentCommentId,
+			Replies:         replyCounts[comment.Id.String()],
+			Likes:           likeCounts[comment.Id.String()],
+			Liked:           likedByCurrentUser[comment.Id.String()],
+			Mentions:        utils.GenerateUserDTOsFromUsers(comment.Mentions),
+		})
+	}
+	return commentRecords, nil
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// dataExportBuildTimeout is the time after which a pending export counts as failed,
+// e.g. because the server restarted while the archive was built in the background
+const dataExportBuildTimeout = 30 * time.Minute
+
+type DataExportServiceInterface interface {
+	CreateDataExport(currentUsername string) (*models.DataExportResponseDTO, *customerrors.CustomError, int)
+	GetDataExportArchive(exportId string, token string) ([]byte, *customerrors.CustomError, int)
+}
+
+type DataExportService struct {
+	dataExportRepo repositories.DataExportRepositoryInterface
+	userRepo       repositories.UserRepositoryInterface
+	mailService    MailServiceInterface
+}
+
+// NewDataExportService can be used as a constructor to create a DataExportService "object"
+func NewDataExportService(
+	dataExportRepo repositories.DataExportRepositoryInterface,
+	userRepo repositories.UserRepositoryInterface,
+	mailService MailServiceInterface) *DataExportService {
+	return &DataExportService{dataExportRepo: dataExportRepo, userRepo: userRepo, mailService: mailService}
+}
+
+// CreateDataExport creates a new data export for the current user and builds the archive in the background,
+// if an export of the user is still being built, this export is returned instead of starting another one
+// Pending exports that are older than dataExportBuildTimeout are marked as failed and a new export is started
+func (service *DataExportService) CreateDataExport(currentUsername string) (*models.DataExportResponseDTO, *customerrors.CustomError, int) {
+	// Get user to send mail to when the export is ready
+	user, err := service.userRepo.FindUserByUsername(currentUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.Unauthorized, http.StatusUnauthorized // not reachable, because of JWT middleware
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Return the export that is still being built
+	pendingExport, err := service.dataExportRepo.GetPendingDataExportByUsername(currentUsername)
+	if err == nil {
+		if pendingExport.CreatedAt.After(time.Now().Add(-dataExportBuildTimeout)) {
+			return &models.DataExportResponseDTO{
+				ExportId:     pendingExport.Id.String(),
+				Status:       pendingExport.Status,
+				CreationDate: pendingExport.CreatedAt,
+			}, nil, http.StatusAccepted
+		}
+
+		// The archive of the export is not built anymore, so it must not block new exports
+		pendingExport.Status = "failed"
+		if err := service.dataExportRepo.UpdateDataExport(pendingExport); err != nil {
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Generate download token
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return nil, customerrors.InternalServerError, http.StatusInternalServerError
+	}
+
+	// Create export and save it to database
+	currentTime := time.Now()
+	dataExport := models.DataExport{
+		Id:             uuid.New(),
+		Username:       currentUsername,
+		Status:         "pending",
+		Token:          token,
+		CreatedAt:      currentTime,
+		ExpirationTime: currentTime.Add(7 * 24 * time.Hour),
+	}
+	if err := service.dataExportRepo.CreateDataExport(&dataExport); err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Build archive in background, user is informed via mail
+	go service.buildDataExport(dataExport, user.Email, user.Locale)
+
+	response := models.DataExportResponseDTO{
+		ExportId:     dataExport.Id.String(),
+		Status:       dataExport.Status,
+		CreationDate: dataExport.CreatedAt,
+	}
+
+	return &response, nil, http.StatusAccepted
+}
+
+// GetDataExportArchive returns the zip archive of a data export if the given token is valid
+func (service *DataExportService) GetDataExportArchive(exportId string, token string) ([]byte, *customerrors.CustomError, int) {
+	dataExport, err := service.dataExportRepo.GetDataExportById(exportId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.DataExportNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Invalid tokens and expired exports are handled as not found to not reveal any information
+	// The token is compared in constant time, so that the response time does not reveal how much of the token is correct
+	if token == "" || subtle.ConstantTimeCompare([]byte(dataExport.Token), []byte(token)) != 1 || dataExport.ExpirationTime.Before(time.Now()) {
+		return nil, customerrors.DataExportNotFound, http.StatusNotFound
+	}
+
+	if dataExport.Status != "ready" {
+		return nil, customerrors.DataExportNotReady, http.StatusConflict
+	}
+
+	return dataExport.Data, nil, http.StatusOK
+}
+
+// buildDataExport collects all data of the user, saves the zip archive and sends the download link via mail
+func (service *DataExportService) buildDataExport(dataExport models.DataExport, email string, locale string) {
+	archive, err := service.createArchive(dataExport.Username)
+	if err != nil {
+		fmt.Println("Error building data export for", dataExport.Username, err)
+		dataExport.Status = "failed"
+		_ = service.dataExportRepo.UpdateDataExport(&dataExport)
+		return
+	}
+
+	dataExport.Status = "ready"
+	dataExport.Data = archive
+	if err := service.dataExportRepo.UpdateDataExport(&dataExport); err != nil {
+		fmt.Println("Error saving data export for", dataExport.Username, err)
+		return
+	}
+
+	subject := utils.Translate(locale, "email.dataExport.subject")
+	body := utils.GetDataExportEmailBody(locale, dataExport.Username, utils.FormatDataExportUrl(dataExport.Id.String(), dataExport.Token))
+	if err := service.mailService.SendMail(email, subject, body); err != nil {
+		fmt.Println("Error sending data export mail to", dataExport.Username, err)
+	}
+}
+
+// createArchive creates a zip archive with json files for all data of the user and the raw image files
+func (service *DataExportService) createArchive(username string) ([]byte, error) {
+	data, err := service.dataExportRepo.GetUserExportData(username)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buffer)
+
+	// Profile
+	profilePicture, err := writeExportImage(zipWriter, &data.User.Image)
+	if err != nil {
+		return nil, err
+	}
+	digestFrequency := DefaultDigestFrequency
+	if data.DigestSetting != nil {
+		digestFrequency = data.DigestSetting.Frequency
+	}
+	presenceVisibility := data.User.PresenceVisibility
+	if presenceVisibility != PresenceVisibilityContacts {
+		presenceVisibility = PresenceVisibilityEveryone
+	}
+	profile := models.ExportProfileDTO{
+		Username:           data.User.Username,
+		Nickname:           data.User.Nickname,
+		Email:              data.User.Email,
+		Status:             data.User.Status,
+		CreationDate:       data.User.CreatedAt,
+		Picture:            profilePicture,
+		DigestFrequency:    digestFrequency,
+		Locale:             data.User.Locale,
+		PresenceVisibility: presenceVisibility,
+		LastSeen:           data.User.LastSeenAt,
+	}
+	if err := writeExportJson(zipWriter, "profile.json", profile); err != nil {
+		return nil, err
+	}
+
+	// Posts with their previous versions
+	revisions := make(map[uuid.UUID][]models.ExportPostRevisionDTO)
+	for _, revision := range data.PostRevisions {
+		revisions[revision.PostId] = append(revisions[revision.PostId], models.ExportPostRevisionDTO{
+			Content:      revision.Content,
+			Location:     utils.GenerateLocationDTOFromLocation(&revision.Location),
+			CreationDate: revision.CreatedAt,
+		})
+	}
+	posts := make([]models.ExportPostDTO, 0)
+	for _, post := range data.Posts {
+		hashtags := make([]string, 0)
+		for _, hashtag := range post.Hashtags {
+			hashtags = append(hashtags, hashtag.Name)
+		}
+		repostedPostId := ""
+		if post.RepostId != nil {
+			repostedPostId = post.RepostId.String()
+		}
+		postPicture, err := writeExportImage(zipWriter, &post.Image)
+		if err != nil {
+			return nil, err
+		}
+		postRevisions := revisions[post.Id]
+		if postRevisions == nil {
+			postRevisions = make([]models.ExportPostRevisionDTO, 0)
+		}
+		posts = append(posts, models.ExportPostDTO{
+			PostId:         post.Id.String(),
+			Content:        post.Content,
+			CreationDate:   post.CreatedAt,
+			Hashtags:       hashtags,
+			Location:       utils.GenerateLocationDTOFromLocation(&post.Location),
+			Picture:        postPicture,
+			RepostedPostId: repostedPostId,
+			Revisions:      postRevisions,
+		})
+	}
+	if err := writeExportJson(zipWriter, "posts.json", posts); err != nil {
+		return nil, err
+	}
+
+	// Comments
+	comments := make([]models.ExportCommentDTO, 0)
+	for _, comment := range data.Comments {
+		comments = append(comments, models.ExportCommentDTO{
+			CommentId:    comment.Id.String(),
+			PostId:       comment.PostID.String(),
+			Content:      comment.Content,
+			CreationDate: comment.CreatedAt,
+		})
+	}
+	if err := writeExportJson(zipWriter, "comments.json", comments); err != nil {
+		return nil, err
+	}
+
+	// Likes
+	likes := make([]models.ExportLikeDTO, 0)
+	for _, like := range data.Likes {
+		likes = append(likes, models.ExportLikeDTO{PostId: like.PostId.String()})
+	}
+	if err := writeExportJson(zipWriter, "likes.json", likes); err != nil {
+		return nil, err
+	}
+	commentLikes := make([]models.ExportCommentLikeDTO, 0)
+	for _, commentLike := range data.CommentLikes {
+		commentLikes = append(commentLikes, models.ExportCommentLikeDTO{CommentId: commentLike.CommentId.String()})
+	}
+	if err := writeExportJson(zipWriter, "comment_likes.json", commentLikes); err != nil {
+		return nil, err
+	}
+
+	// Subscriptions
+	subscriptions := make([]models.ExportSubscriptionDTO, 0)
+	for _, subscription := range data.Subscriptions {
+		subscriptions = append(subscriptions, models.ExportSubscriptionDTO{
+			SubscriptionId:   subscription.Id.String(),
+			SubscriptionDate: subscription.SubscriptionDate,
+			Follower:         subscription.FollowerUsername,
+			Following:        subscription.FollowingUsername,
+		})
+	}
+	if err := writeExportJson(zipWriter, "subscriptions.json", subscriptions); err != nil {
+		return nil, err
+	}
+
+	// Notifications
+	notifications := make([]models.ExportNotificationDTO, 0)
+	for _, notification := range data.Notifications {
+		notifications = append(notifications, models.ExportNotificationDTO{
+			NotificationId:   notification.Id.String(),
+			Timestamp:        notification.Timestamp,
+			NotificationType: notification.NotificationType,
+			ForUsername:      notification.ForUsername,
+			FromUsername:     notification.FromUsername,
+		})
+	}
+	if err := writeExportJson(zipWriter, "notifications.json", notifications); err != nil {
+		return nil, err
+	}
+
+	// Notification settings
+	notificationSettings := models.ExportNotificationSettingsDTO{
+		Settings:   make([]models.NotificationTypeSettingDTO, 0),
+		MutedUsers: make([]models.ExportMutedUserDTO, 0),
+	}
+	for _, setting := range data.NotificationSettings {
+		notificationSettings.Settings = append(notificationSettings.Settings, models.NotificationTypeSettingDTO{
+			NotificationType: setting.NotificationType,
+			InApp:            setting.InApp,
+			Push:             setting.Push,
+		})
+	}
+	for _, mute := range data.NotificationMutes {
+		notificationSettings.MutedUsers = append(notificationSettings.MutedUsers, models.ExportMutedUserDTO{
+			Username:  mute.MutedUsername,
+			MutedDate: mute.CreatedAt,
+		})
+	}
+	if err := writeExportJson(zipWriter, "notification_settings.json", notificationSettings); err != nil {
+		return nil, err
+	}
+
+	// Push subscriptions
+	pushSubscriptions := make([]models.ExportPushSubscriptionDTO, 0)
+	for _, pushSubscription := range data.PushSubscriptions {
+		token := pushSubscription.DeviceToken
+		switch pushSubscription.Type {
+		case "web":
+			token = pushSubscription.Endpoint
+		case "expo":
+			token = pushSubscription.ExpoToken
+		}
+		pushSubscriptions = append(pushSubscriptions, models.ExportPushSubscriptionDTO{
+			SubscriptionId: pushSubscription.Id.String(),
+			Type:           pushSubscription.Type,
+			Token:          token,
+			CreationDate:   pushSubscription.CreatedAt,
+		})
+	}
+	if err := writeExportJson(zipWriter, "push_subscriptions.json", pushSubscriptions); err != nil {
+		return nil, err
+	}
+
+	// Chat messages
+	messages := make([]models.ExportMessageDTO, 0)
+	for _, message := range data.Messages {
+		messagePicture, err := writeExportImage(zipWriter, &message.Image)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, models.ExportMessageDTO{
+			ChatId:       message.ChatId.String(),
+			Username:     message.Username,
+			Content:      message.Content,
+			Picture:      messagePicture,
+			CreationDate: message.CreatedAt,
+		})
+	}
+	if err := writeExportJson(zipWriter, "messages.json", messages); err != nil {
+		return nil, err
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// writeExportJson writes the given object as json file to the zip archive
+func writeExportJson(zipWriter *zip.Writer, fileName string, object interface{}) error {
+	jsonBytes, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := zipWriter.Create(fileName)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(jsonBytes)
+	return err
+}
+
+// writeExportImage writes the raw image data to the images folder of the zip archive and returns the file name or "" if there is no image
+func writeExportImage(zipWriter *zip.Writer, image *models.Image) (string, error) {
+	if image == nil || image.Id == uuid.Nil {
+		return "", nil
+	}
+	fileName := "images/" + image.Id.String() + "." + image.Format
+	file, err := zipWriter.Create(fileName)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(image.ImageData); err != nil {
+		return "", err
+	}
+	return fileName, nil
+}
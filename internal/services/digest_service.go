@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// DefaultDigestFrequency is used for users that have not chosen a digest frequency yet, digests are opt-in
+const DefaultDigestFrequency = "off"
+
+type DigestServiceInterface interface {
+	GetDigestSetting(currentUsername string) (*models.DigestSettingResponseDTO, *customerrors.CustomError, int)
+	UpdateDigestSetting(req *models.DigestSettingUpdateRequestDTO, currentUsername string) (*models.DigestSettingResponseDTO, *customerrors.CustomError, int)
+	Unsubscribe(token string) (*models.DigestSettingResponseDTO, *customerrors.CustomError, int)
+}
+
+type DigestService struct {
+	digestRepo repositories.DigestRepositoryInterface
+}
+
+// NewDigestService can be used as a constructor to create a DigestService "object"
+func NewDigestService(digestRepo repositories.DigestRepositoryInterface) *DigestService {
+	return &DigestService{digestRepo: digestRepo}
+}
+
+// GetDigestSetting returns the digest frequency of the current user
+func (service *DigestService) GetDigestSetting(currentUsername string) (*models.DigestSettingResponseDTO, *customerrors.CustomError, int) {
+	setting, err := service.digestRepo.GetDigestSettingByUsername(currentUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.DigestSettingResponseDTO{Frequency: DefaultDigestFrequency}, nil, http.StatusOK
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return &models.DigestSettingResponseDTO{Frequency: setting.Frequency}, nil, http.StatusOK
+}
+
+// UpdateDigestSetting changes the digest frequency of the current user to daily, weekly or off
+func (service *DigestService) UpdateDigestSetting(req *models.DigestSettingUpdateRequestDTO, currentUsername string) (*models.DigestSettingResponseDTO, *customerrors.CustomError, int) {
+	if req.Frequency != "daily" && req.Frequency != "weekly" && req.Frequency != "off" {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	setting, err := service.digestRepo.GetDigestSettingByUsername(currentUsername)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+
+		// User has no digest setting yet, so a new one with an unsubscribe token is created
+		setting, err = NewDigestSetting(currentUsername, req.Frequency)
+		if err != nil {
+			return nil, customerrors.InternalServerError, http.StatusInternalServerError
+		}
+		if err := service.digestRepo.CreateDigestSetting(setting); err != nil {
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+		return &models.DigestSettingResponseDTO{Frequency: setting.Frequency}, nil, http.StatusOK
+	}
+
+	setting.Frequency = req.Frequency
+	if err := service.digestRepo.UpdateDigestSetting(setting); err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return &models.DigestSettingResponseDTO{Frequency: setting.Frequency}, nil, http.StatusOK
+}
+
+// Unsubscribe turns off the email digest of the user the unsubscribe token belongs to
+func (service *DigestService) Unsubscribe(token string) (*models.DigestSettingResponseDTO, *customerrors.CustomError, int) {
+	if token == "" {
+		return nil, customerrors.DigestSettingNotFound, http.StatusNotFound
+	}
+
+	setting, err := service.digestRepo.GetDigestSettingByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.DigestSettingNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	setting.Frequency = "off"
+	if err := service.digestRepo.UpdateDigestSetting(setting); err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return &models.DigestSettingResponseDTO{Frequency: setting.Frequency}, nil, http.StatusOK
+}
+
+// NewDigestSetting creates a digest setting with a new unsubscribe token for the user
+func NewDigestSetting(username string, frequency string) (*models.DigestSetting, error) {
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DigestSetting{
+		Id:               uuid.New(),
+		Username:         username,
+		Frequency:        frequency,
+		UnsubscribeToken: token,
+	}, nil
+}
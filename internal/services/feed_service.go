@@ -15,8 +15,13 @@ type FeedServiceInterface interface {
 	GetPostsGlobalFeed(lastPostId string, limit int, currentUsername string) (*models.GeneralFeedDTO, *customerrors.CustomError, int)
 	GetPostsPersonalFeed(username string, lastPostId string, limit int, currentUsername string) (*models.GeneralFeedDTO, *customerrors.CustomError, int)
 	GetPostsByHashtag(hashtag string, lastPostId string, limit int, currentUsername string) (*models.GeneralFeedDTO, *customerrors.CustomError, int)
+	GetPostsByMention(username string, lastPostId string, limit int, currentUsername string) (*models.GeneralFeedDTO, *customerrors.CustomError, int)
+	GetPostById(postId string, currentUsername string) (*models.PostDetailResponseDTO, *customerrors.CustomError, int)
 }
 
+// commentsFirstPageLimit is the number of comments that are returned together with a single post
+const commentsFirstPageLimit = 10
+
 type FeedService struct {
 	postRepo    repositories.PostRepositoryInterface
 	userRepo    repositories.UserRepositoryInterface
@@ -70,6 +75,8 @@ func (service *FeedService) GetPostsByUsername(username string, offset, limit in
 			Location:     utils.GenerateLocationDTOFromLocation(&post.Location),
 			Picture:      utils.GenerateImageMetadataDTOFromImage(&post.Image),
 			Repost:       repostDto,
+			EditedAt:     post.EditedAt,
+			Mentions:     utils.GenerateUserDTOsFromUsers(post.Mentions),
 		}
 		postDtos = append(postDtos, postDto)
 	}
@@ -205,6 +212,94 @@ func (service *FeedService) GetPostsByHashtag(hashtag string, lastPostId string,
 	return feed, nil, http.StatusOK
 }
 
+// GetPostsByMention returns a pagination object with the posts that mention a user using pagination parameters
+func (service *FeedService) GetPostsByMention(username string, lastPostId string, limit int, currentUsername string) (*models.GeneralFeedDTO, *customerrors.CustomError, int) {
+	// See if user exists
+	_, err := service.userRepo.FindUserByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.UserNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Get last post if lastPostId is not empty
+	var lastPost models.Post
+	if lastPostId != "" {
+		post, err := service.postRepo.GetPostById(lastPostId)
+		if err != nil {
+
+			// If post is not found, return empty feed with number of records
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				_, totalPostsCount, err := service.postRepo.GetPostsByMention(username, &lastPost, limit)
+				if err != nil {
+					return nil, customerrors.DatabaseError, http.StatusInternalServerError
+				}
+				emptyFeed := service.createEmptyFeedObject(limit, totalPostsCount)
+				return emptyFeed, nil, http.StatusOK
+			}
+
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+
+		lastPost = post
+	}
+
+	// Retrieve posts from the database
+	posts, totalPostsCount, err := service.postRepo.GetPostsByMention(username, &lastPost, limit)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Create response dto
+	feed, err := service.generatePostFeedWithAuthor(posts, totalPostsCount, limit, currentUsername)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return feed, nil, http.StatusOK
+}
+
+// GetPostById returns a single post with like, comment and repost information and the first page of its comments
+func (service *FeedService) GetPostById(postId string, currentUsername string) (*models.PostDetailResponseDTO, *customerrors.CustomError, int) {
+	post, err := service.postRepo.GetPostById(postId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.PostNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	postDto, err := service.getPostResponseDto(post, currentUsername)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Get first page of comments
+	comments, commentCount, err := service.commentRepo.GetCommentsByPostId(postId, 0, commentsFirstPageLimit, false)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	commentRecords, err := createCommentResponseDtos(comments, currentUsername, service.commentRepo, service.likeRepo)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	responseDto := models.PostDetailResponseDTO{
+		PostResponseDTO: *postDto,
+		CommentFeed: &models.CommentFeedResponseDTO{
+			Records: commentRecords,
+			Pagination: &models.OffsetPaginationDTO{
+				Offset:  0,
+				Limit:   commentsFirstPageLimit,
+				Records: commentCount,
+			},
+		},
+	}
+
+	return &responseDto, nil, http.StatusOK
+}
+
 // generatePostFeedWithAuthor creates a GeneralFeedDTO from a list of posts and a total count
 func (service *FeedService) generatePostFeedWithAuthor(posts []models.Post, totalPostsCount int64, limit int, currentUsername string) (*models.GeneralFeedDTO, error) {
 	// Create response dto
@@ -221,32 +316,42 @@ func (service *FeedService) generatePostFeedWithAuthor(posts []models.Post, tota
 		},
 	}
 	for _, post := range posts {
-
-		likedByCurrentUser, likeCount, commentCount, err := service.getLikeAndCommentInformationByPost(post, currentUsername)
+		postDto, err := service.getPostResponseDto(post, currentUsername)
 		if err != nil {
 			return nil, err
 		}
+		feed.Records = append(feed.Records, *postDto)
+	}
+	return &feed, nil
+}
 
-		repostDto, err := service.getRepostResponseDto(post, currentUsername)
-		if err != nil {
-			return nil, err
-		}
+// getPostResponseDto creates a PostResponseDTO with like, comment and repost information for a post
+func (service *FeedService) getPostResponseDto(post models.Post, currentUsername string) (*models.PostResponseDTO, error) {
+	likedByCurrentUser, likeCount, commentCount, err := service.getLikeAndCommentInformationByPost(post, currentUsername)
+	if err != nil {
+		return nil, err
+	}
 
-		postDto := models.PostResponseDTO{
-			PostId:       post.Id,
-			Author:       utils.GenerateUserDTOFromUser(&post.User),
-			CreationDate: post.CreatedAt,
-			Content:      post.Content,
-			Picture:      utils.GenerateImageMetadataDTOFromImage(&post.Image),
-			Comments:     commentCount,
-			Likes:        likeCount,
-			Liked:        likedByCurrentUser,
-			Location:     utils.GenerateLocationDTOFromLocation(&post.Location),
-			Repost:       repostDto,
-		}
-		feed.Records = append(feed.Records, postDto)
+	repostDto, err := service.getRepostResponseDto(post, currentUsername)
+	if err != nil {
+		return nil, err
 	}
-	return &feed, nil
+
+	postDto := models.PostResponseDTO{
+		PostId:       post.Id,
+		Author:       utils.GenerateUserDTOFromUser(&post.User),
+		CreationDate: post.CreatedAt,
+		Content:      post.Content,
+		Picture:      utils.GenerateImageMetadataDTOFromImage(&post.Image),
+		Comments:     commentCount,
+		Likes:        likeCount,
+		Liked:        likedByCurrentUser,
+		Location:     utils.GenerateLocationDTOFromLocation(&post.Location),
+		Repost:       repostDto,
+		EditedAt:     post.EditedAt,
+		Mentions:     utils.GenerateUserDTOsFromUsers(post.Mentions),
+	}
+	return &postDto, nil
 }
 
 // getLikeAndCommentInformationByPost returns whether the post is liked by the current user and the like count
@@ -311,6 +416,8 @@ func (service *FeedService) getRepostResponseDto(post models.Post, currentUserna
 		Liked:        likedByCurrentUser,
 		Location:     utils.GenerateLocationDTOFromLocation(&repost.Location),
 		Repost:       nil, // cannot have a repost of a repost, so always nil
+		EditedAt:     repost.EditedAt,
+		Mentions:     utils.GenerateUserDTOsFromUsers(repost.Mentions),
 	}
 	return repostDto, nil
 }
@@ -11,7 +11,7 @@ import (
 )
 
 type ImageServiceInterface interface {
-	GetImageById(imageId string) (*models.ImageDTO, *customerrors.CustomError, int)
+	GetImageById(imageId, currentUsername string) (*models.ImageDTO, *customerrors.CustomError, int)
 }
 
 type ImageService struct {
@@ -23,8 +23,9 @@ func NewImageService(imageRepo repositories.ImageRepositoryInterface) *ImageServ
 	return &ImageService{imageRepo: imageRepo}
 }
 
-// GetImageById can be used in image controller to return an image from the database
-func (service *ImageService) GetImageById(imageId string) (*models.ImageDTO, *customerrors.CustomError, int) {
+// GetImageById can be used in image controller to return an image from the database,
+// images of chats can only be fetched by participants of the chat, currentUsername is empty if the user is not logged in
+func (service *ImageService) GetImageById(imageId, currentUsername string) (*models.ImageDTO, *customerrors.CustomError, int) {
 	// Image id consists of the image name and the file format
 	// The image name is the primary key in the database
 	// The image format is the file format of the image
@@ -43,6 +44,27 @@ func (service *ImageService) GetImageById(imageId string) (*models.ImageDTO, *cu
 		return nil, customerrors.ImageNotFound, http.StatusNotFound
 	}
 
+	// Check if the image belongs to a chat and if the current user is a participant of it
+	chat, err := service.imageRepo.GetChatByImageId(imageIdSeperated[0])
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	if err == nil {
+		if currentUsername == "" {
+			return nil, customerrors.Unauthorized, http.StatusUnauthorized
+		}
+		isParticipant := false
+		for _, user := range chat.Users {
+			if user.Username == currentUsername {
+				isParticipant = true
+				break
+			}
+		}
+		if !isParticipant {
+			return nil, customerrors.ImageNotFound, http.StatusNotFound // do not reveal that the image exists
+		}
+	}
+
 	// Create response
 	response := models.ImageDTO{
 		Format: image.Format,
@@ -13,18 +13,24 @@ import (
 type LikeServiceInterface interface {
 	PostLike(postId, currentUsername string) (*customerrors.CustomError, int)
 	DeleteLike(postId, currentUsername string) (*customerrors.CustomError, int)
+	PostCommentLike(postId, commentId, currentUsername string) (*customerrors.CustomError, int)
+	DeleteCommentLike(postId, commentId, currentUsername string) (*customerrors.CustomError, int)
 }
 
 type LikeService struct {
-	likeRepo repositories.LikeRepositoryInterface
-	postRepo repositories.PostRepositoryInterface
+	likeRepo            repositories.LikeRepositoryInterface
+	postRepo            repositories.PostRepositoryInterface
+	commentRepo         repositories.CommentRepositoryInterface
+	notificationService NotificationServiceInterface
 }
 
 // NewLikeService can be used as a constructor to create a LikeService "object"
 func NewLikeService(
 	likeRepo repositories.LikeRepositoryInterface,
-	postRepo repositories.PostRepositoryInterface) *LikeService {
-	return &LikeService{likeRepo: likeRepo, postRepo: postRepo}
+	postRepo repositories.PostRepositoryInterface,
+	commentRepo repositories.CommentRepositoryInterface,
+	notificationService NotificationServiceInterface) *LikeService {
+	return &LikeService{likeRepo: likeRepo, postRepo: postRepo, commentRepo: commentRepo, notificationService: notificationService}
 }
 
 // PostLike creates a like for a given post id and the current logged-in user
@@ -60,6 +66,9 @@ func (service *LikeService) PostLike(postId, currentUsername string) (*customerr
 		return customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
+	// Create notification for author of the post
+	_ = service.notificationService.CreateNotification("like", post.Username, currentUsername, &post.Id, nil)
+
 	// Return
 	return nil, http.StatusNoContent
 }
@@ -67,7 +76,7 @@ func (service *LikeService) PostLike(postId, currentUsername string) (*customerr
 // DeleteLike deletes a like for a given post id and the current logged-in user
 func (service *LikeService) DeleteLike(postId string, currentUsername string) (*customerrors.CustomError, int) {
 	// Check if post exists
-	_, err := service.postRepo.GetPostById(postId)
+	post, err := service.postRepo.GetPostById(postId)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return customerrors.PostNotFound, http.StatusNotFound
@@ -90,5 +99,66 @@ func (service *LikeService) DeleteLike(postId string, currentUsername string) (*
 		return customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
+	// Take the like back from the grouped notification of the post author
+	_ = service.notificationService.RemoveLikeNotification(post.Username, &post.Id)
+
+	return nil, http.StatusNoContent
+}
+
+// PostCommentLike creates a like for a given comment id and the current logged-in user
+func (service *LikeService) PostCommentLike(postId, commentId, currentUsername string) (*customerrors.CustomError, int) {
+	// Check if comment exists under the given post
+	comment, serviceErr, httpStatus := getCommentOfPost(service.commentRepo, postId, commentId)
+	if serviceErr != nil {
+		return serviceErr, httpStatus
+	}
+
+	// Check if like already exists
+	_, err := service.likeRepo.FindCommentLike(commentId, currentUsername)
+	if err == nil {
+		return customerrors.CommentAlreadyLiked, http.StatusConflict
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Create like
+	newLike := models.CommentLike{
+		Id:        uuid.New(),
+		CommentId: comment.Id,
+		Username:  currentUsername,
+	}
+
+	err = service.likeRepo.CreateCommentLike(&newLike)
+	if err != nil {
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return nil, http.StatusNoContent
+}
+
+// DeleteCommentLike deletes a like for a given comment id and the current logged-in user
+func (service *LikeService) DeleteCommentLike(postId, commentId, currentUsername string) (*customerrors.CustomError, int) {
+	// Check if comment exists under the given post
+	_, serviceErr, httpStatus := getCommentOfPost(service.commentRepo, postId, commentId)
+	if serviceErr != nil {
+		return serviceErr, httpStatus
+	}
+
+	// Get like
+	like, err := service.likeRepo.FindCommentLike(commentId, currentUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customerrors.CommentNotLiked, http.StatusConflict
+		}
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Delete like
+	err = service.likeRepo.DeleteCommentLike(like.Id.String())
+	if err != nil {
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
 	return nil, http.StatusNoContent
 }
@@ -1,34 +1,61 @@
 package services
 
 import (
+	"encoding/base64"
 	"errors"
 	"github.com/google/uuid"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
 	"gorm.io/gorm"
 	"net/http"
 	"strings"
 	"time"
+	"unicode"
 )
 
 type MessageServiceInterface interface {
 	GetChatById(chatId string, currentUsername string) (*models.Chat, *customerrors.CustomError, int)
+	GetChatIdsByUsername(currentUsername string) ([]string, *customerrors.CustomError, int)
+	GetChatRecordById(chatId string, currentUsername string) (*models.ChatRecordDTO, *customerrors.CustomError, int)
 	GetMessagesByChatId(chatId, currentUsername string, offset, limit int) (*models.MessagesResponseDTO, *customerrors.CustomError, int)
+	GetMessagesSinceSequence(chatId, currentUsername string, sequence int64) ([]models.MessageRecordDTO, *customerrors.CustomError, int)
 	CreateMessage(chatId, currentUsername string, req *models.MessageCreateRequestDTO, connectedParticipants []string) (*models.MessageRecordDTO, *customerrors.CustomError, int)
+	MarkChatAsRead(chatId, currentUsername string) (*models.ChatEventDTO, *customerrors.CustomError, int)
+	UpdateMessage(chatId, messageId, currentUsername string, req *models.MessageUpdateRequestDTO) (*models.MessageRecordDTO, *customerrors.CustomError, int)
+	DeleteMessage(chatId, messageId, currentUsername string) (*models.MessageRecordDTO, *customerrors.CustomError, int)
+	SetMessageReaction(chatId, messageId, currentUsername string, req *models.MessageReactionRequestDTO) (*models.MessageRecordDTO, *customerrors.CustomError, int)
+	DeleteMessageReaction(chatId, messageId, currentUsername string) (*models.MessageRecordDTO, *customerrors.CustomError, int)
 }
 
+// Types of the requests and events on the chat websocket
+const (
+	ChatEventMessage     = "message"
+	ChatEventRead        = "read"
+	ChatEventTypingStart = "typingStart"
+	ChatEventTypingStop  = "typingStop"
+	ChatEventEdit        = "edit"
+	ChatEventDelete      = "delete"
+	ChatEventReaction    = "reaction"
+)
+
+const maxReactionRunes = 8 // emojis can consist of multiple runes, e.g. with skin tone modifiers or joined emojis
+
+const maxReplayedMessages = 100 // older missed messages have to be loaded with GetMessagesByChatId
+
 type MessageService struct {
 	messageRepo         repositories.MessageRepositoryInterface
 	chatRepo            repositories.ChatRepositoryInterface
 	notificationService NotificationServiceInterface
+	validator           utils.ValidatorInterface
 	policy              *bluemonday.Policy
 }
 
 // NewMessageService can be used as a constructor to create a MessageService "object"
-func NewMessageService(messageRepo repositories.MessageRepositoryInterface, chatRepo repositories.ChatRepositoryInterface, notificationService NotificationServiceInterface) *MessageService {
-	return &MessageService{messageRepo: messageRepo, chatRepo: chatRepo, notificationService: notificationService, policy: bluemonday.UGCPolicy()}
+func NewMessageService(messageRepo repositories.MessageRepositoryInterface, chatRepo repositories.ChatRepositoryInterface, notificationService NotificationServiceInterface, validator utils.ValidatorInterface) *MessageService {
+	return &MessageService{messageRepo: messageRepo, chatRepo: chatRepo, notificationService: notificationService, validator: validator, policy: bluemonday.UGCPolicy()}
 }
 
 // GetChatById retrieves a chat by its chatId and checks if the current user is a participant of the chat
@@ -57,6 +84,30 @@ func (service *MessageService) GetChatById(chatId string, currentUsername string
 	return &chat, nil, http.StatusOK
 }
 
+// GetChatIdsByUsername retrieves the ids of all chats the current user is a participant of
+func (service *MessageService) GetChatIdsByUsername(currentUsername string) ([]string, *customerrors.CustomError, int) {
+	chats, err := service.chatRepo.GetChatsByUsername(currentUsername)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	chatIds := make([]string, 0, len(chats))
+	for _, chat := range chats {
+		chatIds = append(chatIds, chat.Id.String())
+	}
+	return chatIds, nil, http.StatusOK
+}
+
+// GetChatRecordById retrieves a chat of the current user with all participants as DTO, e.g. to send a new chat to the user websockets
+func (service *MessageService) GetChatRecordById(chatId string, currentUsername string) (*models.ChatRecordDTO, *customerrors.CustomError, int) {
+	chat, serviceErr, httpStatus := service.GetChatById(chatId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	return generateChatRecordDTO(chat), nil, http.StatusOK
+}
+
 // GetMessagesByChatId retrieves all messages of a chat by its chatId
 func (service *MessageService) GetMessagesByChatId(chatId, currentUsername string, offset, limit int) (*models.MessagesResponseDTO, *customerrors.CustomError, int) {
 	// Get chat by chatId, also checks if current user is a participant of the chat
@@ -74,12 +125,7 @@ func (service *MessageService) GetMessagesByChatId(chatId, currentUsername strin
 	// Create response DTO
 	records := make([]models.MessageRecordDTO, 0)
 	for _, message := range messages {
-		record := models.MessageRecordDTO{
-			Content:      message.Content,
-			Username:     message.Username,
-			CreationDate: message.CreatedAt,
-		}
-		records = append(records, record)
+		records = append(records, *generateMessageRecordDTO(&message))
 	}
 
 	response := models.MessagesResponseDTO{
@@ -94,17 +140,64 @@ func (service *MessageService) GetMessagesByChatId(chatId, currentUsername strin
 	return &response, nil, http.StatusOK
 }
 
+// GetMessagesSinceSequence retrieves the messages of a chat with a higher sequence number than the given one, oldest first,
+// so that a reconnecting client receives the messages it missed, at most the newest maxReplayedMessages are returned
+func (service *MessageService) GetMessagesSinceSequence(chatId, currentUsername string, sequence int64) ([]models.MessageRecordDTO, *customerrors.CustomError, int) {
+	if sequence < 0 {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	// Get chat by chatId, also checks if current user is a participant of the chat
+	_, serviceErr, httpStatus := service.GetChatById(chatId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	messages, err := service.messageRepo.GetMessagesByChatIdSinceSequence(chatId, sequence, maxReplayedMessages)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Messages are loaded newest first, so they are reversed
+	records := make([]models.MessageRecordDTO, 0, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		records = append(records, *generateMessageRecordDTO(&messages[i]))
+	}
+
+	return records, nil, http.StatusOK
+}
+
 // CreateMessage creates a new message for a given chatId and username
 func (service *MessageService) CreateMessage(chatId, currentUsername string, req *models.MessageCreateRequestDTO, connectedParticipants []string) (*models.MessageRecordDTO, *customerrors.CustomError, int) {
 	// Sanitize message content because it is a free text field
 	req.Content = strings.Trim(req.Content, " ") // remove leading and trailing whitespaces
 	req.Content = service.policy.Sanitize(req.Content)
 
-	// Validate input
-	if len(req.Content) <= 0 || len(req.Content) > 256 {
+	// Validate input, a message needs a content or a picture
+	if (len(req.Content) <= 0 && req.Picture == "") || len(req.Content) > 256 {
 		return nil, customerrors.BadRequest, http.StatusBadRequest
 	}
 
+	var image *models.Image
+	if req.Picture != "" {
+		imageBytes, err := base64.StdEncoding.DecodeString(req.Picture)
+		if err != nil {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+		valid, format, width, height := service.validator.ValidateImage(imageBytes)
+		if !valid {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+		image = &models.Image{
+			Id:        uuid.New(),
+			Format:    format,
+			ImageData: imageBytes,
+			Width:     width,
+			Height:    height,
+			Tag:       time.Now().UTC(),
+		}
+	}
+
 	// Get chat by chatId
 	chat, err := service.chatRepo.GetChatById(chatId)
 	if err != nil {
@@ -134,8 +227,12 @@ func (service *MessageService) CreateMessage(chatId, currentUsername string, req
 		Content:   req.Content,
 		CreatedAt: time.Now(),
 	}
+	if image != nil {
+		message.ImageId = &image.Id
+		message.Image = *image
+	}
 
-	// Save message
+	// Save message (the picture is saved with it)
 	err = service.messageRepo.CreateMessage(&message)
 	if err != nil {
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
@@ -144,18 +241,236 @@ func (service *MessageService) CreateMessage(chatId, currentUsername string, req
 	// Send notifications to other chat participants that have no active websocket connection
 	for _, user := range chat.Users {
 		if user.Username != currentUsername && !contains(connectedParticipants, user.Username) {
-			_ = service.notificationService.CreateNotification("message", user.Username, currentUsername) // ignore creation/sending error for current user
+			_ = service.notificationService.CreateNotification("message", user.Username, currentUsername, nil, nil) // ignore creation/sending error for current user
 		}
 	}
 
-	// Create response DTO
-	response := models.MessageRecordDTO{
+	return generateMessageRecordDTO(&message), nil, http.StatusCreated
+}
+
+// MarkChatAsRead sets the latest message of a chat as last read message of the current user and returns the read receipt,
+// no read receipt is returned if the chat has no messages
+func (service *MessageService) MarkChatAsRead(chatId, currentUsername string) (*models.ChatEventDTO, *customerrors.CustomError, int) {
+	// Get chat by chatId, also checks if current user is a participant of the chat
+	_, serviceErr, httpStatus := service.GetChatById(chatId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	// Get latest message of the chat
+	messages, _, err := service.messageRepo.GetMessagesByChatId(chatId, 0, 1)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	if len(messages) == 0 {
+		return nil, nil, http.StatusNoContent
+	}
+	latestMessage := messages[0]
+
+	err = service.chatRepo.UpdateLastReadMessage(chatId, currentUsername, latestMessage.Id, latestMessage.CreatedAt)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	messageId := latestMessage.Id.String()
+	response := models.ChatEventDTO{
+		Type:      ChatEventRead,
+		Username:  currentUsername,
+		MessageId: &messageId,
+		Timestamp: time.Now(),
+	}
+
+	return &response, nil, http.StatusOK
+}
+
+// UpdateMessage changes the content of a message, only the author can edit a message
+func (service *MessageService) UpdateMessage(chatId, messageId, currentUsername string, req *models.MessageUpdateRequestDTO) (*models.MessageRecordDTO, *customerrors.CustomError, int) {
+	// Sanitize message content because it is a free text field
+	req.Content = strings.Trim(req.Content, " ") // remove leading and trailing whitespaces
+	req.Content = service.policy.Sanitize(req.Content)
+
+	// Validate input
+	if len(req.Content) <= 0 || len(req.Content) > 256 {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	message, serviceErr, httpStatus := service.getOwnMessage(chatId, messageId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	editedAt := time.Now()
+	message.Content = req.Content
+	message.EditedAt = &editedAt
+	if err := service.messageRepo.UpdateMessage(message); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { // the message was deleted in the meantime
+			return nil, customerrors.MessageNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return generateMessageRecordDTO(message), nil, http.StatusOK
+}
+
+// DeleteMessage removes the content and reactions of a message, the message is kept and shown as deleted
+func (service *MessageService) DeleteMessage(chatId, messageId, currentUsername string) (*models.MessageRecordDTO, *customerrors.CustomError, int) {
+	message, serviceErr, httpStatus := service.getOwnMessage(chatId, messageId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	message.Content = ""
+	message.Deleted = true
+	if err := service.messageRepo.SoftDeleteMessage(message); err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// The deleted message is returned to send it to the connections of the chat, the response itself has no content
+	return generateMessageRecordDTO(message), nil, http.StatusNoContent
+}
+
+// SetMessageReaction adds an emoji reaction of the current user to a message or replaces the previous reaction
+func (service *MessageService) SetMessageReaction(chatId, messageId, currentUsername string, req *models.MessageReactionRequestDTO) (*models.MessageRecordDTO, *customerrors.CustomError, int) {
+	if !isValidEmoji(req.Emoji) {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	message, serviceErr, httpStatus := service.getMessage(chatId, messageId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	reaction := models.MessageReaction{
+		MessageId: message.Id,
+		Username:  currentUsername,
+		Emoji:     req.Emoji,
+		CreatedAt: time.Now(),
+	}
+	if err := service.messageRepo.SetMessageReaction(&reaction); err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Replace previous reaction of the user in the response
+	reactions := []models.MessageReaction{reaction}
+	for _, existingReaction := range message.Reactions {
+		if existingReaction.Username != currentUsername {
+			reactions = append(reactions, existingReaction)
+		}
+	}
+	message.Reactions = reactions
+
+	return generateMessageRecordDTO(message), nil, http.StatusOK
+}
+
+// DeleteMessageReaction removes the reaction of the current user from a message
+func (service *MessageService) DeleteMessageReaction(chatId, messageId, currentUsername string) (*models.MessageRecordDTO, *customerrors.CustomError, int) {
+	message, serviceErr, httpStatus := service.getMessage(chatId, messageId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	if err := service.messageRepo.DeleteMessageReaction(messageId, currentUsername); err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	var reactions []models.MessageReaction
+	for _, reaction := range message.Reactions {
+		if reaction.Username != currentUsername {
+			reactions = append(reactions, reaction)
+		}
+	}
+	message.Reactions = reactions
+
+	return generateMessageRecordDTO(message), nil, http.StatusOK
+}
+
+// getMessage retrieves a message that is not deleted from a chat of the current user
+func (service *MessageService) getMessage(chatId, messageId, currentUsername string) (*models.Message, *customerrors.CustomError, int) {
+	// Get chat by chatId, also checks if current user is a participant of the chat
+	_, serviceErr, httpStatus := service.GetChatById(chatId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+
+	message, err := service.messageRepo.GetMessageById(messageId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.MessageNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	if message.ChatId.String() != chatId || message.Deleted {
+		return nil, customerrors.MessageNotFound, http.StatusNotFound
+	}
+
+	return &message, nil, http.StatusOK
+}
+
+// getOwnMessage retrieves a message like getMessage and checks if the current user is its author
+func (service *MessageService) getOwnMessage(chatId, messageId, currentUsername string) (*models.Message, *customerrors.CustomError, int) {
+	message, serviceErr, httpStatus := service.getMessage(chatId, messageId, currentUsername)
+	if serviceErr != nil {
+		return nil, serviceErr, httpStatus
+	}
+	if message.Username != currentUsername {
+		return nil, customerrors.MessageModificationForbidden, http.StatusForbidden
+	}
+
+	return message, nil, http.StatusOK
+}
+
+// generateMessageRecordDTO creates the DTO of a message with its reactions grouped by emoji
+func generateMessageRecordDTO(message *models.Message) *models.MessageRecordDTO {
+	reactions := make([]models.MessageReactionDTO, 0)
+	for _, reaction := range message.Reactions {
+		found := false
+		for i := range reactions {
+			if reactions[i].Emoji == reaction.Emoji {
+				reactions[i].Count++
+				reactions[i].Usernames = append(reactions[i].Usernames, reaction.Username)
+				found = true
+				break
+			}
+		}
+		if !found {
+			reactions = append(reactions, models.MessageReactionDTO{Emoji: reaction.Emoji, Count: 1, Usernames: []string{reaction.Username}})
+		}
+	}
+
+	return &models.MessageRecordDTO{
+		MessageId:    message.Id.String(),
+		Sequence:     message.Sequence,
 		Content:      message.Content,
+		Picture:      utils.GenerateImageMetadataDTOFromImage(&message.Image),
 		Username:     message.Username,
 		CreationDate: message.CreatedAt,
+		EditDate:     message.EditedAt,
+		Deleted:      message.Deleted,
+		Reactions:    reactions,
 	}
+}
 
-	return &response, nil, http.StatusCreated
+// isValidEmoji checks if a reaction consists of a single emoji, including modifiers and joined emojis
+func isValidEmoji(emoji string) bool {
+	runes := []rune(emoji)
+	if len(runes) == 0 || len(runes) > maxReactionRunes {
+		return false
+	}
+
+	hasSymbol := false
+	for _, r := range runes {
+		switch {
+		case unicode.Is(unicode.So, r):
+			hasSymbol = true
+		case r == 0x200D, r == 0x20E3, r >= 0xFE00 && r <= 0xFE0F: // zero width joiner, keycap and variation selectors
+		case r >= 0x1F3FB && r <= 0x1F3FF: // skin tone modifiers
+		case r >= 0xE0020 && r <= 0xE007F: // tags of flags
+		case r == '#' || r == '*' || (r >= '0' && r <= '9'): // base of keycap emojis
+		default:
+			return false
+		}
+	}
+	return hasSymbol || strings.ContainsRune(emoji, 0x20E3)
 }
 
 // contains checks if a slice contains a specific string
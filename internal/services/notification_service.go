@@ -9,86 +9,242 @@ import (
 	"github.com/wwi21seb-projekt/server-beta/internal/utils"
 	"gorm.io/gorm"
 	"net/http"
+	"sync"
 	"time"
 )
 
 type NotificationServiceInterface interface {
-	CreateNotification(notificationType string, forUsername string, fromUsername string) error
-	GetNotifications(username string) (*models.NotificationsResponseDTO, *customerrors.CustomError, int)
+	CreateNotification(notificationType string, forUsername string, fromUsername string, postId *uuid.UUID, commentId *uuid.UUID) error
+	RemoveLikeNotification(forUsername string, postId *uuid.UUID) error
+	GetNotifications(username string, lastNotificationId string, limit int) (*models.NotificationsResponseDTO, *customerrors.CustomError, int)
+	GetUnreadNotificationCount(username string) (*models.NotificationUnreadCountResponseDTO, *customerrors.CustomError, int)
+	MarkNotificationAsRead(notificationId string, currentUsername string) (*customerrors.CustomError, int)
+	MarkAllNotificationsAsRead(currentUsername string) (*customerrors.CustomError, int)
 	DeleteNotificationById(notificationId string, currentUsername string) (*customerrors.CustomError, int)
+	GetNotificationSettings(currentUsername string) (*models.NotificationSettingsResponseDTO, *customerrors.CustomError, int)
+	UpdateNotificationSettings(req *models.NotificationSettingsUpdateRequestDTO, currentUsername string) (*models.NotificationSettingsResponseDTO, *customerrors.CustomError, int)
+	SubscribeToNotifications(username string) (<-chan models.NotificationRecordDTO, func())
 }
 
+// notificationTypes are all types of notifications that can be turned on or off in the notification settings
+var notificationTypes = []string{"follow", "repost", "message", "like", "comment", "mention"}
+
 type NotificationService struct {
-	notificationRepository  repositories.NotificationRepositoryInterface
-	PushSubscriptionService PushSubscriptionServiceInterface
+	notificationRepository        repositories.NotificationRepositoryInterface
+	PushSubscriptionService       PushSubscriptionServiceInterface
+	notificationSettingRepository repositories.NotificationSettingRepositoryInterface
+	userRepository                repositories.UserRepositoryInterface
+
+	// Live notification streams:
+	subscribers     map[string][]chan models.NotificationRecordDTO // username -> one channel for each open stream of the user
+	subscribersLock sync.RWMutex
 }
 
 // NewNotificationService can be used as a constructor to create a NotificationService "object"
 func NewNotificationService(
 	notificationRepository repositories.NotificationRepositoryInterface,
-	puhSubscriptionService PushSubscriptionServiceInterface) *NotificationService {
-	return &NotificationService{notificationRepository: notificationRepository, PushSubscriptionService: puhSubscriptionService}
+	puhSubscriptionService PushSubscriptionServiceInterface,
+	notificationSettingRepository repositories.NotificationSettingRepositoryInterface,
+	userRepository repositories.UserRepositoryInterface) *NotificationService {
+	return &NotificationService{
+		notificationRepository:        notificationRepository,
+		PushSubscriptionService:       puhSubscriptionService,
+		notificationSettingRepository: notificationSettingRepository,
+		userRepository:                userRepository,
+		subscribers:                   make(map[string][]chan models.NotificationRecordDTO),
+	}
 }
 
-// CreateNotification is a service function that creates a notification and pushes it to client if push service is registered
-func (service *NotificationService) CreateNotification(notificationType string, forUsername string, fromUsername string) error {
+// CreateNotification is a service function that creates a notification and pushes it to client if push service is registered,
+// post and comment id are optional references to the post or comment the notification is about
+// The notification settings of the receiving user decide whether the notification is stored and/or pushed
+func (service *NotificationService) CreateNotification(notificationType string, forUsername string, fromUsername string, postId *uuid.UUID, commentId *uuid.UUID) error {
 	if forUsername == fromUsername { // do not create notification if user is the same
 		return nil
 	}
 
-	// Create notification and save to database
+	// Do not create notification if the receiving user muted the other user
+	_, err := service.notificationSettingRepository.FindNotificationMute(forUsername, fromUsername)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// Check if the notification type is turned on, all types are turned on by default
+	inApp, push := true, true
+	setting, err := service.notificationSettingRepository.FindNotificationSetting(forUsername, notificationType)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil {
+		inApp, push = setting.InApp, setting.Push
+	}
+
+	var notificationDto models.NotificationRecordDTO
+	if inApp {
+		notificationId, err := service.saveNotification(notificationType, forUsername, fromUsername, postId, commentId)
+		if err != nil {
+			return err
+		}
+
+		// Get just created or updated notification from database to get user metadata
+		createdNotification, err := service.notificationRepository.GetNotificationById(notificationId.String())
+		if err != nil {
+			return err
+		}
+		notificationDto = createNotificationRecordDto(&createdNotification)
+
+		// Deliver stored notification to all open streams of the user
+		service.publishNotification(&notificationDto, forUsername)
+	} else if push {
+		// Notification is only pushed, so it is not stored and has no id
+		fromUser, err := service.userRepository.FindUserByUsername(fromUsername)
+		if err != nil {
+			return err
+		}
+		notificationDto = models.NotificationRecordDTO{
+			Timestamp:        time.Now(),
+			NotificationType: notificationType,
+			User:             utils.GenerateUserDTOFromUser(fromUser),
+			PostId:           postId,
+			CommentId:        commentId,
+			Count:            1,
+		}
+	}
+
+	// Send push message to client if push service is registered
+	if push {
+		service.PushSubscriptionService.SendPushMessages(&notificationDto, forUsername) // send push message in background
+	}
+
+	return nil
+}
+
+// saveNotification saves a new notification to the database, repeated likes on the same post are grouped into the existing notification
+func (service *NotificationService) saveNotification(notificationType string, forUsername string, fromUsername string, postId *uuid.UUID, commentId *uuid.UUID) (uuid.UUID, error) {
+	if notificationType == "like" && postId != nil {
+		existingNotification, err := service.notificationRepository.GetNotificationByPostId(notificationType, forUsername, postId.String())
+		if err == nil {
+			// Latest user and time are shown, the count tells how many likes were grouped
+			updated, err := service.notificationRepository.IncrementNotificationCount(existingNotification.Id.String(), fromUsername, time.Now())
+			if err != nil {
+				return uuid.Nil, err
+			}
+			if updated > 0 {
+				return existingNotification.Id, nil
+			}
+			// The last like of the notification was taken back in the meantime, so a new notification is created
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, err
+		}
+	}
+
 	newNotification := models.Notification{
 		Id:               uuid.New(),
 		NotificationType: notificationType,
 		Timestamp:        time.Now(),
 		ForUsername:      forUsername,
 		FromUsername:     fromUsername,
+		PostId:           postId,
+		CommentId:        commentId,
+		Count:            1,
 	}
-	err := service.notificationRepository.CreateNotification(&newNotification)
+	return newNotification.Id, service.notificationRepository.CreateNotification(&newNotification)
+}
 
-	// Get just created notification from database to get user metadata
-	createdNotification, err := service.notificationRepository.GetNotificationById(newNotification.Id.String())
+// RemoveLikeNotification takes back one like from the grouped like notification of a post after a like was deleted,
+// the notification is deleted once no like is left
+func (service *NotificationService) RemoveLikeNotification(forUsername string, postId *uuid.UUID) error {
+	existingNotification, err := service.notificationRepository.GetNotificationByPostId("like", forUsername, postId.String())
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // like was not stored as notification, e.g. because likes are muted
+		}
 		return err
 	}
 
-	// Send push message to client if push service is registered
-	notificationDto := models.NotificationRecordDTO{
-		NotificationId:   createdNotification.Id.String(),
-		Timestamp:        createdNotification.Timestamp,
-		NotificationType: createdNotification.NotificationType,
-		User:             utils.GenerateUserDTOFromUser(&createdNotification.FromUser),
+	return service.notificationRepository.DecrementNotificationCount(existingNotification.Id.String())
+}
+
+// GetNotifications is a service function that gets the notifications for the current user using cursor pagination, newest first
+func (service *NotificationService) GetNotifications(username string, lastNotificationId string, limit int) (*models.NotificationsResponseDTO, *customerrors.CustomError, int) {
+	// Get last notification if lastNotificationId is not empty
+	var lastNotification models.Notification
+	if lastNotificationId != "" {
+		notification, err := service.notificationRepository.GetNotificationById(lastNotificationId)
+		if err != nil {
+
+			// If notification is not found, return empty list with number of records
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				_, totalNotificationsCount, err := service.notificationRepository.GetNotificationsByUsername(username, &lastNotification, limit)
+				if err != nil {
+					return nil, customerrors.DatabaseError, http.StatusInternalServerError
+				}
+				return createNotificationsResponseDto([]models.Notification{}, totalNotificationsCount, limit), nil, http.StatusOK
+			}
+
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+
+		// Notifications of other users must not be used as cursor, because their timestamp would be leaked
+		if notification.ForUsername != username {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+
+		lastNotification = notification
 	}
-	service.PushSubscriptionService.SendPushMessages(&notificationDto, forUsername) // send push message in background
 
-	return err
+	// Retrieve notifications from database
+	notifications, totalNotificationsCount, err := service.notificationRepository.GetNotificationsByUsername(username, &lastNotification, limit)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return createNotificationsResponseDto(notifications, totalNotificationsCount, limit), nil, http.StatusOK
 }
 
-// GetNotifications is a service function that gets all notifications for the current user
-func (service *NotificationService) GetNotifications(username string) (*models.NotificationsResponseDTO, *customerrors.CustomError, int) {
-	// Retrieve notifications from database
-	notifications, err := service.notificationRepository.GetNotificationsByUsername(username)
+// GetUnreadNotificationCount is a service function that returns the number of unread notifications of the current user, e.g. for badge counters
+func (service *NotificationService) GetUnreadNotificationCount(username string) (*models.NotificationUnreadCountResponseDTO, *customerrors.CustomError, int) {
+	count, err := service.notificationRepository.CountUnreadNotifications(username)
 	if err != nil {
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
-	// Create response dto
-	notificationResponseDTOs := make([]models.NotificationRecordDTO, 0)
-	for _, notification := range notifications {
-		notificationResponseDTO := models.NotificationRecordDTO{
-			NotificationId:   notification.Id.String(),
-			Timestamp:        notification.Timestamp,
-			NotificationType: notification.NotificationType,
-			User:             utils.GenerateUserDTOFromUser(&notification.FromUser),
+	return &models.NotificationUnreadCountResponseDTO{UnreadCount: count}, nil, http.StatusOK
+}
+
+// MarkNotificationAsRead is a service function that marks a notification of the current user as read
+func (service *NotificationService) MarkNotificationAsRead(notificationId string, currentUsername string) (*customerrors.CustomError, int) {
+	notification, err := service.notificationRepository.GetNotificationById(notificationId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customerrors.NotificationNotFound, http.StatusNotFound
 		}
-		notificationResponseDTOs = append(notificationResponseDTOs, notificationResponseDTO)
+		return customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
-	responseDto := models.NotificationsResponseDTO{
-		Records: notificationResponseDTOs,
+	if notification.ForUsername != currentUsername {
+		return customerrors.UpdateNotificationForbidden, http.StatusForbidden
+	}
+
+	err = service.notificationRepository.MarkNotificationAsRead(notificationId)
+	if err != nil {
+		return customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
-	return &responseDto, nil, http.StatusOK
+	return nil, http.StatusNoContent
+}
+
+// MarkAllNotificationsAsRead is a service function that marks all notifications of the current user as read
+func (service *NotificationService) MarkAllNotificationsAsRead(currentUsername string) (*customerrors.CustomError, int) {
+	err := service.notificationRepository.MarkAllNotificationsAsRead(currentUsername)
+	if err != nil {
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return nil, http.StatusNoContent
 }
 
 // DeleteNotificationById is a service function that deletes a notification by its id
@@ -112,3 +268,197 @@ func (service *NotificationService) DeleteNotificationById(notificationId string
 
 	return nil, http.StatusNoContent
 }
+
+// SubscribeToNotifications is a service function that returns a channel receiving every notification stored for the user from now on,
+// the returned function has to be called to close the subscription
+func (service *NotificationService) SubscribeToNotifications(username string) (<-chan models.NotificationRecordDTO, func()) {
+	channel := make(chan models.NotificationRecordDTO, 16)
+
+	service.subscribersLock.Lock()
+	service.subscribers[username] = append(service.subscribers[username], channel)
+	service.subscribersLock.Unlock()
+
+	unsubscribe := func() {
+		service.subscribersLock.Lock()
+		defer service.subscribersLock.Unlock()
+
+		channels := service.subscribers[username]
+		for i, c := range channels {
+			if c == channel {
+				service.subscribers[username] = append(channels[:i], channels[i+1:]...)
+				close(channel)
+				break
+			}
+		}
+		// Delete username from subscribers if user has no other streams left
+		if len(service.subscribers[username]) == 0 {
+			delete(service.subscribers, username)
+		}
+	}
+
+	return channel, unsubscribe
+}
+
+// publishNotification sends a notification to all open streams of a user without blocking,
+// the notification is dropped for streams that do not keep up
+func (service *NotificationService) publishNotification(notificationDto *models.NotificationRecordDTO, username string) {
+	service.subscribersLock.RLock()
+	defer service.subscribersLock.RUnlock()
+
+	for _, channel := range service.subscribers[username] {
+		select {
+		case channel <- *notificationDto:
+		default:
+		}
+	}
+}
+
+// GetNotificationSettings is a service function that returns the notification settings of all types and the muted users of the current user
+func (service *NotificationService) GetNotificationSettings(currentUsername string) (*models.NotificationSettingsResponseDTO, *customerrors.CustomError, int) {
+	settings, err := service.notificationSettingRepository.GetNotificationSettingsByUsername(currentUsername)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	mutes, err := service.notificationSettingRepository.GetNotificationMutesByUsername(currentUsername)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	mutedUsers := make([]models.User, 0)
+	for _, mute := range mutes {
+		mutedUsers = append(mutedUsers, mute.MutedUser)
+	}
+
+	return createNotificationSettingsResponseDto(settings, mutedUsers), nil, http.StatusOK
+}
+
+// UpdateNotificationSettings is a service function that replaces the notification settings and muted users of the current user
+func (service *NotificationService) UpdateNotificationSettings(req *models.NotificationSettingsUpdateRequestDTO, currentUsername string) (*models.NotificationSettingsResponseDTO, *customerrors.CustomError, int) {
+	// Validate notification types, each type may only be given once
+	settings := make([]models.NotificationSetting, 0)
+	for _, settingDto := range req.Settings {
+		if !contains(notificationTypes, settingDto.NotificationType) {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+		for _, setting := range settings {
+			if setting.NotificationType == settingDto.NotificationType {
+				return nil, customerrors.BadRequest, http.StatusBadRequest
+			}
+		}
+		settings = append(settings, models.NotificationSetting{
+			Id:               uuid.New(),
+			Username:         currentUsername,
+			NotificationType: settingDto.NotificationType,
+			InApp:            settingDto.InApp,
+			Push:             settingDto.Push,
+		})
+	}
+
+	// Muted users must exist and users cannot mute themselves, a user that is given more than once is muted once
+	mutedUsernames := make([]string, 0)
+	for _, username := range req.MutedUsernames {
+		if !contains(mutedUsernames, username) {
+			mutedUsernames = append(mutedUsernames, username)
+		}
+	}
+	mutedUsers := make([]models.User, 0)
+	if len(mutedUsernames) > 0 {
+		if contains(mutedUsernames, currentUsername) {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+		users, err := service.userRepository.FindUsersByUsernames(mutedUsernames)
+		if err != nil {
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+		for _, username := range mutedUsernames {
+			found := false
+			for _, user := range users {
+				if user.Username == username {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, customerrors.UserNotFound, http.StatusNotFound
+			}
+		}
+		mutedUsers = users
+	}
+
+	mutes := make([]models.NotificationMute, 0)
+	for _, user := range mutedUsers {
+		mutes = append(mutes, models.NotificationMute{
+			Id:            uuid.New(),
+			Username:      currentUsername,
+			MutedUsername: user.Username,
+			CreatedAt:     time.Now(),
+		})
+	}
+
+	err := service.notificationSettingRepository.ReplaceNotificationSettings(currentUsername, settings, mutes)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return createNotificationSettingsResponseDto(settings, mutedUsers), nil, http.StatusOK
+}
+
+// createNotificationSettingsResponseDto creates the settings response with an entry for every notification type, missing settings are turned on
+func createNotificationSettingsResponseDto(settings []models.NotificationSetting, mutedUsers []models.User) *models.NotificationSettingsResponseDTO {
+	settingDtos := make([]models.NotificationTypeSettingDTO, 0)
+	for _, notificationType := range notificationTypes {
+		settingDto := models.NotificationTypeSettingDTO{
+			NotificationType: notificationType,
+			InApp:            true,
+			Push:             true,
+		}
+		for _, setting := range settings {
+			if setting.NotificationType == notificationType {
+				settingDto.InApp = setting.InApp
+				settingDto.Push = setting.Push
+			}
+		}
+		settingDtos = append(settingDtos, settingDto)
+	}
+
+	return &models.NotificationSettingsResponseDTO{
+		Settings:   settingDtos,
+		MutedUsers: utils.GenerateUserDTOsFromUsers(mutedUsers),
+	}
+}
+
+// createNotificationRecordDto creates the response dto of a notification including the user that triggered it
+func createNotificationRecordDto(notification *models.Notification) models.NotificationRecordDTO {
+	return models.NotificationRecordDTO{
+		NotificationId:   notification.Id.String(),
+		Timestamp:        notification.Timestamp,
+		NotificationType: notification.NotificationType,
+		User:             utils.GenerateUserDTOFromUser(&notification.FromUser),
+		PostId:           notification.PostId,
+		CommentId:        notification.CommentId,
+		Count:            notification.Count,
+		Read:             notification.Read,
+	}
+}
+
+// createNotificationsResponseDto creates the paginated response dto for a list of notifications
+func createNotificationsResponseDto(notifications []models.Notification, totalNotificationsCount int64, limit int) *models.NotificationsResponseDTO {
+	lastNotificationId := ""
+	if len(notifications) > 0 {
+		lastNotificationId = notifications[len(notifications)-1].Id.String()
+	}
+
+	notificationResponseDTOs := make([]models.NotificationRecordDTO, 0)
+	for _, notification := range notifications {
+		notificationResponseDTOs = append(notificationResponseDTOs, createNotificationRecordDto(&notification))
+	}
+
+	return &models.NotificationsResponseDTO{
+		Records: notificationResponseDTOs,
+		Pagination: &models.NotificationCursorPaginationDTO{
+			LastNotificationId: lastNotificationId,
+			Limit:              limit,
+			Records:            totalNotificationsCount,
+		},
+	}
+}
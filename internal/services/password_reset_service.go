@@ -71,8 +71,8 @@ func (service *PasswordResetService) InitiatePasswordReset(username string) (*mo
 	}
 
 	// Send email with token
-	subject := "Reset your password"
-	body := utils.GetPasswordResetEmailBody(username, resetToken.Token)
+	subject := utils.Translate(user.Locale, "email.passwordReset.subject")
+	body := utils.GetPasswordResetEmailBody(user.Locale, username, resetToken.Token)
 	err = service.mailService.SendMail(user.Email, subject, body)
 	if err != nil {
 		return nil, customerrors.EmailNotSent, http.StatusInternalServerError
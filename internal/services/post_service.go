@@ -18,6 +18,7 @@ import (
 
 type PostServiceInterface interface {
 	CreatePost(req *models.PostCreateRequestDTO, username string) (*models.PostResponseDTO, *customerrors.CustomError, int)
+	UpdatePost(req *models.PostUpdateRequestDTO, postId string, username string) (*models.PostResponseDTO, *customerrors.CustomError, int)
 	DeletePost(postId string, username string) (*customerrors.CustomError, int)
 }
 
@@ -69,21 +70,7 @@ func (service *PostService) CreatePost(req *models.PostCreateRequestDTO, usernam
 		}
 
 		// Get like and comments information of repost
-		var repostLikeCount int64 = 0
-		var repostLikedByCurrentUser = false
-		var repostCommentsCount int64 = 0
-		_, err = service.likeRepo.FindLike(repost.Id.String(), username)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, customerrors.DatabaseError, http.StatusInternalServerError
-		}
-		if err == nil {
-			repostLikedByCurrentUser = true
-		}
-		repostLikeCount, err = service.likeRepo.CountLikes(repost.Id.String())
-		if err != nil {
-			return nil, customerrors.DatabaseError, http.StatusInternalServerError
-		}
-		repostCommentsCount, err = service.commentRepo.CountComments(repost.Id.String())
+		repostLikedByCurrentUser, repostLikeCount, repostCommentsCount, err := service.getLikeAndCommentInformation(repost.Id.String(), username)
 		if err != nil {
 			return nil, customerrors.DatabaseError, http.StatusInternalServerError
 		}
@@ -165,12 +152,19 @@ func (service *PostService) CreatePost(req *models.PostCreateRequestDTO, usernam
 		hashtags = append(hashtags, hashtag)
 	}
 
+	// Find mentioned users, mentions of non-existing users are ignored
+	mentions, err := findMentionedUsers(service.userRepo, req.Content)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
 	// Create post
 	post := models.Post{
 		Id:        uuid.New(),
 		Username:  username,
 		Content:   req.Content,
 		Hashtags:  hashtags,
+		Mentions:  mentions,
 		CreatedAt: time.Now(),
 		RepostId:  repostId,
 	}
@@ -195,10 +189,14 @@ func (service *PostService) CreatePost(req *models.PostCreateRequestDTO, usernam
 	// Create response dto and return
 	postDto := createPostResponseFromPostObject(&post, user, location, image, repostDto, 0, 0, false) // no likes and comments yet
 
-	// Create notification for owner of original post
+	// Create notification for owner of original post, it refers to the original post that was reposted
 	if repostId != nil {
-		_ = service.notificationService.CreateNotification("repost", repostDto.Author.Username, username)
+		_ = service.notificationService.CreateNotification("repost", repostDto.Author.Username, username, repostId, nil)
 	}
+
+	// Create notifications for mentioned users
+	notifyMentionedUsers(service.notificationService, nil, post.Mentions, username, &post.Id, nil)
+
 	return postDto, nil, http.StatusCreated
 }
 
@@ -241,10 +239,174 @@ func createPostResponseFromPostObject(
 		Liked:        likedByCurrentUser,
 		Location:     locationDto,
 		Repost:       repostDto,
+		EditedAt:     post.EditedAt,
+		Mentions:     utils.GenerateUserDTOsFromUsers(post.Mentions),
 	}
 	return &postDto
 }
 
+// findMentionedUsers returns the existing users that are mentioned with @username in the given text
+func findMentionedUsers(userRepo repositories.UserRepositoryInterface, text string) ([]models.User, error) {
+	usernames := utils.ExtractMentions(text)
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	return userRepo.FindUsersByUsernames(usernames)
+}
+
+// notifyMentionedUsers creates a mention notification for each mentioned user that was not already mentioned before, e.g. before an edit
+func notifyMentionedUsers(notificationService NotificationServiceInterface, previousMentions, mentions []models.User, fromUsername string, postId *uuid.UUID, commentId *uuid.UUID) {
+	alreadyMentioned := make(map[string]bool)
+	for _, user := range previousMentions {
+		alreadyMentioned[user.Username] = true
+	}
+
+	for _, user := range mentions {
+		if !alreadyMentioned[user.Username] {
+			_ = notificationService.CreateNotification("mention", user.Username, fromUsername, postId, commentId) // ignore creation/sending error for current user
+		}
+	}
+}
+
+// UpdatePost changes content and/or location of a post, keeps the previous version as revision and returns the updated post
+func (service *PostService) UpdatePost(req *models.PostUpdateRequestDTO, postId string, username string) (*models.PostResponseDTO, *customerrors.CustomError, int) {
+	if req.Content == nil && req.Location == nil {
+		return nil, customerrors.BadRequest, http.StatusBadRequest // nothing to update
+	}
+
+	// Find post by ID
+	post, err := service.postRepo.GetPostById(postId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.PostNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Check if the requesting user is the author of the post
+	if post.Username != username {
+		return nil, customerrors.UpdatePostForbidden, http.StatusForbidden
+	}
+
+	// Keep previous version as revision before changing the post
+	currentTime := time.Now()
+	revision := models.PostRevision{
+		Id:         uuid.New(),
+		PostId:     post.Id,
+		Content:    post.Content,
+		LocationId: post.LocationId,
+		CreatedAt:  currentTime,
+	}
+
+	if req.Content != nil {
+		// Sanitize content the same way as on creation
+		content := strings.Trim(*req.Content, " ")
+		content = service.policy.Sanitize(content)
+
+		// Validations: 0-256 characters and utf8 characters
+		if len(content) > 256 {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+		if len(content) <= 0 && post.ImageId == nil && post.RepostId == nil { // either content, repostId or image is required
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+		if !utf8.ValidString(content) {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+		post.Content = content
+	}
+
+	if req.Location != nil {
+		if !service.validator.ValidateLongitude(*req.Location.Longitude) || !service.validator.ValidateLatitude(*req.Location.Latitude) {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+
+		location := models.Location{
+			Id:        uuid.New(),
+			Longitude: *req.Location.Longitude,
+			Latitude:  *req.Location.Latitude,
+			Accuracy:  *req.Location.Accuracy,
+		}
+		post.LocationId = &location.Id
+		post.Location = location
+	}
+
+	// Extract hashtags again because the content may have changed
+	hashtagNames := utils.ExtractHashtags(post.Content)
+	var hashtags []models.Hashtag
+	for _, name := range hashtagNames {
+		hashtag, err := service.hashtagRepo.FindOrCreateHashtag(name)
+		if err != nil {
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+		hashtags = append(hashtags, hashtag)
+	}
+	post.Hashtags = hashtags
+
+	// Find mentioned users again, only newly mentioned users are notified
+	previousMentions := post.Mentions
+	post.Mentions, err = findMentionedUsers(service.userRepo, post.Content)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	post.EditedAt = &currentTime
+
+	// Save changes to database
+	err = service.postRepo.UpdatePost(&post, &revision)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	notifyMentionedUsers(service.notificationService, previousMentions, post.Mentions, username, &post.Id, nil)
+
+	// Get repost if post is a repost
+	var repostDto *models.PostResponseDTO
+	if post.RepostId != nil {
+		repost, err := service.postRepo.GetPostById(post.RepostId.String())
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+		if err != nil {
+			repostDto = &models.PostResponseDTO{PostId: *post.RepostId} // original post may have been deleted
+		} else {
+			repostLikedByCurrentUser, repostLikeCount, repostCommentsCount, err := service.getLikeAndCommentInformation(repost.Id.String(), username)
+			if err != nil {
+				return nil, customerrors.DatabaseError, http.StatusInternalServerError
+			}
+			repostDto = createPostResponseFromPostObject(&repost, &repost.User, &repost.Location, &repost.Image, nil, repostCommentsCount, repostLikeCount, repostLikedByCurrentUser)
+		}
+	}
+
+	// Create response dto and return
+	likedByCurrentUser, likeCount, commentsCount, err := service.getLikeAndCommentInformation(post.Id.String(), username)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	postDto := createPostResponseFromPostObject(&post, &post.User, &post.Location, &post.Image, repostDto, commentsCount, likeCount, likedByCurrentUser)
+
+	return postDto, nil, http.StatusOK
+}
+
+// getLikeAndCommentInformation returns whether the post is liked by the user, the like count and the comment count
+func (service *PostService) getLikeAndCommentInformation(postId string, username string) (bool, int64, int64, error) {
+	likedByCurrentUser := false
+	_, err := service.likeRepo.FindLike(postId, username)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, 0, 0, err
+	}
+	if err == nil {
+		likedByCurrentUser = true
+	}
+	likeCount, err := service.likeRepo.CountLikes(postId)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	commentsCount, err := service.commentRepo.CountComments(postId)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	return likedByCurrentUser, likeCount, commentsCount, nil
+}
+
 // DeletePost deletes a post by id and returns an error if the post does not exist or the requesting user is not the author
 func (service *PostService) DeletePost(postId string, username string) (*customerrors.CustomError, int) {
 	// Find post by ID
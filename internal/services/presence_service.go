@@ -0,0 +1,140 @@
+package services
+
+import (
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
+	"time"
+)
+
+// Values of the presence visibility of a user
+const (
+	PresenceVisibilityEveryone = "everyone"
+	PresenceVisibilityContacts = "contacts" // only users that the user follows see the presence
+)
+
+type PresenceServiceInterface interface {
+	UpdateLastSeen(username string) error
+	GetPresences(users []models.User, currentUsername string) (map[string]models.UserPresenceDTO, error)
+	GetVisibleUsernames(usernames []string, currentUsername string) ([]string, error)
+	IsPresencePublic(username string) (bool, error)
+}
+
+// PresenceService combines the websocket connections of the chat broker with the last-seen timestamps and privacy settings of the users
+type PresenceService struct {
+	userRepo         repositories.UserRepositoryInterface
+	subscriptionRepo repositories.SubscriptionRepositoryInterface
+	chatBroker       ChatBroker
+}
+
+// NewPresenceService can be used as a constructor to create a PresenceService "object"
+func NewPresenceService(
+	userRepo repositories.UserRepositoryInterface,
+	subscriptionRepo repositories.SubscriptionRepositoryInterface,
+	chatBroker ChatBroker) *PresenceService {
+	return &PresenceService{userRepo: userRepo, subscriptionRepo: subscriptionRepo, chatBroker: chatBroker}
+}
+
+// UpdateLastSeen sets the last-seen timestamp of a user to the current time
+func (service *PresenceService) UpdateLastSeen(username string) error {
+	return service.userRepo.UpdateLastSeen(username, time.Now())
+}
+
+// GetPresences returns the presence of the given users as seen by the current user,
+// users that hide their presence from the current user are returned as offline without last-seen timestamp
+func (service *PresenceService) GetPresences(users []models.User, currentUsername string) (map[string]models.UserPresenceDTO, error) {
+	visible, err := service.getVisibleUsers(users, currentUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	presences := make(map[string]models.UserPresenceDTO)
+	var visibleUsernames []string
+	for _, user := range users {
+		if visible[user.Username] {
+			presences[user.Username] = models.UserPresenceDTO{LastSeen: user.LastSeenAt}
+			visibleUsernames = append(visibleUsernames, user.Username)
+		} else {
+			presences[user.Username] = models.UserPresenceDTO{}
+		}
+	}
+
+	onlineUsernames, err := service.chatBroker.OnlineUsers(visibleUsernames)
+	if err != nil {
+		return nil, err
+	}
+	for _, username := range onlineUsernames {
+		presence := presences[username]
+		presence.Online = true
+		presences[username] = presence
+	}
+
+	return presences, nil
+}
+
+// GetVisibleUsernames returns the given usernames whose presence the current user is allowed to see
+func (service *PresenceService) GetVisibleUsernames(usernames []string, currentUsername string) ([]string, error) {
+	visibleUsernames := make([]string, 0, len(usernames))
+	var otherUsernames []string
+	for _, username := range usernames {
+		if username == currentUsername {
+			visibleUsernames = append(visibleUsernames, username)
+		} else {
+			otherUsernames = append(otherUsernames, username)
+		}
+	}
+	if len(otherUsernames) == 0 {
+		return visibleUsernames, nil
+	}
+
+	users, err := service.userRepo.FindUsersByUsernames(otherUsernames)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := service.getVisibleUsers(users, currentUsername)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if visible[user.Username] {
+			visibleUsernames = append(visibleUsernames, user.Username)
+		}
+	}
+
+	return visibleUsernames, nil
+}
+
+// IsPresencePublic checks whether everyone is allowed to see the presence of a user
+func (service *PresenceService) IsPresencePublic(username string) (bool, error) {
+	user, err := service.userRepo.FindUserByUsername(username)
+	if err != nil {
+		return false, err
+	}
+	return user.PresenceVisibility != PresenceVisibilityContacts, nil
+}
+
+// getVisibleUsers returns the usernames of the given users whose presence the current user is allowed to see,
+// users with the visibility "contacts" only show it to themselves and to the users they follow
+// The subscriptions of all these users are loaded in a single query
+func (service *PresenceService) getVisibleUsers(users []models.User, currentUsername string) (map[string]bool, error) {
+	visible := make(map[string]bool)
+	var contactsUsernames []string
+	for _, user := range users {
+		if user.PresenceVisibility != PresenceVisibilityContacts || user.Username == currentUsername {
+			visible[user.Username] = true
+		} else {
+			contactsUsernames = append(contactsUsernames, user.Username)
+		}
+	}
+	if len(contactsUsernames) == 0 {
+		return visible, nil
+	}
+
+	followerUsernames, err := service.subscriptionRepo.GetFollowerUsernames(currentUsername, contactsUsernames)
+	if err != nil {
+		return nil, err
+	}
+	for _, username := range followerUsernames {
+		visible[username] = true
+	}
+	return visible, nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SherClockHolmes/webpush-go"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrPushSubscriptionGone is returned by push providers if the subscription is expired or the device is not registered anymore
+var ErrPushSubscriptionGone = errors.New("push subscription is expired or not registered anymore")
+
+const defaultExpoPushUrl = "https://exp.host/--/api/v2/push/send"
+
+// PushMessage is the content of a push notification that is sent to a push subscription
+type PushMessage struct {
+	Title string
+	Body  string // notification text, not shown for web push notifications
+	Data  string // notification as json string
+}
+
+// PushProvider sends push messages to the subscriptions of one type, e.g. "web" or "expo"
+type PushProvider interface {
+	// Send returns the status code of the push service, 0 if no response was received,
+	// and whether a failed attempt should be retried
+	Send(pushSubscription *models.PushSubscription, message *PushMessage) (int, bool, error)
+}
+
+// NewPushProvidersFromEnv creates the push providers for all subscription types that are configured in the environment,
+// web and expo push notifications are always available
+func NewPushProvidersFromEnv() map[string]PushProvider {
+	expoUrl := os.Getenv("EXPO_PUSH_URL")
+	if expoUrl == "" {
+		expoUrl = defaultExpoPushUrl
+	}
+
+	providers := map[string]PushProvider{
+		"web":  NewWebPushProvider(os.Getenv("VAPID_PRIVATE_KEY"), os.Getenv("VAPID_PUBLIC_KEY"), os.Getenv("EMAIL_ADDRESS")),
+		"expo": NewExpoPushProvider(expoUrl),
+	}
+
+	if os.Getenv("FCM_PROJECT_ID") != "" {
+		fcmProvider, err := NewFcmPushProviderFromFile(os.Getenv("FCM_PROJECT_ID"), os.Getenv("FCM_CREDENTIALS_FILE"), os.Getenv("FCM_URL"))
+		if err != nil {
+			fmt.Println("Error loading FCM credentials:", err)
+		} else {
+			providers["fcm"] = fcmProvider
+		}
+	}
+
+	if os.Getenv("APNS_KEY_ID") != "" {
+		apnsProvider, err := NewApnsPushProviderFromFile(os.Getenv("APNS_KEY_FILE"), os.Getenv("APNS_KEY_ID"), os.Getenv("APNS_TEAM_ID"), os.Getenv("APNS_TOPIC"), os.Getenv("APNS_URL"))
+		if err != nil {
+			fmt.Println("Error loading APNs key:", err)
+		} else {
+			providers["apns"] = apnsProvider
+		}
+	}
+
+	return providers
+}
+
+// isRetryableStatusCode returns true for responses of push services that indicate a temporary problem
+func isRetryableStatusCode(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+type WebPushProvider struct {
+	vapidPrivateKey string
+	vapidPublicKey  string
+	subscriber      string
+}
+
+// NewWebPushProvider can be used as a constructor to create a WebPushProvider "object"
+func NewWebPushProvider(vapidPrivateKey string, vapidPublicKey string, subscriber string) *WebPushProvider {
+	return &WebPushProvider{vapidPrivateKey: vapidPrivateKey, vapidPublicKey: vapidPublicKey, subscriber: subscriber}
+}
+
+// Send sends a push notification to a web client using the webpush-go library and the keys of the subscription
+func (provider *WebPushProvider) Send(pushSubscription *models.PushSubscription, message *PushMessage) (int, bool, error) {
+	sub := &webpush.Subscription{
+		Endpoint: pushSubscription.Endpoint,
+		Keys: webpush.Keys{
+			P256dh: pushSubscription.P256dh,
+			Auth:   pushSubscription.Auth,
+		},
+	}
+
+	resp, err := webpush.SendNotification([]byte(message.Data), sub, &webpush.Options{
+		Subscriber:      provider.subscriber,
+		VAPIDPublicKey:  provider.vapidPublicKey,
+		VAPIDPrivateKey: provider.vapidPrivateKey,
+		TTL:             30,
+	})
+	if err != nil {
+		return 0, true, err // network errors are temporary
+	}
+	defer resp.Body.Close()
+
+	// The subscription is deactivated or expired
+	if resp.StatusCode == http.StatusGone {
+		return resp.StatusCode, false, ErrPushSubscriptionGone
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, isRetryableStatusCode(resp.StatusCode), fmt.Errorf("web push service responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, false, nil
+}
+
+type ExpoPushProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewExpoPushProvider can be used as a constructor to create an ExpoPushProvider "object"
+func NewExpoPushProvider(url string) *ExpoPushProvider {
+	return &ExpoPushProvider{url: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Send sends a push notification to an expo client using the expo API
+func (provider *ExpoPushProvider) Send(pushSubscription *models.PushSubscription, message *PushMessage) (int, bool, error) {
+	data := map[string]interface{}{
+		"to":    pushSubscription.ExpoToken, // token is sent in ExponentPushToken[...] format
+		"title": message.Title,
+		"body":  message.Body,
+		"data":  message.Data,
+		"sound": "default",
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Send request to expo API
+	req, err := http.NewRequest("POST", provider.url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := provider.client.Do(req)
+	if err != nil {
+		return 0, true, err // network errors are temporary
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, isRetryableStatusCode(resp.StatusCode), fmt.Errorf("expo push service responded with status %d", resp.StatusCode)
+	}
+
+	// Check response from expo
+	type Response struct {
+		Data struct {
+			Status  string `json:"status"`
+			Message string `json:"message,omitempty"`
+			Details struct {
+				Error string `json:"error,omitempty"`
+			} `json:"details,omitempty"`
+		} `json:"data"`
+	}
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	var response Response
+	err = json.Unmarshal(bodyBytes, &response)
+	if err != nil {
+		return resp.StatusCode, true, err
+	}
+
+	if response.Data.Details.Error == "DeviceNotRegistered" {
+		return resp.StatusCode, false, ErrPushSubscriptionGone
+	}
+
+	if response.Data.Status == "error" {
+		return resp.StatusCode, response.Data.Details.Error == "MessageRateExceeded", errors.New("expo push service returned error: " + response.Data.Message)
+	}
+
+	return resp.StatusCode, false, nil
+}
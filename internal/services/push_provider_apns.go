@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultApnsUrl       = "https://api.push.apple.com"
+	apnsTokenRenewalTime = 50 * time.Minute // provider tokens must be renewed at least every hour, but not more than every 20 minutes
+)
+
+// ApnsPushProvider sends push notifications to iOS apps using the Apple Push Notification service with token-based authentication
+type ApnsPushProvider struct {
+	keyId      string
+	teamId     string
+	topic      string // bundle id of the app
+	url        string
+	privateKey *ecdsa.PrivateKey
+	client     *http.Client
+
+	providerToken       string
+	providerTokenIssued time.Time
+	providerTokenLock   sync.Mutex
+}
+
+// NewApnsPushProvider can be used as a constructor to create an ApnsPushProvider "object"
+func NewApnsPushProvider(privateKeyPem []byte, keyId string, teamId string, topic string, url string) (*ApnsPushProvider, error) {
+	privateKey, err := jwt.ParseECPrivateKeyFromPEM(privateKeyPem)
+	if err != nil {
+		return nil, err
+	}
+	if url == "" {
+		url = defaultApnsUrl
+	}
+
+	return &ApnsPushProvider{
+		keyId:      keyId,
+		teamId:     teamId,
+		topic:      topic,
+		url:        strings.TrimSuffix(url, "/"),
+		privateKey: privateKey,
+		client:     &http.Client{Timeout: 30 * time.Second}, // HTTP/2 is used automatically for https urls
+	}, nil
+}
+
+// NewApnsPushProviderFromFile creates an ApnsPushProvider with the signing key of a .p8 file
+func NewApnsPushProviderFromFile(keyFile string, keyId string, teamId string, topic string, url string) (*ApnsPushProvider, error) {
+	privateKeyPem, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewApnsPushProvider(privateKeyPem, keyId, teamId, topic, url)
+}
+
+// Send sends a push notification to the device token of the subscription
+func (provider *ApnsPushProvider) Send(pushSubscription *models.PushSubscription, message *PushMessage) (int, bool, error) {
+	providerToken, err := provider.getProviderToken(false)
+	if err != nil {
+		return 0, false, err
+	}
+
+	data := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": message.Title,
+				"body":  message.Body,
+			},
+			"sound": "default",
+		},
+		"data": message.Data,
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return 0, false, err
+	}
+
+	req, err := http.NewRequest("POST", provider.url+"/3/device/"+pushSubscription.DeviceToken, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", provider.topic)
+	req.Header.Set("apns-push-type", "alert")
+
+	resp, err := provider.client.Do(req)
+	if err != nil {
+		return 0, true, err // network errors are temporary
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp.StatusCode, false, nil
+	}
+
+	// Check error response from APNs
+	var response struct {
+		Reason string `json:"reason"`
+	}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	_ = json.Unmarshal(bodyBytes, &response)
+
+	switch response.Reason {
+	case "Unregistered", "BadDeviceToken", "DeviceTokenNotForTopic":
+		return resp.StatusCode, false, ErrPushSubscriptionGone
+	case "ExpiredProviderToken":
+		_, _ = provider.getProviderToken(true) // the next attempt uses a new token
+		return resp.StatusCode, true, fmt.Errorf("apns rejected provider token: %s", response.Reason)
+	}
+
+	return resp.StatusCode, isRetryableStatusCode(resp.StatusCode), fmt.Errorf("apns responded with status %d: %s", resp.StatusCode, response.Reason)
+}
+
+// getProviderToken returns a cached provider token or signs a new one if it is too old or renewal is forced
+func (provider *ApnsPushProvider) getProviderToken(forceRenewal bool) (string, error) {
+	provider.providerTokenLock.Lock()
+	defer provider.providerTokenLock.Unlock()
+
+	if !forceRenewal && provider.providerToken != "" && time.Since(provider.providerTokenIssued) < apnsTokenRenewalTime {
+		return provider.providerToken, nil
+	}
+
+	issuedAt := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": provider.teamId,
+		"iat": issuedAt.Unix(),
+	})
+	token.Header["kid"] = provider.keyId
+
+	providerToken, err := token.SignedString(provider.privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	provider.providerToken = providerToken
+	provider.providerTokenIssued = issuedAt
+	return providerToken, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"net/http"
+	"sync"
+)
+
+// FakePushMessage is a push message that was sent with the FakePushProvider
+type FakePushMessage struct {
+	Subscription models.PushSubscription
+	Message      PushMessage
+}
+
+// FakePushProvider keeps sent push messages in memory and returns a configurable result, it can be used in tests
+type FakePushProvider struct {
+	StatusCode int
+	Retryable  bool
+	Err        error
+
+	sent     []FakePushMessage
+	sentLock sync.Mutex
+}
+
+// NewFakePushProvider can be used as a constructor to create a FakePushProvider "object" that accepts all messages
+func NewFakePushProvider() *FakePushProvider {
+	return &FakePushProvider{StatusCode: http.StatusOK}
+}
+
+// Send stores the push message and returns the configured result
+func (provider *FakePushProvider) Send(pushSubscription *models.PushSubscription, message *PushMessage) (int, bool, error) {
+	provider.sentLock.Lock()
+	defer provider.sentLock.Unlock()
+
+	provider.sent = append(provider.sent, FakePushMessage{Subscription: *pushSubscription, Message: *message})
+	return provider.StatusCode, provider.Retryable, provider.Err
+}
+
+// Sent returns all push messages that were sent with the provider
+func (provider *FakePushProvider) Sent() []FakePushMessage {
+	provider.sentLock.Lock()
+	defer provider.sentLock.Unlock()
+
+	return append([]FakePushMessage{}, provider.sent...)
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFcmUrl = "https://fcm.googleapis.com"
+	fcmScope      = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+// FcmCredentials contains the fields of a Google service account key file that are needed to send FCM messages
+type FcmCredentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenUri    string `json:"token_uri"`
+}
+
+// FcmPushProvider sends push notifications to android and iOS apps using the Firebase Cloud Messaging HTTP v1 API
+type FcmPushProvider struct {
+	projectId   string
+	url         string
+	credentials FcmCredentials
+	privateKey  *rsa.PrivateKey
+	client      *http.Client
+
+	accessToken       string
+	accessTokenExpiry time.Time
+	accessTokenLock   sync.Mutex
+}
+
+// NewFcmPushProvider can be used as a constructor to create a FcmPushProvider "object"
+func NewFcmPushProvider(projectId string, credentials FcmCredentials, url string) (*FcmPushProvider, error) {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(credentials.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	if url == "" {
+		url = defaultFcmUrl
+	}
+
+	return &FcmPushProvider{
+		projectId:   projectId,
+		url:         strings.TrimSuffix(url, "/"),
+		credentials: credentials,
+		privateKey:  privateKey,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// NewFcmPushProviderFromFile creates a FcmPushProvider with the credentials of a service account key file
+func NewFcmPushProviderFromFile(projectId string, credentialsFile string, url string) (*FcmPushProvider, error) {
+	fileContent, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials FcmCredentials
+	if err := json.Unmarshal(fileContent, &credentials); err != nil {
+		return nil, err
+	}
+
+	return NewFcmPushProvider(projectId, credentials, url)
+}
+
+// Send sends a push notification to the registration token of the subscription
+func (provider *FcmPushProvider) Send(pushSubscription *models.PushSubscription, message *PushMessage) (int, bool, error) {
+	accessToken, err := provider.getAccessToken()
+	if err != nil {
+		return 0, true, err
+	}
+
+	data := map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": pushSubscription.DeviceToken,
+			"notification": map[string]string{
+				"title": message.Title,
+				"body":  message.Body,
+			},
+			"data": map[string]string{
+				"notification": message.Data, // data values need to be strings
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return 0, false, err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/projects/%s/messages:send", provider.url, provider.projectId), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := provider.client.Do(req)
+	if err != nil {
+		return 0, true, err // network errors are temporary
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp.StatusCode, false, nil
+	}
+
+	// Check error response from FCM
+	type Response struct {
+		Error struct {
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	var response Response
+	_ = json.Unmarshal(bodyBytes, &response)
+
+	for _, detail := range response.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return resp.StatusCode, false, ErrPushSubscriptionGone
+		}
+	}
+
+	// The cached access token might have been revoked, so a new one is requested for the next attempt
+	if resp.StatusCode == http.StatusUnauthorized {
+		provider.accessTokenLock.Lock()
+		provider.accessToken = ""
+		provider.accessTokenLock.Unlock()
+		return resp.StatusCode, true, errors.New("fcm rejected access token: " + response.Error.Message)
+	}
+
+	return resp.StatusCode, isRetryableStatusCode(resp.StatusCode), fmt.Errorf("fcm responded with status %d: %s", resp.StatusCode, response.Error.Message)
+}
+
+// getAccessToken returns a cached OAuth 2.0 access token or requests a new one with a signed service account JWT
+func (provider *FcmPushProvider) getAccessToken() (string, error) {
+	provider.accessTokenLock.Lock()
+	defer provider.accessTokenLock.Unlock()
+
+	// Tokens are renewed a minute before they expire
+	if provider.accessToken != "" && time.Now().Add(time.Minute).Before(provider.accessTokenExpiry) {
+		return provider.accessToken, nil
+	}
+
+	issuedAt := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   provider.credentials.ClientEmail,
+		"scope": fcmScope,
+		"aud":   provider.credentials.TokenUri,
+		"iat":   issuedAt.Unix(),
+		"exp":   issuedAt.Add(time.Hour).Unix(),
+	}).SignedString(provider.privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	resp, err := provider.client.PostForm(provider.credentials.TokenUri, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint responded with status %d", resp.StatusCode)
+	}
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", err
+	}
+
+	provider.accessToken = tokenResponse.AccessToken
+	provider.accessTokenExpiry = issuedAt.Add(time.Duration(tokenResponse.ExpiresIn) * time.Second)
+	return provider.accessToken, nil
+}
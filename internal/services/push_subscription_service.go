@@ -1,43 +1,74 @@
 package services
 
 import (
-	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/SherClockHolmes/webpush-go"
 	"github.com/google/uuid"
 	"github.com/wwi21seb-projekt/server-beta/internal/customerrors"
 	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"github.com/wwi21seb-projekt/server-beta/internal/repositories"
-	"io"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
+	"gorm.io/gorm"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
+	"time"
 )
 
+// Status of a push message in the push outbox
+const (
+	PushDeliveryStatusPending = "pending"
+	PushDeliveryStatusSent    = "sent"
+	PushDeliveryStatusFailed  = "failed"
+	PushDeliveryStatusGone    = "gone" // subscription is expired or was unregistered
+)
+
+const (
+	maxPushDeliveryAttempts = 6
+	pushDeliveryBaseBackoff = 30 * time.Second
+	pushDeliveryMaxBackoff  = time.Hour
+)
+
+// pushTokenRegex validates the tokens of push subscriptions that are not web push subscriptions:
+// expo tokens need to be in the format ExponentPushToken[...], fcm registration tokens are url-safe strings and apns device tokens are hex strings
+var pushTokenRegex = map[string]*regexp.Regexp{
+	"expo": regexp.MustCompile(`ExponentPushToken\[[a-zA-Z0-9-_]+\]`),
+	"fcm":  regexp.MustCompile(`^[a-zA-Z0-9_:-]+$`),
+	"apns": regexp.MustCompile(`^[a-fA-F0-9]{64,200}$`),
+}
+
 type PushSubscriptionServiceInterface interface {
 	GetVapidKey() (*models.VapidKeyResponseDTO, *customerrors.CustomError, int)
-	CreatePushSubscription(req *models.PushSubscriptionRequestDTO, currentUsername string) (*models.PushSubscriptionResponseDTO, *customerrors.CustomError, int)
+	CreatePushSubscription(req *models.PushSubscriptionRequestDTO, currentUsername string, sessionId string) (*models.PushSubscriptionResponseDTO, *customerrors.CustomError, int)
+	GetPushSubscriptions(currentUsername string, sessionId string) (*models.PushSubscriptionsResponseDTO, *customerrors.CustomError, int)
+	DeletePushSubscription(subscriptionId string, currentUsername string) (*customerrors.CustomError, int)
 	SendPushMessages(notificationObject *models.NotificationRecordDTO, toUsername string)
+	ProcessPushDelivery(delivery *models.PushDelivery, now time.Time)
+	GetFailedPushDeliveries(offset, limit int) (*models.PushDeliveriesResponseDTO, *customerrors.CustomError, int)
 }
 
 type PushSubscriptionService struct {
 	pushSubscriptionRepo repositories.PushSubscriptionRepositoryInterface
+	pushDeliveryRepo     repositories.PushDeliveryRepositoryInterface
+	providers            map[string]PushProvider // push provider for each subscription type
 
-	vapidPrivateKey string
-	vapidPublicKey  string
-	serverMail      string
+	vapidPublicKey string
 }
 
 // NewPushSubscriptionService can be used as a constructor to create a PushSubscriptionService "object"
-func NewPushSubscriptionService(pushSubscriptionRepo repositories.PushSubscriptionRepositoryInterface) *PushSubscriptionService {
-	vapidPrivateKey := os.Getenv("VAPID_PRIVATE_KEY")
+func NewPushSubscriptionService(pushSubscriptionRepo repositories.PushSubscriptionRepositoryInterface, pushDeliveryRepo repositories.PushDeliveryRepositoryInterface) *PushSubscriptionService {
+	return NewPushSubscriptionServiceWithProviders(pushSubscriptionRepo, pushDeliveryRepo, NewPushProvidersFromEnv())
+}
+
+// NewPushSubscriptionServiceWithProviders creates a PushSubscriptionService that sends push messages with the given providers,
+// subscription types without a provider cannot be registered
+func NewPushSubscriptionServiceWithProviders(pushSubscriptionRepo repositories.PushSubscriptionRepositoryInterface, pushDeliveryRepo repositories.PushDeliveryRepositoryInterface, providers map[string]PushProvider) *PushSubscriptionService {
 	vapidPublicKey := os.Getenv("VAPID_PUBLIC_KEY")
-	serverMail := os.Getenv("EMAIL_ADDRESS")
 
-	return &PushSubscriptionService{pushSubscriptionRepo: pushSubscriptionRepo, vapidPrivateKey: vapidPrivateKey, vapidPublicKey: vapidPublicKey, serverMail: serverMail}
+	return &PushSubscriptionService{pushSubscriptionRepo: pushSubscriptionRepo, pushDeliveryRepo: pushDeliveryRepo, providers: providers, vapidPublicKey: vapidPublicKey}
 }
 
 // GetVapidKey returns a VAPID key for clients to register for push notifications
@@ -49,11 +80,12 @@ func (service *PushSubscriptionService) GetVapidKey() (*models.VapidKeyResponseD
 	return &response, nil, http.StatusOK
 }
 
-// CreatePushSubscription saves a new push subscription key to the database to send notifications to the client
-func (service *PushSubscriptionService) CreatePushSubscription(req *models.PushSubscriptionRequestDTO, currentUsername string) (*models.PushSubscriptionResponseDTO, *customerrors.CustomError, int) {
+// CreatePushSubscription saves a new push subscription key to the database to send notifications to the client,
+// registering the same endpoint or token again updates the existing subscription instead of creating a duplicate
+func (service *PushSubscriptionService) CreatePushSubscription(req *models.PushSubscriptionRequestDTO, currentUsername string, sessionId string) (*models.PushSubscriptionResponseDTO, *customerrors.CustomError, int) {
 	// Input validations
-	// type either needs to be "web" or "expo"
-	if req.Type != "web" && req.Type != "expo" {
+	// type needs to be "web", "expo", "fcm" or "apns" and a push provider needs to be configured for it
+	if _, ok := service.providers[req.Type]; !ok {
 		return nil, customerrors.BadRequest, http.StatusBadRequest
 	}
 
@@ -80,42 +112,122 @@ func (service *PushSubscriptionService) CreatePushSubscription(req *models.PushS
 		req.Token = "" // token is not required for web push notifications
 	}
 
-	// For expo push notifications only token is required
-	if req.Type == "expo" {
-		// token needs to be in the format ExponentPushToken[...]
-		tokenRegex := `ExponentPushToken\[[a-zA-Z0-9-_]+\]`
-		if match, _ := regexp.MatchString(tokenRegex, req.Token); !match {
+	// For expo, fcm and apns push notifications only token is required
+	if req.Type != "web" {
+		if !pushTokenRegex[req.Type].MatchString(req.Token) {
+			return nil, customerrors.BadRequest, http.StatusBadRequest
+		}
+		// fcm registration tokens have no fixed length, the length is checked here because RE2 allows at most 1000 repetitions
+		if req.Type == "fcm" && (len(req.Token) < 20 || len(req.Token) > 4096) {
 			return nil, customerrors.BadRequest, http.StatusBadRequest
 		}
 
-		req.SubscriptionInfo = &models.SubscriptionInfo{} // subscription info is not required for expo push notifications
+		req.SubscriptionInfo = &models.SubscriptionInfo{} // subscription info is only required for web push notifications
 	}
 
-	// Create a new push subscription
-	newPushSubscription := models.PushSubscription{
+	// Check if the endpoint or token is already registered, e.g. by another user on the same device
+	existingPushSubscription, err := service.pushSubscriptionRepo.FindPushSubscription(req.Type, req.SubscriptionInfo.Endpoint, req.Token)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	pushSubscription := models.PushSubscription{
 		Id:        uuid.New(),
 		Username:  currentUsername,
 		Type:      req.Type,
 		Endpoint:  req.SubscriptionInfo.Endpoint,
 		P256dh:    req.SubscriptionInfo.SubscriptionKeys.P256dh,
 		Auth:      req.SubscriptionInfo.SubscriptionKeys.Auth,
-		ExpoToken: req.Token,
+		SessionId: sessionId,
+		CreatedAt: time.Now(),
+	}
+	if req.Type == "expo" {
+		pushSubscription.ExpoToken = req.Token
+	} else {
+		pushSubscription.DeviceToken = req.Token
 	}
 
-	err := service.pushSubscriptionRepo.CreatePushSubscription(&newPushSubscription)
+	httpStatus := http.StatusCreated
+	if err == nil {
+		// Update existing subscription, so that pushes are only sent once
+		pushSubscription.Id = existingPushSubscription.Id
+		err = service.pushSubscriptionRepo.UpdatePushSubscription(&pushSubscription)
+		httpStatus = http.StatusOK
+	} else {
+		err = service.pushSubscriptionRepo.CreatePushSubscription(&pushSubscription)
+	}
 	if err != nil {
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
+	// A device session has only one subscription, older ones are replaced by the new one
+	if sessionId != "" {
+		err = service.pushSubscriptionRepo.DeletePushSubscriptionsBySessionId(sessionId, pushSubscription.Id.String())
+		if err != nil {
+			return nil, customerrors.DatabaseError, http.StatusInternalServerError
+		}
+	}
+
 	// Create response object with subscription id
 	responseDto := models.PushSubscriptionResponseDTO{
-		SubscriptionId: newPushSubscription.Id.String(),
+		SubscriptionId: pushSubscription.Id.String(),
+	}
+
+	return &responseDto, nil, httpStatus
+}
+
+// GetPushSubscriptions returns all push subscriptions of the current user and marks the one of the current device session
+func (service *PushSubscriptionService) GetPushSubscriptions(currentUsername string, sessionId string) (*models.PushSubscriptionsResponseDTO, *customerrors.CustomError, int) {
+	pushSubscriptions, err := service.pushSubscriptionRepo.GetPushSubscriptionsByUsername(currentUsername)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	records := make([]models.PushSubscriptionRecordDTO, 0)
+	for _, pushSubscription := range pushSubscriptions {
+		records = append(records, models.PushSubscriptionRecordDTO{
+			SubscriptionId: pushSubscription.Id.String(),
+			Type:           pushSubscription.Type,
+			Endpoint:       pushSubscription.Endpoint,
+			Token:          pushSubscription.ExpoToken + pushSubscription.DeviceToken, // only one of them is set
+			CreationDate:   pushSubscription.CreatedAt,
+			CurrentSession: sessionId != "" && pushSubscription.SessionId == sessionId,
+		})
+	}
+
+	return &models.PushSubscriptionsResponseDTO{Records: records}, nil, http.StatusOK
+}
+
+// DeletePushSubscription unregisters a push subscription of the current user, e.g. when logging out on a device
+func (service *PushSubscriptionService) DeletePushSubscription(subscriptionId string, currentUsername string) (*customerrors.CustomError, int) {
+	// Ids that are no uuid cannot belong to a subscription, the database would reject them with an error
+	if _, err := uuid.Parse(subscriptionId); err != nil {
+		return customerrors.PushSubscriptionNotFound, http.StatusNotFound
+	}
+
+	pushSubscription, err := service.pushSubscriptionRepo.GetPushSubscriptionById(subscriptionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customerrors.PushSubscriptionNotFound, http.StatusNotFound
+		}
+		return customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
-	return &responseDto, nil, http.StatusCreated
+	// Subscriptions of other users are treated as missing, so their ids cannot be probed
+	if pushSubscription.Username != currentUsername {
+		return customerrors.PushSubscriptionNotFound, http.StatusNotFound
+	}
+
+	err = service.pushSubscriptionRepo.DeletePushSubscriptionById(subscriptionId)
+	if err != nil {
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return nil, http.StatusNoContent
 }
 
-// SendPushMessages sends push messages to all push subscriptions of a user
+// SendPushMessages stores a push message for all push subscriptions of a user in the push outbox,
+// the messages are sent in the background by the push delivery workers
 func (service *PushSubscriptionService) SendPushMessages(notificationObject *models.NotificationRecordDTO, toUsername string) {
 	// Create notification json string from object
 	notificationJson, err := json.Marshal(notificationObject)
@@ -130,118 +242,154 @@ func (service *PushSubscriptionService) SendPushMessages(notificationObject *mod
 		return
 	}
 
-	// Send push messages
+	fromUsername := ""
+	if notificationObject.User != nil {
+		fromUsername = notificationObject.User.Username
+	}
+
+	// Queue push messages in the locale of the receiving user
 	for _, pushSubscription := range pushSubscriptions {
-		if pushSubscription.Type == "web" {
-			go service.sendWebPushNotification(&pushSubscription, notificationDataString) // send push message in background
+		if _, ok := service.providers[pushSubscription.Type]; !ok {
 			continue
 		}
-		if pushSubscription.Type == "expo" {
-			// Create notification text for body of expo push notification
-			var notificationText = ""
-			if notificationObject.NotificationType == "follow" {
-				notificationText = notificationObject.User.Username + " started following you"
-			} else if notificationObject.NotificationType == "repost" {
-				notificationText = notificationObject.User.Username + " reposted your post"
-			} else {
-				notificationText = "New notification"
-			}
-
-			go service.sendExpoPushNotification(&pushSubscription, notificationDataString, notificationText) // send push message in background
-			continue
+
+		delivery := models.PushDelivery{
+			Id:             uuid.New(),
+			SubscriptionId: pushSubscription.Id,
+			Username:       toUsername,
+			Type:           pushSubscription.Type,
+			Title:          utils.Translate(pushSubscription.User.Locale, "push.title"),
+			Payload:        notificationDataString,
+			Status:         PushDeliveryStatusPending,
+			NextAttemptAt:  time.Now(),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+		if pushSubscription.Type != "web" {
+			// app push notifications show a text in the body
+			delivery.Text = utils.GetNotificationText(pushSubscription.User.Locale, notificationObject.NotificationType, fromUsername, notificationObject.Count)
+		}
+
+		if err := service.pushDeliveryRepo.CreatePushDelivery(&delivery); err != nil {
+			fmt.Println(err, ", error queueing push message for", toUsername)
 		}
 	}
 }
 
-// sendWebPushNotification sends a push notification to a web client using the webpush-go library and provided keys
-func (service *PushSubscriptionService) sendWebPushNotification(pushSubscription *models.PushSubscription, notificationDataString string) {
-	// send notification to web client
-	sub := &webpush.Subscription{
-		Endpoint: pushSubscription.Endpoint,
-		Keys: webpush.Keys{
-			P256dh: pushSubscription.P256dh,
-			Auth:   pushSubscription.Auth,
-		},
+// ProcessPushDelivery makes one attempt to send a queued push message and logs the result
+// Temporary errors are retried with exponential backoff until the maximum number of attempts is reached
+func (service *PushSubscriptionService) ProcessPushDelivery(delivery *models.PushDelivery, now time.Time) {
+	delivery.Attempts++
+	attempt := models.PushDeliveryAttempt{
+		Id:            uuid.New(),
+		DeliveryId:    delivery.Id,
+		AttemptNumber: delivery.Attempts,
+		CreatedAt:     now,
 	}
 
-	resp, err := webpush.SendNotification([]byte(notificationDataString), sub, &webpush.Options{
-		Subscriber:      service.serverMail,
-		VAPIDPublicKey:  service.vapidPublicKey,
-		VAPIDPrivateKey: service.vapidPrivateKey,
-		TTL:             30,
-	})
-
+	var retryable bool
+	pushSubscription, err := service.pushSubscriptionRepo.GetPushSubscriptionById(delivery.SubscriptionId.String())
 	if err != nil {
-		fmt.Println(err, ", error sending notification to", pushSubscription.Username)
-		return
-	}
-
-	// Print response information
-	fmt.Println("Notification sent to", pushSubscription.Username, "with type web")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrPushSubscriptionGone // subscription was unregistered after the message was queued
+		}
+		retryable = !errors.Is(err, ErrPushSubscriptionGone)
+	} else if provider, ok := service.providers[delivery.Type]; !ok {
+		err = fmt.Errorf("no push provider configured for type %s", delivery.Type)
+	} else {
+		title := delivery.Title
+		if title == "" {
+			title = utils.Translate(utils.DefaultLocale, "push.title") // deliveries queued before titles were stored
+		}
+		message := PushMessage{
+			Title: title,
+			Body:  delivery.Text,
+			Data:  delivery.Payload,
+		}
+		attempt.StatusCode, retryable, err = provider.Send(pushSubscription, &message)
 
-	// If the subscription is deactivated or expired, delete it
-	if resp.StatusCode == http.StatusGone {
-		_ = service.pushSubscriptionRepo.DeletePushSubscriptionById(pushSubscription.Id.String())
-		return
+		// If the subscription is deactivated, expired or the device is not registered anymore, delete it
+		if errors.Is(err, ErrPushSubscriptionGone) {
+			_ = service.pushSubscriptionRepo.DeletePushSubscriptionById(pushSubscription.Id.String())
+		}
 	}
-}
-
-// sendExpoPushNotification sends a push notification to an expo client using the expo API
-func (service *PushSubscriptionService) sendExpoPushNotification(pushSubscription *models.PushSubscription, notificationDataString string, notificationText string) {
-	// Create request
-	expoApiUrl := "https://exp.host/--/api/v2/push/send"
 
-	data := map[string]interface{}{
-		"to":    pushSubscription.ExpoToken, // token is sent in ExponentPushToken[...] format
-		"title": "Notification from Server Beta",
-		"body":  notificationText,
-		"data":  notificationDataString,
-		"sound": "default",
+	switch {
+	case err == nil:
+		delivery.Status = PushDeliveryStatusSent
+	case errors.Is(err, ErrPushSubscriptionGone):
+		delivery.Status = PushDeliveryStatusGone
+	case retryable && delivery.Attempts < maxPushDeliveryAttempts:
+		delivery.Status = PushDeliveryStatusPending
+		delivery.NextAttemptAt = now.Add(getPushDeliveryBackoff(delivery.Attempts))
+	default:
+		delivery.Status = PushDeliveryStatusFailed
 	}
 
-	jsonData, err := json.Marshal(data)
+	delivery.LastError = ""
 	if err != nil {
-		return
+		delivery.LastError = err.Error()
+		attempt.Error = err.Error()
 	}
+	delivery.UpdatedAt = now
+	attempt.Status = delivery.Status
 
-	// Send request to expo API
-	req, err := http.NewRequest("POST", expoApiUrl, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return
+	if err := service.pushDeliveryRepo.UpdatePushDelivery(delivery, &attempt); err != nil {
+		fmt.Println(err, ", error saving push delivery", delivery.Id.String())
 	}
-	req.Header.Set("Content-Type", "application/json")
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// GetFailedPushDeliveries returns push messages that could not be delivered with their attempt log
+func (service *PushSubscriptionService) GetFailedPushDeliveries(offset, limit int) (*models.PushDeliveriesResponseDTO, *customerrors.CustomError, int) {
+	deliveries, totalCount, err := service.pushDeliveryRepo.GetPushDeliveriesByStatus(PushDeliveryStatusFailed, offset, limit)
 	if err != nil {
-		fmt.Println(err, ", error sending notification to", pushSubscription.Username)
-		return
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
-	// Print response information
-	fmt.Println("Notification sent to", pushSubscription.Username, "with type expo")
+	records := make([]models.PushDeliveryRecordDTO, 0)
+	for _, delivery := range deliveries {
+		attempts := make([]models.PushDeliveryAttemptDTO, 0)
+		for _, attempt := range delivery.AttemptLog {
+			attempts = append(attempts, models.PushDeliveryAttemptDTO{
+				AttemptNumber: attempt.AttemptNumber,
+				Status:        attempt.Status,
+				StatusCode:    attempt.StatusCode,
+				Error:         attempt.Error,
+				CreationDate:  attempt.CreatedAt,
+			})
+		}
 
-	// Check response from expo
-	type Response struct {
-		Data struct {
-			Status  string `json:"status"`
-			Message string `json:"message,omitempty"`
-			Details struct {
-				Error string `json:"error,omitempty"`
-			} `json:"details,omitempty"`
-		} `json:"data"`
+		records = append(records, models.PushDeliveryRecordDTO{
+			DeliveryId:     delivery.Id.String(),
+			SubscriptionId: delivery.SubscriptionId.String(),
+			Username:       delivery.Username,
+			Type:           delivery.Type,
+			Status:         delivery.Status,
+			Attempts:       attempts,
+			CreationDate:   delivery.CreatedAt,
+		})
 	}
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	var response Response
-	err = json.Unmarshal(bodyBytes, &response)
-	if err != nil {
-		return
+	responseDto := models.PushDeliveriesResponseDTO{
+		Records: records,
+		Pagination: &models.OffsetPaginationDTO{
+			Offset:  offset,
+			Limit:   limit,
+			Records: totalCount,
+		},
 	}
 
-	// If the notification could not be sent, delete the subscription
-	if response.Data.Details.Error == "DeviceNotRegistered" {
-		_ = service.pushSubscriptionRepo.DeletePushSubscriptionById(pushSubscription.Id.String())
-		return
+	return &responseDto, nil, http.StatusOK
+}
+
+// getPushDeliveryBackoff returns the time to wait before the next attempt, doubling with every failed attempt
+func getPushDeliveryBackoff(attempts int) time.Duration {
+	backoff := pushDeliveryBaseBackoff
+	for i := 1; i < attempts && backoff < pushDeliveryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > pushDeliveryMaxBackoff {
+		backoff = pushDeliveryMaxBackoff
 	}
+	return backoff
 }
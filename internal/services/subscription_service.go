@@ -70,7 +70,7 @@ func (service *SubscriptionService) PostSubscription(req *models.SubscriptionPos
 	}
 
 	// Create notification
-	_ = service.notificationService.CreateNotification("follow", req.Following, currentUsername)
+	_ = service.notificationService.CreateNotification("follow", req.Following, currentUsername, nil, nil)
 
 	// Create response
 	response := &models.SubscriptionPostResponseDTO{
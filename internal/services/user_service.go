@@ -17,7 +17,7 @@ import (
 )
 
 type UserServiceInterface interface {
-	sendActivationToken(email string, tokenObject *models.ActivationToken) *customerrors.CustomError
+	sendActivationToken(email string, locale string, tokenObject *models.ActivationToken) *customerrors.CustomError
 	CreateUser(req models.UserCreateRequestDTO) (*models.UserCreateResponseDTO, *customerrors.CustomError, int)
 	LoginUser(req models.UserLoginRequestDTO) (*models.UserLoginResponseDTO, *customerrors.CustomError, int)
 	ActivateUser(username string, token string) (*models.UserLoginResponseDTO, *customerrors.CustomError, int)
@@ -27,6 +27,11 @@ type UserServiceInterface interface {
 	UpdateUserInformation(req *models.UserInformationUpdateRequestDTO, currentUsername string) (*models.UserInformationUpdateResponseDTO, *customerrors.CustomError, int)
 	ChangeUserPassword(req *models.ChangePasswordDTO, currentUsername string) (*customerrors.CustomError, int)
 	GetUserProfile(username string, currentUser string) (*models.UserProfileResponseDTO, *customerrors.CustomError, int)
+	DeleteUser(req *models.UserDeleteRequestDTO, currentUsername string) (*customerrors.CustomError, int)
+	GetUserLocale(currentUsername string) (*models.UserLocaleDTO, *customerrors.CustomError, int)
+	UpdateUserLocale(req *models.UserLocaleDTO, currentUsername string) (*models.UserLocaleDTO, *customerrors.CustomError, int)
+	GetPrivacySettings(currentUsername string) (*models.UserPrivacySettingsDTO, *customerrors.CustomError, int)
+	UpdatePrivacySettings(req *models.UserPrivacySettingsDTO, currentUsername string) (*models.UserPrivacySettingsDTO, *customerrors.CustomError, int)
 }
 
 type UserService struct {
@@ -37,6 +42,7 @@ type UserService struct {
 	postRepo            repositories.PostRepositoryInterface
 	imageRepo           repositories.ImageRepositoryInterface
 	subscriptionRepo    repositories.SubscriptionRepositoryInterface
+	presenceService     PresenceServiceInterface
 	policy              *bluemonday.Policy
 }
 
@@ -48,7 +54,8 @@ func NewUserService(
 	validator utils.ValidatorInterface,
 	postRepo repositories.PostRepositoryInterface,
 	imageRepo repositories.ImageRepositoryInterface,
-	subscriptionRepo repositories.SubscriptionRepositoryInterface) *UserService {
+	subscriptionRepo repositories.SubscriptionRepositoryInterface,
+	presenceService PresenceServiceInterface) *UserService {
 	return &UserService{
 		userRepo:            userRepo,
 		activationTokenRepo: activationTokenRepo,
@@ -57,14 +64,15 @@ func NewUserService(
 		postRepo:            postRepo,
 		imageRepo:           imageRepo,
 		subscriptionRepo:    subscriptionRepo,
+		presenceService:     presenceService,
 		policy:              bluemonday.UGCPolicy(),
 	}
 }
 
 // sendActivationToken deletes old activation tokens, generates a new six-digit code and sends it to user via mail
-func (service *UserService) sendActivationToken(email string, tokenObject *models.ActivationToken) *customerrors.CustomError {
-	subject := "Verify your account"
-	body := utils.GetActivationEmailBody(tokenObject.Token)
+func (service *UserService) sendActivationToken(email string, locale string, tokenObject *models.ActivationToken) *customerrors.CustomError {
+	subject := utils.Translate(locale, "email.activation.subject")
+	body := utils.GetActivationEmailBody(locale, tokenObject.Token)
 	err := service.mailService.SendMail(email, subject, body)
 	if err != nil {
 		return customerrors.EmailNotSent
@@ -158,6 +166,10 @@ func (service *UserService) CreateUser(req models.UserCreateRequestDTO) (*models
 		CreatedAt:    time.Now(),
 		Activated:    false,
 		Status:       "", //status is empty in the beginning
+		Locale:       utils.DefaultLocale,
+	}
+	if utils.IsSupportedLocale(req.Locale) {
+		user.Locale = req.Locale
 	}
 
 	// Add image to user if image was given
@@ -192,7 +204,7 @@ func (service *UserService) CreateUser(req models.UserCreateRequestDTO) (*models
 	}
 
 	// Send activation code
-	if err := service.sendActivationToken(user.Email, &codeObject); err != nil {
+	if err := service.sendActivationToken(user.Email, user.Locale, &codeObject); err != nil {
 		service.userRepo.RollbackTx(tx)
 		return nil, err, http.StatusInternalServerError
 	}
@@ -268,9 +280,10 @@ func (service *UserService) LoginUser(req models.UserLoginRequestDTO) (*models.U
 		return nil, customerrors.UserNotActivated, http.StatusForbidden
 	}
 
-	// Create access token
-	accessTokenString, err := utils.GenerateAccessToken(user.Username)
-	refreshTokenString, err := utils.GenerateRefreshToken(user.Username)
+	// Create access token for a new device session
+	sessionId := uuid.New().String()
+	accessTokenString, err := utils.GenerateSessionAccessToken(user.Username, sessionId)
+	refreshTokenString, err := utils.GenerateSessionRefreshToken(user.Username, sessionId)
 	if err != nil {
 		return nil, customerrors.InternalServerError, http.StatusInternalServerError
 	}
@@ -325,8 +338,8 @@ func (service *UserService) ActivateUser(username string, token string) (*models
 	}
 
 	// Send welcome email
-	subject := "Welcome to Server Beta"
-	body := utils.GetWelcomeEmailBody(username)
+	subject := utils.Translate(user.Locale, "email.welcome.subject")
+	body := utils.GetWelcomeEmailBody(user.Locale, username)
 	if err := service.mailService.SendMail(user.Email, subject, body); err != nil {
 		return nil, customerrors.InternalServerError, http.StatusInternalServerError
 	}
@@ -336,12 +349,13 @@ func (service *UserService) ActivateUser(username string, token string) (*models
 		return nil, customerrors.DatabaseError, http.StatusInternalServerError
 	}
 
-	// Generate access and refresh token
-	accessTokenString, err := utils.GenerateAccessToken(user.Username)
+	// Generate access and refresh token for a new device session
+	sessionId := uuid.New().String()
+	accessTokenString, err := utils.GenerateSessionAccessToken(user.Username, sessionId)
 	if err != nil {
 		return nil, customerrors.InternalServerError, http.StatusInternalServerError
 	}
-	refreshTokenString, err := utils.GenerateRefreshToken(user.Username)
+	refreshTokenString, err := utils.GenerateSessionRefreshToken(user.Username, sessionId)
 	if err != nil {
 		return nil, customerrors.InternalServerError, http.StatusInternalServerError
 	}
@@ -394,7 +408,7 @@ func (service *UserService) ResendActivationToken(username string) (*customerror
 	}
 
 	// Resend code
-	customError := service.sendActivationToken(user.Email, &codeObject)
+	customError := service.sendActivationToken(user.Email, user.Locale, &codeObject)
 	if customError != nil {
 		return customError, http.StatusInternalServerError
 	}
@@ -407,17 +421,17 @@ func (service *UserService) ResendActivationToken(username string) (*customerror
 func (service *UserService) RefreshToken(req *models.UserRefreshTokenRequestDTO) (*models.UserLoginResponseDTO, *customerrors.CustomError, int) {
 
 	// Verify refresh token
-	username, isRefreshToken, err := utils.VerifyJWTToken(req.RefreshToken)
+	username, sessionId, isRefreshToken, err := utils.VerifySessionJWTToken(req.RefreshToken)
 	if err != nil || !isRefreshToken {
 		return nil, customerrors.InvalidToken, http.StatusUnauthorized
 	}
 
-	// Generate new access token
-	accessTokenString, err := utils.GenerateAccessToken(username)
+	// Generate new access token, the device session stays the same
+	accessTokenString, err := utils.GenerateSessionAccessToken(username, sessionId)
 	if err != nil {
 		return nil, customerrors.InternalServerError, http.StatusInternalServerError
 	}
-	refreshTokenString, err := utils.GenerateRefreshToken(username)
+	refreshTokenString, err := utils.GenerateSessionRefreshToken(username, sessionId)
 	if err != nil {
 		return nil, customerrors.InternalServerError, http.StatusInternalServerError
 	}
@@ -623,6 +637,13 @@ func (service *UserService) GetUserProfile(username string, currentUser string)
 		subscriptionId = &id
 	}
 
+	// Online status and last-seen timestamp are empty if the user hides them from the current user
+	presences, err := service.presenceService.GetPresences([]models.User{*user}, currentUser)
+	if err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+	presence := presences[user.Username]
+
 	// Create response
 	var imageDto *models.ImageMetadataDTO
 	if user.ImageId != nil {
@@ -643,7 +664,130 @@ func (service *UserService) GetUserProfile(username string, currentUser string)
 		Following:      followingCount,
 		Posts:          postCount,
 		SubscriptionId: subscriptionId,
+		Online:         presence.Online,
+		LastSeen:       presence.LastSeen,
 	}
 
 	return userProfile, nil, http.StatusOK
 }
+
+// DeleteUser deletes the current user with all posts, comments including the replies of other users to them, likes, subscriptions,
+// notifications, chats and images after verifying the password
+func (service *UserService) DeleteUser(req *models.UserDeleteRequestDTO, currentUsername string) (*customerrors.CustomError, int) {
+	// Find the user by username
+	user, err := service.userRepo.FindUserByUsername(currentUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customerrors.Unauthorized, http.StatusUnauthorized
+		}
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Verify the password
+	if !utils.CheckPassword(req.Password, user.PasswordHash) {
+		return customerrors.InvalidCredentials, http.StatusForbidden
+	}
+
+	// Start a transaction, so that either all data of the user is deleted or nothing
+	tx := service.userRepo.BeginTx()
+	if tx.Error != nil {
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	// Delete posts first, so that the post cleanup also removes comments and likes of other users on these posts
+	if err := service.postRepo.DeletePostsByUsernameTx(currentUsername, tx); err != nil {
+		service.userRepo.RollbackTx(tx)
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	if err := service.userRepo.DeleteUserTx(currentUsername, tx); err != nil {
+		service.userRepo.RollbackTx(tx)
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	if err := service.userRepo.CommitTx(tx); err != nil {
+		return customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return nil, http.StatusNoContent
+}
+
+// GetUserLocale returns the locale that is used for push notifications and emails of the current user
+func (service *UserService) GetUserLocale(currentUsername string) (*models.UserLocaleDTO, *customerrors.CustomError, int) {
+	user, err := service.userRepo.FindUserByUsername(currentUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.UserNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	locale := user.Locale
+	if !utils.IsSupportedLocale(locale) {
+		locale = utils.DefaultLocale
+	}
+
+	return &models.UserLocaleDTO{Locale: locale}, nil, http.StatusOK
+}
+
+// UpdateUserLocale changes the locale that is used for push notifications and emails of the current user
+func (service *UserService) UpdateUserLocale(req *models.UserLocaleDTO, currentUsername string) (*models.UserLocaleDTO, *customerrors.CustomError, int) {
+	if !utils.IsSupportedLocale(req.Locale) {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	user, err := service.userRepo.FindUserByUsername(currentUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.UserNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	user.Locale = req.Locale
+	if err := service.userRepo.UpdateUser(user); err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return &models.UserLocaleDTO{Locale: user.Locale}, nil, http.StatusOK
+}
+
+// GetPrivacySettings returns who can see if the current user is online and when the user was last seen
+func (service *UserService) GetPrivacySettings(currentUsername string) (*models.UserPrivacySettingsDTO, *customerrors.CustomError, int) {
+	user, err := service.userRepo.FindUserByUsername(currentUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.UserNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	visibility := user.PresenceVisibility
+	if visibility != PresenceVisibilityContacts {
+		visibility = PresenceVisibilityEveryone
+	}
+
+	return &models.UserPrivacySettingsDTO{PresenceVisibility: visibility}, nil, http.StatusOK
+}
+
+// UpdatePrivacySettings changes who can see if the current user is online and when the user was last seen
+func (service *UserService) UpdatePrivacySettings(req *models.UserPrivacySettingsDTO, currentUsername string) (*models.UserPrivacySettingsDTO, *customerrors.CustomError, int) {
+	if req.PresenceVisibility != PresenceVisibilityEveryone && req.PresenceVisibility != PresenceVisibilityContacts {
+		return nil, customerrors.BadRequest, http.StatusBadRequest
+	}
+
+	user, err := service.userRepo.FindUserByUsername(currentUsername)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customerrors.UserNotFound, http.StatusNotFound
+		}
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	user.PresenceVisibility = req.PresenceVisibility
+	if err := service.userRepo.UpdateUser(user); err != nil {
+		return nil, customerrors.DatabaseError, http.StatusInternalServerError
+	}
+
+	return &models.UserPrivacySettingsDTO{PresenceVisibility: user.PresenceVisibility}, nil, http.StatusOK
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/rand"
+	"encoding/hex"
 	"math/big"
 )
 
@@ -14,3 +15,12 @@ func GenerateSixDigitCode() (int64, error) {
 	}
 	return n.Int64() + 100000, nil
 }
+
+// GenerateRandomToken generates a random hex encoded token that can be used in links, e.g. for downloads
+func GenerateRandomToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -25,3 +25,29 @@ func TestGenerateSixDigitCode(t *testing.T) {
 		}
 	}
 }
+
+// TestGenerateRandomToken tests if GenerateRandomToken returns a 64 character hex token that differs between calls
+func TestGenerateRandomToken(t *testing.T) {
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		t.Fatalf("GenerateRandomToken() returned an error: %v", err)
+	}
+
+	if len(token) != 64 {
+		t.Errorf("Expected a 64 character token, got %d characters", len(token))
+	}
+
+	for _, r := range token {
+		if !unicode.Is(unicode.ASCII_Hex_Digit, r) {
+			t.Errorf("Expected only hex characters in the token, found: %c", r)
+		}
+	}
+
+	otherToken, err := utils.GenerateRandomToken()
+	if err != nil {
+		t.Fatalf("GenerateRandomToken() returned an error: %v", err)
+	}
+	if token == otherToken {
+		t.Errorf("Expected two different tokens, got the same token twice")
+	}
+}
@@ -52,3 +52,15 @@ func GenerateLocationDTOFromLocation(location *models.Location) *models.Location
 		Accuracy:  &tempAccuracy,
 	}
 }
+
+// GenerateUserDTOsFromUsers generates a list of UserDTOs from a list of Users, e.g. for mentioned users
+// An empty list is returned instead of nil, so that clients always receive an array
+func GenerateUserDTOsFromUsers(users []models.User) []models.UserDTO {
+	userDtos := make([]models.UserDTO, 0)
+	for i := range users {
+		if userDto := GenerateUserDTOFromUser(&users[i]); userDto != nil {
+			userDtos = append(userDtos, *userDto)
+		}
+	}
+	return userDtos
+}
@@ -2,15 +2,25 @@ package utils
 
 import (
 	"fmt"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"html"
+	"strings"
 	"time"
 )
 
-// GetActivationEmailBody returns the HTML body for an activation email sending the activation code
-func GetActivationEmailBody(token string) string {
+// getEmailLayout returns the HTML frame that is shared by all emails with the given header, content and an optional footer note
+func getEmailLayout(locale string, header string, content string, footerNote string) string {
 	currentYear := time.Now().Year()
+	if !IsSupportedLocale(locale) {
+		locale = DefaultLocale
+	}
+	if footerNote != "" {
+		footerNote += "\n\t\t\t\t<br>"
+	}
+
 	return fmt.Sprintf(`
 	<!DOCTYPE html>
-	<html lang="en">
+	<html lang="%s">
 	<head>
 		<meta charset="UTF-8">
 		<meta name="viewport" content="width=device-width, initial-scale=1.0">
@@ -19,104 +29,146 @@ func GetActivationEmailBody(token string) string {
 			.container { width: 100%%; max-width: 600px; margin: auto; background-color: #f9f9f9; padding: 20px; }
 			.header { background-color: #007bff; color: white; padding: 10px 20px; text-align: center; }
 			.content { margin: 20px; text-align: center; }
+			.list { text-align: left; }
 			.code { font-size: 24px; color: #007bff; padding: 20px; margin: 20px 0; background-color: #eef; border-radius: 8px; display: inline-block; }
+			.button { font-size: 18px; color: white; background-color: #007bff; padding: 10px 20px; margin: 20px 0; border-radius: 8px; display: inline-block; text-decoration: none; }
 			.footer { font-size: 0.8em; text-align: center; margin-top: 20px; color: #666; }
 		</style>
 	</head>
 	<body>
 		<div class="container">
 			<div class="header">
-				Verification Code
+				%s
 			</div>
 			<div class="content">
-				<p>Hello!</p>
-				<p>Please use the following code to complete your account registration at Server Beta:</p>
-				<div class="code">%s</div>
-				<p>This code is valid for 2 hours. Enter this code on the appropriate page for your registration.</p>
+				%s
 			</div>
 			<div class="footer">
-				© %d Server Beta - All rights reserved.
+				%s
+				%s
 				<br>
-				For more information, see our <a href="https://server-beta.de/api/imprint">imprint</a>.
+				%s
 			</div>
 		</div>
 	</body>
-	</html>`, token, currentYear)
+	</html>`, locale, header, content, footerNote, Translate(locale, "email.rights", currentYear), Translate(locale, "email.imprint"))
+}
+
+// GetActivationEmailBody returns the HTML body for an activation email sending the activation code
+func GetActivationEmailBody(locale string, token string) string {
+	content := fmt.Sprintf(`<p>%s</p>
+				<p>%s</p>
+				<div class="code">%s</div>
+				<p>%s</p>`,
+		Translate(locale, "email.greeting"),
+		Translate(locale, "email.activation.text"),
+		token,
+		Translate(locale, "email.activation.validity"))
+
+	return getEmailLayout(locale, Translate(locale, "email.activation.header"), content, "")
 }
 
 // GetWelcomeEmailBody returns the HTML body for a welcome email
-func GetWelcomeEmailBody(username string) string {
-	currentYear := time.Now().Year()
-	return fmt.Sprintf(`
-	<!DOCTYPE html>
-	<html lang="en">
-	<head>
-		<meta charset="UTF-8">
-		<meta name="viewport" content="width=device-width, initial-scale=1.0">
-		<style>
-			body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
-			.container { width: 100%%; max-width: 600px; margin: auto; background-color: #f9f9f9; padding: 20px; }
-			.header { background-color: #007bff; color: white; padding: 10px 20px; text-align: center; }
-			.content { margin: 20px; text-align: center; }
-			.footer { font-size: 0.8em; text-align: center; margin-top: 20px; color: #666; }
-		</style>
-	</head>
-	<body>
-		<div class="container">
-			<div class="header">
-				Welcome to Server Beta!
-			</div>
-			<div class="content">
-				<p>Hello %s!</p>
-				<p>Let's go!</p>
-				<p>Your account has been successfully verified. You can now use our network.</p>
-				<p>We invite you to actively participate in our community and share your thoughts with us.</p>
-			</div>
-			<div class="footer">
-				© %d Server Beta - All rights reserved.
-				<br>
-				For more information, see our <a href="https://server-beta.de/api/imprint">imprint</a>.
-			</div>
-		</div>
-	</body>
-	</html>`, username, currentYear)
+func GetWelcomeEmailBody(locale string, username string) string {
+	content := fmt.Sprintf(`<p>%s</p>
+				<p>%s</p>
+				<p>%s</p>
+				<p>%s</p>`,
+		Translate(locale, "email.greetingName", html.EscapeString(username)),
+		Translate(locale, "email.welcome.start"),
+		Translate(locale, "email.welcome.verified"),
+		Translate(locale, "email.welcome.invitation"))
+
+	return getEmailLayout(locale, Translate(locale, "email.welcome.header"), content, "")
 }
 
 // GetPasswordResetEmailBody returns the HTML body for a password reset email
-func GetPasswordResetEmailBody(username string, resetToken string) string {
-	currentYear := time.Now().Year()
-	return fmt.Sprintf(`
-	<!DOCTYPE html>
-	<html lang="en">
-	<head>
-		<meta charset="UTF-8">
-		<meta name="viewport" content="width=device-width, initial-scale=1.0">
-		<style>
-			body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
-			.container { width: 100%%; max-width: 600px; margin: auto; background-color: #f9f9f9; padding: 20px; }
-			.header { background-color: #007bff; color: white; padding: 10px 20px; text-align: center; }
-			.content { margin: 20px; text-align: center; }
-			.code { font-size: 24px; color: #007bff; padding: 20px; margin: 20px 0; background-color: #eef; border-radius: 8px; display: inline-block; }
-			.footer { font-size: 0.8em; text-align: center; margin-top: 20px; color: #666; }
-		</style>
-	</head>
-	<body>
-		<div class="container">
-			<div class="header">
-				Password Reset
-			</div>
-			<div class="content">
-				<p>Hello %s!</p>
-				<p>Your code is:</p>
+func GetPasswordResetEmailBody(locale string, username string, resetToken string) string {
+	content := fmt.Sprintf(`<p>%s</p>
+				<p>%s</p>
 				<div class="code">%s</div>
-				<p>Use this code to reset your password.</p>
-			</div>
-			<div class="footer">
-				© %d Server Beta - All rights reserved.
-				<br>
-				For more information, see our <a href="https://server-beta.de/api/imprint">imprint</a>.
-			</div>
-		</div>
-	</body>
-	</html>`, username, resetToken, currentYear)
+				<p>%s</p>`,
+		Translate(locale, "email.greetingName", html.EscapeString(username)),
+		Translate(locale, "email.passwordReset.code"),
+		resetToken,
+		Translate(locale, "email.passwordReset.text"))
+
+	return getEmailLayout(locale, Translate(locale, "email.passwordReset.header"), content, "")
+}
+
+// GetDataExportEmailBody returns the HTML body for an email with the download link of a data export
+func GetDataExportEmailBody(locale string, username string, downloadUrl string) string {
+	content := fmt.Sprintf(`<p>%s</p>
+				<p>%s</p>
+				<a class="button" href="%s">%s</a>
+				<p>%s</p>`,
+		Translate(locale, "email.greetingName", html.EscapeString(username)),
+		Translate(locale, "email.dataExport.text"),
+		downloadUrl,
+		Translate(locale, "email.dataExport.download"),
+		Translate(locale, "email.dataExport.validity"))
+
+	return getEmailLayout(locale, Translate(locale, "email.dataExport.header"), content, "")
+}
+
+// GetDigestEmailBody returns the HTML body for a daily or weekly email digest with unread notifications and top posts of followed users
+func GetDigestEmailBody(locale string, username string, frequency string, unreadCount int64, notifications []models.Notification, posts []models.Post, unsubscribeUrl string) string {
+	period := Translate(locale, "email.digest.period."+frequency)
+
+	// List unread notifications
+	notificationList := "<p>" + Translate(locale, "email.digest.noNotifications") + "</p>"
+	if len(notifications) > 0 {
+		var items strings.Builder
+		for _, notification := range notifications {
+			text := GetNotificationText(locale, notification.NotificationType, notification.FromUsername, notification.Count)
+			items.WriteString("<li>" + html.EscapeString(text) + "</li>")
+		}
+		notificationList = fmt.Sprintf(`<p>%s</p><ul class="list">%s</ul>`, Translate(locale, "email.digest.notifications", unreadCount), items.String())
+	}
+
+	// List top posts of followed users
+	postList := "<p>" + Translate(locale, "email.digest.noPosts", period) + "</p>"
+	if len(posts) > 0 {
+		var items strings.Builder
+		for _, post := range posts {
+			items.WriteString(fmt.Sprintf("<li><b>%s</b>: %s</li>", html.EscapeString(post.Username), html.EscapeString(post.Content)))
+		}
+		postList = fmt.Sprintf(`<p>%s</p><ul class="list">%s</ul>`, Translate(locale, "email.digest.posts", period), items.String())
+	}
+
+	content := fmt.Sprintf(`<p>%s</p>
+				<p>%s</p>
+				%s
+				%s`,
+		Translate(locale, "email.greetingName", html.EscapeString(username)),
+		Translate(locale, "email.digest.intro", period),
+		notificationList,
+		postList)
+
+	footerNote := Translate(locale, "email.digest.footer."+frequency, unsubscribeUrl)
+	return getEmailLayout(locale, Translate(locale, "email.digest.header"), content, footerNote)
+}
+
+// GetDigestUnsubscribePage returns the HTML page that the unsubscribe link of an email digest opens,
+// the form posts to the same url, so that link scanners of mail providers that open the link do not unsubscribe the user
+func GetDigestUnsubscribePage(locale string) string {
+	content := fmt.Sprintf(`<p>%s</p>
+				<form method="post">
+					<button class="button" type="submit">%s</button>
+				</form>`,
+		Translate(locale, "email.digest.unsubscribe.text"),
+		Translate(locale, "email.digest.unsubscribe.confirm"))
+
+	return getEmailLayout(locale, Translate(locale, "email.digest.unsubscribe.header"), content, "")
+}
+
+// GetDigestUnsubscribedPage returns the HTML page that is shown after the form of the unsubscribe page was sent
+func GetDigestUnsubscribedPage(locale string, unsubscribed bool) string {
+	text := Translate(locale, "email.digest.unsubscribe.done")
+	if !unsubscribed {
+		text = Translate(locale, "email.digest.unsubscribe.failed")
+	}
+	content := fmt.Sprintf(`<p>%s</p>`, text)
+
+	return getEmailLayout(locale, Translate(locale, "email.digest.unsubscribe.header"), content, "")
 }
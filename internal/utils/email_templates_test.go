@@ -1,6 +1,7 @@
 package utils_test
 
 import (
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
 	"github.com/wwi21seb-projekt/server-beta/internal/utils"
 	"strconv"
 	"strings"
@@ -11,7 +12,7 @@ import (
 // TestGetActivationEmailBody tests if GetActivationEmailBody returns the expected HTML content
 func TestGetActivationEmailBody(t *testing.T) {
 	token := "123456"
-	body := utils.GetActivationEmailBody(token)
+	body := utils.GetActivationEmailBody("en", token)
 	currentYear := time.Now().Year()
 
 	if !strings.Contains(body, token) {
@@ -43,7 +44,7 @@ func TestGetActivationEmailBody(t *testing.T) {
 // TestGetWelcomeEmailBody tests if GetWelcomeEmailBody returns the expected HTML content with the username
 func TestGetWelcomeEmailBody(t *testing.T) {
 	username := "testuser"
-	body := utils.GetWelcomeEmailBody(username)
+	body := utils.GetWelcomeEmailBody("en", username)
 	currentYear := time.Now().Year()
 
 	if !strings.Contains(body, username) {
@@ -76,7 +77,7 @@ func TestGetWelcomeEmailBody(t *testing.T) {
 func TestGetPasswordResetEmailBody(t *testing.T) {
 	username := "testuser"
 	resetToken := "abcdef"
-	body := utils.GetPasswordResetEmailBody(username, resetToken)
+	body := utils.GetPasswordResetEmailBody("en", username, resetToken)
 	currentYear := time.Now().Year()
 
 	if !strings.Contains(body, username) {
@@ -109,3 +110,84 @@ func TestGetPasswordResetEmailBody(t *testing.T) {
 		}
 	}
 }
+
+// TestGetDataExportEmailBody tests if GetDataExportEmailBody returns the expected HTML content with the download link
+func TestGetDataExportEmailBody(t *testing.T) {
+	username := "testuser"
+	downloadUrl := "https://example.com/api/exports/123?token=abc"
+	body := utils.GetDataExportEmailBody("en", username, downloadUrl)
+	currentYear := time.Now().Year()
+
+	expectedStrings := []string{
+		"<!DOCTYPE html>",
+		"<html lang=\"en\">",
+		"Your Data Export",
+		"Hello " + username + "!",
+		"href=\"" + downloadUrl + "\"",
+		"This link is valid for 7 days.",
+		"© " + strconv.Itoa(currentYear) + " Server Beta - All rights reserved.",
+	}
+
+	for _, str := range expectedStrings {
+		if !strings.Contains(body, str) {
+			t.Errorf("Expected body to contain %s, but it didn't", str)
+		}
+	}
+}
+
+// TestGetDigestEmailBody tests if GetDigestEmailBody returns the expected HTML content with notifications, posts and unsubscribe link
+func TestGetDigestEmailBody(t *testing.T) {
+	username := "testuser"
+	unsubscribeUrl := "https://example.com/api/digests/unsubscribe?token=abc"
+	notifications := []models.Notification{
+		{NotificationType: "follow", FromUsername: "follower"},
+		{NotificationType: "like", FromUsername: "liker", Count: 3},
+	}
+	posts := []models.Post{
+		{Username: "author", Content: "<b>Hello</b> world"},
+	}
+	body := utils.GetDigestEmailBody("en", username, "weekly", 5, notifications, posts, unsubscribeUrl)
+	currentYear := time.Now().Year()
+
+	expectedStrings := []string{
+		"<!DOCTYPE html>",
+		"<html lang=\"en\">",
+		"Your Server Beta Digest",
+		"Hello " + username + "!",
+		"Here is what happened this week:",
+		"You have 5 new unread notifications:",
+		"follower started following you",
+		"liker and 2 others liked your post",
+		"&lt;b&gt;Hello&lt;/b&gt; world", // post content is escaped
+		"href=\"" + unsubscribeUrl + "\"",
+		"© " + strconv.Itoa(currentYear) + " Server Beta - All rights reserved.",
+	}
+
+	for _, str := range expectedStrings {
+		if !strings.Contains(body, str) {
+			t.Errorf("Expected body to contain %s, but it didn't", str)
+		}
+	}
+}
+
+// TestGetDigestUnsubscribePages tests if the unsubscribe pages of the email digest ask for confirmation and show the result
+func TestGetDigestUnsubscribePages(t *testing.T) {
+	page := utils.GetDigestUnsubscribePage("en")
+	expectedStrings := []string{
+		"Unsubscribe from the Digest",
+		"<form method=\"post\">",
+		"<button class=\"button\" type=\"submit\">Unsubscribe</button>",
+	}
+	for _, str := range expectedStrings {
+		if !strings.Contains(page, str) {
+			t.Errorf("Expected page to contain %s, but it didn't", str)
+		}
+	}
+
+	if page := utils.GetDigestUnsubscribedPage("de", true); !strings.Contains(page, "Du erhältst keine E-Mail-Zusammenfassungen mehr.") {
+		t.Errorf("Expected page to confirm unsubscribing, but it didn't")
+	}
+	if page := utils.GetDigestUnsubscribedPage("en", false); !strings.Contains(page, "The digest could not be turned off.") {
+		t.Errorf("Expected page to show the error, but it didn't")
+	}
+}
@@ -24,3 +24,13 @@ func CensorEmail(email string) string {
 func FormatImageUrl(imageId string, extension string) string {
 	return os.Getenv("SERVER_URL") + "/api/images/" + imageId + "." + extension
 }
+
+// FormatDataExportUrl formats the download url of a data export that is sent to the user via mail
+func FormatDataExportUrl(exportId string, token string) string {
+	return os.Getenv("SERVER_URL") + "/api/exports/" + exportId + "?token=" + token
+}
+
+// FormatDigestUnsubscribeUrl formats the one-click unsubscribe url that is added to every email digest
+func FormatDigestUnsubscribeUrl(token string) string {
+	return os.Getenv("SERVER_URL") + "/api/digests/unsubscribe?token=" + token
+}
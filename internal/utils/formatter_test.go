@@ -67,3 +67,31 @@ func TestFormatImageUrl(t *testing.T) {
 		})
 	}
 }
+
+// TestFormatDataExportUrl tests the FormatDataExportUrl function if it formats the download url correctly
+func TestFormatDataExportUrl(t *testing.T) {
+	err := os.Setenv("SERVER_URL", "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := utils.FormatDataExportUrl("123456789", "abcdef")
+	expectedUrl := "https://example.com/api/exports/123456789?token=abcdef"
+	if result != expectedUrl {
+		t.Errorf("FormatDataExportUrl returned unexpected result, got: %s, want: %s", result, expectedUrl)
+	}
+}
+
+// TestFormatDigestUnsubscribeUrl tests the FormatDigestUnsubscribeUrl function if it formats the unsubscribe url correctly
+func TestFormatDigestUnsubscribeUrl(t *testing.T) {
+	err := os.Setenv("SERVER_URL", "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := utils.FormatDigestUnsubscribeUrl("abcdef")
+	expectedUrl := "https://example.com/api/digests/unsubscribe?token=abcdef"
+	if result != expectedUrl {
+		t.Errorf("FormatDigestUnsubscribeUrl returned unexpected result, got: %s, want: %s", result, expectedUrl)
+	}
+}
@@ -7,12 +7,13 @@ import (
 	"time"
 )
 
-// generateJWTToken generates new jwt token with user id claim
-func generateJWTToken(username string, expirationTime time.Time, isRefreshToken bool) (string, error) {
+// generateJWTToken generates new jwt token with user id claim and the id of the device session the token belongs to
+func generateJWTToken(username string, sessionId string, expirationTime time.Time, isRefreshToken bool) (string, error) {
 	issuedAtTime := time.Now().UTC()
 
 	claims := &jwt.MapClaims{
 		"username": username,
+		"session":  sessionId,
 		"exp":      expirationTime.Unix(),
 		"iat":      issuedAtTime.Unix(), // issued at
 		"refresh":  isRefreshToken,
@@ -28,20 +29,37 @@ func generateJWTToken(username string, expirationTime time.Time, isRefreshToken
 
 // GenerateAccessToken generates new access jwt token with user id claim for 3 hours validity
 func GenerateAccessToken(username string) (string, error) {
-	expirationTime := time.Now().Add(time.Hour * 3)
-	tokenString, err := generateJWTToken(username, expirationTime, false)
-	return tokenString, err
+	return GenerateSessionAccessToken(username, "")
 }
 
 // GenerateRefreshToken generates new refresh jwt token with user id claim for one week validity
 func GenerateRefreshToken(username string) (string, error) {
+	return GenerateSessionRefreshToken(username, "")
+}
+
+// GenerateSessionAccessToken generates new access jwt token for a device session for 3 hours validity
+func GenerateSessionAccessToken(username string, sessionId string) (string, error) {
+	expirationTime := time.Now().Add(time.Hour * 3)
+	tokenString, err := generateJWTToken(username, sessionId, expirationTime, false)
+	return tokenString, err
+}
+
+// GenerateSessionRefreshToken generates new refresh jwt token for a device session for one week validity
+func GenerateSessionRefreshToken(username string, sessionId string) (string, error) {
 	expirationTime := time.Now().Add(time.Hour * 7 * 24)
-	tokenString, err := generateJWTToken(username, expirationTime, true)
+	tokenString, err := generateJWTToken(username, sessionId, expirationTime, true)
 	return tokenString, err
 }
 
 // VerifyJWTToken verifies given token and returns username and true if token is refresh token
 func VerifyJWTToken(tokenString string) (string, bool, error) {
+	username, _, isRefreshToken, err := VerifySessionJWTToken(tokenString)
+	return username, isRefreshToken, err
+}
+
+// VerifySessionJWTToken verifies given token and returns username, session id and true if token is refresh token,
+// the session id is empty for tokens that were not issued for a device session
+func VerifySessionJWTToken(tokenString string) (string, string, bool, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -50,12 +68,12 @@ func VerifyJWTToken(tokenString string) (string, bool, error) {
 	})
 
 	if err != nil || token == nil || !token.Valid {
-		return "", false, fmt.Errorf("invalid token")
+		return "", "", false, fmt.Errorf("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", false, fmt.Errorf("invalid token")
+		return "", "", false, fmt.Errorf("invalid token")
 	}
 
 	// jwt.Parse already checks for expiration
@@ -67,12 +85,14 @@ func VerifyJWTToken(tokenString string) (string, bool, error) {
 
 	username, ok := claims["username"].(string)
 	if !ok {
-		return "", false, fmt.Errorf("invalid token")
+		return "", "", false, fmt.Errorf("invalid token")
 	}
 	isRefreshToken, ok := claims["refresh"].(bool)
 	if !ok {
-		return "", false, fmt.Errorf("invalid token")
+		return "", "", false, fmt.Errorf("invalid token")
 	}
 
-	return username, isRefreshToken, nil
+	sessionId, _ := claims["session"].(string) // tokens issued before sessions were introduced have no session
+
+	return username, sessionId, isRefreshToken, nil
 }
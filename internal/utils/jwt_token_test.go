@@ -133,3 +133,38 @@ func TestVerifyJWTTokenInvalid(t *testing.T) {
 	}
 
 }
+
+// TestVerifySessionJWTToken tests the VerifySessionJWTToken function if it returns the session id of access and refresh tokens
+func TestVerifySessionJWTToken(t *testing.T) {
+	username := "testUser"
+	sessionId := "session-id"
+
+	accessToken, err := utils.GenerateSessionAccessToken(username, sessionId)
+	if err != nil {
+		t.Errorf("Error generating access token: %v", err)
+	}
+	refreshToken, err := utils.GenerateSessionRefreshToken(username, sessionId)
+	if err != nil {
+		t.Errorf("Error generating refresh token: %v", err)
+	}
+
+	returnedUsername, returnedSessionId, isRefresh, err := utils.VerifySessionJWTToken(accessToken)
+	if err != nil || returnedUsername != username || returnedSessionId != sessionId || isRefresh {
+		t.Errorf("Error verifying valid access token: %v", err)
+	}
+
+	returnedUsername, returnedSessionId, isRefresh, err = utils.VerifySessionJWTToken(refreshToken)
+	if err != nil || returnedUsername != username || returnedSessionId != sessionId || !isRefresh {
+		t.Errorf("Error verifying valid refresh token: %v", err)
+	}
+
+	// Tokens without session have an empty session id
+	tokenWithoutSession, err := utils.GenerateAccessToken(username)
+	if err != nil {
+		t.Errorf("Error generating access token: %v", err)
+	}
+	_, returnedSessionId, _, err = utils.VerifySessionJWTToken(tokenWithoutSession)
+	if err != nil || returnedSessionId != "" {
+		t.Errorf("Expected empty session id, got: %s", returnedSessionId)
+	}
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ExtractMentions extracts all mentioned usernames (@username) from a given text and returns them as a slice of strings
+// An @ directly preceded by a word character is not a mention, so that e-mail addresses are ignored
+func ExtractMentions(text string) []string {
+	re := regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_\-\.]+)`)
+	matches := re.FindAllStringSubmatch(text, -1)
+
+	mentionsMap := make(map[string]bool)
+	var mentions []string
+
+	for _, match := range matches {
+		// Remove trailing dots, e.g. at the end of a sentence
+		mention := strings.TrimRight(match[1], ".")
+		if mention == "" || len(mention) > 20 {
+			continue
+		}
+
+		// Add to slice if not exist in map
+		if !mentionsMap[mention] {
+			mentionsMap[mention] = true
+			mentions = append(mentions, mention)
+		}
+	}
+
+	return mentions
+}
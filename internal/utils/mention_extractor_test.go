@@ -0,0 +1,67 @@
+package utils_test
+
+import (
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "Standard mentions",
+			text:     "Hello @first and @second_user",
+			expected: []string{"first", "second_user"},
+		},
+		{
+			name:     "No mentions",
+			text:     "A post without mentions",
+			expected: []string{},
+		},
+		{
+			name:     "Mention at the start and end of a sentence",
+			text:     "@start, have you met @end.",
+			expected: []string{"start", "end"},
+		},
+		{
+			name:     "Usernames with dots and hyphens",
+			text:     "Thanks @john.doe and @jane-doe!",
+			expected: []string{"john.doe", "jane-doe"},
+		},
+		{
+			name:     "E-mail addresses are no mentions",
+			text:     "Write to mail@example.com or @support",
+			expected: []string{"support"},
+		},
+		{
+			name:     "Texts with same mentions twice",
+			text:     "Mentions of @test and @test",
+			expected: []string{"test"},
+		},
+		{
+			name:     "Usernames that are too long",
+			text:     "Hello @aVeryLongUsernameThatIsTooLong",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := utils.ExtractMentions(tt.text)
+
+			// Test if both slices are empty
+			if len(result) == 0 && len(tt.expected) == 0 {
+				return
+			}
+
+			// Test if both slices have the same elements
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ExtractMentions(%s) = %v, expected %v", tt.text, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is used for users that have not chosen a locale and for texts that are not translated
+const DefaultLocale = "en"
+
+// messageCatalogue contains the texts of push notifications and emails for every supported locale
+var messageCatalogue = map[string]map[string]string{
+	"en": {
+		"push.title": "Notification from Server Beta",
+
+		"notification.follow":      "%s started following you",
+		"notification.repost":      "%s reposted your post",
+		"notification.like":        "%s liked your post",
+		"notification.likeOthers":  "%s and %d others liked your post",
+		"notification.comment":     "%s commented on your post",
+		"notification.mention":     "%s mentioned you",
+		"notification.message":     "%s sent you a message",
+		"notification.default":     "New notification",
+		"notification.defaultFrom": "New notification from %s",
+
+		"email.greeting":     "Hello!",
+		"email.greetingName": "Hello %s!",
+		"email.rights":       "© %d Server Beta - All rights reserved.",
+		"email.imprint":      `For more information, see our <a href="https://server-beta.de/api/imprint">imprint</a>.`,
+
+		"email.activation.subject":  "Verify your account",
+		"email.activation.header":   "Verification Code",
+		"email.activation.text":     "Please use the following code to complete your account registration at Server Beta:",
+		"email.activation.validity": "This code is valid for 2 hours. Enter this code on the appropriate page for your registration.",
+
+		"email.welcome.subject":    "Welcome to Server Beta",
+		"email.welcome.header":     "Welcome to Server Beta!",
+		"email.welcome.start":      "Let's go!",
+		"email.welcome.verified":   "Your account has been successfully verified. You can now use our network.",
+		"email.welcome.invitation": "We invite you to actively participate in our community and share your thoughts with us.",
+
+		"email.passwordReset.subject": "Reset your password",
+		"email.passwordReset.header":  "Password Reset",
+		"email.passwordReset.code":    "Your code is:",
+		"email.passwordReset.text":    "Use this code to reset your password.",
+
+		"email.dataExport.subject":  "Your data export is ready",
+		"email.dataExport.header":   "Your Data Export",
+		"email.dataExport.text":     "The export of your data at Server Beta is ready:",
+		"email.dataExport.download": "Download",
+		"email.dataExport.validity": "This link is valid for 7 days.",
+
+		"email.digest.subject.daily":   "Your daily digest",
+		"email.digest.subject.weekly":  "Your weekly digest",
+		"email.digest.header":          "Your Server Beta Digest",
+		"email.digest.period.daily":    "today",
+		"email.digest.period.weekly":   "this week",
+		"email.digest.intro":           "Here is what happened %s:",
+		"email.digest.noNotifications": "You have no unread notifications.",
+		"email.digest.notifications":   "You have %d new unread notifications:",
+		"email.digest.noPosts":         "The people you follow have not posted anything %s.",
+		"email.digest.posts":           "Top posts of the people you follow %s:",
+		"email.digest.footer.daily":    `You receive this email because you subscribed to the daily digest. <a href="%s">Unsubscribe</a>`,
+		"email.digest.footer.weekly":   `You receive this email because you subscribed to the weekly digest. <a href="%s">Unsubscribe</a>`,
+
+		"email.digest.unsubscribe.header":  "Unsubscribe from the Digest",
+		"email.digest.unsubscribe.text":    "Do you want to stop receiving email digests from Server Beta?",
+		"email.digest.unsubscribe.confirm": "Unsubscribe",
+		"email.digest.unsubscribe.done":    "You will not receive email digests anymore. You can subscribe again in the settings of the app.",
+		"email.digest.unsubscribe.failed":  "The digest could not be turned off. The link may be invalid.",
+	},
+	"de": {
+		"push.title": "Benachrichtigung von Server Beta",
+
+		"notification.follow":      "%s folgt dir jetzt",
+		"notification.repost":      "%s hat deinen Beitrag geteilt",
+		"notification.like":        "%s gefällt dein Beitrag",
+		"notification.likeOthers":  "%s und %d weiteren gefällt dein Beitrag",
+		"notification.comment":     "%s hat deinen Beitrag kommentiert",
+		"notification.mention":     "%s hat dich erwähnt",
+		"notification.message":     "%s hat dir eine Nachricht geschickt",
+		"notification.default":     "Neue Benachrichtigung",
+		"notification.defaultFrom": "Neue Benachrichtigung von %s",
+
+		"email.greeting":     "Hallo!",
+		"email.greetingName": "Hallo %s!",
+		"email.rights":       "© %d Server Beta - Alle Rechte vorbehalten.",
+		"email.imprint":      `Weitere Informationen findest du in unserem <a href="https://server-beta.de/api/imprint">Impressum</a>.`,
+
+		"email.activation.subject":  "Bestätige dein Konto",
+		"email.activation.header":   "Bestätigungscode",
+		"email.activation.text":     "Bitte verwende den folgenden Code, um deine Registrierung bei Server Beta abzuschließen:",
+		"email.activation.validity": "Dieser Code ist 2 Stunden gültig. Gib ihn auf der entsprechenden Seite deiner Registrierung ein.",
+
+		"email.welcome.subject":    "Willkommen bei Server Beta",
+		"email.welcome.header":     "Willkommen bei Server Beta!",
+		"email.welcome.start":      "Los geht's!",
+		"email.welcome.verified":   "Dein Konto wurde erfolgreich bestätigt. Du kannst unser Netzwerk jetzt nutzen.",
+		"email.welcome.invitation": "Wir laden dich ein, aktiv an unserer Community teilzunehmen und deine Gedanken mit uns zu teilen.",
+
+		"email.passwordReset.subject": "Setze dein Passwort zurück",
+		"email.passwordReset.header":  "Passwort zurücksetzen",
+		"email.passwordReset.code":    "Dein Code lautet:",
+		"email.passwordReset.text":    "Verwende diesen Code, um dein Passwort zurückzusetzen.",
+
+		"email.dataExport.subject":  "Dein Datenexport ist bereit",
+		"email.dataExport.header":   "Dein Datenexport",
+		"email.dataExport.text":     "Der Export deiner Daten bei Server Beta ist bereit:",
+		"email.dataExport.download": "Herunterladen",
+		"email.dataExport.validity": "Dieser Link ist 7 Tage gültig.",
+
+		"email.digest.subject.daily":   "Deine tägliche Zusammenfassung",
+		"email.digest.subject.weekly":  "Deine wöchentliche Zusammenfassung",
+		"email.digest.header":          "Deine Server Beta Zusammenfassung",
+		"email.digest.period.daily":    "heute",
+		"email.digest.period.weekly":   "diese Woche",
+		"email.digest.intro":           "Das ist %s passiert:",
+		"email.digest.noNotifications": "Du hast keine ungelesenen Benachrichtigungen.",
+		"email.digest.notifications":   "Du hast %d neue ungelesene Benachrichtigungen:",
+		"email.digest.noPosts":         "Die Personen, denen du folgst, haben %s nichts gepostet.",
+		"email.digest.posts":           "Top-Beiträge der Personen, denen du folgst (%s):",
+		"email.digest.footer.daily":    `Du erhältst diese E-Mail, weil du die tägliche Zusammenfassung abonniert hast. <a href="%s">Abbestellen</a>`,
+		"email.digest.footer.weekly":   `Du erhältst diese E-Mail, weil du die wöchentliche Zusammenfassung abonniert hast. <a href="%s">Abbestellen</a>`,
+
+		"email.digest.unsubscribe.header":  "Zusammenfassung abbestellen",
+		"email.digest.unsubscribe.text":    "Möchtest du keine E-Mail-Zusammenfassungen von Server Beta mehr erhalten?",
+		"email.digest.unsubscribe.confirm": "Abbestellen",
+		"email.digest.unsubscribe.done":    "Du erhältst keine E-Mail-Zusammenfassungen mehr. Du kannst sie in den Einstellungen der App wieder abonnieren.",
+		"email.digest.unsubscribe.failed":  "Die Zusammenfassung konnte nicht abbestellt werden. Der Link ist möglicherweise ungültig.",
+	},
+}
+
+// IsSupportedLocale returns true if texts are available in the given locale
+func IsSupportedLocale(locale string) bool {
+	_, ok := messageCatalogue[locale]
+	return ok
+}
+
+// GetSupportedLocales returns all locales of the message catalogue in alphabetical order
+func GetSupportedLocales() []string {
+	locales := make([]string, 0, len(messageCatalogue))
+	for locale := range messageCatalogue {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// ParseAcceptLanguage returns the supported locale with the highest quality of an Accept-Language header,
+// e.g. "de-DE,de;q=0.9,en;q=0.8" returns "de", the default locale is returned if no language is supported
+func ParseAcceptLanguage(header string) string {
+	bestLocale := DefaultLocale
+	bestQuality := 0.0
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		language := strings.ToLower(strings.TrimSpace(fields[0]))
+		language, _, _ = strings.Cut(language, "-") // only the primary language is used, e.g. "de" of "de-AT"
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if value, found := strings.CutPrefix(strings.TrimSpace(param), "q="); found {
+				parsedQuality, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					parsedQuality = 0
+				}
+				quality = parsedQuality
+			}
+		}
+
+		if IsSupportedLocale(language) && quality > bestQuality {
+			bestLocale = language
+			bestQuality = quality
+		}
+	}
+
+	return bestLocale
+}
+
+// Translate returns the text of the key in the given locale formatted with the arguments,
+// texts that are not translated are returned in the default locale
+func Translate(locale string, key string, args ...interface{}) string {
+	text, ok := messageCatalogue[locale][key]
+	if !ok {
+		text, ok = messageCatalogue[DefaultLocale][key]
+		if !ok {
+			return key
+		}
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// GetNotificationText returns a short text describing a notification, e.g. for push notifications or the email digest
+func GetNotificationText(locale string, notificationType string, fromUsername string, count int) string {
+	switch notificationType {
+	case "follow", "repost", "comment", "mention", "message":
+		return Translate(locale, "notification."+notificationType, fromUsername)
+	case "like":
+		if count > 1 {
+			return Translate(locale, "notification.likeOthers", fromUsername, count-1)
+		}
+		return Translate(locale, "notification.like", fromUsername)
+	}
+
+	if fromUsername == "" {
+		return Translate(locale, "notification.default")
+	}
+	return Translate(locale, "notification.defaultFrom", fromUsername)
+}
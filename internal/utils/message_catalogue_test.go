@@ -0,0 +1,102 @@
+package utils_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wwi21seb-projekt/server-beta/internal/models"
+	"github.com/wwi21seb-projekt/server-beta/internal/utils"
+	"strings"
+	"testing"
+)
+
+// TestParseAcceptLanguage tests if ParseAcceptLanguage returns the supported locale with the highest quality
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                        "en",
+		"de":                      "de",
+		"de-DE,de;q=0.9,en;q=0.8": "de",
+		"en-US,en;q=0.9,de;q=0.8": "en",
+		"fr-FR,fr;q=0.9,de;q=0.5": "de",
+		"fr,es":                   "en",
+		"en;q=0.3, DE-AT;q=0.7":   "de",
+		"de;q=invalid,en;q=0.1":   "en",
+		"*":                       "en",
+	}
+
+	for header, expectedLocale := range tests {
+		assert.Equal(t, expectedLocale, utils.ParseAcceptLanguage(header), "Accept-Language: %s", header)
+	}
+}
+
+// TestTranslate tests if Translate formats texts and falls back to the default locale and the key
+func TestTranslate(t *testing.T) {
+	assert.Equal(t, "testUser folgt dir jetzt", utils.Translate("de", "notification.follow", "testUser"))
+	assert.Equal(t, "testUser started following you", utils.Translate("fr", "notification.follow", "testUser"))
+	assert.Equal(t, "unknown.key", utils.Translate("de", "unknown.key"))
+}
+
+// TestMessageCatalogueComplete tests if every supported locale contains all texts of the default locale
+func TestMessageCatalogueComplete(t *testing.T) {
+	assert.Equal(t, []string{"de", "en"}, utils.GetSupportedLocales())
+
+	keys := []string{
+		"push.title", "notification.follow", "notification.repost", "notification.like", "notification.likeOthers",
+		"notification.comment", "notification.mention", "notification.message", "notification.default",
+		"notification.defaultFrom", "email.greeting", "email.greetingName", "email.rights", "email.imprint",
+		"email.activation.subject", "email.activation.header", "email.activation.text", "email.activation.validity",
+		"email.welcome.subject", "email.welcome.header", "email.welcome.start", "email.welcome.verified",
+		"email.welcome.invitation", "email.passwordReset.subject", "email.passwordReset.header",
+		"email.passwordReset.code", "email.passwordReset.text", "email.dataExport.subject", "email.dataExport.header",
+		"email.dataExport.text", "email.dataExport.download", "email.dataExport.validity",
+		"email.digest.subject.daily", "email.digest.subject.weekly", "email.digest.header",
+		"email.digest.period.daily", "email.digest.period.weekly", "email.digest.intro",
+		"email.digest.noNotifications", "email.digest.notifications", "email.digest.noPosts", "email.digest.posts",
+		"email.digest.footer.daily", "email.digest.footer.weekly", "email.digest.unsubscribe.header",
+		"email.digest.unsubscribe.text", "email.digest.unsubscribe.confirm", "email.digest.unsubscribe.done",
+		"email.digest.unsubscribe.failed",
+	}
+
+	for _, locale := range utils.GetSupportedLocales() {
+		for _, key := range keys {
+			assert.NotEqual(t, key, utils.Translate(locale, key), "missing key %s in locale %s", key, locale)
+		}
+	}
+	assert.NotEqual(t, utils.Translate("en", "push.title"), utils.Translate("de", "push.title"))
+}
+
+// TestGetNotificationText tests if GetNotificationText returns localised texts for all notification types
+func TestGetNotificationText(t *testing.T) {
+	assert.Equal(t, "testUser liked your post", utils.GetNotificationText("en", "like", "testUser", 1))
+	assert.Equal(t, "testUser and 2 others liked your post", utils.GetNotificationText("en", "like", "testUser", 3))
+	assert.Equal(t, "testUser und 2 weiteren gefällt dein Beitrag", utils.GetNotificationText("de", "like", "testUser", 3))
+	assert.Equal(t, "testUser hat dich erwähnt", utils.GetNotificationText("de", "mention", "testUser", 0))
+	assert.Equal(t, "Neue Benachrichtigung", utils.GetNotificationText("de", "unknown", "", 0))
+	assert.Equal(t, "New notification from testUser", utils.GetNotificationText("en", "unknown", "testUser", 0))
+}
+
+// TestGetEmailBodiesGerman tests if the email templates are rendered in German for the German locale
+func TestGetEmailBodiesGerman(t *testing.T) {
+	bodies := map[string][]string{
+		utils.GetActivationEmailBody("de", "123456"): {
+			"<html lang=\"de\">", "Bestätigungscode", "123456", "Alle Rechte vorbehalten", "Impressum",
+		},
+		utils.GetPasswordResetEmailBody("de", "testUser", "654321"): {
+			"Hallo testUser!", "Passwort zurücksetzen", "654321",
+		},
+		utils.GetDigestEmailBody("de", "testUser", "daily", 1, []models.Notification{{NotificationType: "follow", FromUsername: "otherUser"}}, nil, "https://example.com/unsubscribe"): {
+			"Das ist heute passiert:", "otherUser folgt dir jetzt", "tägliche Zusammenfassung", "Abbestellen",
+		},
+	}
+
+	for body, expectedStrings := range bodies {
+		for _, str := range expectedStrings {
+			if !strings.Contains(body, str) {
+				t.Errorf("Expected body to contain %s, but it didn't", str)
+			}
+		}
+		assert.NotContains(t, body, "All rights reserved")
+	}
+
+	// Unsupported locales fall back to English
+	assert.Contains(t, utils.GetActivationEmailBody("fr", "123456"), "<html lang=\"en\">")
+	assert.Contains(t, utils.GetActivationEmailBody("fr", "123456"), "Verification Code")
+}